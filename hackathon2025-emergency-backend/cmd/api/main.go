@@ -1,14 +1,23 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"invoiceflow/internal/app"
 	"invoiceflow/internal/config"
 	"invoiceflow/internal/db"
+	"invoiceflow/internal/jwtkeys"
 )
 
+const shutdownTimeout = 30 * time.Second
+
 func main() {
 	cfg, err := config.Load()
 	if err != nil {
@@ -23,9 +32,33 @@ func main() {
 	}
 	defer database.Close()
 
-	router := app.New(cfg, database)
-	if err := router.Run(":" + cfg.Port); err != nil {
+	keys, err := jwtkeys.Load(cfg)
+	if err != nil {
+		log.Println("jwt key setup failed")
+		os.Exit(1)
+	}
+
+	// Background workers stop with the server on SIGINT or SIGTERM.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	keys.StartRotation(ctx, time.Duration(cfg.JWTRotateIntervalHours)*time.Hour, db.AdvisoryLocker(database, db.LockJWTRotation))
+
+	server := &http.Server{Addr: ":" + cfg.Port, Handler: app.New(ctx, cfg, database, keys)}
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("server shutdown: %v", err)
+		}
+	}()
+
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Println("server error")
 		os.Exit(1)
 	}
+	<-drained
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"invoiceflow/internal/config"
+	"invoiceflow/internal/db"
+	"invoiceflow/internal/mailer"
+	"invoiceflow/internal/repositories"
+	"invoiceflow/internal/services"
+)
+
+func main() {
+	name := flag.String("name", "", "admin display name")
+	email := flag.String("email", "", "admin email")
+	flag.Parse()
+
+	if *name == "" || *email == "" {
+		fmt.Fprintln(os.Stderr, "usage: bootstrap-admin -name NAME -email EMAIL (password from BOOTSTRAP_ADMIN_PASSWORD or stdin)")
+		os.Exit(2)
+	}
+
+	password := os.Getenv("BOOTSTRAP_ADMIN_PASSWORD")
+	if password == "" {
+		fmt.Fprint(os.Stderr, "password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			log.Println("could not read password")
+			os.Exit(1)
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+	if len(password) < 12 {
+		log.Println("password must be at least 12 characters")
+		os.Exit(1)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Println("config error")
+		os.Exit(1)
+	}
+
+	database, err := db.New(cfg)
+	if err != nil {
+		log.Println("database connection failed")
+		os.Exit(1)
+	}
+	defer database.Close()
+
+	mail, err := mailer.New(cfg)
+	if err != nil {
+		log.Println("mailer setup failed")
+		os.Exit(1)
+	}
+
+	userRepo := repositories.NewUserRepository(database)
+	audit := services.NewAuditService(database, repositories.NewAuditRepository(database), cfg.AuditHMACKey)
+	orgs := services.NewOrganizationService(cfg, database, repositories.NewOrganizationRepository(database), userRepo, services.NewMailOutbox(database, repositories.NewMailOutboxRepository(database), mail))
+	service := services.NewUserAdminService(database, userRepo, repositories.NewSessionRepository(database), repositories.NewRoleRepository(database), repositories.NewAuthEventRepository(database), orgs, audit)
+	user, err := service.BootstrapAdmin(context.Background(), *name, *email, password)
+	if err != nil {
+		log.Printf("bootstrap failed: %v", err)
+		os.Exit(1)
+	}
+
+	log.Printf("created admin %s (%s)", user.Email, user.ID)
+}
@@ -0,0 +1,53 @@
+// Command webhook-receiver is a development endpoint for InvoiceFlow
+// webhooks. It verifies each delivery's signature and logs the event.
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"invoiceflow/internal/webhook"
+)
+
+func main() {
+	addr := flag.String("addr", ":9000", "listen address")
+	secret := flag.String("secret", os.Getenv("WEBHOOK_SECRET"), "subscription signing secret (defaults to $WEBHOOK_SECRET)")
+	flag.Parse()
+
+	if *secret == "" {
+		log.Println("a signing secret is required (-secret or WEBHOOK_SECRET)")
+		os.Exit(1)
+	}
+
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if err := webhook.Verify(*secret, r.Header.Get(webhook.HeaderSignature), body, 5*time.Minute, time.Now()); err != nil {
+			log.Printf("rejected delivery %s: %v", r.Header.Get(webhook.HeaderDelivery), err)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		log.Printf("%s delivery %s: %s", r.Header.Get(webhook.HeaderEvent), r.Header.Get(webhook.HeaderDelivery), body)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	log.Printf("listening on %s", *addr)
+	if err := http.ListenAndServe(*addr, nil); err != nil {
+		log.Println("server error")
+		os.Exit(1)
+	}
+}
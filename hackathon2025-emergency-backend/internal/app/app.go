@@ -1,7 +1,11 @@
 package app
 
 import (
+	"context"
+	"log"
+
 	"invoiceflow/internal/config"
+	"invoiceflow/internal/jwtkeys"
 	"invoiceflow/internal/middleware"
 	"invoiceflow/internal/routes"
 
@@ -9,11 +13,19 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
-func New(cfg *config.Config, db *sqlx.DB) *gin.Engine {
+func New(ctx context.Context, cfg *config.Config, db *sqlx.DB, keys *jwtkeys.KeySet) *gin.Engine {
 	router := gin.New()
-	router.Use(middleware.CORSMiddleware(cfg.CORSOrigins), gin.Logger(), gin.Recovery())
+	// Client IPs feed login throttling, so X-Forwarded-For is only honoured
+	// from configured proxies.
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Printf("invalid TRUSTED_PROXIES: %v", err)
+	}
+	// /stream may carry the access token in its query string; keep it out of
+	// the access log.
+	logger := gin.LoggerWithConfig(gin.LoggerConfig{SkipPaths: []string{"/stream"}})
+	router.Use(middleware.RequestID(), middleware.CORSMiddleware(cfg.CORSOrigins), logger, gin.Recovery())
 
-	routes.Register(router, cfg, db)
+	routes.Register(ctx, router, cfg, db, keys)
 
 	return router
 }
@@ -3,6 +3,7 @@ package blockchain
 import (
 	"errors"
 	"math/big"
+	"strings"
 
 	"invoiceflow/internal/config"
 
@@ -10,29 +11,113 @@ import (
 	"github.com/ethereum/go-ethereum/ethclient"
 )
 
+var (
+	ErrChainDisabled  = errors.New("chain disabled")
+	ErrUnknownProfile = errors.New("unknown chain profile")
+)
+
 type Client struct {
-	RPC      *ethclient.Client
-	ChainID  *big.Int
-	Contract common.Address
+	Profile       string
+	RPC           *ethclient.Client
+	ChainID       *big.Int
+	Contract      common.Address
+	Confirmations uint64
+	MaxTxFeeWei   *big.Int
 }
 
-func New(cfg *config.Config) (*Client, error) {
-	if !cfg.EnableChain {
-		return nil, errors.New("chain disabled")
-	}
-
-	if cfg.ChainRPCURL == "" || cfg.ContractInvoiceNFTAddress == "" || cfg.ChainID == 0 {
+func New(profile config.ChainProfile) (*Client, error) {
+	if profile.RPCURL == "" || profile.ContractAddress == "" || profile.ChainID == 0 {
 		return nil, errors.New("chain config missing")
 	}
 
-	rpc, err := ethclient.Dial(cfg.ChainRPCURL)
+	rpc, err := ethclient.Dial(profile.RPCURL)
 	if err != nil {
 		return nil, err
 	}
 
+	confirmations := profile.Confirmations
+	if confirmations == 0 {
+		confirmations = 1
+	}
+
 	return &Client{
-		RPC:      rpc,
-		ChainID:  big.NewInt(cfg.ChainID),
-		Contract: common.HexToAddress(cfg.ContractInvoiceNFTAddress),
+		Profile:       profile.ID,
+		RPC:           rpc,
+		ChainID:       big.NewInt(profile.ChainID),
+		Contract:      common.HexToAddress(profile.ContractAddress),
+		Confirmations: confirmations,
+		MaxTxFeeWei:   profile.MaxTxFeeWei,
 	}, nil
 }
+
+type Registry struct {
+	clients   []*Client
+	defaultID string
+}
+
+func NewRegistry(cfg *config.Config) (*Registry, error) {
+	if !cfg.EnableChain {
+		return nil, ErrChainDisabled
+	}
+
+	registry := &Registry{defaultID: cfg.DefaultChainProfile}
+	for _, profile := range cfg.ChainProfiles {
+		client, err := New(profile)
+		if err != nil {
+			registry.Close()
+			return nil, err
+		}
+		registry.clients = append(registry.clients, client)
+	}
+
+	if len(registry.clients) == 0 {
+		return nil, errors.New("chain config missing")
+	}
+
+	return registry, nil
+}
+
+func (r *Registry) Get(profileID string) (*Client, error) {
+	if profileID == "" {
+		profileID = r.defaultID
+	}
+	for _, client := range r.clients {
+		if client.Profile == profileID {
+			return client, nil
+		}
+	}
+	return nil, ErrUnknownProfile
+}
+
+func (r *Registry) Default() *Client {
+	client, err := r.Get("")
+	if err != nil {
+		return r.clients[0]
+	}
+	return client
+}
+
+// ForContract resolves the client that owns a stored on-chain record. Rows
+// written before chain ids were tracked carry chain id 0 and are matched on
+// the contract address alone.
+func (r *Registry) ForContract(chainID int64, contract string) (*Client, error) {
+	for _, client := range r.clients {
+		if chainID != 0 && client.ChainID.Int64() != chainID {
+			continue
+		}
+		if strings.EqualFold(client.Contract.Hex(), contract) {
+			return client, nil
+		}
+	}
+	return nil, ErrUnknownProfile
+}
+
+func (r *Registry) Clients() []*Client {
+	return r.clients
+}
+
+func (r *Registry) Close() {
+	for _, client := range r.clients {
+		client.RPC.Close()
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"math/big"
 	"strings"
 
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
@@ -12,6 +13,8 @@ import (
 )
 
 type InvoiceNFT struct {
+	client   *Client
+	abi      abi.ABI
 	contract *bind.BoundContract
 }
 
@@ -22,7 +25,16 @@ func NewInvoiceNFT(client *Client) (*InvoiceNFT, error) {
 	}
 
 	bound := bind.NewBoundContract(client.Contract, parsed, client.RPC, client.RPC, client.RPC)
-	return &InvoiceNFT{contract: bound}, nil
+	return &InvoiceNFT{client: client, abi: parsed, contract: bound}, nil
+}
+
+func (nft *InvoiceNFT) QuoteMint(ctx context.Context, from common.Address, to common.Address, tokenID *big.Int, tokenURI string) (*FeeQuote, error) {
+	data, err := nft.abi.Pack("mint", to, tokenID, tokenURI)
+	if err != nil {
+		return nil, err
+	}
+
+	return QuoteCall(ctx, nft.client, ethereum.CallMsg{From: from, To: &nft.client.Contract, Data: data})
 }
 
 func (nft *InvoiceNFT) Mint(ctx context.Context, auth *bind.TransactOpts, to common.Address, tokenID *big.Int, tokenURI string) (*types.Transaction, error) {
@@ -0,0 +1,69 @@
+package blockchain
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const gasLimitBufferPercent = 20
+
+type FeeQuote struct {
+	GasLimit  uint64
+	GasFeeCap *big.Int
+	GasTipCap *big.Int
+	GasPrice  *big.Int
+	MaxCost   *big.Int
+}
+
+// QuoteCall estimates gas for msg and prices it at the worst case the
+// transaction may pay: EIP-1559 chains use tip + 2 * base fee as the fee cap,
+// legacy chains use the suggested gas price.
+func QuoteCall(ctx context.Context, client *Client, msg ethereum.CallMsg) (*FeeQuote, error) {
+	estimated, err := client.RPC.EstimateGas(ctx, msg)
+	if err != nil {
+		return nil, err
+	}
+	quote := &FeeQuote{GasLimit: estimated + estimated*gasLimitBufferPercent/100}
+
+	header, err := client.RPC.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if header.BaseFee != nil {
+		tip, err := client.RPC.SuggestGasTipCap(ctx)
+		if err != nil {
+			return nil, err
+		}
+		quote.GasTipCap = tip
+		quote.GasFeeCap = new(big.Int).Add(tip, new(big.Int).Mul(header.BaseFee, big.NewInt(2)))
+		quote.MaxCost = new(big.Int).Mul(quote.GasFeeCap, new(big.Int).SetUint64(quote.GasLimit))
+		return quote, nil
+	}
+
+	price, err := client.RPC.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	quote.GasPrice = price
+	quote.MaxCost = new(big.Int).Mul(price, new(big.Int).SetUint64(quote.GasLimit))
+	return quote, nil
+}
+
+func (q *FeeQuote) Apply(auth *bind.TransactOpts) {
+	auth.GasLimit = q.GasLimit
+	auth.GasFeeCap = q.GasFeeCap
+	auth.GasTipCap = q.GasTipCap
+	auth.GasPrice = q.GasPrice
+}
+
+func ReceiptFee(receipt *types.Receipt) *big.Int {
+	if receipt.EffectiveGasPrice == nil {
+		return nil
+	}
+	return new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
+}
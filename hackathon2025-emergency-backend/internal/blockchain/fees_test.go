@@ -0,0 +1,132 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// nodeStub answers the eth_ calls QuoteCall makes. A nil baseFee makes it a
+// legacy chain.
+type nodeStub struct {
+	estimate uint64
+	baseFee  *big.Int
+	tip      *big.Int
+	gasPrice *big.Int
+}
+
+func (n *nodeStub) EstimateGas(args map[string]any) hexutil.Uint64 {
+	return hexutil.Uint64(n.estimate)
+}
+
+func (n *nodeStub) GetBlockByNumber(number string, full bool) (json.RawMessage, error) {
+	return json.Marshal(&types.Header{Number: big.NewInt(1), Difficulty: big.NewInt(0), BaseFee: n.baseFee})
+}
+
+func (n *nodeStub) MaxPriorityFeePerGas() *hexutil.Big {
+	return (*hexutil.Big)(n.tip)
+}
+
+func (n *nodeStub) GasPrice() *hexutil.Big {
+	return (*hexutil.Big)(n.gasPrice)
+}
+
+func startNodeStub(t *testing.T, stub *nodeStub) *Client {
+	t.Helper()
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", stub); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	client, err := ethclient.Dial(httpServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		client.Close()
+		httpServer.Close()
+		server.Stop()
+	})
+	return &Client{RPC: client, ChainID: testChainID}
+}
+
+func gwei(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), big.NewInt(1_000_000_000))
+}
+
+func TestQuoteCallPricesDynamicFeesAtTwiceTheBaseFee(t *testing.T) {
+	client := startNodeStub(t, &nodeStub{estimate: 100_000, baseFee: gwei(10), tip: gwei(1)})
+
+	quote, err := QuoteCall(context.Background(), client, ethereum.CallMsg{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 20% on top of the estimate.
+	if quote.GasLimit != 120_000 {
+		t.Fatalf("gas limit = %d, want 120000", quote.GasLimit)
+	}
+	if quote.GasTipCap.Cmp(gwei(1)) != 0 {
+		t.Fatalf("tip cap = %s, want 1 gwei", quote.GasTipCap)
+	}
+	if quote.GasFeeCap.Cmp(gwei(21)) != 0 {
+		t.Fatalf("fee cap = %s, want tip + 2 * base fee = 21 gwei", quote.GasFeeCap)
+	}
+	if quote.GasPrice != nil {
+		t.Fatalf("gas price = %s, want none on a dynamic fee chain", quote.GasPrice)
+	}
+	if want := new(big.Int).Mul(gwei(21), big.NewInt(120_000)); quote.MaxCost.Cmp(want) != 0 {
+		t.Fatalf("max cost = %s, want %s", quote.MaxCost, want)
+	}
+}
+
+func TestQuoteCallPricesLegacyChainsAtTheGasPrice(t *testing.T) {
+	client := startNodeStub(t, &nodeStub{estimate: 21_000, gasPrice: gwei(5)})
+
+	quote, err := QuoteCall(context.Background(), client, ethereum.CallMsg{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if quote.GasLimit != 25_200 {
+		t.Fatalf("gas limit = %d, want 25200", quote.GasLimit)
+	}
+	if quote.GasFeeCap != nil || quote.GasTipCap != nil {
+		t.Fatalf("fee cap = %v, tip cap = %v, want none on a legacy chain", quote.GasFeeCap, quote.GasTipCap)
+	}
+	if want := new(big.Int).Mul(gwei(5), big.NewInt(25_200)); quote.MaxCost.Cmp(want) != 0 {
+		t.Fatalf("max cost = %s, want %s", quote.MaxCost, want)
+	}
+}
+
+func TestFeeQuoteApply(t *testing.T) {
+	quote := &FeeQuote{GasLimit: 120_000, GasFeeCap: gwei(21), GasTipCap: gwei(1)}
+	auth := &bind.TransactOpts{GasPrice: gwei(99)}
+
+	quote.Apply(auth)
+
+	if auth.GasLimit != 120_000 || auth.GasFeeCap.Cmp(gwei(21)) != 0 || auth.GasTipCap.Cmp(gwei(1)) != 0 || auth.GasPrice != nil {
+		t.Fatalf("auth = limit %d, fee cap %v, tip cap %v, price %v", auth.GasLimit, auth.GasFeeCap, auth.GasTipCap, auth.GasPrice)
+	}
+}
+
+func TestReceiptFee(t *testing.T) {
+	fee := ReceiptFee(&types.Receipt{GasUsed: 84_000, EffectiveGasPrice: gwei(12)})
+	if want := new(big.Int).Mul(gwei(12), big.NewInt(84_000)); fee == nil || fee.Cmp(want) != 0 {
+		t.Fatalf("fee = %v, want %s", fee, want)
+	}
+
+	if fee := ReceiptFee(&types.Receipt{GasUsed: 84_000}); fee != nil {
+		t.Fatalf("fee = %s, want none without an effective gas price", fee)
+	}
+}
@@ -0,0 +1,253 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"invoiceflow/internal/config"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	ErrSignerNotConfigured = errors.New("chain signer not configured")
+	ErrSignerTxMismatch    = errors.New("external signer returned a different transaction")
+)
+
+type Signer interface {
+	Address() common.Address
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+func NewSigner(ctx context.Context, cfg *config.Config) (Signer, error) {
+	switch cfg.ChainSigner {
+	case config.ChainSignerKeystore:
+		return NewKeystoreSigner(cfg.ChainKeystorePath, cfg.ChainKeystorePassphrase)
+	case config.ChainSignerExternal:
+		return NewExternalSigner(ctx, cfg.ChainSignerURL, cfg.ChainSignerAddress)
+	case config.ChainSignerKey:
+		if cfg.AppEnv != "dev" {
+			return nil, errors.New("raw private key signer is only allowed when APP_ENV=dev")
+		}
+		return NewKeySigner(cfg.ChainPrivateKey)
+	case "":
+		return nil, ErrSignerNotConfigured
+	default:
+		return nil, fmt.Errorf("unsupported chain signer %q", cfg.ChainSigner)
+	}
+}
+
+func NewTransactor(ctx context.Context, signer Signer, chainID *big.Int) *bind.TransactOpts {
+	from := signer.Address()
+	return &bind.TransactOpts{
+		From:    from,
+		Context: ctx,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != from {
+				return nil, bind.ErrNotAuthorized
+			}
+			return signer.SignTx(ctx, tx, chainID)
+		},
+	}
+}
+
+type KeySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+func NewKeySigner(hexKey string) (*KeySigner, error) {
+	if hexKey == "" {
+		return nil, ErrSignerNotConfigured
+	}
+
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(hexKey, "0x"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeySigner{key: key, address: crypto.PubkeyToAddress(key.PublicKey)}, nil
+}
+
+func (s *KeySigner) Address() common.Address {
+	return s.address
+}
+
+func (s *KeySigner) SignTx(_ context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainID), s.key)
+}
+
+// KeystoreSigner decrypts a go-ethereum keystore (V3 JSON) file once at
+// startup and keeps the key in memory.
+type KeystoreSigner struct {
+	*KeySigner
+}
+
+func NewKeystoreSigner(path string, passphrase string) (*KeystoreSigner, error) {
+	if path == "" {
+		return nil, ErrSignerNotConfigured
+	}
+
+	keyJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := keystore.DecryptKey(keyJSON, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeystoreSigner{KeySigner: &KeySigner{key: key.PrivateKey, address: key.Address}}, nil
+}
+
+// ExternalSigner delegates signing to a Clef-compatible JSON-RPC endpoint
+// (account_list / account_signTransaction).
+type ExternalSigner struct {
+	client  *rpc.Client
+	address common.Address
+}
+
+type externalSignArgs struct {
+	From                 common.MixedcaseAddress  `json:"from"`
+	To                   *common.MixedcaseAddress `json:"to"`
+	Gas                  hexutil.Uint64           `json:"gas"`
+	GasPrice             *hexutil.Big             `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big             `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big             `json:"maxPriorityFeePerGas,omitempty"`
+	Value                hexutil.Big              `json:"value"`
+	Nonce                hexutil.Uint64           `json:"nonce"`
+	Input                *hexutil.Bytes           `json:"input,omitempty"`
+	ChainID              *hexutil.Big             `json:"chainId,omitempty"`
+}
+
+type externalSignResult struct {
+	Raw hexutil.Bytes `json:"raw"`
+}
+
+func NewExternalSigner(ctx context.Context, endpoint string, address string) (*ExternalSigner, error) {
+	if endpoint == "" {
+		return nil, ErrSignerNotConfigured
+	}
+
+	client, err := rpc.DialContext(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	signer := &ExternalSigner{client: client}
+	if address != "" {
+		if !common.IsHexAddress(address) {
+			client.Close()
+			return nil, errors.New("invalid external signer address")
+		}
+		signer.address = common.HexToAddress(address)
+		return signer, nil
+	}
+
+	var accounts []common.Address
+	if err := client.CallContext(ctx, &accounts, "account_list"); err != nil {
+		client.Close()
+		return nil, err
+	}
+	if len(accounts) == 0 {
+		client.Close()
+		return nil, errors.New("external signer has no accounts")
+	}
+	signer.address = accounts[0]
+
+	return signer, nil
+}
+
+func (s *ExternalSigner) Address() common.Address {
+	return s.address
+}
+
+func (s *ExternalSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	input := hexutil.Bytes(tx.Data())
+	args := externalSignArgs{
+		From:    common.NewMixedcaseAddress(s.address),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   hexutil.Big(*tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Input:   &input,
+		ChainID: (*hexutil.Big)(chainID),
+	}
+	if tx.To() != nil {
+		to := common.NewMixedcaseAddress(*tx.To())
+		args.To = &to
+	}
+
+	switch tx.Type() {
+	case types.LegacyTxType, types.AccessListTxType:
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	case types.DynamicFeeTxType:
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+	default:
+		return nil, fmt.Errorf("unsupported tx type %d", tx.Type())
+	}
+
+	var res externalSignResult
+	if err := s.client.CallContext(ctx, &res, "account_signTransaction", args); err != nil {
+		return nil, err
+	}
+
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(res.Raw); err != nil {
+		return nil, err
+	}
+
+	if !sameTransaction(signed, tx, chainID) {
+		return nil, ErrSignerTxMismatch
+	}
+
+	sender, err := types.Sender(types.LatestSignerForChainID(chainID), signed)
+	if err != nil {
+		return nil, err
+	}
+	if sender != s.address {
+		return nil, ErrSignerTxMismatch
+	}
+
+	return signed, nil
+}
+
+func (s *ExternalSigner) Close() {
+	s.client.Close()
+}
+
+// sameTransaction checks a signed transaction is the one that was asked for:
+// a signer must not change where value goes, how much, what is called, or
+// what the transaction may spend on fees.
+func sameTransaction(signed *types.Transaction, tx *types.Transaction, chainID *big.Int) bool {
+	return signed.Type() == tx.Type() &&
+		signed.ChainId().Cmp(chainID) == 0 &&
+		signed.Nonce() == tx.Nonce() &&
+		signed.Gas() == tx.Gas() &&
+		signed.GasPrice().Cmp(tx.GasPrice()) == 0 &&
+		signed.GasFeeCap().Cmp(tx.GasFeeCap()) == 0 &&
+		signed.GasTipCap().Cmp(tx.GasTipCap()) == 0 &&
+		signed.Value().Cmp(tx.Value()) == 0 &&
+		bytes.Equal(signed.Data(), tx.Data()) &&
+		sameRecipient(signed.To(), tx.To())
+}
+
+func sameRecipient(a, b *common.Address) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
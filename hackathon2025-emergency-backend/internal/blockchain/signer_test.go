@@ -0,0 +1,259 @@
+package blockchain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const testKeyHex = "b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291"
+
+var testChainID = big.NewInt(11155111)
+
+func testTx() *types.Transaction {
+	to := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   testChainID,
+		Nonce:     7,
+		GasTipCap: big.NewInt(1_000_000_000),
+		GasFeeCap: big.NewInt(30_000_000_000),
+		Gas:       120_000,
+		To:        &to,
+		Value:     big.NewInt(0),
+		Data:      []byte{0xde, 0xad, 0xbe, 0xef},
+	})
+}
+
+// clefStub is a local stand-in for Clef: it answers account_list and
+// account_signTransaction with its own key. tamper, when set, changes the
+// transaction before it is signed, as a misbehaving signer would.
+type clefStub struct {
+	key    *ecdsa.PrivateKey
+	tamper func(*types.DynamicFeeTx)
+}
+
+func (s *clefStub) List() []common.Address {
+	return []common.Address{crypto.PubkeyToAddress(s.key.PublicKey)}
+}
+
+func (s *clefStub) SignTransaction(args externalSignArgs) (*externalSignResult, error) {
+	var to *common.Address
+	if args.To != nil {
+		address := args.To.Address()
+		to = &address
+	}
+	var input []byte
+	if args.Input != nil {
+		input = *args.Input
+	}
+
+	unsigned := &types.DynamicFeeTx{
+		ChainID:   (*big.Int)(args.ChainID),
+		Nonce:     uint64(args.Nonce),
+		GasTipCap: (*big.Int)(args.MaxPriorityFeePerGas),
+		GasFeeCap: (*big.Int)(args.MaxFeePerGas),
+		Gas:       uint64(args.Gas),
+		To:        to,
+		Value:     (*big.Int)(&args.Value),
+		Data:      input,
+	}
+	if s.tamper != nil {
+		s.tamper(unsigned)
+	}
+
+	signed, err := types.SignNewTx(s.key, types.LatestSignerForChainID(unsigned.ChainID), unsigned)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := signed.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &externalSignResult{Raw: hexutil.Bytes(raw)}, nil
+}
+
+func startClefStub(t *testing.T, stub *clefStub) string {
+	t.Helper()
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("account", stub); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(func() {
+		httpServer.Close()
+		server.Stop()
+	})
+	return httpServer.URL
+}
+
+func testKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := crypto.HexToECDSA(testKeyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func assertSignedBy(t *testing.T, signed *types.Transaction, address common.Address) {
+	t.Helper()
+	sender, err := types.Sender(types.LatestSignerForChainID(testChainID), signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sender != address {
+		t.Fatalf("signed by %s, want %s", sender, address)
+	}
+}
+
+func TestKeySignerSignsForChain(t *testing.T) {
+	signer, err := NewKeySigner("0x" + testKeyHex)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signed, err := signer.SignTx(context.Background(), testTx(), testChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSignedBy(t, signed, signer.Address())
+}
+
+func TestKeySignerRequiresKey(t *testing.T) {
+	if _, err := NewKeySigner(""); !errors.Is(err, ErrSignerNotConfigured) {
+		t.Fatalf("err = %v, want ErrSignerNotConfigured", err)
+	}
+}
+
+func TestKeystoreSignerDecryptsKeystore(t *testing.T) {
+	key := testKey(t)
+	store := keystore.NewKeyStore(t.TempDir(), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := store.ImportECDSA(key, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewKeystoreSigner(account.URL.Path, "wrong"); err == nil {
+		t.Fatal("wrong passphrase was accepted")
+	}
+
+	signer, err := NewKeystoreSigner(account.URL.Path, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer.Address() != account.Address {
+		t.Fatalf("address = %s, want %s", signer.Address(), account.Address)
+	}
+
+	signed, err := signer.SignTx(context.Background(), testTx(), testChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSignedBy(t, signed, account.Address)
+}
+
+func TestExternalSignerUsesFirstAccount(t *testing.T) {
+	stub := &clefStub{key: testKey(t)}
+	url := startClefStub(t, stub)
+
+	signer, err := NewExternalSigner(context.Background(), url, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer signer.Close()
+
+	want := crypto.PubkeyToAddress(stub.key.PublicKey)
+	if signer.Address() != want {
+		t.Fatalf("address = %s, want %s", signer.Address(), want)
+	}
+
+	tx := testTx()
+	signed, err := signer.SignTx(context.Background(), tx, testChainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSignedBy(t, signed, want)
+	if signed.Hash() == tx.Hash() {
+		t.Fatal("transaction was not signed")
+	}
+}
+
+func TestExternalSignerRejectsAlteredTransaction(t *testing.T) {
+	tampers := map[string]func(*types.DynamicFeeTx){
+		"chain id": func(tx *types.DynamicFeeTx) { tx.ChainID = big.NewInt(1) },
+		"nonce":    func(tx *types.DynamicFeeTx) { tx.Nonce++ },
+		"gas":      func(tx *types.DynamicFeeTx) { tx.Gas *= 10 },
+		"fee cap":  func(tx *types.DynamicFeeTx) { tx.GasFeeCap = big.NewInt(3_000_000_000_000) },
+		"tip cap":  func(tx *types.DynamicFeeTx) { tx.GasTipCap = big.NewInt(29_000_000_000) },
+		"value":    func(tx *types.DynamicFeeTx) { tx.Value = big.NewInt(1) },
+		"data":     func(tx *types.DynamicFeeTx) { tx.Data = []byte{0x01} },
+		"to": func(tx *types.DynamicFeeTx) {
+			to := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+			tx.To = &to
+		},
+	}
+
+	for name, tamper := range tampers {
+		t.Run(name, func(t *testing.T) {
+			url := startClefStub(t, &clefStub{key: testKey(t), tamper: tamper})
+
+			signer, err := NewExternalSigner(context.Background(), url, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer signer.Close()
+
+			if _, err := signer.SignTx(context.Background(), testTx(), testChainID); !errors.Is(err, ErrSignerTxMismatch) {
+				t.Fatalf("err = %v, want ErrSignerTxMismatch", err)
+			}
+		})
+	}
+}
+
+func TestSameTransactionComparesType(t *testing.T) {
+	tx := testTx()
+	legacy := types.NewTx(&types.LegacyTx{
+		Nonce:    tx.Nonce(),
+		GasPrice: tx.GasFeeCap(),
+		Gas:      tx.Gas(),
+		To:       tx.To(),
+		Value:    tx.Value(),
+		Data:     tx.Data(),
+	})
+	signed, err := types.SignTx(legacy, types.LatestSignerForChainID(testChainID), testKey(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sameTransaction(signed, tx, testChainID) {
+		t.Fatal("a legacy transaction matched a dynamic fee transaction")
+	}
+	if !sameTransaction(tx, tx, testChainID) {
+		t.Fatal("a transaction did not match itself")
+	}
+}
+
+func TestExternalSignerRejectsOtherAccount(t *testing.T) {
+	url := startClefStub(t, &clefStub{key: testKey(t)})
+
+	signer, err := NewExternalSigner(context.Background(), url, "0x00000000000000000000000000000000000000cc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer signer.Close()
+
+	if _, err := signer.SignTx(context.Background(), testTx(), testChainID); !errors.Is(err, ErrSignerTxMismatch) {
+		t.Fatalf("err = %v, want ErrSignerTxMismatch", err)
+	}
+}
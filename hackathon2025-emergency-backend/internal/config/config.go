@@ -1,26 +1,87 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"math/big"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	AppEnv                    string
-	Port                      string
-	DBURL                     string
-	JWTSecret                 string
-	JWTTTLMinutes             int
-	CORSOrigins               []string
-	EnableChain               bool
-	ChainRPCURL               string
-	ChainID                   int64
-	ContractInvoiceNFTAddress string
-	ChainPrivateKey           string
+	AppEnv                  string
+	Port                    string
+	DBURL                   string
+	JWTSecret               string
+	JWTAlgorithm            string
+	JWTKeyDir               string
+	JWTRotateIntervalHours  int
+	JWTKeyRetentionHours    int
+	JWTTTLMinutes           int
+	RefreshTTLHours         int
+	CORSOrigins             []string
+	TrustedProxies          []string
+	EnableChain             bool
+	ChainProfiles           []ChainProfile
+	DefaultChainProfile     string
+	ChainSigner             string
+	ChainPrivateKey         string
+	ChainKeystorePath       string
+	ChainKeystorePassphrase string
+	ChainSignerURL          string
+	ChainSignerAddress      string
+	AppBaseURL              string
+	MailDriver              string
+	MailFrom                string
+	MailFileDir             string
+	SMTPHost                string
+	SMTPPort                int
+	SMTPUsername            string
+	SMTPPassword            string
+	MFAIssuer               string
+	MFAEncryptionKey        []byte
+	AuditHMACKey            []byte
+	LoginMaxFailures        int
+	LoginIPMaxFailures      int
+	LoginFailureWindow      time.Duration
+	LoginLockout            time.Duration
+	IdempotencyTTL          time.Duration
+	WebhookMaxAttempts      int
+	WebhookTimeout          time.Duration
+	EmergencyMaxAmount      float64
+	EmergencyMaxTermMonths  int
+	EmergencyReviewSLA      time.Duration
+	MetricsRefreshInterval  time.Duration
+}
+
+// 0.01 ETH
+const defaultMaxTxFeeWei = "10000000000000000"
+
+const (
+	MailDriverSMTP = "smtp"
+	MailDriverFile = "file"
+	MailDriverLog  = "log"
+)
+
+const (
+	ChainSignerKey      = "key"
+	ChainSignerKeystore = "keystore"
+	ChainSignerExternal = "external"
+)
+
+type ChainProfile struct {
+	ID              string
+	RPCURL          string
+	ChainID         int64
+	ContractAddress string
+	Confirmations   uint64
+	MaxTxFeeWei     *big.Int
 }
 
 func Load() (*Config, error) {
@@ -36,20 +97,60 @@ func Load() (*Config, error) {
 		return nil, errors.New("DB_URL is required")
 	}
 
-	cfg.JWTSecret = os.Getenv("JWT_SECRET")
-	if cfg.JWTSecret == "" {
-		return nil, errors.New("JWT_SECRET is required")
+	if err := loadJWTKeys(cfg); err != nil {
+		return nil, err
 	}
 
-	ttlStr := getEnv("JWT_TTL_MINUTES", "60")
+	ttlStr := getEnv("JWT_TTL_MINUTES", "15")
 	ttlMinutes, err := strconv.Atoi(ttlStr)
 	if err != nil || ttlMinutes <= 0 {
 		return nil, errors.New("JWT_TTL_MINUTES must be a positive integer")
 	}
 	cfg.JWTTTLMinutes = ttlMinutes
 
+	refreshTTL, err := strconv.Atoi(getEnv("REFRESH_TTL_HOURS", "720"))
+	if err != nil || refreshTTL <= 0 {
+		return nil, errors.New("REFRESH_TTL_HOURS must be a positive integer")
+	}
+	cfg.RefreshTTLHours = refreshTTL
+
 	corsOrigins := getEnv("CORS_ORIGINS", "http://localhost:5173")
 	cfg.CORSOrigins = parseCSV(corsOrigins)
+	cfg.TrustedProxies = parseCSV(os.Getenv("TRUSTED_PROXIES"))
+
+	if err := loadMail(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := loadMFA(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := loadAudit(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := loadLoginThrottle(cfg); err != nil {
+		return nil, err
+	}
+
+	idempotencyTTL, err := strconv.Atoi(getEnv("IDEMPOTENCY_TTL_HOURS", "24"))
+	if err != nil || idempotencyTTL <= 0 {
+		return nil, errors.New("IDEMPOTENCY_TTL_HOURS must be a positive integer")
+	}
+	cfg.IdempotencyTTL = time.Duration(idempotencyTTL) * time.Hour
+
+	if err := loadWebhooks(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := loadMetrics(cfg); err != nil {
+		return nil, err
+	}
+
+	if err := loadEmergencyLane(cfg); err != nil {
+		return nil, err
+	}
 
 	enableChain := getEnv("ENABLE_CHAIN", "false")
 	parsedEnable, err := strconv.ParseBool(enableChain)
@@ -59,25 +160,365 @@ func Load() (*Config, error) {
 	cfg.EnableChain = parsedEnable
 
 	if cfg.EnableChain {
-		cfg.ChainRPCURL = os.Getenv("CHAIN_RPC_URL")
-		cfg.ContractInvoiceNFTAddress = os.Getenv("CONTRACT_INVOICE_NFT_ADDRESS")
-		cfg.ChainPrivateKey = os.Getenv("CHAIN_PRIVATE_KEY")
-
-		chainIDStr := os.Getenv("CHAIN_ID")
-		if cfg.ChainRPCURL == "" || cfg.ContractInvoiceNFTAddress == "" || chainIDStr == "" {
-			return nil, errors.New("CHAIN_RPC_URL, CHAIN_ID, and CONTRACT_INVOICE_NFT_ADDRESS are required when ENABLE_CHAIN=true")
+		if err := loadChainSigner(cfg); err != nil {
+			return nil, err
 		}
 
-		chainID, err := strconv.ParseInt(chainIDStr, 10, 64)
+		profiles, defaultProfile, err := loadChainProfiles()
 		if err != nil {
-			return nil, errors.New("CHAIN_ID must be an integer")
+			return nil, err
 		}
-		cfg.ChainID = chainID
+		cfg.ChainProfiles = profiles
+		cfg.DefaultChainProfile = defaultProfile
 	}
 
 	return cfg, nil
 }
 
+func loadJWTKeys(cfg *Config) error {
+	defaultAlg := "RS256"
+	if cfg.AppEnv == "dev" {
+		defaultAlg = "HS256"
+	}
+	cfg.JWTAlgorithm = getEnv("JWT_ALG", defaultAlg)
+
+	switch cfg.JWTAlgorithm {
+	case "HS256":
+		if cfg.AppEnv != "dev" {
+			return errors.New("JWT_ALG=HS256 is only allowed when APP_ENV=dev")
+		}
+		cfg.JWTSecret = os.Getenv("JWT_SECRET")
+		if cfg.JWTSecret == "" {
+			return errors.New("JWT_SECRET is required")
+		}
+		return nil
+	case "RS256", "EdDSA":
+	default:
+		return errors.New("JWT_ALG must be HS256, RS256, or EdDSA")
+	}
+
+	cfg.JWTKeyDir = os.Getenv("JWT_KEY_DIR")
+	if cfg.JWTKeyDir == "" {
+		return errors.New("JWT_KEY_DIR is required when JWT_ALG is RS256 or EdDSA")
+	}
+
+	rotate, err := strconv.Atoi(getEnv("JWT_ROTATE_INTERVAL_HOURS", "0"))
+	if err != nil || rotate < 0 {
+		return errors.New("JWT_ROTATE_INTERVAL_HOURS must be a non-negative integer")
+	}
+	cfg.JWTRotateIntervalHours = rotate
+
+	retention, err := strconv.Atoi(getEnv("JWT_KEY_RETENTION_HOURS", "0"))
+	if err != nil || retention < 0 {
+		return errors.New("JWT_KEY_RETENTION_HOURS must be a non-negative integer")
+	}
+	if retention > 0 && retention <= rotate {
+		return errors.New("JWT_KEY_RETENTION_HOURS must be greater than JWT_ROTATE_INTERVAL_HOURS")
+	}
+	cfg.JWTKeyRetentionHours = retention
+
+	return nil
+}
+
+// loadMail configures outgoing email. Emailed links point at APP_BASE_URL,
+// the frontend that handles /verify-email and /reset-password.
+func loadMail(cfg *Config) error {
+	cfg.AppBaseURL = strings.TrimRight(getEnv("APP_BASE_URL", "http://localhost:5173"), "/")
+	cfg.MailFrom = getEnv("MAIL_FROM", "InvoiceFlow <no-reply@invoiceflow.local>")
+
+	defaultDriver := MailDriverSMTP
+	if cfg.AppEnv == "dev" {
+		defaultDriver = MailDriverLog
+	}
+	cfg.MailDriver = strings.ToLower(getEnv("MAIL_DRIVER", defaultDriver))
+
+	switch cfg.MailDriver {
+	case MailDriverSMTP:
+		cfg.SMTPHost = os.Getenv("SMTP_HOST")
+		if cfg.SMTPHost == "" {
+			return errors.New("SMTP_HOST is required when MAIL_DRIVER=smtp")
+		}
+		port, err := strconv.Atoi(getEnv("SMTP_PORT", "587"))
+		if err != nil || port <= 0 {
+			return errors.New("SMTP_PORT must be a positive integer")
+		}
+		cfg.SMTPPort = port
+		cfg.SMTPUsername = os.Getenv("SMTP_USERNAME")
+		cfg.SMTPPassword = os.Getenv("SMTP_PASSWORD")
+	case MailDriverFile:
+		cfg.MailFileDir = getEnv("MAIL_FILE_DIR", "./tmp/mail")
+	case MailDriverLog:
+		if cfg.AppEnv != "dev" {
+			return errors.New("MAIL_DRIVER=log is only allowed when APP_ENV=dev")
+		}
+	default:
+		return errors.New("MAIL_DRIVER must be smtp, file, or log")
+	}
+
+	return nil
+}
+
+// loadMFA reads the AES-256 key that encrypts TOTP secrets at rest. Dev runs
+// fall back to a fixed key so enrollment works out of the box.
+func loadMFA(cfg *Config) error {
+	cfg.MFAIssuer = getEnv("MFA_ISSUER", "InvoiceFlow")
+
+	encoded := os.Getenv("MFA_ENCRYPTION_KEY")
+	if encoded == "" {
+		if cfg.AppEnv != "dev" {
+			return errors.New("MFA_ENCRYPTION_KEY is required")
+		}
+		sum := sha256.Sum256([]byte("invoiceflow-dev-mfa-key"))
+		cfg.MFAEncryptionKey = sum[:]
+		return nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != 32 {
+		return errors.New("MFA_ENCRYPTION_KEY must be 32 bytes, base64 encoded")
+	}
+	cfg.MFAEncryptionKey = key
+
+	return nil
+}
+
+// loadAudit reads the HMAC key that seals the audit log's hash chains, so
+// someone with database access alone cannot rewrite a chain. Dev runs fall
+// back to a fixed key.
+func loadAudit(cfg *Config) error {
+	encoded := os.Getenv("AUDIT_HMAC_KEY")
+	if encoded == "" {
+		if cfg.AppEnv != "dev" {
+			return errors.New("AUDIT_HMAC_KEY is required")
+		}
+		sum := sha256.Sum256([]byte("invoiceflow-dev-audit-key"))
+		cfg.AuditHMACKey = sum[:]
+		return nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) < 32 {
+		return errors.New("AUDIT_HMAC_KEY must be at least 32 bytes, base64 encoded")
+	}
+	cfg.AuditHMACKey = key
+
+	return nil
+}
+
+func loadLoginThrottle(cfg *Config) error {
+	maxFailures, err := strconv.Atoi(getEnv("LOGIN_MAX_FAILURES", "5"))
+	if err != nil || maxFailures < 2 {
+		return errors.New("LOGIN_MAX_FAILURES must be an integer of at least 2")
+	}
+	cfg.LoginMaxFailures = maxFailures
+
+	ipMaxFailures, err := strconv.Atoi(getEnv("LOGIN_IP_MAX_FAILURES", "50"))
+	if err != nil || ipMaxFailures < 2 {
+		return errors.New("LOGIN_IP_MAX_FAILURES must be an integer of at least 2")
+	}
+	cfg.LoginIPMaxFailures = ipMaxFailures
+
+	window, err := strconv.Atoi(getEnv("LOGIN_FAILURE_WINDOW_MINUTES", "15"))
+	if err != nil || window <= 0 {
+		return errors.New("LOGIN_FAILURE_WINDOW_MINUTES must be a positive integer")
+	}
+	cfg.LoginFailureWindow = time.Duration(window) * time.Minute
+
+	lockout, err := strconv.Atoi(getEnv("LOGIN_LOCKOUT_MINUTES", "15"))
+	if err != nil || lockout <= 0 {
+		return errors.New("LOGIN_LOCKOUT_MINUTES must be a positive integer")
+	}
+	cfg.LoginLockout = time.Duration(lockout) * time.Minute
+
+	return nil
+}
+
+func loadWebhooks(cfg *Config) error {
+	maxAttempts, err := strconv.Atoi(getEnv("WEBHOOK_MAX_ATTEMPTS", "8"))
+	if err != nil || maxAttempts <= 0 {
+		return errors.New("WEBHOOK_MAX_ATTEMPTS must be a positive integer")
+	}
+	cfg.WebhookMaxAttempts = maxAttempts
+
+	timeout, err := strconv.Atoi(getEnv("WEBHOOK_TIMEOUT_SECONDS", "10"))
+	if err != nil || timeout <= 0 {
+		return errors.New("WEBHOOK_TIMEOUT_SECONDS must be a positive integer")
+	}
+	cfg.WebhookTimeout = time.Duration(timeout) * time.Second
+
+	return nil
+}
+
+// loadEmergencyLane reads the caps an emergency-lane invoice must stay
+// within and how long reviewers have before it is escalated.
+func loadEmergencyLane(cfg *Config) error {
+	maxAmount, err := strconv.ParseFloat(getEnv("EMERGENCY_MAX_AMOUNT", "50000"), 64)
+	if err != nil || maxAmount <= 0 {
+		return errors.New("EMERGENCY_MAX_AMOUNT must be a positive number")
+	}
+	cfg.EmergencyMaxAmount = maxAmount
+
+	maxTerm, err := strconv.Atoi(getEnv("EMERGENCY_MAX_TERM_MONTHS", "3"))
+	if err != nil || maxTerm <= 0 {
+		return errors.New("EMERGENCY_MAX_TERM_MONTHS must be a positive integer")
+	}
+	cfg.EmergencyMaxTermMonths = maxTerm
+
+	sla, err := strconv.Atoi(getEnv("EMERGENCY_REVIEW_SLA_MINUTES", "240"))
+	if err != nil || sla <= 0 {
+		return errors.New("EMERGENCY_REVIEW_SLA_MINUTES must be a positive integer")
+	}
+	cfg.EmergencyReviewSLA = time.Duration(sla) * time.Minute
+
+	return nil
+}
+
+// loadMetrics reads how often the dashboard rollups are refreshed.
+func loadMetrics(cfg *Config) error {
+	refresh, err := strconv.Atoi(getEnv("METRICS_REFRESH_MINUTES", "5"))
+	if err != nil || refresh <= 0 {
+		return errors.New("METRICS_REFRESH_MINUTES must be a positive integer")
+	}
+	cfg.MetricsRefreshInterval = time.Duration(refresh) * time.Minute
+
+	return nil
+}
+
+func (c *Config) ChainProfile(id string) (ChainProfile, bool) {
+	if id == "" {
+		id = c.DefaultChainProfile
+	}
+	for _, profile := range c.ChainProfiles {
+		if profile.ID == id {
+			return profile, true
+		}
+	}
+	return ChainProfile{}, false
+}
+
+func loadChainSigner(cfg *Config) error {
+	cfg.ChainPrivateKey = os.Getenv("CHAIN_PRIVATE_KEY")
+	cfg.ChainKeystorePath = os.Getenv("CHAIN_KEYSTORE_PATH")
+	cfg.ChainKeystorePassphrase = os.Getenv("CHAIN_KEYSTORE_PASSPHRASE")
+	cfg.ChainSignerURL = os.Getenv("CHAIN_SIGNER_URL")
+	cfg.ChainSignerAddress = os.Getenv("CHAIN_SIGNER_ADDRESS")
+
+	if passphraseFile := os.Getenv("CHAIN_KEYSTORE_PASSPHRASE_FILE"); passphraseFile != "" {
+		content, err := os.ReadFile(passphraseFile)
+		if err != nil {
+			return errors.New("CHAIN_KEYSTORE_PASSPHRASE_FILE could not be read")
+		}
+		cfg.ChainKeystorePassphrase = strings.TrimRight(string(content), "\r\n")
+	}
+
+	signer := strings.ToLower(os.Getenv("CHAIN_SIGNER"))
+	if signer == "" {
+		switch {
+		case cfg.ChainKeystorePath != "":
+			signer = ChainSignerKeystore
+		case cfg.ChainSignerURL != "":
+			signer = ChainSignerExternal
+		case cfg.ChainPrivateKey != "":
+			signer = ChainSignerKey
+		}
+	}
+
+	switch signer {
+	case "":
+	case ChainSignerKeystore:
+		if cfg.ChainKeystorePath == "" {
+			return errors.New("CHAIN_KEYSTORE_PATH is required when CHAIN_SIGNER=keystore")
+		}
+	case ChainSignerExternal:
+		if cfg.ChainSignerURL == "" {
+			return errors.New("CHAIN_SIGNER_URL is required when CHAIN_SIGNER=external")
+		}
+	case ChainSignerKey:
+		if cfg.AppEnv != "dev" {
+			return errors.New("CHAIN_PRIVATE_KEY is only allowed when APP_ENV=dev")
+		}
+		if cfg.ChainPrivateKey == "" {
+			return errors.New("CHAIN_PRIVATE_KEY is required when CHAIN_SIGNER=key")
+		}
+	default:
+		return errors.New("CHAIN_SIGNER must be keystore, external, or key")
+	}
+	cfg.ChainSigner = signer
+
+	return nil
+}
+
+// loadChainProfiles reads CHAIN_PROFILES (e.g. "sepolia,mainnet") and the
+// matching CHAIN_<ID>_* variables. Without CHAIN_PROFILES the legacy
+// single-chain variables become a profile named "default".
+func loadChainProfiles() ([]ChainProfile, string, error) {
+	ids := parseCSV(os.Getenv("CHAIN_PROFILES"))
+	if len(ids) == 0 {
+		profile, err := loadChainProfile("default", "CHAIN_RPC_URL", "CHAIN_ID", "CONTRACT_INVOICE_NFT_ADDRESS", "CHAIN_CONFIRMATIONS", "CHAIN_MAX_TX_FEE_WEI")
+		if err != nil {
+			return nil, "", err
+		}
+		return []ChainProfile{profile}, profile.ID, nil
+	}
+
+	profiles := make([]ChainProfile, 0, len(ids))
+	seen := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		id = strings.ToLower(id)
+		if _, ok := seen[id]; ok {
+			return nil, "", fmt.Errorf("chain profile %q is listed twice", id)
+		}
+		seen[id] = struct{}{}
+
+		prefix := "CHAIN_" + strings.ToUpper(strings.ReplaceAll(id, "-", "_")) + "_"
+		profile, err := loadChainProfile(id, prefix+"RPC_URL", prefix+"ID", prefix+"CONTRACT_INVOICE_NFT_ADDRESS", prefix+"CONFIRMATIONS", prefix+"MAX_TX_FEE_WEI")
+		if err != nil {
+			return nil, "", err
+		}
+		profiles = append(profiles, profile)
+	}
+
+	defaultProfile := strings.ToLower(getEnv("CHAIN_DEFAULT_PROFILE", profiles[0].ID))
+	if _, ok := seen[defaultProfile]; !ok {
+		return nil, "", errors.New("CHAIN_DEFAULT_PROFILE must be one of CHAIN_PROFILES")
+	}
+
+	return profiles, defaultProfile, nil
+}
+
+func loadChainProfile(id, rpcKey, chainIDKey, contractKey, confirmationsKey, maxFeeKey string) (ChainProfile, error) {
+	profile := ChainProfile{
+		ID:              id,
+		RPCURL:          os.Getenv(rpcKey),
+		ContractAddress: os.Getenv(contractKey),
+	}
+
+	chainIDStr := os.Getenv(chainIDKey)
+	if profile.RPCURL == "" || profile.ContractAddress == "" || chainIDStr == "" {
+		return ChainProfile{}, fmt.Errorf("%s, %s, and %s are required when ENABLE_CHAIN=true", rpcKey, chainIDKey, contractKey)
+	}
+
+	chainID, err := strconv.ParseInt(chainIDStr, 10, 64)
+	if err != nil || chainID <= 0 {
+		return ChainProfile{}, fmt.Errorf("%s must be a positive integer", chainIDKey)
+	}
+	profile.ChainID = chainID
+
+	confirmations, err := strconv.ParseUint(getEnv(confirmationsKey, "1"), 10, 64)
+	if err != nil || confirmations == 0 {
+		return ChainProfile{}, fmt.Errorf("%s must be a positive integer", confirmationsKey)
+	}
+	profile.Confirmations = confirmations
+
+	maxFee, ok := new(big.Int).SetString(getEnv(maxFeeKey, getEnv("CHAIN_MAX_TX_FEE_WEI", defaultMaxTxFeeWei)), 10)
+	if !ok || maxFee.Sign() <= 0 {
+		return ChainProfile{}, fmt.Errorf("%s must be a positive integer amount of wei", maxFeeKey)
+	}
+	profile.MaxTxFeeWei = maxFee
+
+	return profile, nil
+}
+
 func getEnv(key, fallback string) string {
 	value := os.Getenv(key)
 	if value == "" {
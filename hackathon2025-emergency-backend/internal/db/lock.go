@@ -0,0 +1,57 @@
+package db
+
+import (
+	"context"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Advisory lock keys for work that only one instance should do at a time.
+// LockAdminBootstrap is taken per transaction, so two bootstrap runs at once
+// cannot both see no admin.
+const (
+	LockJWTRotation    int64 = 0x4a575452 // "JWTR"
+	LockMetricsRefresh int64 = 0x4d455452 // "METR"
+	LockAdminBootstrap int64 = 0x626f6f74 // "boot"
+)
+
+// Locker takes a lock shared by every instance without waiting; acquired is
+// false when another instance holds it.
+type Locker func(ctx context.Context) (release func(), acquired bool, err error)
+
+// AdvisoryLocker returns a Locker that takes the advisory lock key with
+// TryLock.
+func AdvisoryLocker(database *sqlx.DB, key int64) Locker {
+	return func(ctx context.Context) (func(), bool, error) {
+		return TryLock(ctx, database, key)
+	}
+}
+
+// TryLock takes a session-level advisory lock on its own connection without
+// waiting. When another instance holds it, acquired is false. release unlocks
+// and returns the connection; it must be called once acquired is true.
+func TryLock(ctx context.Context, database *sqlx.DB, key int64) (release func(), acquired bool, err error) {
+	conn, err := database.Connx(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := conn.GetContext(ctx, &acquired, "SELECT pg_try_advisory_lock($1)", key); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !acquired {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	release = func() {
+		// The request context may be done by now; unlock regardless.
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key); err != nil {
+			log.Printf("advisory unlock %d failed: %v", key, err)
+		}
+		conn.Close()
+	}
+	return release, true, nil
+}
@@ -0,0 +1,39 @@
+package domain
+
+import "time"
+
+const (
+	AuthEventLoginSuccess = "LOGIN_SUCCESS"
+	AuthEventLoginFailure = "LOGIN_FAILURE"
+	AuthEventThrottled    = "LOGIN_THROTTLED"
+	AuthEventLocked       = "LOGIN_LOCKED"
+	AuthEventMFAChallenge = "MFA_CHALLENGE"
+	AuthEventMFASuccess   = "MFA_SUCCESS"
+	AuthEventMFAFailure   = "MFA_FAILURE"
+	AuthEventUnlock       = "UNLOCK"
+)
+
+const (
+	ThrottleScopeEmail = "email"
+	ThrottleScopeIP    = "ip"
+)
+
+type AuthEvent struct {
+	ID        string    `db:"id" json:"id"`
+	UserID    *string   `db:"user_id" json:"user_id"`
+	Email     *string   `db:"email" json:"email"`
+	IP        *string   `db:"ip" json:"ip"`
+	UserAgent *string   `db:"user_agent" json:"user_agent"`
+	Event     string    `db:"event" json:"event"`
+	Reason    *string   `db:"reason" json:"reason"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type LoginThrottle struct {
+	Scope         string     `db:"scope" json:"scope"`
+	Key           string     `db:"key" json:"key"`
+	Failures      int        `db:"failures" json:"failures"`
+	FirstFailedAt time.Time  `db:"first_failed_at" json:"first_failed_at"`
+	LastFailedAt  time.Time  `db:"last_failed_at" json:"last_failed_at"`
+	LockedUntil   *time.Time `db:"locked_until" json:"locked_until"`
+}
@@ -0,0 +1,47 @@
+package domain
+
+import "time"
+
+// Emergency-lane preferences of an auto-invest strategy.
+const (
+	AutoInvestEmergencyInclude = "include"
+	AutoInvestEmergencyOnly    = "only"
+	AutoInvestEmergencyExclude = "exclude"
+)
+
+func ValidAutoInvestEmergencyLane(value string) bool {
+	switch value {
+	case AutoInvestEmergencyInclude, AutoInvestEmergencyOnly, AutoInvestEmergencyExclude:
+		return true
+	default:
+		return false
+	}
+}
+
+type AutoInvestStrategy struct {
+	ID             string      `db:"id" json:"id"`
+	OrganizationID string      `db:"organization_id" json:"organization_id"`
+	InvestorID     string      `db:"investor_id" json:"investor_id"`
+	Name           string      `db:"name" json:"name"`
+	Currency       string      `db:"currency" json:"currency"`
+	MaxPerInvoice  float64     `db:"max_per_invoice" json:"max_per_invoice"`
+	Budget         float64     `db:"budget" json:"budget"`
+	InvestedAmount float64     `db:"invested_amount" json:"invested_amount"`
+	RiskTiers      StringSlice `db:"risk_tiers" json:"risk_tiers"`
+	MinAPRPercent  *float64    `db:"min_apr_percent" json:"min_apr_percent"`
+	MaxTermMonths  *int        `db:"max_term_months" json:"max_term_months"`
+	Tags           StringSlice `db:"tags" json:"tags"`
+	EmergencyLane  string      `db:"emergency_lane" json:"emergency_lane"`
+	Active         bool        `db:"active" json:"active"`
+	CreatedAt      time.Time   `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time   `db:"updated_at" json:"updated_at"`
+}
+
+type AutoInvestAllocation struct {
+	ID         string    `db:"id" json:"id"`
+	StrategyID string    `db:"strategy_id" json:"strategy_id"`
+	InvoiceID  string    `db:"invoice_id" json:"invoice_id"`
+	FundingID  string    `db:"funding_id" json:"funding_id"`
+	Amount     float64   `db:"amount" json:"amount"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
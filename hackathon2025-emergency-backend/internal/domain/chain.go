@@ -10,6 +10,7 @@ const (
 
 type InvoiceOnChain struct {
 	InvoiceID       string     `db:"invoice_id" json:"invoice_id"`
+	ChainID         int64      `db:"chain_id" json:"chain_id"`
 	ContractAddress string     `db:"contract_address" json:"contract_address"`
 	TokenID         *string    `db:"token_id" json:"token_id"`
 	MintTxHash      *string    `db:"mint_tx_hash" json:"mint_tx_hash"`
@@ -20,11 +21,45 @@ type InvoiceOnChain struct {
 }
 
 type ChainTx struct {
-	TxHash      string     `db:"tx_hash" json:"tx_hash"`
-	Type        string     `db:"type" json:"type"`
-	Status      string     `db:"status" json:"status"`
-	Error       *string    `db:"error" json:"error"`
-	ReceiptJSON []byte     `db:"receipt_json" json:"receipt_json"`
-	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
-	ConfirmedAt *time.Time `db:"confirmed_at" json:"confirmed_at"`
+	TxHash            string     `db:"tx_hash" json:"tx_hash"`
+	ChainID           int64      `db:"chain_id" json:"chain_id"`
+	InvoiceID         *string    `db:"invoice_id" json:"invoice_id"`
+	Type              string     `db:"type" json:"type"`
+	Status            string     `db:"status" json:"status"`
+	Error             *string    `db:"error" json:"error"`
+	ReceiptJSON       []byte     `db:"receipt_json" json:"receipt_json"`
+	GasLimit          *int64     `db:"gas_limit" json:"gas_limit"`
+	MaxFeeWei         *string    `db:"max_fee_wei" json:"max_fee_wei"`
+	GasUsed           *int64     `db:"gas_used" json:"gas_used"`
+	EffectiveGasPrice *string    `db:"effective_gas_price" json:"effective_gas_price"`
+	FeeWei            *string    `db:"fee_wei" json:"fee_wei"`
+	CreatedAt         time.Time  `db:"created_at" json:"created_at"`
+	ConfirmedAt       *time.Time `db:"confirmed_at" json:"confirmed_at"`
+}
+
+type ChainTxCost struct {
+	GasUsed           int64
+	EffectiveGasPrice string
+	FeeWei            string
+}
+
+type InvoiceChainCost struct {
+	InvoiceID string  `db:"invoice_id" json:"invoice_id"`
+	Title     string  `db:"title" json:"title"`
+	ChainID   int64   `db:"chain_id" json:"chain_id"`
+	TxCount   int     `db:"tx_count" json:"tx_count"`
+	GasUsed   int64   `db:"gas_used" json:"gas_used"`
+	FeeWei    string  `db:"fee_wei" json:"fee_wei"`
+	FeeEth    float64 `db:"fee_eth" json:"fee_eth"`
+}
+
+type ChainCostSummary struct {
+	ChainID        int64   `db:"chain_id" json:"chain_id"`
+	TxCount        int     `db:"tx_count" json:"tx_count"`
+	ConfirmedCount int     `db:"confirmed_count" json:"confirmed_count"`
+	FailedCount    int     `db:"failed_count" json:"failed_count"`
+	GasUsed        int64   `db:"gas_used" json:"gas_used"`
+	FeeWei         string  `db:"fee_wei" json:"fee_wei"`
+	FeeEth         float64 `db:"fee_eth" json:"fee_eth"`
+	AvgFeeEth      float64 `db:"avg_fee_eth" json:"avg_fee_eth"`
 }
@@ -0,0 +1,53 @@
+package domain
+
+import "time"
+
+const (
+	LiquidityCommitmentActive    = "ACTIVE"
+	LiquidityCommitmentExhausted = "EXHAUSTED"
+	LiquidityCommitmentWithdrawn = "WITHDRAWN"
+)
+
+// EmergencyQueueItem is an emergency-lane invoice waiting for review, with
+// its SLA deadline.
+type EmergencyQueueItem struct {
+	Invoice
+	SubmittedAt     time.Time  `db:"submitted_at" json:"submitted_at"`
+	DueAt           time.Time  `db:"due_at" json:"due_at"`
+	Overdue         bool       `db:"overdue" json:"overdue"`
+	Escalations     int        `db:"escalations" json:"escalations"`
+	LastEscalatedAt *time.Time `db:"last_escalated_at" json:"last_escalated_at"`
+}
+
+type EmergencyReview struct {
+	InvoiceID       string     `db:"invoice_id" json:"invoice_id"`
+	SubmittedAt     time.Time  `db:"submitted_at" json:"submitted_at"`
+	DueAt           time.Time  `db:"due_at" json:"due_at"`
+	Escalations     int        `db:"escalations" json:"escalations"`
+	LastEscalatedAt *time.Time `db:"last_escalated_at" json:"last_escalated_at"`
+	ResolvedAt      *time.Time `db:"resolved_at" json:"resolved_at"`
+}
+
+// LiquidityCommitment is an investor's pre-committed share of the emergency
+// pool. AvailableAmount is what is left after the fundings drawn from it.
+type LiquidityCommitment struct {
+	ID              string    `db:"id" json:"id"`
+	OrganizationID  string    `db:"organization_id" json:"organization_id"`
+	InvestorID      string    `db:"investor_id" json:"investor_id"`
+	Currency        string    `db:"currency" json:"currency"`
+	CommittedAmount float64   `db:"committed_amount" json:"committed_amount"`
+	AvailableAmount float64   `db:"available_amount" json:"available_amount"`
+	MaxPerInvoice   *float64  `db:"max_per_invoice" json:"max_per_invoice"`
+	MinAPRPercent   *float64  `db:"min_apr_percent" json:"min_apr_percent"`
+	Status          string    `db:"status" json:"status"`
+	CreatedAt       time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// LiquidityPoolBalance totals the active commitments in one currency.
+type LiquidityPoolBalance struct {
+	Currency        string  `db:"currency" json:"currency"`
+	Commitments     int     `db:"commitments" json:"commitments"`
+	CommittedAmount float64 `db:"committed_amount" json:"committed_amount"`
+	AvailableAmount float64 `db:"available_amount" json:"available_amount"`
+}
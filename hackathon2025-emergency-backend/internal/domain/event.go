@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	EventInvoiceSubmitted     = "invoice.submitted"
+	EventInvoiceApproved      = "invoice.approved"
+	EventInvoiceFunded        = "invoice.funded"
+	EventInvoiceTokenized     = "invoice.tokenized"
+	EventInvoicePartiallyPaid = "invoice.partially_paid"
+	EventInvoicePaid          = "invoice.paid"
+	EventInvoiceDefaulted     = "invoice.defaulted"
+	// EventInvoiceEmergencyEscalated is raised for reviewers when an
+	// emergency-lane invoice is past its review SLA.
+	EventInvoiceEmergencyEscalated = "invoice.emergency_escalated"
+	EventFundingCreated            = "funding.created"
+	EventFundingTransferred        = "funding.transferred"
+	EventChainMintFailed           = "chain.mint_failed"
+)
+
+// Aggregate types name the kind of entity an event is about.
+const (
+	AggregateInvoice = "invoice"
+	AggregateFunding = "funding"
+)
+
+// An event is PENDING until the relay has queued a delivery for each of its
+// subscribers, then PUBLISHED. FAILED is only found on events from before
+// per-subscriber delivery.
+const (
+	EventStatusPending   = "PENDING"
+	EventStatusPublished = "PUBLISHED"
+	EventStatusFailed    = "FAILED"
+)
+
+const (
+	EventDeliveryPending   = "PENDING"
+	EventDeliveryDelivered = "DELIVERED"
+	EventDeliveryFailed    = "FAILED"
+)
+
+// Event is a domain event as stored in the outbox. OrganizationIDs are the
+// organizations the event concerns; Payload is the event data as JSON.
+type Event struct {
+	ID              string          `db:"id" json:"id"`
+	Sequence        int64           `db:"sequence" json:"sequence"`
+	Type            string          `db:"event_type" json:"type"`
+	AggregateType   string          `db:"aggregate_type" json:"aggregate_type"`
+	AggregateID     string          `db:"aggregate_id" json:"aggregate_id"`
+	OrganizationIDs StringSlice     `db:"organization_ids" json:"organization_ids"`
+	Payload         json.RawMessage `db:"payload" json:"payload"`
+	Status          string          `db:"status" json:"status"`
+	Attempts        int             `db:"attempts" json:"attempts"`
+	NextAttemptAt   time.Time       `db:"next_attempt_at" json:"next_attempt_at"`
+	LastError       *string         `db:"last_error" json:"last_error"`
+	OccurredAt      time.Time       `db:"occurred_at" json:"occurred_at"`
+	PublishedAt     *time.Time      `db:"published_at" json:"published_at"`
+}
+
+// EventDelivery tracks the handling of one event by one subscriber.
+type EventDelivery struct {
+	EventID       string     `db:"event_id" json:"event_id"`
+	Subscriber    string     `db:"subscriber" json:"subscriber"`
+	Status        string     `db:"status" json:"status"`
+	Attempts      int        `db:"attempts" json:"attempts"`
+	NextAttemptAt time.Time  `db:"next_attempt_at" json:"next_attempt_at"`
+	LastError     *string    `db:"last_error" json:"last_error"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+	DeliveredAt   *time.Time `db:"delivered_at" json:"delivered_at"`
+}
@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+const (
+	IdempotencyStatusInProgress = "IN_PROGRESS"
+	IdempotencyStatusCompleted  = "COMPLETED"
+)
+
+// IdempotencyRecord is a stored Idempotency-Key: the request it was first
+// used with and, once the handler finished, the response to replay.
+type IdempotencyRecord struct {
+	UserID              string     `db:"user_id"`
+	OrganizationID      string     `db:"organization_id"`
+	Key                 string     `db:"key"`
+	Method              string     `db:"method"`
+	Path                string     `db:"path"`
+	Fingerprint         string     `db:"fingerprint"`
+	Status              string     `db:"status"`
+	ResponseStatus      *int       `db:"response_status"`
+	ResponseContentType *string    `db:"response_content_type"`
+	ResponseBody        []byte     `db:"response_body"`
+	CreatedAt           time.Time  `db:"created_at"`
+	CompletedAt         *time.Time `db:"completed_at"`
+	ExpiresAt           time.Time  `db:"expires_at"`
+}
@@ -15,21 +15,24 @@ const (
 )
 
 type Invoice struct {
-	ID            string      `db:"id" json:"id"`
-	IssuerID      string      `db:"issuer_id" json:"issuer_id"`
-	Title         string      `db:"title" json:"title"`
-	InvoiceNumber string      `db:"invoice_number" json:"invoice_number"`
-	Amount        float64     `db:"amount" json:"amount"`
-	Currency      string      `db:"currency" json:"currency"`
-	TermMonths    int         `db:"term_months" json:"term_months"`
-	DueDate       time.Time   `db:"due_date" json:"due_date"`
-	RiskTier      *string     `db:"risk_tier" json:"risk_tier"`
-	APRPercent    *float64    `db:"apr_percent" json:"apr_percent"`
-	FundingTarget float64     `db:"funding_target" json:"funding_target"`
-	FundedAmount  float64     `db:"funded_amount" json:"funded_amount"`
-	Status        string      `db:"status" json:"status"`
-	EmergencyLane bool        `db:"emergency_lane" json:"emergency_lane"`
-	Tags          StringSlice `db:"tags" json:"tags"`
-	CreatedAt     time.Time   `db:"created_at" json:"created_at"`
-	UpdatedAt     time.Time   `db:"updated_at" json:"updated_at"`
+	ID             string      `db:"id" json:"id"`
+	IssuerID       string      `db:"issuer_id" json:"issuer_id"`
+	OrganizationID string      `db:"organization_id" json:"organization_id"`
+	Title          string      `db:"title" json:"title"`
+	InvoiceNumber  string      `db:"invoice_number" json:"invoice_number"`
+	Amount         float64     `db:"amount" json:"amount"`
+	Currency       string      `db:"currency" json:"currency"`
+	TermMonths     int         `db:"term_months" json:"term_months"`
+	DueDate        time.Time   `db:"due_date" json:"due_date"`
+	RiskTier       *string     `db:"risk_tier" json:"risk_tier"`
+	APRPercent     *float64    `db:"apr_percent" json:"apr_percent"`
+	FundingTarget  float64     `db:"funding_target" json:"funding_target"`
+	FundedAmount   float64     `db:"funded_amount" json:"funded_amount"`
+	PaidAmount     float64     `db:"paid_amount" json:"paid_amount"`
+	Status         string      `db:"status" json:"status"`
+	EmergencyLane  bool        `db:"emergency_lane" json:"emergency_lane"`
+	Tags           StringSlice `db:"tags" json:"tags"`
+	DebtorName     *string     `db:"debtor_name" json:"debtor_name"`
+	CreatedAt      time.Time   `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time   `db:"updated_at" json:"updated_at"`
 }
@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	KYCKindIndividual = "KYC"
+	KYCKindBusiness   = "KYB"
+)
+
+const (
+	KYCStatusSubmitted = "SUBMITTED"
+	KYCStatusApproved  = "APPROVED"
+	KYCStatusRejected  = "REJECTED"
+)
+
+type KYCSubmission struct {
+	ID          string       `db:"id" json:"id"`
+	UserID      string       `db:"user_id" json:"user_id"`
+	Kind        string       `db:"kind" json:"kind"`
+	Status      string       `db:"status" json:"status"`
+	Details     JSONObject   `db:"details" json:"details"`
+	Documents   KYCDocuments `db:"documents" json:"documents"`
+	ReviewerID  *string      `db:"reviewer_id" json:"reviewer_id"`
+	ReviewNote  *string      `db:"review_note" json:"review_note"`
+	SubmittedAt time.Time    `db:"submitted_at" json:"submitted_at"`
+	ReviewedAt  *time.Time   `db:"reviewed_at" json:"reviewed_at"`
+}
+
+type KYCQueueItem struct {
+	KYCSubmission
+	UserName  string `db:"user_name" json:"user_name"`
+	UserEmail string `db:"user_email" json:"user_email"`
+	UserRole  string `db:"user_role" json:"user_role"`
+}
+
+type KYCDocument struct {
+	Type        string `json:"type" binding:"required"`
+	FileName    string `json:"file_name" binding:"required"`
+	URL         string `json:"url" binding:"required,url"`
+	SHA256      string `json:"sha256"`
+	ContentType string `json:"content_type"`
+}
+
+type KYCDocuments []KYCDocument
+
+func (d *KYCDocuments) Scan(value any) error {
+	if value == nil {
+		*d = KYCDocuments{}
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("invalid type for KYCDocuments")
+	}
+
+	return json.Unmarshal(bytes, d)
+}
+
+func (d KYCDocuments) Value() (driver.Value, error) {
+	if d == nil {
+		return []byte("[]"), nil
+	}
+
+	return json.Marshal(d)
+}
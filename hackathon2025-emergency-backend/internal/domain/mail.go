@@ -0,0 +1,26 @@
+package domain
+
+import "time"
+
+const (
+	MailStatusPending = "PENDING"
+	MailStatusSent    = "SENT"
+	MailStatusFailed  = "FAILED"
+)
+
+// OutboxMail is a message waiting in the mail outbox. EventID is set for
+// mail raised by a domain event.
+type OutboxMail struct {
+	ID            string     `db:"id" json:"id"`
+	Sequence      int64      `db:"sequence" json:"sequence"`
+	EventID       *string    `db:"event_id" json:"event_id"`
+	Recipient     string     `db:"recipient" json:"recipient"`
+	Subject       string     `db:"subject" json:"subject"`
+	Body          string     `db:"body" json:"body"`
+	Status        string     `db:"status" json:"status"`
+	Attempts      int        `db:"attempts" json:"attempts"`
+	NextAttemptAt time.Time  `db:"next_attempt_at" json:"next_attempt_at"`
+	LastError     *string    `db:"last_error" json:"last_error"`
+	CreatedAt     time.Time  `db:"created_at" json:"created_at"`
+	SentAt        *time.Time `db:"sent_at" json:"sent_at"`
+}
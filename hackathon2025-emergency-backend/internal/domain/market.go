@@ -0,0 +1,42 @@
+package domain
+
+import "time"
+
+const (
+	MarketListingActive   = "ACTIVE"
+	MarketListingFilled   = "FILLED"
+	MarketListingCanceled = "CANCELED"
+)
+
+// MarketListing offers part or all of a confirmed funding position for sale
+// on the secondary market. A buyer pays UnitPrice per unit of principal.
+type MarketListing struct {
+	ID                 string    `db:"id" json:"id"`
+	FundingID          string    `db:"funding_id" json:"funding_id"`
+	InvoiceID          string    `db:"invoice_id" json:"invoice_id"`
+	OrganizationID     string    `db:"organization_id" json:"organization_id"`
+	SellerID           string    `db:"seller_id" json:"seller_id"`
+	Currency           string    `db:"currency" json:"currency"`
+	Principal          float64   `db:"principal" json:"principal"`
+	RemainingPrincipal float64   `db:"remaining_principal" json:"remaining_principal"`
+	UnitPrice          float64   `db:"unit_price" json:"unit_price"`
+	Status             string    `db:"status" json:"status"`
+	CreatedAt          time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt          time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// PositionTransfer records one purchase: Principal moved from the seller's
+// funding to a new funding held by the buyer, for Price.
+type PositionTransfer struct {
+	ID                   string    `db:"id" json:"id"`
+	ListingID            string    `db:"listing_id" json:"listing_id"`
+	InvoiceID            string    `db:"invoice_id" json:"invoice_id"`
+	FromFundingID        string    `db:"from_funding_id" json:"from_funding_id"`
+	ToFundingID          string    `db:"to_funding_id" json:"to_funding_id"`
+	SellerOrganizationID string    `db:"seller_organization_id" json:"seller_organization_id"`
+	BuyerOrganizationID  string    `db:"buyer_organization_id" json:"buyer_organization_id"`
+	BuyerID              string    `db:"buyer_id" json:"buyer_id"`
+	Principal            float64   `db:"principal" json:"principal"`
+	Price                float64   `db:"price" json:"price"`
+	CreatedAt            time.Time `db:"created_at" json:"created_at"`
+}
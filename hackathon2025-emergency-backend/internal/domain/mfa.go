@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+type UserMFA struct {
+	UserID          string     `db:"user_id"`
+	SecretEncrypted []byte     `db:"secret_encrypted"`
+	EnabledAt       *time.Time `db:"enabled_at"`
+	LastUsedStep    int64      `db:"last_used_step"`
+	CreatedAt       time.Time  `db:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at"`
+}
+
+type MFAStatus struct {
+	Enabled                bool       `json:"enabled"`
+	Required               bool       `json:"required"`
+	EnabledAt              *time.Time `json:"enabled_at"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
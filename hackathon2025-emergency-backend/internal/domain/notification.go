@@ -0,0 +1,66 @@
+package domain
+
+import "time"
+
+const (
+	NotificationChannelInApp = "in_app"
+	NotificationChannelEmail = "email"
+)
+
+// NotificationEventTypes lists the domain events users are notified about
+// and may set preferences for.
+var NotificationEventTypes = []string{
+	EventInvoiceSubmitted,
+	EventInvoiceEmergencyEscalated,
+	EventInvoiceApproved,
+	EventInvoiceFunded,
+	EventInvoiceTokenized,
+	EventInvoicePartiallyPaid,
+	EventInvoicePaid,
+	EventInvoiceDefaulted,
+	EventFundingCreated,
+	EventFundingTransferred,
+	EventChainMintFailed,
+}
+
+func ValidNotificationEventType(eventType string) bool {
+	for _, known := range NotificationEventTypes {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+type Notification struct {
+	ID         string     `db:"id" json:"id"`
+	UserID     string     `db:"user_id" json:"user_id"`
+	EventID    string     `db:"event_id" json:"event_id"`
+	EventType  string     `db:"event_type" json:"event_type"`
+	EntityType string     `db:"entity_type" json:"entity_type"`
+	EntityID   string     `db:"entity_id" json:"entity_id"`
+	Title      string     `db:"title" json:"title"`
+	Body       string     `db:"body" json:"body"`
+	ReadAt     *time.Time `db:"read_at" json:"read_at"`
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+}
+
+type NotificationPreference struct {
+	UserID    string `db:"user_id" json:"-"`
+	EventType string `db:"event_type" json:"event_type"`
+	InApp     bool   `db:"in_app" json:"in_app"`
+	Email     bool   `db:"email" json:"email"`
+}
+
+// DefaultNotificationPreference applies when the user has not chosen. Only
+// emergency-lane escalations are emailed by default, as they are time-critical.
+func DefaultNotificationPreference(userID string, eventType string) NotificationPreference {
+	return NotificationPreference{UserID: userID, EventType: eventType, InApp: true, Email: eventType == EventInvoiceEmergencyEscalated}
+}
+
+// NotificationRecipient is a user an event is delivered to.
+type NotificationRecipient struct {
+	ID    string `db:"id"`
+	Name  string `db:"name"`
+	Email string `db:"email"`
+}
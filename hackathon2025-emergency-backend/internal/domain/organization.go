@@ -0,0 +1,70 @@
+package domain
+
+import "time"
+
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleEditor = "editor"
+	OrgRoleViewer = "viewer"
+)
+
+// An organization's kind is the role of the user who created it. SME
+// organizations issue invoices and investor organizations fund them.
+const (
+	OrgKindSME      = RoleSME
+	OrgKindInvestor = RoleInvestor
+)
+
+var orgRoleRank = map[string]int{
+	OrgRoleViewer: 1,
+	OrgRoleEditor: 2,
+	OrgRoleOwner:  3,
+}
+
+func ValidOrgRole(role string) bool {
+	_, ok := orgRoleRank[role]
+	return ok
+}
+
+// OrgRoleAtLeast reports whether role grants everything min does.
+func OrgRoleAtLeast(role string, min string) bool {
+	return orgRoleRank[role] >= orgRoleRank[min] && orgRoleRank[role] > 0
+}
+
+type Organization struct {
+	ID        string    `db:"id" json:"id"`
+	Name      string    `db:"name" json:"name"`
+	Kind      string    `db:"kind" json:"kind"`
+	Personal  bool      `db:"personal" json:"personal"`
+	CreatedBy *string   `db:"created_by" json:"created_by"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// Membership is an organization as seen by one of its members.
+type Membership struct {
+	Organization
+	Role string `db:"member_role" json:"member_role"`
+}
+
+type OrganizationMember struct {
+	OrganizationID string    `db:"organization_id" json:"organization_id"`
+	UserID         string    `db:"user_id" json:"user_id"`
+	Role           string    `db:"role" json:"role"`
+	Name           string    `db:"name" json:"name"`
+	Email          string    `db:"email" json:"email"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+}
+
+type OrganizationInvitation struct {
+	ID             string     `db:"id" json:"id"`
+	OrganizationID string     `db:"organization_id" json:"organization_id"`
+	Email          string     `db:"email" json:"email"`
+	Role           string     `db:"role" json:"role"`
+	TokenHash      string     `db:"token_hash" json:"-"`
+	InvitedBy      *string    `db:"invited_by" json:"invited_by"`
+	ExpiresAt      time.Time  `db:"expires_at" json:"expires_at"`
+	AcceptedAt     *time.Time `db:"accepted_at" json:"accepted_at"`
+	RevokedAt      *time.Time `db:"revoked_at" json:"revoked_at"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+}
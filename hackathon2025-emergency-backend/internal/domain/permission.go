@@ -0,0 +1,79 @@
+package domain
+
+import "time"
+
+const (
+	PermInvoiceRead          = "invoice.read"
+	PermInvoiceReadListed    = "invoice.read_listed"
+	PermInvoiceReadAll       = "invoice.read_all"
+	PermInvoiceCreate        = "invoice.create"
+	PermInvoiceSubmit        = "invoice.submit"
+	PermInvoiceApprove       = "invoice.approve"
+	PermInvoiceMarkPaid      = "invoice.mark_paid"
+	PermInvoiceMarkDefaulted = "invoice.mark_defaulted"
+	PermFundingCreate        = "funding.create"
+	PermFundingReadOwn       = "funding.read_own"
+	PermChainRead            = "chain.read"
+	PermChainTokenize        = "chain.tokenize"
+	PermChainRefresh         = "chain.refresh"
+	PermMetricsRead          = "metrics.read"
+	PermKYCSubmit            = "kyc.submit"
+	PermKYCRead              = "kyc.read"
+	PermKYCReview            = "kyc.review"
+	PermUsersRead            = "users.read"
+	PermUsersManage          = "users.manage"
+	PermRolesManage          = "roles.manage"
+	PermAuditRead            = "audit.read"
+	PermWebhooksManage       = "webhooks.manage"
+)
+
+// AdminPermissions are the permissions the /admin routes check. That group
+// requires an MFA-verified session, so every role holding one of them must
+// use a second factor.
+var AdminPermissions = []string{
+	PermInvoiceApprove,
+	PermInvoiceMarkPaid,
+	PermInvoiceMarkDefaulted,
+	PermMetricsRead,
+	PermKYCRead,
+	PermKYCReview,
+	PermUsersRead,
+	PermUsersManage,
+	PermRolesManage,
+	PermAuditRead,
+	PermWebhooksManage,
+}
+
+func IsAdminPermission(permission string) bool {
+	for _, admin := range AdminPermissions {
+		if permission == admin {
+			return true
+		}
+	}
+	return false
+}
+
+// RequiresMFA reports whether a role with permissions reaches the /admin
+// routes and so must use a second factor.
+func RequiresMFA(permissions []string) bool {
+	for _, permission := range permissions {
+		if IsAdminPermission(permission) {
+			return true
+		}
+	}
+	return false
+}
+
+type Role struct {
+	Name        string      `db:"name" json:"name"`
+	Description string      `db:"description" json:"description"`
+	IsSystem    bool        `db:"is_system" json:"is_system"`
+	Permissions StringSlice `db:"permissions" json:"permissions"`
+	CreatedAt   time.Time   `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time   `db:"updated_at" json:"updated_at"`
+}
+
+type Permission struct {
+	Name        string `db:"name" json:"name"`
+	Description string `db:"description" json:"description"`
+}
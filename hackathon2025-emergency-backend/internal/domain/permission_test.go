@@ -0,0 +1,26 @@
+package domain
+
+import "testing"
+
+func TestRequiresMFA(t *testing.T) {
+	cases := []struct {
+		name        string
+		permissions []string
+		want        bool
+	}{
+		{"sme", []string{PermInvoiceRead, PermInvoiceCreate, PermInvoiceSubmit, PermKYCSubmit}, false},
+		{"investor", []string{PermInvoiceReadListed, PermFundingCreate, PermFundingReadOwn}, false},
+		{"auditor", []string{PermInvoiceReadAll, PermAuditRead}, true},
+		{"risk officer", []string{PermInvoiceReadAll, PermInvoiceApprove, PermKYCRead}, true},
+		{"ops", []string{PermMetricsRead, PermWebhooksManage}, true},
+		{"no permissions", nil, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := RequiresMFA(tc.permissions); got != tc.want {
+				t.Fatalf("RequiresMFA = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
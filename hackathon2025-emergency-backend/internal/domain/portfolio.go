@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// PortfolioPosition is a funding with what portfolio analytics need from its
+// invoice and its purchase on the secondary market. InvoiceOwed is what all
+// open positions of the invoice are owed, principal plus interest, which its
+// repayments are shared against once it closes.
+type PortfolioPosition struct {
+	Funding
+	Currency      string     `db:"currency"`
+	DueDate       time.Time  `db:"due_date"`
+	RiskTier      *string    `db:"risk_tier"`
+	DebtorName    *string    `db:"debtor_name"`
+	SMEName       string     `db:"sme_name"`
+	InvoiceStatus string     `db:"invoice_status"`
+	PaidAmount    float64    `db:"paid_amount"`
+	ClosedAt      *time.Time `db:"closed_at"`
+	InvoiceOwed   float64    `db:"invoice_owed"`
+	PurchasePrice *float64   `db:"purchase_price"`
+}
+
+// PositionSale is a secondary-market sale out of a position.
+type PositionSale struct {
+	FundingID string    `db:"from_funding_id"`
+	Principal float64   `db:"principal"`
+	Price     float64   `db:"price"`
+	SoldAt    time.Time `db:"created_at"`
+}
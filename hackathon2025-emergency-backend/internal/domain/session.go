@@ -0,0 +1,27 @@
+package domain
+
+import "time"
+
+type Session struct {
+	ID                string     `db:"id" json:"id"`
+	UserID            string     `db:"user_id" json:"user_id"`
+	RefreshTokenHash  string     `db:"refresh_token_hash" json:"-"`
+	PreviousTokenHash *string    `db:"previous_token_hash" json:"-"`
+	UserAgent         *string    `db:"user_agent" json:"user_agent"`
+	IP                *string    `db:"ip" json:"ip"`
+	MFAVerified       bool       `db:"mfa_verified" json:"mfa_verified"`
+	ExpiresAt         time.Time  `db:"expires_at" json:"expires_at"`
+	LastUsedAt        *time.Time `db:"last_used_at" json:"last_used_at"`
+	RevokedAt         *time.Time `db:"revoked_at" json:"revoked_at"`
+	CreatedAt         time.Time  `db:"created_at" json:"created_at"`
+}
+
+type SessionState struct {
+	UserStatus   string      `db:"status"`
+	Role         string      `db:"role"`
+	Permissions  StringSlice `db:"permissions"`
+	TokenVersion int         `db:"token_version"`
+	MFAVerified  bool        `db:"mfa_verified"`
+	ExpiresAt    time.Time   `db:"expires_at"`
+	RevokedAt    *time.Time  `db:"revoked_at"`
+}
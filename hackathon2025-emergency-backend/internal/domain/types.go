@@ -34,3 +34,32 @@ func (s StringSlice) Value() (driver.Value, error) {
 
 	return json.Marshal(s)
 }
+
+type JSONObject map[string]any
+
+func (o *JSONObject) Scan(value any) error {
+	if value == nil {
+		*o = JSONObject{}
+		return nil
+	}
+
+	bytes, ok := value.([]byte)
+	if !ok {
+		return errors.New("invalid type for JSONObject")
+	}
+
+	if len(bytes) == 0 {
+		*o = JSONObject{}
+		return nil
+	}
+
+	return json.Unmarshal(bytes, o)
+}
+
+func (o JSONObject) Value() (driver.Value, error) {
+	if o == nil {
+		return []byte("{}"), nil
+	}
+
+	return json.Marshal(o)
+}
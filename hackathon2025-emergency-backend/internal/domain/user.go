@@ -15,12 +15,33 @@ const (
 )
 
 type User struct {
-	ID           string    `db:"id" json:"id"`
-	Role         string    `db:"role" json:"role"`
-	Name         string    `db:"name" json:"name"`
-	Email        string    `db:"email" json:"email"`
-	PasswordHash string    `db:"password_hash" json:"-"`
-	Status       string    `db:"status" json:"status"`
-	CreatedAt    time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt    time.Time `db:"updated_at" json:"updated_at"`
+	ID              string     `db:"id" json:"id"`
+	Role            string     `db:"role" json:"role"`
+	Name            string     `db:"name" json:"name"`
+	Email           string     `db:"email" json:"email"`
+	PasswordHash    string     `db:"password_hash" json:"-"`
+	Status          string     `db:"status" json:"status"`
+	TokenVersion    int        `db:"token_version" json:"-"`
+	EmailVerifiedAt *time.Time `db:"email_verified_at" json:"email_verified_at"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+const (
+	UserActionSuspend     = "SUSPEND"
+	UserActionReactivate  = "REACTIVATE"
+	UserActionRoleChange  = "ROLE_CHANGE"
+	UserActionCreateAdmin = "CREATE_ADMIN"
+	UserActionUnlock      = "UNLOCK"
+)
+
+type UserAdminAction struct {
+	ID        string    `db:"id" json:"id"`
+	UserID    string    `db:"user_id" json:"user_id"`
+	ActorID   *string   `db:"actor_id" json:"actor_id"`
+	Action    string    `db:"action" json:"action"`
+	FromValue *string   `db:"from_value" json:"from_value"`
+	ToValue   *string   `db:"to_value" json:"to_value"`
+	Reason    *string   `db:"reason" json:"reason"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
 }
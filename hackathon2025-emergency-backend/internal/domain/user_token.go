@@ -0,0 +1,20 @@
+package domain
+
+import "time"
+
+const (
+	TokenPurposeEmailVerification = "EMAIL_VERIFICATION"
+	TokenPurposePasswordReset     = "PASSWORD_RESET"
+)
+
+// UserToken is a single-use emailed token. Only the sha256 of the token is
+// stored.
+type UserToken struct {
+	ID        string     `db:"id"`
+	UserID    string     `db:"user_id"`
+	Purpose   string     `db:"purpose"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
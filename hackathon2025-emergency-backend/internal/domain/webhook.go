@@ -0,0 +1,72 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WebhookEventTypes lists the domain events a subscription may ask for.
+var WebhookEventTypes = []string{
+	EventInvoiceSubmitted,
+	EventInvoiceApproved,
+	EventInvoiceFunded,
+	EventInvoiceTokenized,
+	EventInvoicePartiallyPaid,
+	EventInvoicePaid,
+	EventInvoiceDefaulted,
+	EventFundingCreated,
+	EventFundingTransferred,
+	EventChainMintFailed,
+}
+
+func ValidWebhookEventType(eventType string) bool {
+	for _, known := range WebhookEventTypes {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	WebhookDeliveryPending   = "PENDING"
+	WebhookDeliverySucceeded = "SUCCEEDED"
+	WebhookDeliveryFailed    = "FAILED"
+)
+
+type WebhookSubscription struct {
+	ID             string      `db:"id" json:"id"`
+	OrganizationID *string     `db:"organization_id" json:"organization_id"`
+	URL            string      `db:"url" json:"url"`
+	Description    string      `db:"description" json:"description"`
+	EventTypes     StringSlice `db:"event_types" json:"event_types"`
+	Secret         string      `db:"secret" json:"-"`
+	Active         bool        `db:"active" json:"active"`
+	CreatedBy      *string     `db:"created_by" json:"created_by"`
+	CreatedAt      time.Time   `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time   `db:"updated_at" json:"updated_at"`
+}
+
+type WebhookDelivery struct {
+	ID             string          `db:"id" json:"id"`
+	SubscriptionID string          `db:"subscription_id" json:"subscription_id"`
+	EventID        string          `db:"event_id" json:"event_id"`
+	EventType      string          `db:"event_type" json:"event_type"`
+	Payload        json.RawMessage `db:"payload" json:"payload"`
+	Status         string          `db:"status" json:"status"`
+	Attempts       int             `db:"attempts" json:"attempts"`
+	NextAttemptAt  time.Time       `db:"next_attempt_at" json:"next_attempt_at"`
+	LastAttemptAt  *time.Time      `db:"last_attempt_at" json:"last_attempt_at"`
+	LastStatusCode *int            `db:"last_status_code" json:"last_status_code"`
+	LastError      *string         `db:"last_error" json:"last_error"`
+	DeliveredAt    *time.Time      `db:"delivered_at" json:"delivered_at"`
+	ReplayOf       *string         `db:"replay_of" json:"replay_of"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+}
+
+// WebhookDispatch is a claimed delivery together with where and how to send it.
+type WebhookDispatch struct {
+	WebhookDelivery
+	URL    string `db:"url"`
+	Secret string `db:"secret"`
+}
@@ -0,0 +1,262 @@
+// Package events carries domain events from the services that raise them to
+// the subscribers that react to them, through a transactional outbox.
+package events
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/repositories"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	relayPollInterval = 2 * time.Second
+	relayMaxAttempts  = 10
+	relayBaseBackoff  = 5 * time.Second
+	relayMaxBackoff   = time.Hour
+)
+
+// Handler reacts to an event. Each subscriber gets the event in its own
+// transaction: its writes commit together with its delivery being marked
+// done, and an error rolls back only its writes and retries only its
+// delivery. Delivery is at least once, so side effects outside tx must
+// tolerate repeats.
+type Handler func(ctx context.Context, tx *sqlx.Tx, event *domain.Event) error
+
+type subscriber struct {
+	name       string
+	eventTypes map[string]bool
+	handler    Handler
+}
+
+type Bus struct {
+	db   *sqlx.DB
+	repo *repositories.OutboxRepository
+
+	mu          sync.RWMutex
+	subscribers []subscriber
+}
+
+func NewBus(db *sqlx.DB, repo *repositories.OutboxRepository) *Bus {
+	return &Bus{db: db, repo: repo}
+}
+
+// Subscribe registers handler for the given event types, or for every event
+// when none are given. name is stored with each delivery, so it must be
+// unique and stay the same across releases.
+func (b *Bus) Subscribe(name string, handler Handler, eventTypes ...string) {
+	var types map[string]bool
+	if len(eventTypes) > 0 {
+		types = make(map[string]bool, len(eventTypes))
+		for _, eventType := range eventTypes {
+			types[eventType] = true
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subscriber(name) != nil {
+		panic("events: duplicate subscriber " + name)
+	}
+	b.subscribers = append(b.subscribers, subscriber{name: name, eventTypes: types, handler: handler})
+}
+
+// Publish writes an event to the outbox in tx, so it is dispatched if and
+// only if the change that raised it commits. organizationIDs are the
+// organizations the event concerns.
+func (b *Bus) Publish(ctx context.Context, tx *sqlx.Tx, eventType string, aggregateType string, aggregateID string, organizationIDs []string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.repo.Insert(ctx, tx, &domain.Event{
+		Type:            eventType,
+		AggregateType:   aggregateType,
+		AggregateID:     aggregateID,
+		OrganizationIDs: uniqueIDs(organizationIDs),
+		Payload:         payload,
+	})
+	return err
+}
+
+// Start runs the relay until ctx is cancelled. Several API instances may run
+// it at once; each event is claimed with SKIP LOCKED.
+func (b *Bus) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(relayPollInterval)
+		defer ticker.Stop()
+		for {
+			b.relayPending(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (b *Bus) relayPending(ctx context.Context) {
+	for _, step := range []func(context.Context) (bool, error){b.fanOutNext, b.deliverNext} {
+		for {
+			done, err := step(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("outbox relay failed: %v", err)
+				}
+				return
+			}
+			if !done {
+				break
+			}
+		}
+	}
+}
+
+// fanOutNext queues a delivery of one pending event for each subscriber to
+// its type and reports whether there was one.
+func (b *Bus) fanOutNext(ctx context.Context) (bool, error) {
+	tx, err := b.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	event, err := b.repo.ClaimNext(ctx, tx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	for _, name := range b.subscribersFor(event.Type) {
+		if err := b.repo.CreateDelivery(ctx, tx, event.ID, name); err != nil {
+			return false, err
+		}
+	}
+
+	if err := b.repo.MarkPublished(ctx, tx, event.ID); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// deliverNext hands one due delivery to its subscriber and reports whether
+// there was one.
+func (b *Bus) deliverNext(ctx context.Context) (bool, error) {
+	tx, err := b.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	delivery, err := b.repo.ClaimNextDelivery(ctx, tx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	event, err := b.repo.Get(ctx, tx, delivery.EventID)
+	if err != nil {
+		return false, err
+	}
+
+	if err := b.deliver(ctx, tx, delivery.Subscriber, event); err != nil {
+		tx.Rollback()
+		b.recordFailure(ctx, delivery, event, err)
+		return true, nil
+	}
+
+	if err := b.repo.MarkDelivered(ctx, tx, delivery.EventID, delivery.Subscriber); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (b *Bus) deliver(ctx context.Context, tx *sqlx.Tx, name string, event *domain.Event) error {
+	b.mu.RLock()
+	sub := b.subscriber(name)
+	b.mu.RUnlock()
+
+	if sub == nil {
+		return fmt.Errorf("no subscriber %q in this instance", name)
+	}
+	return sub.handler(ctx, tx, event)
+}
+
+// subscribersFor names the subscribers to eventType in registration order.
+func (b *Bus) subscribersFor(eventType string) []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var names []string
+	for _, sub := range b.subscribers {
+		if sub.eventTypes == nil || sub.eventTypes[eventType] {
+			names = append(names, sub.name)
+		}
+	}
+	return names
+}
+
+// subscriber finds a subscriber by name; callers hold b.mu.
+func (b *Bus) subscriber(name string) *subscriber {
+	for i := range b.subscribers {
+		if b.subscribers[i].name == name {
+			return &b.subscribers[i]
+		}
+	}
+	return nil
+}
+
+func (b *Bus) recordFailure(ctx context.Context, delivery *domain.EventDelivery, event *domain.Event, cause error) {
+	var retryAt *time.Time
+	if attempts := delivery.Attempts + 1; attempts < relayMaxAttempts {
+		next := time.Now().Add(relayBackoff(attempts))
+		retryAt = &next
+	}
+
+	log.Printf("outbox event %s (%s) failed for %s: %v", event.ID, event.Type, delivery.Subscriber, cause)
+	if err := b.repo.MarkDeliveryFailed(ctx, delivery.EventID, delivery.Subscriber, cause.Error(), retryAt); err != nil {
+		log.Printf("outbox event %s: mark %s failed failed: %v", event.ID, delivery.Subscriber, err)
+	}
+}
+
+// relayBackoff doubles from 5s after each failed attempt, capped at an hour.
+func relayBackoff(attempts int) time.Duration {
+	delay := relayBaseBackoff
+	for i := 1; i < attempts && delay < relayMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > relayMaxBackoff {
+		delay = relayMaxBackoff
+	}
+	return delay
+}
+
+func uniqueIDs(ids []string) domain.StringSlice {
+	seen := make(map[string]bool, len(ids))
+	unique := domain.StringSlice{}
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		unique = append(unique, id)
+	}
+	return unique
+}
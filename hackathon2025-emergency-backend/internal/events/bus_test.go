@@ -0,0 +1,91 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"invoiceflow/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func recordingHandler(calls *[]string, name string, err error) Handler {
+	return func(context.Context, *sqlx.Tx, *domain.Event) error {
+		*calls = append(*calls, name)
+		return err
+	}
+}
+
+func TestSubscribersForMatchesTypes(t *testing.T) {
+	bus := NewBus(nil, nil)
+	var calls []string
+	bus.Subscribe("notifications", recordingHandler(&calls, "notifications", nil), domain.EventInvoiceApproved, domain.EventInvoicePaid)
+	bus.Subscribe("stream", recordingHandler(&calls, "stream", nil))
+	bus.Subscribe("liquidity", recordingHandler(&calls, "liquidity", nil), domain.EventInvoiceApproved)
+
+	if got, want := bus.subscribersFor(domain.EventInvoiceApproved), []string{"notifications", "stream", "liquidity"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("approved: %v, want %v", got, want)
+	}
+	if got, want := bus.subscribersFor(domain.EventFundingCreated), []string{"stream"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("funding created: %v, want %v", got, want)
+	}
+}
+
+func TestDeliverRunsOnlyTheNamedSubscriber(t *testing.T) {
+	bus := NewBus(nil, nil)
+	var calls []string
+	failure := errors.New("liquidity down")
+	bus.Subscribe("liquidity", recordingHandler(&calls, "liquidity", failure))
+	bus.Subscribe("webhooks", recordingHandler(&calls, "webhooks", nil))
+	event := &domain.Event{ID: "evt-1", Type: domain.EventInvoiceApproved}
+
+	if err := bus.deliver(context.Background(), nil, "webhooks", event); err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.deliver(context.Background(), nil, "liquidity", event); !errors.Is(err, failure) {
+		t.Fatalf("err = %v, want the subscriber's error", err)
+	}
+	if want := []string{"webhooks", "liquidity"}; !reflect.DeepEqual(calls, want) {
+		t.Fatalf("calls = %v, want %v", calls, want)
+	}
+}
+
+func TestDeliverFailsForUnknownSubscriber(t *testing.T) {
+	bus := NewBus(nil, nil)
+
+	if err := bus.deliver(context.Background(), nil, "retired", &domain.Event{}); err == nil {
+		t.Fatal("delivery to an unknown subscriber succeeded")
+	}
+}
+
+func TestSubscribeRejectsDuplicateNames(t *testing.T) {
+	bus := NewBus(nil, nil)
+	bus.Subscribe("webhooks", recordingHandler(new([]string), "webhooks", nil))
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("duplicate subscriber was accepted")
+		}
+	}()
+	bus.Subscribe("webhooks", recordingHandler(new([]string), "webhooks", nil))
+}
+
+func TestRelayBackoff(t *testing.T) {
+	cases := map[int]time.Duration{
+		1:  5 * time.Second,
+		2:  10 * time.Second,
+		9:  1280 * time.Second,
+		10: 2560 * time.Second,
+		11: relayMaxBackoff,
+		30: relayMaxBackoff,
+	}
+
+	for attempts, want := range cases {
+		if got := relayBackoff(attempts); got != want {
+			t.Errorf("relayBackoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 
+	"invoiceflow/internal/pagination"
 	"invoiceflow/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -69,8 +70,50 @@ func (h *AdminHandler) MarkPaid(c *gin.Context) {
 	RespondData(c, http.StatusOK, invoice, nil)
 }
 
+func (h *AdminHandler) MarkDefaulted(c *gin.Context) {
+	invoice, err := h.service.MarkDefaulted(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch err {
+		case services.ErrInvoiceInvalidStatus:
+			RespondError(c, http.StatusConflict, "INVOICE.INVALID_STATUS", "invoice status invalid", nil)
+		default:
+			RespondError(c, http.StatusNotFound, "INVOICE.NOT_FOUND", "invoice not found", nil)
+		}
+		return
+	}
+
+	RespondData(c, http.StatusOK, invoice, nil)
+}
+
+// DashboardMetrics takes an optional range: from and to (YYYY-MM-DD,
+// inclusive), interval (day, week or month) and currency.
 func (h *AdminHandler) DashboardMetrics(c *gin.Context) {
-	metrics, err := h.service.GetDashboardMetrics(c.Request.Context())
+	metricsRange := services.MetricsRange{
+		Interval: c.Query("interval"),
+		Currency: c.Query("currency"),
+	}
+	if value := c.Query("from"); value != "" {
+		from, err := parseDate(value)
+		if err != nil {
+			RespondError(c, http.StatusBadRequest, "ADMIN.VALIDATION_FAILED", "from must be YYYY-MM-DD", nil)
+			return
+		}
+		metricsRange.From = from
+	}
+	if value := c.Query("to"); value != "" {
+		to, err := parseDate(value)
+		if err != nil {
+			RespondError(c, http.StatusBadRequest, "ADMIN.VALIDATION_FAILED", "to must be YYYY-MM-DD", nil)
+			return
+		}
+		metricsRange.To = to
+	}
+
+	metrics, err := h.service.GetDashboardMetrics(c.Request.Context(), metricsRange)
+	if err == services.ErrMetricsRangeInvalid {
+		RespondError(c, http.StatusBadRequest, "ADMIN.VALIDATION_FAILED", "from must not be after to, interval must be day, week or month, and the range at most 366 buckets", nil)
+		return
+	}
 	if err != nil {
 		RespondError(c, http.StatusInternalServerError, "ADMIN.METRICS_FAILED", "could not fetch metrics", nil)
 		return
@@ -78,3 +121,23 @@ func (h *AdminHandler) DashboardMetrics(c *gin.Context) {
 
 	RespondData(c, http.StatusOK, metrics, nil)
 }
+
+func (h *AdminHandler) ChainCosts(c *gin.Context) {
+	page, err := parsePage(c)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, "ADMIN.VALIDATION_FAILED", err.Error(), nil)
+		return
+	}
+
+	costs, meta, err := h.service.GetChainCosts(c.Request.Context(), c.Query("invoice_id"), page)
+	if err == pagination.ErrInvalidCursor {
+		RespondError(c, http.StatusBadRequest, "ADMIN.VALIDATION_FAILED", err.Error(), nil)
+		return
+	}
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "ADMIN.CHAIN_COSTS_FAILED", "could not fetch chain costs", nil)
+		return
+	}
+
+	RespondData(c, http.StatusOK, costs, pageMeta(page, meta))
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"invoiceflow/internal/pagination"
+	"invoiceflow/internal/repositories"
+	"invoiceflow/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AuditHandler struct {
+	service *services.AuditService
+}
+
+func NewAuditHandler(service *services.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+func (h *AuditHandler) List(c *gin.Context) {
+	page, err := parsePage(c)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, "AUDIT.VALIDATION_FAILED", err.Error(), nil)
+		return
+	}
+
+	filters := repositories.AuditFilters{
+		EntityType: c.Query("entity_type"),
+		EntityID:   c.Query("entity_id"),
+		ActorID:    c.Query("actor_id"),
+		Action:     c.Query("action"),
+		RequestID:  c.Query("request_id"),
+		Page:       page,
+	}
+
+	if filters.ActorID != "" {
+		if _, err := uuid.Parse(filters.ActorID); err != nil {
+			RespondError(c, http.StatusBadRequest, "AUDIT.VALIDATION_FAILED", "invalid actor_id", nil)
+			return
+		}
+	}
+
+	if value := c.Query("from"); value != "" {
+		from, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			RespondError(c, http.StatusBadRequest, "AUDIT.VALIDATION_FAILED", "invalid from, expected RFC 3339", nil)
+			return
+		}
+		filters.From = &from
+	}
+
+	if value := c.Query("to"); value != "" {
+		to, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			RespondError(c, http.StatusBadRequest, "AUDIT.VALIDATION_FAILED", "invalid to, expected RFC 3339", nil)
+			return
+		}
+		filters.To = &to
+	}
+
+	events, meta, err := h.service.List(c.Request.Context(), filters)
+	if err == pagination.ErrInvalidCursor {
+		RespondError(c, http.StatusBadRequest, "AUDIT.VALIDATION_FAILED", err.Error(), nil)
+		return
+	}
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "AUDIT.LIST_FAILED", "could not list audit events", nil)
+		return
+	}
+
+	RespondData(c, http.StatusOK, events, pageMeta(page, meta))
+}
+
+func (h *AuditHandler) Verify(c *gin.Context) {
+	result, err := h.service.Verify(c.Request.Context())
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "AUDIT.VERIFY_FAILED", "could not verify audit log", nil)
+		return
+	}
+
+	RespondData(c, http.StatusOK, result, nil)
+}
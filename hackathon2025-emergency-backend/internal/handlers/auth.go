@@ -1,7 +1,11 @@
 package handlers
 
 import (
+	"errors"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
 
 	"invoiceflow/internal/domain"
 	"invoiceflow/internal/middleware"
@@ -63,8 +67,11 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, user, err := h.service.Login(c.Request.Context(), req.Email, req.Password)
+	result, err := h.service.Login(c.Request.Context(), req.Email, req.Password, sessionMeta(c))
 	if err != nil {
+		if respondThrottled(c, err) {
+			return
+		}
 		switch err {
 		case services.ErrInvalidCredentials:
 			RespondError(c, http.StatusUnauthorized, "AUTH.INVALID_CREDENTIALS", "invalid credentials", nil)
@@ -76,13 +83,220 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	if result.Challenge != nil {
+		RespondData(c, http.StatusOK, gin.H{
+			"mfa_required": true,
+			"mfa_token":    result.Challenge.Token,
+			"expires_in":   result.Challenge.ExpiresIn,
+		}, nil)
+		return
+	}
+
+	respondTokens(c, result.Tokens, result.User)
+}
+
+type verifyMFARequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+func (h *AuthHandler) VerifyMFA(c *gin.Context) {
+	var req verifyMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, "AUTH.VALIDATION_FAILED", "invalid request", nil)
+		return
+	}
+
+	tokens, user, err := h.service.VerifyMFA(c.Request.Context(), req.MFAToken, req.Code, sessionMeta(c))
+	if err != nil {
+		if respondThrottled(c, err) {
+			return
+		}
+		switch err {
+		case services.ErrInvalidMFAChallenge:
+			RespondError(c, http.StatusUnauthorized, "AUTH.INVALID_MFA_TOKEN", "mfa challenge invalid or expired", nil)
+		case services.ErrMFAInvalidCode, services.ErrMFANotEnrolled:
+			RespondError(c, http.StatusUnauthorized, "AUTH.INVALID_MFA_CODE", "invalid mfa code", nil)
+		case services.ErrUserSuspended:
+			RespondError(c, http.StatusForbidden, "AUTH.USER_SUSPENDED", "user suspended", nil)
+		default:
+			RespondError(c, http.StatusInternalServerError, "AUTH.LOGIN_FAILED", "could not login", nil)
+		}
+		return
+	}
+
+	respondTokens(c, tokens, user)
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, "AUTH.VALIDATION_FAILED", "invalid request", nil)
+		return
+	}
+
+	tokens, user, err := h.service.Refresh(c.Request.Context(), req.RefreshToken, sessionMeta(c))
+	if err != nil {
+		switch err {
+		case services.ErrInvalidRefreshToken, services.ErrSessionRevoked:
+			RespondError(c, http.StatusUnauthorized, "AUTH.INVALID_REFRESH_TOKEN", "invalid refresh token", nil)
+		case services.ErrUserSuspended:
+			RespondError(c, http.StatusForbidden, "AUTH.USER_SUSPENDED", "user suspended", nil)
+		default:
+			RespondError(c, http.StatusInternalServerError, "AUTH.REFRESH_FAILED", "could not refresh session", nil)
+		}
+		return
+	}
+
+	respondTokens(c, tokens, user)
+}
+
+type logoutRequest struct {
+	AllSessions bool `json:"all_sessions"`
+}
+
+func (h *AuthHandler) Logout(c *gin.Context) {
+	var req logoutRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			RespondError(c, http.StatusBadRequest, "AUTH.VALIDATION_FAILED", "invalid request", nil)
+			return
+		}
+	}
+
+	userID := c.GetString(middleware.ContextUserID)
+	sessionID := c.GetString(middleware.ContextSessionID)
+	if err := h.service.Logout(c.Request.Context(), userID, sessionID, req.AllSessions); err != nil {
+		RespondError(c, http.StatusInternalServerError, "AUTH.LOGOUT_FAILED", "could not logout", nil)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	var req verifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, "AUTH.VALIDATION_FAILED", "invalid request", nil)
+		return
+	}
+
+	user, err := h.service.VerifyEmail(c.Request.Context(), req.Token)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidUserToken:
+			RespondError(c, http.StatusBadRequest, "AUTH.INVALID_TOKEN", "invalid or expired token", nil)
+		default:
+			RespondError(c, http.StatusInternalServerError, "AUTH.VERIFY_FAILED", "could not verify email", nil)
+		}
+		return
+	}
+
+	RespondData(c, http.StatusOK, gin.H{"user": user}, nil)
+}
+
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	user, err := h.service.GetUser(c.Request.Context(), c.GetString(middleware.ContextUserID))
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "AUTH.VERIFY_FAILED", "could not send verification email", nil)
+		return
+	}
+
+	if user.EmailVerifiedAt != nil {
+		RespondError(c, http.StatusConflict, "AUTH.EMAIL_ALREADY_VERIFIED", "email already verified", nil)
+		return
+	}
+
+	if err := h.service.SendEmailVerification(c.Request.Context(), user); err != nil {
+		RespondError(c, http.StatusInternalServerError, "AUTH.VERIFY_FAILED", "could not send verification email", nil)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+type forgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req forgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, "AUTH.VALIDATION_FAILED", "invalid request", nil)
+		return
+	}
+
+	// The response is the same whether or not the address is registered.
+	if err := h.service.ForgotPassword(c.Request.Context(), req.Email); err != nil {
+		log.Printf("password reset not queued: %v", err)
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+type resetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=8"`
+}
+
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req resetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, "AUTH.VALIDATION_FAILED", "invalid request", nil)
+		return
+	}
+
+	if err := h.service.ResetPassword(c.Request.Context(), req.Token, req.Password); err != nil {
+		switch err {
+		case services.ErrInvalidUserToken:
+			RespondError(c, http.StatusBadRequest, "AUTH.INVALID_TOKEN", "invalid or expired token", nil)
+		default:
+			RespondError(c, http.StatusInternalServerError, "AUTH.RESET_FAILED", "could not reset password", nil)
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func respondThrottled(c *gin.Context, err error) bool {
+	var throttled *services.ThrottleError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+
+	retryAfter := int(math.Ceil(throttled.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	if throttled.Locked {
+		RespondError(c, http.StatusTooManyRequests, "AUTH.ACCOUNT_LOCKED", "too many failed attempts; try again later", gin.H{"retry_after": retryAfter})
+	} else {
+		RespondError(c, http.StatusTooManyRequests, "AUTH.TOO_MANY_ATTEMPTS", "slow down before trying again", gin.H{"retry_after": retryAfter})
+	}
+	return true
+}
+
+func respondTokens(c *gin.Context, tokens *services.AuthTokens, user *domain.User) {
 	RespondData(c, http.StatusOK, gin.H{
-		"access_token": token,
-		"token_type":   "Bearer",
-		"user":         user,
+		"access_token":  tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"token_type":    tokens.TokenType,
+		"expires_in":    tokens.ExpiresIn,
+		"user":          user,
 	}, nil)
 }
 
+func sessionMeta(c *gin.Context) services.SessionMeta {
+	return services.SessionMeta{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
+
 func (h *AuthHandler) Me(c *gin.Context) {
 	userID, ok := c.Get(middleware.ContextUserID)
 	if !ok {
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"net/http"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/middleware"
+	"invoiceflow/internal/pagination"
+	"invoiceflow/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AutoInvestHandler struct {
+	service *services.AutoInvestService
+	orgs    *services.OrganizationService
+}
+
+func NewAutoInvestHandler(service *services.AutoInvestService, orgs *services.OrganizationService) *AutoInvestHandler {
+	return &AutoInvestHandler{service: service, orgs: orgs}
+}
+
+type strategyRequest struct {
+	Name          *string  `json:"name"`
+	Currency      *string  `json:"currency"`
+	MaxPerInvoice *float64 `json:"max_per_invoice"`
+	Budget        *float64 `json:"budget"`
+	RiskTiers     []string `json:"risk_tiers"`
+	MinAPRPercent *float64 `json:"min_apr_percent"`
+	MaxTermMonths *int     `json:"max_term_months"`
+	Tags          []string `json:"tags"`
+	EmergencyLane *string  `json:"emergency_lane"`
+	Active        *bool    `json:"active"`
+}
+
+func (r strategyRequest) input() services.StrategyInput {
+	return services.StrategyInput{
+		Name:          r.Name,
+		Currency:      r.Currency,
+		MaxPerInvoice: r.MaxPerInvoice,
+		Budget:        r.Budget,
+		RiskTiers:     r.RiskTiers,
+		MinAPRPercent: r.MinAPRPercent,
+		MaxTermMonths: r.MaxTermMonths,
+		Tags:          r.Tags,
+		EmergencyLane: r.EmergencyLane,
+		Active:        r.Active,
+	}
+}
+
+func (h *AutoInvestHandler) List(c *gin.Context) {
+	organizationID, ok := h.authorize(c, domain.OrgRoleViewer)
+	if !ok {
+		return
+	}
+
+	strategies, err := h.service.List(c.Request.Context(), organizationID)
+	if err != nil {
+		respondStrategyError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, strategies, nil)
+}
+
+func (h *AutoInvestHandler) Create(c *gin.Context) {
+	var req strategyRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Name == nil || req.Currency == nil || req.MaxPerInvoice == nil || req.Budget == nil {
+		RespondError(c, http.StatusBadRequest, "AUTO_INVEST.VALIDATION_FAILED", "name, currency, max_per_invoice and budget are required", nil)
+		return
+	}
+
+	organizationID, ok := organizationOfKind(c, h.orgs, c.Param("id"), domain.OrgRoleEditor, domain.OrgKindInvestor)
+	if !ok {
+		return
+	}
+
+	strategy, err := h.service.Create(c.Request.Context(), organizationID, c.GetString(middleware.ContextUserID), req.input())
+	if err != nil {
+		respondStrategyError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusCreated, strategy, nil)
+}
+
+func (h *AutoInvestHandler) Get(c *gin.Context) {
+	organizationID, ok := h.authorize(c, domain.OrgRoleViewer)
+	if !ok {
+		return
+	}
+
+	strategy, err := h.service.Get(c.Request.Context(), organizationID, c.Param("strategy_id"))
+	if err != nil {
+		respondStrategyError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, strategy, nil)
+}
+
+func (h *AutoInvestHandler) Update(c *gin.Context) {
+	var req strategyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, "AUTO_INVEST.VALIDATION_FAILED", "invalid request", nil)
+		return
+	}
+
+	organizationID, ok := organizationOfKind(c, h.orgs, c.Param("id"), domain.OrgRoleEditor, domain.OrgKindInvestor)
+	if !ok {
+		return
+	}
+
+	strategy, err := h.service.Update(c.Request.Context(), organizationID, c.Param("strategy_id"), req.input())
+	if err != nil {
+		respondStrategyError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, strategy, nil)
+}
+
+func (h *AutoInvestHandler) Delete(c *gin.Context) {
+	organizationID, ok := h.authorize(c, domain.OrgRoleEditor)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), organizationID, c.Param("strategy_id")); err != nil {
+		respondStrategyError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AutoInvestHandler) ListAllocations(c *gin.Context) {
+	organizationID, ok := h.authorize(c, domain.OrgRoleViewer)
+	if !ok {
+		return
+	}
+
+	page, err := parsePage(c)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, "AUTO_INVEST.VALIDATION_FAILED", err.Error(), nil)
+		return
+	}
+
+	allocations, meta, err := h.service.ListAllocations(c.Request.Context(), organizationID, c.Param("strategy_id"), page)
+	if err != nil {
+		respondStrategyError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, allocations, pageMeta(page, meta))
+}
+
+func (h *AutoInvestHandler) authorize(c *gin.Context, minRole string) (string, bool) {
+	organizationID := c.Param("id")
+	if _, err := h.orgs.Authorize(c.Request.Context(), c.GetString(middleware.ContextUserID), organizationID, minRole); err != nil {
+		respondOrgError(c, err)
+		return "", false
+	}
+
+	return organizationID, true
+}
+
+func respondStrategyError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrStrategyNotFound:
+		RespondError(c, http.StatusNotFound, "AUTO_INVEST.NOT_FOUND", "strategy not found", nil)
+	case services.ErrStrategyInvalid:
+		RespondError(c, http.StatusBadRequest, "AUTO_INVEST.VALIDATION_FAILED", "invalid strategy", nil)
+	case pagination.ErrInvalidCursor:
+		RespondError(c, http.StatusBadRequest, "AUTO_INVEST.VALIDATION_FAILED", err.Error(), nil)
+	default:
+		RespondError(c, http.StatusInternalServerError, "AUTO_INVEST.REQUEST_FAILED", "auto-invest request failed", nil)
+	}
+}
@@ -13,10 +13,30 @@ import (
 type ChainHandler struct {
 	chainService   *services.ChainService
 	invoiceService *services.InvoiceService
+	orgs           *services.OrganizationService
 }
 
-func NewChainHandler(chainService *services.ChainService, invoiceService *services.InvoiceService) *ChainHandler {
-	return &ChainHandler{chainService: chainService, invoiceService: invoiceService}
+func NewChainHandler(chainService *services.ChainService, invoiceService *services.InvoiceService, orgs *services.OrganizationService) *ChainHandler {
+	return &ChainHandler{chainService: chainService, invoiceService: invoiceService, orgs: orgs}
+}
+
+type tokenizeRequest struct {
+	ChainProfile string `json:"chain_profile"`
+}
+
+func (h *ChainHandler) ListProfiles(c *gin.Context) {
+	if h.chainService == nil {
+		RespondError(c, http.StatusInternalServerError, "CHAIN.NOT_READY", "chain service unavailable", nil)
+		return
+	}
+
+	profiles, err := h.chainService.ListProfiles()
+	if err != nil {
+		RespondError(c, http.StatusNotImplemented, "CHAIN.DISABLED", "chain disabled", nil)
+		return
+	}
+
+	RespondData(c, http.StatusOK, profiles, nil)
 }
 
 func (h *ChainHandler) Tokenize(c *gin.Context) {
@@ -25,8 +45,18 @@ func (h *ChainHandler) Tokenize(c *gin.Context) {
 		return
 	}
 
+	var req tokenizeRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			RespondError(c, http.StatusBadRequest, "CHAIN.VALIDATION_FAILED", "invalid request", nil)
+			return
+		}
+	}
+	if req.ChainProfile == "" {
+		req.ChainProfile = c.Query("chain_profile")
+	}
+
 	invoiceID := c.Param("id")
-	role := c.GetString(middleware.ContextUserRole)
 	userID := c.GetString(middleware.ContextUserID)
 
 	invoice, err := h.invoiceService.GetByID(c.Request.Context(), invoiceID)
@@ -35,16 +65,26 @@ func (h *ChainHandler) Tokenize(c *gin.Context) {
 		return
 	}
 
-	if role == domain.RoleSME && invoice.IssuerID != userID {
-		RespondError(c, http.StatusForbidden, "AUTH.FORBIDDEN", "invoice not accessible", nil)
-		return
+	// Without platform-wide invoice access, only editors of the issuing
+	// organization may tokenize.
+	if !invoiceAccessFor(c).all {
+		if _, err := h.orgs.Authorize(c.Request.Context(), userID, invoice.OrganizationID, domain.OrgRoleEditor); err != nil {
+			RespondError(c, http.StatusForbidden, "AUTH.FORBIDDEN", "invoice not accessible", nil)
+			return
+		}
 	}
 
-	onchain, chainTx, idempotent, err := h.chainService.TokenizeInvoice(c.Request.Context(), invoiceID)
+	onchain, chainTx, idempotent, err := h.chainService.TokenizeInvoice(c.Request.Context(), invoiceID, req.ChainProfile)
 	if err != nil {
 		switch err {
 		case services.ErrChainDisabled:
 			RespondError(c, http.StatusNotImplemented, "CHAIN.DISABLED", "chain disabled", nil)
+		case services.ErrChainProfileUnknown:
+			RespondError(c, http.StatusBadRequest, "CHAIN.UNKNOWN_PROFILE", "unknown chain profile", nil)
+		case services.ErrChainSignerMissing:
+			RespondError(c, http.StatusServiceUnavailable, "CHAIN.SIGNER_MISSING", "chain signer not configured", nil)
+		case services.ErrChainFeeTooHigh:
+			RespondError(c, http.StatusServiceUnavailable, "CHAIN.FEE_TOO_HIGH", "estimated network fee exceeds configured cap", nil)
 		case services.ErrInvoiceInvalidStatus:
 			RespondError(c, http.StatusConflict, "INVOICE.INVALID_STATUS", "invoice status invalid", nil)
 		default:
@@ -94,6 +134,8 @@ func (h *ChainHandler) RefreshOnchain(c *gin.Context) {
 		switch err {
 		case services.ErrChainDisabled:
 			RespondError(c, http.StatusNotImplemented, "CHAIN.DISABLED", "chain disabled", nil)
+		case services.ErrChainProfileUnknown:
+			RespondError(c, http.StatusConflict, "CHAIN.UNKNOWN_PROFILE", "chain profile for record is not configured", nil)
 		default:
 			RespondError(c, http.StatusNotFound, "CHAIN.NOT_FOUND", "onchain record not found", nil)
 		}
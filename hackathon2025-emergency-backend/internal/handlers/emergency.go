@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"net/http"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/middleware"
+	"invoiceflow/internal/pagination"
+	"invoiceflow/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EmergencyHandler struct {
+	emergency *services.EmergencyService
+	liquidity *services.LiquidityService
+	orgs      *services.OrganizationService
+}
+
+func NewEmergencyHandler(emergency *services.EmergencyService, liquidity *services.LiquidityService, orgs *services.OrganizationService) *EmergencyHandler {
+	return &EmergencyHandler{emergency: emergency, liquidity: liquidity, orgs: orgs}
+}
+
+// Queue lists the emergency-lane invoices awaiting review, nearest SLA
+// deadline first.
+func (h *EmergencyHandler) Queue(c *gin.Context) {
+	page, err := parsePage(c)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, "EMERGENCY.VALIDATION_FAILED", err.Error(), nil)
+		return
+	}
+
+	items, meta, err := h.emergency.Queue(c.Request.Context(), page)
+	if err == pagination.ErrInvalidCursor {
+		RespondError(c, http.StatusBadRequest, "EMERGENCY.VALIDATION_FAILED", err.Error(), nil)
+		return
+	}
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "EMERGENCY.QUEUE_FAILED", "could not list the emergency queue", nil)
+		return
+	}
+
+	RespondData(c, http.StatusOK, items, pageMeta(page, meta))
+}
+
+func (h *EmergencyHandler) PoolBalances(c *gin.Context) {
+	balances, err := h.liquidity.Balances(c.Request.Context())
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "LIQUIDITY.REQUEST_FAILED", "liquidity request failed", nil)
+		return
+	}
+
+	RespondData(c, http.StatusOK, balances, nil)
+}
+
+func (h *EmergencyHandler) ListCommitments(c *gin.Context) {
+	organizationID, ok := h.authorize(c, domain.OrgRoleViewer)
+	if !ok {
+		return
+	}
+
+	commitments, err := h.liquidity.List(c.Request.Context(), organizationID)
+	if err != nil {
+		respondLiquidityError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, commitments, nil)
+}
+
+type commitLiquidityRequest struct {
+	Currency      string   `json:"currency" binding:"required"`
+	Amount        float64  `json:"amount" binding:"required,gt=0"`
+	MaxPerInvoice *float64 `json:"max_per_invoice"`
+	MinAPRPercent *float64 `json:"min_apr_percent"`
+}
+
+func (h *EmergencyHandler) Commit(c *gin.Context) {
+	var req commitLiquidityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, "LIQUIDITY.VALIDATION_FAILED", "invalid request", nil)
+		return
+	}
+
+	organizationID, ok := organizationOfKind(c, h.orgs, c.Param("id"), domain.OrgRoleEditor, domain.OrgKindInvestor)
+	if !ok {
+		return
+	}
+
+	commitment, err := h.liquidity.Commit(c.Request.Context(), organizationID, c.GetString(middleware.ContextUserID), services.CommitmentInput{
+		Currency:      req.Currency,
+		Amount:        req.Amount,
+		MaxPerInvoice: req.MaxPerInvoice,
+		MinAPRPercent: req.MinAPRPercent,
+	})
+	if err != nil {
+		respondLiquidityError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusCreated, commitment, nil)
+}
+
+func (h *EmergencyHandler) Withdraw(c *gin.Context) {
+	organizationID, ok := h.authorize(c, domain.OrgRoleEditor)
+	if !ok {
+		return
+	}
+
+	commitment, err := h.liquidity.Withdraw(c.Request.Context(), organizationID, c.Param("commitment_id"))
+	if err != nil {
+		respondLiquidityError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, commitment, nil)
+}
+
+func (h *EmergencyHandler) authorize(c *gin.Context, minRole string) (string, bool) {
+	organizationID := c.Param("id")
+	if _, err := h.orgs.Authorize(c.Request.Context(), c.GetString(middleware.ContextUserID), organizationID, minRole); err != nil {
+		respondOrgError(c, err)
+		return "", false
+	}
+
+	return organizationID, true
+}
+
+func respondLiquidityError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrCommitmentNotFound:
+		RespondError(c, http.StatusNotFound, "LIQUIDITY.NOT_FOUND", "commitment not found", nil)
+	case services.ErrCommitmentInvalid:
+		RespondError(c, http.StatusBadRequest, "LIQUIDITY.VALIDATION_FAILED", "currency and a positive amount are required", nil)
+	case services.ErrCommitmentClosed:
+		RespondError(c, http.StatusConflict, "LIQUIDITY.INVALID_STATUS", "commitment is no longer active", nil)
+	default:
+		RespondError(c, http.StatusInternalServerError, "LIQUIDITY.REQUEST_FAILED", "liquidity request failed", nil)
+	}
+}
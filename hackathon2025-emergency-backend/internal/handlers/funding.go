@@ -3,7 +3,9 @@ package handlers
 import (
 	"net/http"
 
+	"invoiceflow/internal/domain"
 	"invoiceflow/internal/middleware"
+	"invoiceflow/internal/pagination"
 	"invoiceflow/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -11,10 +13,11 @@ import (
 
 type FundingHandler struct {
 	service *services.FundingService
+	orgs    *services.OrganizationService
 }
 
-func NewFundingHandler(service *services.FundingService) *FundingHandler {
-	return &FundingHandler{service: service}
+func NewFundingHandler(service *services.FundingService, orgs *services.OrganizationService) *FundingHandler {
+	return &FundingHandler{service: service, orgs: orgs}
 }
 
 type fundInvoiceRequest struct {
@@ -33,7 +36,12 @@ func (h *FundingHandler) FundInvoice(c *gin.Context) {
 		return
 	}
 
-	funding, invoice, err := h.service.CreateFunding(c.Request.Context(), invoiceID, investorID, req.Amount, req.APRPercent, req.TermMonths)
+	organizationID, ok := organizationOfKind(c, h.orgs, c.GetHeader(HeaderOrganizationID), domain.OrgRoleEditor, domain.OrgKindInvestor)
+	if !ok {
+		return
+	}
+
+	funding, invoice, err := h.service.CreateFunding(c.Request.Context(), invoiceID, investorID, organizationID, req.Amount, req.APRPercent, req.TermMonths)
 	if err != nil {
 		switch err {
 		case services.ErrFundingExceedsTarget:
@@ -55,19 +63,26 @@ func (h *FundingHandler) FundInvoice(c *gin.Context) {
 }
 
 func (h *FundingHandler) ListMyFundings(c *gin.Context) {
-	investorID := c.GetString(middleware.ContextUserID)
-	page := parseInt(c.Query("page"), 1)
-	pageSize := parseInt(c.Query("page_size"), 20)
+	organizationIDs, ok := visibleOrganizations(c, h.orgs)
+	if !ok {
+		return
+	}
 
-	fundings, total, err := h.service.ListInvestorFundings(c.Request.Context(), investorID, pageSize, (page-1)*pageSize)
+	page, err := parsePage(c)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, "FUNDING.VALIDATION_FAILED", err.Error(), nil)
+		return
+	}
+
+	fundings, meta, err := h.service.ListOrganizationFundings(c.Request.Context(), organizationIDs, page)
+	if err == pagination.ErrInvalidCursor {
+		RespondError(c, http.StatusBadRequest, "FUNDING.VALIDATION_FAILED", err.Error(), nil)
+		return
+	}
 	if err != nil {
 		RespondError(c, http.StatusInternalServerError, "FUNDING.LIST_FAILED", "could not list fundings", nil)
 		return
 	}
 
-	RespondData(c, http.StatusOK, fundings, gin.H{
-		"page":      page,
-		"page_size": pageSize,
-		"total":     total,
-	})
+	RespondData(c, http.StatusOK, fundings, pageMeta(page, meta))
 }
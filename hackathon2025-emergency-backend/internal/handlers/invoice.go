@@ -1,12 +1,15 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"invoiceflow/internal/domain"
 	"invoiceflow/internal/middleware"
+	"invoiceflow/internal/pagination"
 	"invoiceflow/internal/repositories"
 	"invoiceflow/internal/services"
 
@@ -15,10 +18,11 @@ import (
 
 type InvoiceHandler struct {
 	service *services.InvoiceService
+	orgs    *services.OrganizationService
 }
 
-func NewInvoiceHandler(service *services.InvoiceService) *InvoiceHandler {
-	return &InvoiceHandler{service: service}
+func NewInvoiceHandler(service *services.InvoiceService, orgs *services.OrganizationService) *InvoiceHandler {
+	return &InvoiceHandler{service: service, orgs: orgs}
 }
 
 type createInvoiceRequest struct {
@@ -33,12 +37,12 @@ type createInvoiceRequest struct {
 	FundingTarget float64  `json:"funding_target" binding:"required,gt=0"`
 	EmergencyLane bool     `json:"emergency_lane"`
 	Tags          []string `json:"tags"`
+	DebtorName    *string  `json:"debtor_name"`
 }
 
 func (h *InvoiceHandler) Create(c *gin.Context) {
-	role := c.GetString(middleware.ContextUserRole)
-	if role != domain.RoleSME {
-		RespondError(c, http.StatusForbidden, "AUTH.FORBIDDEN", "only SME can create invoices", nil)
+	if !middleware.HasPermission(c, domain.PermInvoiceCreate) {
+		RespondError(c, http.StatusForbidden, "AUTH.FORBIDDEN", "missing permission to create invoices", nil)
 		return
 	}
 
@@ -60,24 +64,35 @@ func (h *InvoiceHandler) Create(c *gin.Context) {
 		return
 	}
 
+	organizationID, ok := organizationOfKind(c, h.orgs, c.GetHeader(HeaderOrganizationID), domain.OrgRoleEditor, domain.OrgKindSME)
+	if !ok {
+		return
+	}
+
 	invoice := &domain.Invoice{
-		IssuerID:      issuerID,
-		Title:         req.Title,
-		InvoiceNumber: req.InvoiceNumber,
-		Amount:        req.Amount,
-		Currency:      req.Currency,
-		TermMonths:    req.TermMonths,
-		DueDate:       dueDate,
-		RiskTier:      req.RiskTier,
-		APRPercent:    req.APRPercent,
-		FundingTarget: req.FundingTarget,
-		FundedAmount:  0,
-		Status:        domain.InvoiceStatusDraft,
-		EmergencyLane: req.EmergencyLane,
-		Tags:          req.Tags,
+		IssuerID:       issuerID,
+		OrganizationID: organizationID,
+		Title:          req.Title,
+		InvoiceNumber:  req.InvoiceNumber,
+		Amount:         req.Amount,
+		Currency:       req.Currency,
+		TermMonths:     req.TermMonths,
+		DueDate:        dueDate,
+		RiskTier:       req.RiskTier,
+		APRPercent:     req.APRPercent,
+		FundingTarget:  req.FundingTarget,
+		FundedAmount:   0,
+		Status:         domain.InvoiceStatusDraft,
+		EmergencyLane:  req.EmergencyLane,
+		Tags:           req.Tags,
+		DebtorName:     req.DebtorName,
 	}
 
 	created, err := h.service.Create(c.Request.Context(), invoice)
+	if err == services.ErrInvoiceEmergencyCap {
+		h.respondEmergencyCap(c)
+		return
+	}
 	if err != nil {
 		RespondError(c, http.StatusInternalServerError, "INVOICE.CREATE_FAILED", "could not create invoice", nil)
 		return
@@ -87,46 +102,53 @@ func (h *InvoiceHandler) Create(c *gin.Context) {
 }
 
 func (h *InvoiceHandler) List(c *gin.Context) {
-	role := c.GetString(middleware.ContextUserRole)
-	userID := c.GetString(middleware.ContextUserID)
-
-	page := parseInt(c.Query("page"), 1)
-	pageSize := parseInt(c.Query("page_size"), 20)
+	page, err := parsePage(c)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, "INVOICE.VALIDATION_FAILED", err.Error(), nil)
+		return
+	}
 	status := c.Query("status")
 
 	filters := repositories.InvoiceFilters{
 		Status: status,
-		Limit:  pageSize,
-		Offset: (page - 1) * pageSize,
+		Page:   page,
 	}
 
-	if role == domain.RoleSME {
-		filters.IssuerID = userID
+	if field, err := parseMarketplaceFilters(c, &filters); err != nil {
+		RespondError(c, http.StatusBadRequest, "INVOICE.VALIDATION_FAILED", "invalid "+field, nil)
+		return
 	}
 
-	if role == domain.RoleInvestor {
-		filters.AllowedStatuses = []string{domain.InvoiceStatusApproved, domain.InvoiceStatusTokenized, domain.InvoiceStatusFunded}
+	access := invoiceAccessFor(c)
+	if !access.all {
+		if access.own {
+			organizationIDs, ok := visibleOrganizations(c, h.orgs)
+			if !ok {
+				return
+			}
+			filters.OrganizationIDs = organizationIDs
+		}
+		if access.listed {
+			filters.ListedStatuses = marketplaceStatuses
+			filters.PrioritizeEmergency = true
+		}
 	}
 
-	invoices, total, err := h.service.List(c.Request.Context(), filters)
+	invoices, meta, err := h.service.List(c.Request.Context(), filters)
+	if err == pagination.ErrInvalidCursor {
+		RespondError(c, http.StatusBadRequest, "INVOICE.VALIDATION_FAILED", err.Error(), nil)
+		return
+	}
 	if err != nil {
 		RespondError(c, http.StatusInternalServerError, "INVOICE.LIST_FAILED", "could not list invoices", nil)
 		return
 	}
 
-	meta := gin.H{
-		"page":      page,
-		"page_size": pageSize,
-		"total":     total,
-	}
-
-	RespondData(c, http.StatusOK, invoices, meta)
+	RespondData(c, http.StatusOK, invoices, pageMeta(page, meta))
 }
 
 func (h *InvoiceHandler) GetByID(c *gin.Context) {
 	id := c.Param("id")
-	role := c.GetString(middleware.ContextUserRole)
-	userID := c.GetString(middleware.ContextUserID)
 
 	invoice, err := h.service.GetByID(c.Request.Context(), id)
 	if err != nil {
@@ -134,30 +156,80 @@ func (h *InvoiceHandler) GetByID(c *gin.Context) {
 		return
 	}
 
-	if role == domain.RoleSME && invoice.IssuerID != userID {
+	if !canReadInvoice(c, h.orgs, invoice) {
 		RespondError(c, http.StatusForbidden, "AUTH.FORBIDDEN", "invoice not accessible", nil)
 		return
 	}
 
-	if role == domain.RoleInvestor && (invoice.Status == domain.InvoiceStatusDraft || invoice.Status == domain.InvoiceStatusSubmitted) {
-		RespondError(c, http.StatusForbidden, "AUTH.FORBIDDEN", "invoice not accessible", nil)
-		return
+	RespondData(c, http.StatusOK, invoice, nil)
+}
+
+// marketplaceStatuses are the invoices open for funding that the
+// marketplace lists.
+var marketplaceStatuses = []string{domain.InvoiceStatusApproved, domain.InvoiceStatusTokenized, domain.InvoiceStatusFunded}
+
+// publishedStatuses are the statuses of invoices that have passed review
+// and can be read by anyone browsing listings, including after repayment.
+var publishedStatuses = map[string]bool{
+	domain.InvoiceStatusApproved:      true,
+	domain.InvoiceStatusTokenized:     true,
+	domain.InvoiceStatusFunded:        true,
+	domain.InvoiceStatusPartiallyPaid: true,
+	domain.InvoiceStatusPaid:          true,
+	domain.InvoiceStatusDefaulted:     true,
+}
+
+// invoiceAccess is what the caller's permissions let them read: every
+// invoice, the invoices of their organizations, or published listings.
+type invoiceAccess struct {
+	all    bool
+	own    bool
+	listed bool
+}
+
+func invoiceAccessFor(c *gin.Context) invoiceAccess {
+	return invoiceAccess{
+		all:    middleware.HasPermission(c, domain.PermInvoiceReadAll),
+		own:    middleware.HasPermission(c, domain.PermInvoiceRead),
+		listed: middleware.HasPermission(c, domain.PermInvoiceReadListed),
 	}
+}
 
-	RespondData(c, http.StatusOK, invoice, nil)
+// allows reports whether an invoice of organizationID in status is visible.
+// member is only asked when organization membership decides it.
+func (a invoiceAccess) allows(organizationID string, status string, member func(organizationID string) bool) bool {
+	switch {
+	case a.all:
+		return true
+	case a.listed && publishedStatuses[status]:
+		return true
+	case a.own:
+		return member(organizationID)
+	default:
+		return false
+	}
+}
+
+func canReadInvoice(c *gin.Context, orgs *services.OrganizationService, invoice *domain.Invoice) bool {
+	return invoiceAccessFor(c).allows(invoice.OrganizationID, invoice.Status, func(organizationID string) bool {
+		_, err := orgs.Authorize(c.Request.Context(), c.GetString(middleware.ContextUserID), organizationID, domain.OrgRoleViewer)
+		return err == nil
+	})
 }
 
 func (h *InvoiceHandler) Submit(c *gin.Context) {
 	id := c.Param("id")
-	issuerID := c.GetString(middleware.ContextUserID)
+	userID := c.GetString(middleware.ContextUserID)
 
-	invoice, err := h.service.Submit(c.Request.Context(), id, issuerID)
+	invoice, err := h.service.Submit(c.Request.Context(), id, userID)
 	if err != nil {
 		switch err {
 		case services.ErrInvoiceAccessDenied:
 			RespondError(c, http.StatusForbidden, "AUTH.FORBIDDEN", "invoice not accessible", nil)
 		case services.ErrInvoiceInvalidStatus:
 			RespondError(c, http.StatusConflict, "INVOICE.INVALID_STATUS", "invoice status invalid", nil)
+		case services.ErrInvoiceEmergencyCap:
+			h.respondEmergencyCap(c)
 		default:
 			RespondError(c, http.StatusNotFound, "INVOICE.NOT_FOUND", "invoice not found", nil)
 		}
@@ -167,17 +239,116 @@ func (h *InvoiceHandler) Submit(c *gin.Context) {
 	RespondData(c, http.StatusOK, invoice, nil)
 }
 
-func parseInt(value string, fallback int) int {
+func (h *InvoiceHandler) respondEmergencyCap(c *gin.Context) {
+	maxAmount, maxTermMonths := h.service.EmergencyCaps()
+	RespondError(c, http.StatusUnprocessableEntity, "INVOICE.EMERGENCY_CAP_EXCEEDED", "amount or term exceeds the emergency lane caps", gin.H{
+		"max_amount":      maxAmount,
+		"max_term_months": maxTermMonths,
+	})
+}
+
+// parseMarketplaceFilters reads the search, filter and sort query parameters
+// into filters. On failure it returns the name of the offending parameter.
+func parseMarketplaceFilters(c *gin.Context, filters *repositories.InvoiceFilters) (string, error) {
+	var err error
+
+	filters.RiskTiers = splitList(c.Query("risk_tier"))
+	filters.Tags = splitList(c.Query("tags"))
+	filters.Currency = strings.ToUpper(c.Query("currency"))
+	filters.Query = strings.TrimSpace(c.Query("q"))
+
+	if filters.MinAPR, err = parseOptionalFloat(c.Query("apr_min")); err != nil {
+		return "apr_min", err
+	}
+	if filters.MaxAPR, err = parseOptionalFloat(c.Query("apr_max")); err != nil {
+		return "apr_max", err
+	}
+	if filters.MinAmount, err = parseOptionalFloat(c.Query("amount_min")); err != nil {
+		return "amount_min", err
+	}
+	if filters.MaxAmount, err = parseOptionalFloat(c.Query("amount_max")); err != nil {
+		return "amount_max", err
+	}
+	if filters.MinRemaining, err = parseOptionalFloat(c.Query("remaining_min")); err != nil {
+		return "remaining_min", err
+	}
+	if filters.MaxRemaining, err = parseOptionalFloat(c.Query("remaining_max")); err != nil {
+		return "remaining_max", err
+	}
+	if filters.MinTermMonths, err = parseOptionalInt(c.Query("term_min")); err != nil {
+		return "term_min", err
+	}
+	if filters.MaxTermMonths, err = parseOptionalInt(c.Query("term_max")); err != nil {
+		return "term_max", err
+	}
+
+	if value := c.Query("due_from"); value != "" {
+		due, err := parseDate(value)
+		if err != nil {
+			return "due_from", err
+		}
+		filters.DueFrom = &due
+	}
+	if value := c.Query("due_to"); value != "" {
+		due, err := parseDate(value)
+		if err != nil {
+			return "due_to", err
+		}
+		filters.DueTo = &due
+	}
+
+	if value := c.Query("emergency_lane"); value != "" {
+		lane, err := strconv.ParseBool(value)
+		if err != nil {
+			return "emergency_lane", err
+		}
+		filters.EmergencyLane = &lane
+	}
+
+	if sort := c.Query("sort"); sort != "" {
+		if !repositories.ValidInvoiceSort(sort) {
+			return "sort", errors.New("unknown sort")
+		}
+		filters.Sort = sort
+	}
+
+	return "", nil
+}
+
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parseOptionalFloat(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parsed, nil
+}
+
+func parseOptionalInt(value string) (*int, error) {
 	if value == "" {
-		return fallback
+		return nil, nil
 	}
 
 	parsed, err := strconv.Atoi(value)
 	if err != nil {
-		return fallback
+		return nil, err
 	}
 
-	return parsed
+	return &parsed, nil
 }
 
 func parseDate(value string) (time.Time, error) {
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func contextWithPermissions(permissions ...string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Set(middleware.ContextUserPerms, permissions)
+	return c
+}
+
+func TestInvoiceAccessFollowsPermissions(t *testing.T) {
+	memberOf := func(ids ...string) func(string) bool {
+		return func(organizationID string) bool {
+			for _, id := range ids {
+				if id == organizationID {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	cases := []struct {
+		name        string
+		permissions []string
+		org         string
+		status      string
+		member      func(string) bool
+		want        bool
+	}{
+		{"no permission", nil, "org-1", domain.InvoiceStatusApproved, memberOf("org-1"), false},
+		{"role name alone grants nothing", []string{domain.PermInvoiceCreate}, "org-1", domain.InvoiceStatusDraft, memberOf("org-1"), false},
+		{"own draft as member", []string{domain.PermInvoiceRead}, "org-1", domain.InvoiceStatusDraft, memberOf("org-1"), true},
+		{"other organization's draft", []string{domain.PermInvoiceRead}, "org-2", domain.InvoiceStatusDraft, memberOf("org-1"), false},
+		{"other organization's listing without read_listed", []string{domain.PermInvoiceRead}, "org-2", domain.InvoiceStatusApproved, memberOf("org-1"), false},
+		{"published listing", []string{domain.PermInvoiceReadListed}, "org-2", domain.InvoiceStatusFunded, memberOf(), true},
+		{"repaid listing", []string{domain.PermInvoiceReadListed}, "org-2", domain.InvoiceStatusPaid, memberOf(), true},
+		{"unreviewed listing", []string{domain.PermInvoiceReadListed}, "org-2", domain.InvoiceStatusSubmitted, memberOf(), false},
+		{"canceled listing", []string{domain.PermInvoiceReadListed}, "org-2", domain.InvoiceStatusCanceled, memberOf(), false},
+		{"read_all", []string{domain.PermInvoiceReadAll}, "org-2", domain.InvoiceStatusDraft, memberOf(), true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			access := invoiceAccessFor(contextWithPermissions(tc.permissions...))
+			if got := access.allows(tc.org, tc.status, tc.member); got != tc.want {
+				t.Fatalf("allows = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestInvoiceAccessSkipsMembershipLookupWhenUnneeded(t *testing.T) {
+	called := false
+	member := func(string) bool {
+		called = true
+		return true
+	}
+
+	invoiceAccessFor(contextWithPermissions(domain.PermInvoiceReadAll)).allows("org-1", domain.InvoiceStatusDraft, member)
+	invoiceAccessFor(contextWithPermissions(domain.PermInvoiceReadListed)).allows("org-1", domain.InvoiceStatusDraft, member)
+	if called {
+		t.Fatal("membership was looked up without invoice.read")
+	}
+}
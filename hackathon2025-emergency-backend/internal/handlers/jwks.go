@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"net/http"
+
+	"invoiceflow/internal/jwtkeys"
+
+	"github.com/gin-gonic/gin"
+)
+
+func JWKS(keys *jwtkeys.KeySet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=300")
+		c.JSON(http.StatusOK, keys.JWKS())
+	}
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"net/http"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/middleware"
+	"invoiceflow/internal/pagination"
+	"invoiceflow/internal/repositories"
+	"invoiceflow/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type KYCHandler struct {
+	service *services.KYCService
+}
+
+func NewKYCHandler(service *services.KYCService) *KYCHandler {
+	return &KYCHandler{service: service}
+}
+
+type submitKYCRequest struct {
+	Details   domain.JSONObject    `json:"details" binding:"required"`
+	Documents []domain.KYCDocument `json:"documents" binding:"required,min=1,dive"`
+}
+
+func (h *KYCHandler) Submit(c *gin.Context) {
+	var req submitKYCRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, "KYC.VALIDATION_FAILED", "invalid request", nil)
+		return
+	}
+
+	userID := c.GetString(middleware.ContextUserID)
+	submission, err := h.service.Submit(c.Request.Context(), userID, req.Details, req.Documents)
+	if err != nil {
+		switch err {
+		case services.ErrKYCDocumentsMissing:
+			RespondError(c, http.StatusBadRequest, "KYC.DOCUMENTS_MISSING", "at least one document is required", nil)
+		case services.ErrKYCAlreadySubmitted:
+			RespondError(c, http.StatusConflict, "KYC.ALREADY_SUBMITTED", "a submission is already pending review", nil)
+		case services.ErrKYCNotRequired:
+			RespondError(c, http.StatusConflict, "KYC.NOT_REQUIRED", "account is not pending verification", nil)
+		default:
+			RespondError(c, http.StatusInternalServerError, "KYC.SUBMIT_FAILED", "could not submit verification", nil)
+		}
+		return
+	}
+
+	RespondData(c, http.StatusCreated, submission, nil)
+}
+
+func (h *KYCHandler) GetMine(c *gin.Context) {
+	userID := c.GetString(middleware.ContextUserID)
+	submission, err := h.service.GetLatest(c.Request.Context(), userID)
+	if err != nil {
+		switch err {
+		case services.ErrKYCNotFound:
+			RespondError(c, http.StatusNotFound, "KYC.NOT_FOUND", "no verification submitted", nil)
+		default:
+			RespondError(c, http.StatusInternalServerError, "KYC.FETCH_FAILED", "could not fetch verification", nil)
+		}
+		return
+	}
+
+	RespondData(c, http.StatusOK, submission, nil)
+}
+
+func (h *KYCHandler) List(c *gin.Context) {
+	page, err := parsePage(c)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, "KYC.VALIDATION_FAILED", err.Error(), nil)
+		return
+	}
+
+	status := c.Query("status")
+	if status == "" {
+		status = domain.KYCStatusSubmitted
+	}
+
+	filters := repositories.KYCFilters{
+		Status: status,
+		Kind:   c.Query("kind"),
+		Page:   page,
+	}
+
+	items, meta, err := h.service.List(c.Request.Context(), filters)
+	if err == pagination.ErrInvalidCursor {
+		RespondError(c, http.StatusBadRequest, "KYC.VALIDATION_FAILED", err.Error(), nil)
+		return
+	}
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "KYC.LIST_FAILED", "could not list verifications", nil)
+		return
+	}
+
+	RespondData(c, http.StatusOK, items, pageMeta(page, meta))
+}
+
+func (h *KYCHandler) Get(c *gin.Context) {
+	item, err := h.service.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch err {
+		case services.ErrKYCNotFound:
+			RespondError(c, http.StatusNotFound, "KYC.NOT_FOUND", "verification not found", nil)
+		default:
+			RespondError(c, http.StatusInternalServerError, "KYC.FETCH_FAILED", "could not fetch verification", nil)
+		}
+		return
+	}
+
+	RespondData(c, http.StatusOK, item, nil)
+}
+
+type reviewKYCRequest struct {
+	Note string `json:"note"`
+}
+
+type rejectKYCRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+func (h *KYCHandler) Approve(c *gin.Context) {
+	var req reviewKYCRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			RespondError(c, http.StatusBadRequest, "KYC.VALIDATION_FAILED", "invalid request", nil)
+			return
+		}
+	}
+
+	reviewerID := c.GetString(middleware.ContextUserID)
+	submission, err := h.service.Approve(c.Request.Context(), c.Param("id"), reviewerID, req.Note)
+	h.respondReview(c, submission, err)
+}
+
+func (h *KYCHandler) Reject(c *gin.Context) {
+	var req rejectKYCRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, "KYC.VALIDATION_FAILED", "invalid request", nil)
+		return
+	}
+
+	reviewerID := c.GetString(middleware.ContextUserID)
+	submission, err := h.service.Reject(c.Request.Context(), c.Param("id"), reviewerID, req.Reason)
+	h.respondReview(c, submission, err)
+}
+
+func (h *KYCHandler) respondReview(c *gin.Context, submission *domain.KYCSubmission, err error) {
+	if err != nil {
+		switch err {
+		case services.ErrKYCNotFound:
+			RespondError(c, http.StatusNotFound, "KYC.NOT_FOUND", "verification not found", nil)
+		case services.ErrKYCInvalidStatus:
+			RespondError(c, http.StatusConflict, "KYC.INVALID_STATUS", "verification already reviewed", nil)
+		default:
+			RespondError(c, http.StatusInternalServerError, "KYC.REVIEW_FAILED", "could not review verification", nil)
+		}
+		return
+	}
+
+	RespondData(c, http.StatusOK, submission, nil)
+}
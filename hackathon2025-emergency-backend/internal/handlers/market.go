@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/middleware"
+	"invoiceflow/internal/pagination"
+	"invoiceflow/internal/repositories"
+	"invoiceflow/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MarketHandler struct {
+	service *services.MarketService
+	orgs    *services.OrganizationService
+}
+
+func NewMarketHandler(service *services.MarketService, orgs *services.OrganizationService) *MarketHandler {
+	return &MarketHandler{service: service, orgs: orgs}
+}
+
+// ListListings is the public market view: active listings, optionally for
+// one invoice or currency.
+func (h *MarketHandler) ListListings(c *gin.Context) {
+	h.listListings(c, nil)
+}
+
+// ListMyListings lists the listings of the caller's organizations, whatever
+// their status.
+func (h *MarketHandler) ListMyListings(c *gin.Context) {
+	organizationIDs, ok := visibleOrganizations(c, h.orgs)
+	if !ok {
+		return
+	}
+
+	h.listListings(c, organizationIDs)
+}
+
+func (h *MarketHandler) listListings(c *gin.Context, organizationIDs []string) {
+	page, err := parsePage(c)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, "MARKET.VALIDATION_FAILED", err.Error(), nil)
+		return
+	}
+
+	listings, meta, err := h.service.ListListings(c.Request.Context(), repositories.ListingFilters{
+		InvoiceID:       c.Query("invoice_id"),
+		Currency:        strings.ToUpper(c.Query("currency")),
+		OrganizationIDs: organizationIDs,
+		Page:            page,
+	})
+	if err != nil {
+		respondMarketError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, listings, pageMeta(page, meta))
+}
+
+func (h *MarketHandler) GetListing(c *gin.Context) {
+	listing, err := h.service.GetListing(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondMarketError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, listing, nil)
+}
+
+type createListingRequest struct {
+	FundingID string   `json:"funding_id" binding:"required"`
+	Principal *float64 `json:"principal"`
+	UnitPrice float64  `json:"unit_price" binding:"required,gt=0"`
+}
+
+func (h *MarketHandler) CreateListing(c *gin.Context) {
+	var req createListingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, "MARKET.VALIDATION_FAILED", "funding_id and a positive unit_price are required", nil)
+		return
+	}
+
+	organizationID, ok := actingOrganization(c, h.orgs, domain.OrgRoleEditor)
+	if !ok {
+		return
+	}
+
+	listing, err := h.service.CreateListing(c.Request.Context(), organizationID, c.GetString(middleware.ContextUserID), services.ListingInput{
+		FundingID: req.FundingID,
+		Principal: req.Principal,
+		UnitPrice: req.UnitPrice,
+	})
+	if err != nil {
+		respondMarketError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusCreated, listing, nil)
+}
+
+func (h *MarketHandler) CancelListing(c *gin.Context) {
+	organizationID, ok := actingOrganization(c, h.orgs, domain.OrgRoleEditor)
+	if !ok {
+		return
+	}
+
+	listing, err := h.service.CancelListing(c.Request.Context(), organizationID, c.Param("id"))
+	if err != nil {
+		respondMarketError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, listing, nil)
+}
+
+type buyListingRequest struct {
+	Principal *float64 `json:"principal"`
+}
+
+func (h *MarketHandler) Buy(c *gin.Context) {
+	var req buyListingRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			RespondError(c, http.StatusBadRequest, "MARKET.VALIDATION_FAILED", "invalid request", nil)
+			return
+		}
+	}
+
+	organizationID, ok := organizationOfKind(c, h.orgs, c.GetHeader(HeaderOrganizationID), domain.OrgRoleEditor, domain.OrgKindInvestor)
+	if !ok {
+		return
+	}
+
+	transfer, err := h.service.Buy(c.Request.Context(), organizationID, c.GetString(middleware.ContextUserID), c.Param("id"), req.Principal)
+	if err != nil {
+		respondMarketError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusCreated, transfer, nil)
+}
+
+// ListMyTransfers is the transfer history of positions the caller's
+// organizations bought or sold, optionally for one invoice.
+func (h *MarketHandler) ListMyTransfers(c *gin.Context) {
+	organizationIDs, ok := visibleOrganizations(c, h.orgs)
+	if !ok {
+		return
+	}
+
+	page, err := parsePage(c)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, "MARKET.VALIDATION_FAILED", err.Error(), nil)
+		return
+	}
+
+	transfers, meta, err := h.service.ListTransfers(c.Request.Context(), organizationIDs, c.Query("invoice_id"), page)
+	if err != nil {
+		respondMarketError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, transfers, pageMeta(page, meta))
+}
+
+func respondMarketError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrListingNotFound:
+		RespondError(c, http.StatusNotFound, "MARKET.NOT_FOUND", "listing not found", nil)
+	case services.ErrPositionNotFound:
+		RespondError(c, http.StatusNotFound, "MARKET.POSITION_NOT_FOUND", "funding position not found", nil)
+	case services.ErrPositionNotTradable:
+		RespondError(c, http.StatusConflict, "MARKET.POSITION_NOT_TRADABLE", "only confirmed positions of open invoices can be traded", nil)
+	case services.ErrPositionOnchain:
+		RespondError(c, http.StatusConflict, "MARKET.POSITION_ONCHAIN", "positions of tokenized invoices cannot be traded while chain mode is on", nil)
+	case services.ErrPositionAlreadyListed:
+		RespondError(c, http.StatusConflict, "MARKET.ALREADY_LISTED", "position already has an active listing", nil)
+	case services.ErrListingClosed:
+		RespondError(c, http.StatusConflict, "MARKET.INVALID_STATUS", "listing is no longer active", nil)
+	case services.ErrListingOwnPosition:
+		RespondError(c, http.StatusConflict, "MARKET.OWN_POSITION", "cannot buy a position of your own organization", nil)
+	case services.ErrListingExceedsPrincipal:
+		RespondError(c, http.StatusUnprocessableEntity, "MARKET.EXCEEDS_PRINCIPAL", "principal exceeds what is left of the listing", nil)
+	case services.ErrListingInvalid, pagination.ErrInvalidCursor:
+		RespondError(c, http.StatusBadRequest, "MARKET.VALIDATION_FAILED", err.Error(), nil)
+	default:
+		RespondError(c, http.StatusInternalServerError, "MARKET.REQUEST_FAILED", "market request failed", nil)
+	}
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"net/http"
+
+	"invoiceflow/internal/middleware"
+	"invoiceflow/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAHandler struct {
+	service     *services.MFAService
+	authService *services.AuthService
+}
+
+func NewMFAHandler(service *services.MFAService, authService *services.AuthService) *MFAHandler {
+	return &MFAHandler{service: service, authService: authService}
+}
+
+func (h *MFAHandler) Status(c *gin.Context) {
+	user, err := h.authService.GetUser(c.Request.Context(), c.GetString(middleware.ContextUserID))
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "MFA.STATUS_FAILED", "could not load mfa status", nil)
+		return
+	}
+
+	status, err := h.service.Status(c.Request.Context(), user)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "MFA.STATUS_FAILED", "could not load mfa status", nil)
+		return
+	}
+
+	RespondData(c, http.StatusOK, status, nil)
+}
+
+func (h *MFAHandler) Enroll(c *gin.Context) {
+	user, err := h.authService.GetUser(c.Request.Context(), c.GetString(middleware.ContextUserID))
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "MFA.ENROLL_FAILED", "could not start enrollment", nil)
+		return
+	}
+
+	enrollment, err := h.service.Enroll(c.Request.Context(), user)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusCreated, enrollment, nil)
+}
+
+type mfaCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+func (h *MFAHandler) Activate(c *gin.Context) {
+	var req mfaCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, "MFA.VALIDATION_FAILED", "invalid request", nil)
+		return
+	}
+
+	userID := c.GetString(middleware.ContextUserID)
+	sessionID := c.GetString(middleware.ContextSessionID)
+	codes, err := h.service.Activate(c.Request.Context(), userID, sessionID, req.Code)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, gin.H{"recovery_codes": codes}, nil)
+}
+
+func (h *MFAHandler) Disable(c *gin.Context) {
+	var req mfaCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, "MFA.VALIDATION_FAILED", "invalid request", nil)
+		return
+	}
+
+	user, err := h.authService.GetUser(c.Request.Context(), c.GetString(middleware.ContextUserID))
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "MFA.DISABLE_FAILED", "could not disable mfa", nil)
+		return
+	}
+
+	if err := h.service.Disable(c.Request.Context(), user, req.Code); err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req mfaCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, "MFA.VALIDATION_FAILED", "invalid request", nil)
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(c.Request.Context(), c.GetString(middleware.ContextUserID), req.Code)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, gin.H{"recovery_codes": codes}, nil)
+}
+
+func respondMFAError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrMFANotEnrolled:
+		RespondError(c, http.StatusConflict, "MFA.NOT_ENROLLED", "mfa is not enabled", nil)
+	case services.ErrMFAAlreadyEnabled:
+		RespondError(c, http.StatusConflict, "MFA.ALREADY_ENABLED", "mfa is already enabled", nil)
+	case services.ErrMFAInvalidCode:
+		RespondError(c, http.StatusUnprocessableEntity, "MFA.INVALID_CODE", "invalid mfa code", nil)
+	case services.ErrMFARequiredForRole:
+		RespondError(c, http.StatusForbidden, "MFA.REQUIRED", "mfa cannot be disabled for this role", nil)
+	default:
+		RespondError(c, http.StatusInternalServerError, "MFA.FAILED", "mfa operation failed", nil)
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"net/http"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/middleware"
+	"invoiceflow/internal/pagination"
+	"invoiceflow/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	service *services.NotificationService
+}
+
+func NewNotificationHandler(service *services.NotificationService) *NotificationHandler {
+	return &NotificationHandler{service: service}
+}
+
+func (h *NotificationHandler) List(c *gin.Context) {
+	userID := c.GetString(middleware.ContextUserID)
+	page, err := parsePage(c)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, "NOTIFICATION.VALIDATION_FAILED", err.Error(), nil)
+		return
+	}
+
+	notifications, meta, err := h.service.List(c.Request.Context(), userID, c.Query("unread") == "true", page)
+	if err == pagination.ErrInvalidCursor {
+		RespondError(c, http.StatusBadRequest, "NOTIFICATION.VALIDATION_FAILED", err.Error(), nil)
+		return
+	}
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "NOTIFICATION.LIST_FAILED", "could not list notifications", nil)
+		return
+	}
+
+	unread, err := h.service.UnreadCount(c.Request.Context(), userID)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "NOTIFICATION.LIST_FAILED", "could not list notifications", nil)
+		return
+	}
+
+	listMeta := pageMeta(page, meta)
+	listMeta["unread"] = unread
+	RespondData(c, http.StatusOK, notifications, listMeta)
+}
+
+func (h *NotificationHandler) UnreadCount(c *gin.Context) {
+	unread, err := h.service.UnreadCount(c.Request.Context(), c.GetString(middleware.ContextUserID))
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "NOTIFICATION.COUNT_FAILED", "could not count notifications", nil)
+		return
+	}
+
+	RespondData(c, http.StatusOK, gin.H{"unread": unread}, nil)
+}
+
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	err := h.service.MarkRead(c.Request.Context(), c.GetString(middleware.ContextUserID), c.Param("id"))
+	if err != nil {
+		switch err {
+		case services.ErrNotificationNotFound:
+			RespondError(c, http.StatusNotFound, "NOTIFICATION.NOT_FOUND", "notification not found", nil)
+		default:
+			RespondError(c, http.StatusInternalServerError, "NOTIFICATION.UPDATE_FAILED", "could not mark notification read", nil)
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	updated, err := h.service.MarkAllRead(c.Request.Context(), c.GetString(middleware.ContextUserID))
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "NOTIFICATION.UPDATE_FAILED", "could not mark notifications read", nil)
+		return
+	}
+
+	RespondData(c, http.StatusOK, gin.H{"updated": updated}, nil)
+}
+
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	prefs, err := h.service.Preferences(c.Request.Context(), c.GetString(middleware.ContextUserID))
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "NOTIFICATION.PREFERENCES_FAILED", "could not load preferences", nil)
+		return
+	}
+
+	RespondData(c, http.StatusOK, prefs, nil)
+}
+
+type updatePreferencesRequest struct {
+	Preferences []struct {
+		EventType string `json:"event_type" binding:"required"`
+		InApp     bool   `json:"in_app"`
+		Email     bool   `json:"email"`
+	} `json:"preferences" binding:"required,dive"`
+}
+
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	var req updatePreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, "NOTIFICATION.VALIDATION_FAILED", "invalid request", nil)
+		return
+	}
+
+	prefs := make([]domain.NotificationPreference, 0, len(req.Preferences))
+	for _, pref := range req.Preferences {
+		prefs = append(prefs, domain.NotificationPreference{EventType: pref.EventType, InApp: pref.InApp, Email: pref.Email})
+	}
+
+	updated, err := h.service.UpdatePreferences(c.Request.Context(), c.GetString(middleware.ContextUserID), prefs)
+	if err != nil {
+		switch err {
+		case services.ErrNotificationInvalidEvent:
+			RespondError(c, http.StatusBadRequest, "NOTIFICATION.INVALID_EVENT", "unknown event type", gin.H{"allowed": domain.NotificationEventTypes})
+		default:
+			RespondError(c, http.StatusInternalServerError, "NOTIFICATION.PREFERENCES_FAILED", "could not save preferences", nil)
+		}
+		return
+	}
+
+	RespondData(c, http.StatusOK, updated, nil)
+}
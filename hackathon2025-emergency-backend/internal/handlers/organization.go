@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"net/http"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/middleware"
+	"invoiceflow/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HeaderOrganizationID selects which organization a request acts for. When it
+// is absent the caller's personal organization is used.
+const HeaderOrganizationID = middleware.HeaderOrganizationID
+
+type OrganizationHandler struct {
+	service     *services.OrganizationService
+	authService *services.AuthService
+}
+
+func NewOrganizationHandler(service *services.OrganizationService, authService *services.AuthService) *OrganizationHandler {
+	return &OrganizationHandler{service: service, authService: authService}
+}
+
+func (h *OrganizationHandler) List(c *gin.Context) {
+	memberships, err := h.service.ListForUser(c.Request.Context(), c.GetString(middleware.ContextUserID))
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "ORG.LIST_FAILED", "could not list organizations", nil)
+		return
+	}
+
+	RespondData(c, http.StatusOK, memberships, nil)
+}
+
+type createOrganizationRequest struct {
+	Name string `json:"name" binding:"required,max=200"`
+}
+
+func (h *OrganizationHandler) Create(c *gin.Context) {
+	var req createOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, "ORG.VALIDATION_FAILED", "invalid request", nil)
+		return
+	}
+
+	user, err := h.authService.GetUser(c.Request.Context(), c.GetString(middleware.ContextUserID))
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "ORG.CREATE_FAILED", "could not create organization", nil)
+		return
+	}
+
+	membership, err := h.service.Create(c.Request.Context(), user, req.Name)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "ORG.CREATE_FAILED", "could not create organization", nil)
+		return
+	}
+
+	RespondData(c, http.StatusCreated, membership, nil)
+}
+
+func (h *OrganizationHandler) ListMembers(c *gin.Context) {
+	members, err := h.service.ListMembers(c.Request.Context(), c.GetString(middleware.ContextUserID), c.Param("id"))
+	if err != nil {
+		respondOrgError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, members, nil)
+}
+
+type updateMemberRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+func (h *OrganizationHandler) UpdateMember(c *gin.Context) {
+	var req updateMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, "ORG.VALIDATION_FAILED", "invalid request", nil)
+		return
+	}
+
+	err := h.service.UpdateMemberRole(c.Request.Context(), c.GetString(middleware.ContextUserID), c.Param("id"), c.Param("user_id"), req.Role)
+	if err != nil {
+		respondOrgError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
+	err := h.service.RemoveMember(c.Request.Context(), c.GetString(middleware.ContextUserID), c.Param("id"), c.Param("user_id"))
+	if err != nil {
+		respondOrgError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type inviteMemberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required"`
+}
+
+func (h *OrganizationHandler) Invite(c *gin.Context) {
+	var req inviteMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, "ORG.VALIDATION_FAILED", "invalid request", nil)
+		return
+	}
+
+	invitation, err := h.service.Invite(c.Request.Context(), c.GetString(middleware.ContextUserID), c.Param("id"), req.Email, req.Role)
+	if err != nil {
+		respondOrgError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusCreated, invitation, nil)
+}
+
+func (h *OrganizationHandler) ListInvitations(c *gin.Context) {
+	invitations, err := h.service.ListInvitations(c.Request.Context(), c.GetString(middleware.ContextUserID), c.Param("id"))
+	if err != nil {
+		respondOrgError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, invitations, nil)
+}
+
+func (h *OrganizationHandler) RevokeInvitation(c *gin.Context) {
+	err := h.service.RevokeInvitation(c.Request.Context(), c.GetString(middleware.ContextUserID), c.Param("id"), c.Param("invitation_id"))
+	if err != nil {
+		respondOrgError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type acceptInvitationRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+func (h *OrganizationHandler) AcceptInvitation(c *gin.Context) {
+	var req acceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, "ORG.VALIDATION_FAILED", "invalid request", nil)
+		return
+	}
+
+	user, err := h.authService.GetUser(c.Request.Context(), c.GetString(middleware.ContextUserID))
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "ORG.INVITATION_FAILED", "could not accept invitation", nil)
+		return
+	}
+
+	membership, err := h.service.AcceptInvitation(c.Request.Context(), user, req.Token)
+	if err != nil {
+		respondOrgError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, membership, nil)
+}
+
+func respondOrgError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrOrgNotFound:
+		RespondError(c, http.StatusNotFound, "ORG.NOT_FOUND", "organization not found", nil)
+	case services.ErrOrgAccessDenied:
+		RespondError(c, http.StatusForbidden, "ORG.FORBIDDEN", "organization role insufficient", nil)
+	case services.ErrOrgInvalidRole:
+		RespondError(c, http.StatusBadRequest, "ORG.INVALID_ROLE", "role must be owner, editor or viewer", nil)
+	case services.ErrOrgMemberNotFound:
+		RespondError(c, http.StatusNotFound, "ORG.MEMBER_NOT_FOUND", "member not found", nil)
+	case services.ErrOrgLastOwner:
+		RespondError(c, http.StatusConflict, "ORG.LAST_OWNER", "organization needs at least one owner", nil)
+	case services.ErrInvitationInvalid:
+		RespondError(c, http.StatusBadRequest, "ORG.INVITATION_INVALID", "invitation invalid or expired", nil)
+	case services.ErrInvitationEmailMismatch:
+		RespondError(c, http.StatusForbidden, "ORG.INVITATION_EMAIL_MISMATCH", "invitation was sent to a different email", nil)
+	case services.ErrOrgAlreadyMember:
+		RespondError(c, http.StatusConflict, "ORG.ALREADY_MEMBER", "already a member of the organization", nil)
+	case services.ErrOrgWrongKind:
+		RespondError(c, http.StatusForbidden, "ORG.WRONG_KIND", "organization kind cannot take this action", nil)
+	default:
+		RespondError(c, http.StatusInternalServerError, "ORG.REQUEST_FAILED", "organization request failed", nil)
+	}
+}
+
+// actingOrganization resolves the organization named by X-Organization-ID (or
+// the personal one) and checks the caller holds at least minRole in it. It
+// writes the error response itself and returns false on failure.
+func actingOrganization(c *gin.Context, orgs *services.OrganizationService, minRole string) (string, bool) {
+	membership, err := orgs.Authorize(c.Request.Context(), c.GetString(middleware.ContextUserID), c.GetHeader(HeaderOrganizationID), minRole)
+	if err != nil {
+		respondOrgError(c, err)
+		return "", false
+	}
+
+	return membership.ID, true
+}
+
+// organizationOfKind checks the caller holds at least minRole in
+// organizationID (the personal organization when empty) and that it is an
+// organization of kind. It writes the error response itself and returns
+// false on failure.
+func organizationOfKind(c *gin.Context, orgs *services.OrganizationService, organizationID string, minRole string, kind string) (string, bool) {
+	membership, err := orgs.AuthorizeKind(c.Request.Context(), c.GetString(middleware.ContextUserID), organizationID, minRole, kind)
+	if err != nil {
+		respondOrgError(c, err)
+		return "", false
+	}
+
+	return membership.ID, true
+}
+
+// visibleOrganizations returns the organizations whose records a list
+// endpoint should include: the one named by X-Organization-ID, or every
+// organization the caller belongs to.
+func visibleOrganizations(c *gin.Context, orgs *services.OrganizationService) ([]string, bool) {
+	if c.GetHeader(HeaderOrganizationID) != "" {
+		id, ok := actingOrganization(c, orgs, domain.OrgRoleViewer)
+		if !ok {
+			return nil, false
+		}
+		return []string{id}, true
+	}
+
+	ids, err := orgs.MemberOrganizationIDs(c.Request.Context(), c.GetString(middleware.ContextUserID))
+	if err != nil {
+		respondOrgError(c, err)
+		return nil, false
+	}
+
+	return ids, true
+}
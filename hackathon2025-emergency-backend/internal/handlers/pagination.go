@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+
+	"invoiceflow/internal/pagination"
+
+	"github.com/gin-gonic/gin"
+)
+
+var errInvalidPageSize = errors.New("page_size must be between 1 and " + strconv.Itoa(pagination.MaxLimit))
+
+// parsePage reads the page_size, cursor and include_total query parameters
+// shared by the list endpoints.
+func parsePage(c *gin.Context) (pagination.Page, error) {
+	page := pagination.Page{Limit: pagination.DefaultLimit}
+
+	if value := c.Query("page_size"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 1 || size > pagination.MaxLimit {
+			return page, errInvalidPageSize
+		}
+		page.Limit = size
+	}
+
+	if value := c.Query("cursor"); value != "" {
+		cursor, err := pagination.Decode(value)
+		if err != nil {
+			return page, err
+		}
+		page.After = cursor
+	}
+
+	page.IncludeTotal = c.Query("include_total") == "true"
+
+	return page, nil
+}
+
+// pageMeta is the meta of a list response. next_cursor is null on the last
+// page and total is only present when include_total was asked for.
+func pageMeta(page pagination.Page, meta pagination.Meta) gin.H {
+	result := gin.H{
+		"page_size":   page.Limit,
+		"next_cursor": nil,
+	}
+	if meta.NextCursor != "" {
+		result["next_cursor"] = meta.NextCursor
+	}
+	if meta.Total != nil {
+		result["total"] = *meta.Total
+	}
+	return result
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+
+	"invoiceflow/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PortfolioHandler struct {
+	service *services.PortfolioService
+	orgs    *services.OrganizationService
+}
+
+func NewPortfolioHandler(service *services.PortfolioService, orgs *services.OrganizationService) *PortfolioHandler {
+	return &PortfolioHandler{service: service, orgs: orgs}
+}
+
+// Get is the portfolio of the caller's organizations, or of the one in
+// X-Organization-ID.
+func (h *PortfolioHandler) Get(c *gin.Context) {
+	organizationIDs, ok := visibleOrganizations(c, h.orgs)
+	if !ok {
+		return
+	}
+
+	portfolio, err := h.service.Get(c.Request.Context(), organizationIDs)
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "PORTFOLIO.REQUEST_FAILED", "portfolio request failed", nil)
+		return
+	}
+
+	RespondData(c, http.StatusOK, portfolio, nil)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+
+	"invoiceflow/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoleHandler struct {
+	service *services.RoleService
+}
+
+func NewRoleHandler(service *services.RoleService) *RoleHandler {
+	return &RoleHandler{service: service}
+}
+
+func (h *RoleHandler) List(c *gin.Context) {
+	roles, err := h.service.List(c.Request.Context())
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "ROLE.LIST_FAILED", "could not list roles", nil)
+		return
+	}
+
+	RespondData(c, http.StatusOK, roles, nil)
+}
+
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	permissions, err := h.service.ListPermissions(c.Request.Context())
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "ROLE.LIST_FAILED", "could not list permissions", nil)
+		return
+	}
+
+	RespondData(c, http.StatusOK, permissions, nil)
+}
+
+type putRoleRequest struct {
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions" binding:"required"`
+}
+
+func (h *RoleHandler) Put(c *gin.Context) {
+	var req putRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, "ROLE.VALIDATION_FAILED", "invalid request", nil)
+		return
+	}
+
+	role, err := h.service.Put(c.Request.Context(), c.Param("name"), req.Description, req.Permissions)
+	if err != nil {
+		respondRoleError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, role, nil)
+}
+
+func (h *RoleHandler) Delete(c *gin.Context) {
+	if err := h.service.Delete(c.Request.Context(), c.Param("name")); err != nil {
+		respondRoleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func respondRoleError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrRoleNotFound:
+		RespondError(c, http.StatusNotFound, "ROLE.NOT_FOUND", "role not found", nil)
+	case services.ErrRoleInvalidName:
+		RespondError(c, http.StatusBadRequest, "ROLE.INVALID_NAME", "role names are 2-32 lowercase letters, digits or underscores", nil)
+	case services.ErrRoleProtected:
+		RespondError(c, http.StatusConflict, "ROLE.PROTECTED", "role cannot be changed", nil)
+	case services.ErrRoleInUse:
+		RespondError(c, http.StatusConflict, "ROLE.IN_USE", "role is still assigned to users", nil)
+	case services.ErrUnknownPermission:
+		RespondError(c, http.StatusBadRequest, "ROLE.UNKNOWN_PERMISSION", "unknown permission", nil)
+	default:
+		RespondError(c, http.StatusInternalServerError, "ROLE.FAILED", "could not update role", nil)
+	}
+}
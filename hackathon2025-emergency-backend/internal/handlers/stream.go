@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"invoiceflow/internal/middleware"
+	"invoiceflow/internal/services"
+	"invoiceflow/internal/stream"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	streamHeartbeat       = 25 * time.Second
+	streamMembershipCheck = time.Minute
+)
+
+// streamMembers is the part of OrganizationService the stream needs.
+type streamMembers interface {
+	MemberOrganizationIDs(ctx context.Context, userID string) ([]string, error)
+}
+
+type StreamHandler struct {
+	hub      *stream.Hub
+	orgs     streamMembers
+	sessions middleware.SessionChecker
+	recheck  time.Duration
+}
+
+func NewStreamHandler(hub *stream.Hub, orgs *services.OrganizationService, sessions middleware.SessionChecker) *StreamHandler {
+	return &StreamHandler{hub: hub, orgs: orgs, sessions: sessions, recheck: streamMembershipCheck}
+}
+
+// Stream pushes the invoice updates the caller may see as Server-Sent Events
+// until the client disconnects or the access token expires, at which point a
+// token_expired event tells the client to reconnect with a fresh token. The
+// session is checked again with the memberships, and a session_revoked event
+// ends the stream once it was signed out or revoked.
+func (h *StreamHandler) Stream(c *gin.Context) {
+	ctx := c.Request.Context()
+	userID := c.GetString(middleware.ContextUserID)
+	sessionID := c.GetString(middleware.ContextSessionID)
+	tokenVersion := c.GetInt(middleware.ContextTokenVer)
+	viewer := &streamViewer{access: invoiceAccessFor(c)}
+
+	if err := viewer.loadOrganizations(c, h.orgs, userID); err != nil {
+		RespondError(c, http.StatusInternalServerError, "STREAM.UNAVAILABLE", "could not open stream", nil)
+		return
+	}
+
+	expiresIn := time.Hour
+	if exp := c.GetTime(middleware.ContextTokenExpAt); !exp.IsZero() {
+		expiresIn = time.Until(exp)
+	}
+
+	sub := h.hub.Subscribe()
+	defer h.hub.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	fmt.Fprint(c.Writer, "retry: 5000\n\n")
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	membership := time.NewTicker(h.recheck)
+	defer membership.Stop()
+	expired := time.NewTimer(expiresIn)
+	defer expired.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case envelope, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if !viewer.canSee(envelope) {
+				continue
+			}
+			data, err := json.Marshal(envelope.Update)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", envelope.Update.EventID, envelope.Update.Type, data)
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		case <-membership.C:
+			if _, err := h.sessions.CheckSession(ctx, sessionID, userID, tokenVersion); err != nil {
+				fmt.Fprint(c.Writer, "event: session_revoked\ndata: {}\n\n")
+				c.Writer.Flush()
+				return
+			}
+			if err := viewer.loadOrganizations(c, h.orgs, userID); err != nil {
+				return
+			}
+		case <-expired.C:
+			fmt.Fprint(c.Writer, "event: token_expired\ndata: {}\n\n")
+			c.Writer.Flush()
+			return
+		}
+	}
+}
+
+// streamViewer applies the invoice visibility rules of the REST endpoints to
+// stream updates, and lets callers who read their organizations' invoices
+// also follow the invoices those organizations funded. Anything else is
+// hidden.
+type streamViewer struct {
+	access        invoiceAccess
+	organizations map[string]bool
+}
+
+func (v *streamViewer) loadOrganizations(c *gin.Context, orgs streamMembers, userID string) error {
+	ids, err := orgs.MemberOrganizationIDs(c.Request.Context(), userID)
+	if err != nil {
+		return err
+	}
+
+	v.organizations = make(map[string]bool, len(ids))
+	for _, id := range ids {
+		v.organizations[id] = true
+	}
+	return nil
+}
+
+func (v *streamViewer) member(organizationID string) bool {
+	return v.organizations[organizationID]
+}
+
+func (v *streamViewer) canSee(envelope *stream.Envelope) bool {
+	if v.access.allows(envelope.OrganizationID, envelope.Update.Status, v.member) {
+		return true
+	}
+	if !v.access.own {
+		return false
+	}
+	for _, id := range envelope.Audience {
+		if v.member(id) {
+			return true
+		}
+	}
+	return false
+}
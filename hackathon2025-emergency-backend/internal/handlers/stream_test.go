@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/middleware"
+	"invoiceflow/internal/stream"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestStreamViewerDeniesByDefault(t *testing.T) {
+	envelope := func(organizationID string, status string, audience ...string) *stream.Envelope {
+		return &stream.Envelope{
+			Update:         stream.Update{Status: status},
+			OrganizationID: organizationID,
+			Audience:       audience,
+		}
+	}
+
+	cases := []struct {
+		name        string
+		permissions []string
+		envelope    *stream.Envelope
+		want        bool
+	}{
+		{"no read permission", []string{domain.PermAuditRead}, envelope("org-2", domain.InvoiceStatusApproved), false},
+		{"other organization's draft", []string{domain.PermInvoiceRead}, envelope("org-2", domain.InvoiceStatusSubmitted), false},
+		{"own draft", []string{domain.PermInvoiceRead}, envelope("org-1", domain.InvoiceStatusSubmitted), true},
+		{"funded by own organization", []string{domain.PermInvoiceRead}, envelope("org-2", domain.InvoiceStatusPaid, "org-2", "org-1"), true},
+		{"audience without read permission", []string{domain.PermInvoiceReadListed}, envelope("org-2", domain.InvoiceStatusSubmitted, "org-1"), false},
+		{"published listing", []string{domain.PermInvoiceReadListed}, envelope("org-2", domain.InvoiceStatusApproved), true},
+		{"unpublished listing", []string{domain.PermInvoiceReadListed}, envelope("org-2", domain.InvoiceStatusSubmitted), false},
+		{"read_all", []string{domain.PermInvoiceReadAll}, envelope("org-2", domain.InvoiceStatusDraft), true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			viewer := &streamViewer{
+				access:        invoiceAccessFor(contextWithPermissions(tc.permissions...)),
+				organizations: map[string]bool{"org-1": true},
+			}
+			if got := viewer.canSee(tc.envelope); got != tc.want {
+				t.Fatalf("canSee = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+type streamMembersFunc func(ctx context.Context, userID string) ([]string, error)
+
+func (f streamMembersFunc) MemberOrganizationIDs(ctx context.Context, userID string) ([]string, error) {
+	return f(ctx, userID)
+}
+
+// revocableSession answers CheckSession like AuthService until revoke is
+// called, and records the arguments it was asked about.
+type revocableSession struct {
+	mu      sync.Mutex
+	revoked bool
+	checked []string
+}
+
+func (s *revocableSession) CheckSession(ctx context.Context, sessionID string, userID string, tokenVersion int) (*domain.SessionState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checked = append(s.checked, sessionID+"/"+userID)
+	if s.revoked || tokenVersion != 3 {
+		return nil, errors.New("session revoked")
+	}
+	return &domain.SessionState{}, nil
+}
+
+func (s *revocableSession) revoke() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked = true
+}
+
+func TestStreamEndsWhenSessionIsRevoked(t *testing.T) {
+	sessions := &revocableSession{}
+	handler := &StreamHandler{
+		hub:      stream.NewHub(),
+		orgs:     streamMembersFunc(func(context.Context, string) ([]string, error) { return []string{"org-1"}, nil }),
+		sessions: sessions,
+		recheck:  10 * time.Millisecond,
+	}
+
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c.Request = httptest.NewRequest("GET", "/stream", nil).WithContext(ctx)
+	c.Set(middleware.ContextUserID, "user-1")
+	c.Set(middleware.ContextSessionID, "session-1")
+	c.Set(middleware.ContextTokenVer, 3)
+	c.Set(middleware.ContextTokenExpAt, time.Now().Add(time.Hour))
+	c.Set(middleware.ContextUserPerms, []string{domain.PermInvoiceRead})
+
+	time.AfterFunc(50*time.Millisecond, sessions.revoke)
+
+	done := make(chan struct{})
+	go func() {
+		handler.Stream(c)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		t.Fatal("stream kept running after the session was revoked")
+	}
+
+	if !strings.Contains(recorder.Body.String(), "event: session_revoked") {
+		t.Fatalf("body = %q, want a session_revoked event", recorder.Body.String())
+	}
+	sessions.mu.Lock()
+	defer sessions.mu.Unlock()
+	if len(sessions.checked) < 2 || sessions.checked[0] != "session-1/user-1" {
+		t.Fatalf("checked = %v, want session-1/user-1 checked on every tick", sessions.checked)
+	}
+}
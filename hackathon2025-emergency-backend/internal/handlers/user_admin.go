@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"net/http"
+
+	"invoiceflow/internal/middleware"
+	"invoiceflow/internal/pagination"
+	"invoiceflow/internal/repositories"
+	"invoiceflow/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserAdminHandler struct {
+	service *services.UserAdminService
+}
+
+func NewUserAdminHandler(service *services.UserAdminService) *UserAdminHandler {
+	return &UserAdminHandler{service: service}
+}
+
+func (h *UserAdminHandler) List(c *gin.Context) {
+	page, err := parsePage(c)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, "USER.VALIDATION_FAILED", err.Error(), nil)
+		return
+	}
+
+	filters := repositories.UserFilters{
+		Query:  c.Query("q"),
+		Role:   c.Query("role"),
+		Status: c.Query("status"),
+		Page:   page,
+	}
+
+	users, meta, err := h.service.List(c.Request.Context(), filters)
+	if err == pagination.ErrInvalidCursor {
+		RespondError(c, http.StatusBadRequest, "USER.VALIDATION_FAILED", err.Error(), nil)
+		return
+	}
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "USER.LIST_FAILED", "could not list users", nil)
+		return
+	}
+
+	RespondData(c, http.StatusOK, users, pageMeta(page, meta))
+}
+
+func (h *UserAdminHandler) Get(c *gin.Context) {
+	detail, err := h.service.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondUserAdminError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, detail, nil)
+}
+
+type createAdminRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=12"`
+}
+
+func (h *UserAdminHandler) CreateAdmin(c *gin.Context) {
+	var req createAdminRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, "USER.VALIDATION_FAILED", "invalid request", nil)
+		return
+	}
+
+	actorID := c.GetString(middleware.ContextUserID)
+	user, err := h.service.CreateAdmin(c.Request.Context(), actorID, req.Name, req.Email, req.Password)
+	if err != nil {
+		respondUserAdminError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusCreated, user, nil)
+}
+
+type userStatusRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+func (h *UserAdminHandler) Suspend(c *gin.Context) {
+	var req userStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, "USER.VALIDATION_FAILED", "invalid request", nil)
+		return
+	}
+
+	actorID := c.GetString(middleware.ContextUserID)
+	user, err := h.service.Suspend(c.Request.Context(), actorID, c.Param("id"), req.Reason)
+	if err != nil {
+		respondUserAdminError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, user, nil)
+}
+
+func (h *UserAdminHandler) Reactivate(c *gin.Context) {
+	var req userStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, "USER.VALIDATION_FAILED", "invalid request", nil)
+		return
+	}
+
+	actorID := c.GetString(middleware.ContextUserID)
+	user, err := h.service.Reactivate(c.Request.Context(), actorID, c.Param("id"), req.Reason)
+	if err != nil {
+		respondUserAdminError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, user, nil)
+}
+
+func (h *UserAdminHandler) Unlock(c *gin.Context) {
+	var req userStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, "USER.VALIDATION_FAILED", "invalid request", nil)
+		return
+	}
+
+	actorID := c.GetString(middleware.ContextUserID)
+	user, err := h.service.Unlock(c.Request.Context(), actorID, c.Param("id"), req.Reason)
+	if err != nil {
+		respondUserAdminError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, user, nil)
+}
+
+func (h *UserAdminHandler) AuthEvents(c *gin.Context) {
+	page, err := parsePage(c)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, "AUTH_EVENT.VALIDATION_FAILED", err.Error(), nil)
+		return
+	}
+
+	filters := repositories.AuthEventFilters{
+		UserID: c.Query("user_id"),
+		Email:  c.Query("email"),
+		IP:     c.Query("ip"),
+		Event:  c.Query("event"),
+		Page:   page,
+	}
+
+	events, meta, err := h.service.ListAuthEvents(c.Request.Context(), filters)
+	if err == pagination.ErrInvalidCursor {
+		RespondError(c, http.StatusBadRequest, "AUTH_EVENT.VALIDATION_FAILED", err.Error(), nil)
+		return
+	}
+	if err != nil {
+		RespondError(c, http.StatusInternalServerError, "AUTH_EVENT.LIST_FAILED", "could not list auth events", nil)
+		return
+	}
+
+	RespondData(c, http.StatusOK, events, pageMeta(page, meta))
+}
+
+type changeRoleRequest struct {
+	Role   string `json:"role" binding:"required"`
+	Reason string `json:"reason" binding:"required"`
+}
+
+func (h *UserAdminHandler) ChangeRole(c *gin.Context) {
+	var req changeRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, "USER.VALIDATION_FAILED", "invalid request", nil)
+		return
+	}
+
+	actorID := c.GetString(middleware.ContextUserID)
+	user, err := h.service.ChangeRole(c.Request.Context(), actorID, c.Param("id"), req.Role, req.Reason)
+	if err != nil {
+		respondUserAdminError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, user, nil)
+}
+
+func respondUserAdminError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrUserNotFound:
+		RespondError(c, http.StatusNotFound, "USER.NOT_FOUND", "user not found", nil)
+	case services.ErrUserInvalidStatus:
+		RespondError(c, http.StatusConflict, "USER.INVALID_STATUS", "user status invalid for this action", nil)
+	case services.ErrUserSelfAction:
+		RespondError(c, http.StatusUnprocessableEntity, "USER.SELF_ACTION", "cannot apply this action to your own account", nil)
+	case services.ErrInvalidRole:
+		RespondError(c, http.StatusBadRequest, "AUTH.INVALID_ROLE", "unknown role", nil)
+	case services.ErrEmailExists:
+		RespondError(c, http.StatusConflict, "AUTH.EMAIL_EXISTS", "email already exists", nil)
+	default:
+		RespondError(c, http.StatusInternalServerError, "USER.ACTION_FAILED", "could not update user", nil)
+	}
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"net/http"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/middleware"
+	"invoiceflow/internal/pagination"
+	"invoiceflow/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler serves both organization webhooks under /orgs/:id/webhooks
+// (org owners only) and platform webhooks under /admin/webhooks.
+type WebhookHandler struct {
+	service *services.WebhookService
+	orgs    *services.OrganizationService
+}
+
+func NewWebhookHandler(service *services.WebhookService, orgs *services.OrganizationService) *WebhookHandler {
+	return &WebhookHandler{service: service, orgs: orgs}
+}
+
+type webhookRequest struct {
+	URL         *string  `json:"url"`
+	Description *string  `json:"description"`
+	EventTypes  []string `json:"event_types"`
+	Active      *bool    `json:"active"`
+}
+
+func (r webhookRequest) input() services.WebhookSubscriptionInput {
+	return services.WebhookSubscriptionInput{
+		URL:         r.URL,
+		Description: r.Description,
+		EventTypes:  r.EventTypes,
+		Active:      r.Active,
+	}
+}
+
+func (h *WebhookHandler) List(c *gin.Context) {
+	scope, ok := h.scope(c)
+	if !ok {
+		return
+	}
+
+	subs, err := h.service.ListSubscriptions(c.Request.Context(), scope)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, subs, nil)
+}
+
+func (h *WebhookHandler) Create(c *gin.Context) {
+	scope, ok := h.scope(c)
+	if !ok {
+		return
+	}
+
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.URL == nil || len(req.EventTypes) == 0 {
+		RespondError(c, http.StatusBadRequest, "WEBHOOK.VALIDATION_FAILED", "url and event_types are required", nil)
+		return
+	}
+
+	created, err := h.service.CreateSubscription(c.Request.Context(), scope, c.GetString(middleware.ContextUserID), req.input())
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusCreated, created, nil)
+}
+
+func (h *WebhookHandler) Get(c *gin.Context) {
+	scope, ok := h.scope(c)
+	if !ok {
+		return
+	}
+
+	sub, err := h.service.GetSubscription(c.Request.Context(), scope, c.Param("webhook_id"))
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, sub, nil)
+}
+
+func (h *WebhookHandler) Update(c *gin.Context) {
+	scope, ok := h.scope(c)
+	if !ok {
+		return
+	}
+
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		RespondError(c, http.StatusBadRequest, "WEBHOOK.VALIDATION_FAILED", "invalid request", nil)
+		return
+	}
+
+	sub, err := h.service.UpdateSubscription(c.Request.Context(), scope, c.Param("webhook_id"), req.input())
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, sub, nil)
+}
+
+func (h *WebhookHandler) RotateSecret(c *gin.Context) {
+	scope, ok := h.scope(c)
+	if !ok {
+		return
+	}
+
+	sub, err := h.service.RotateSecret(c.Request.Context(), scope, c.Param("webhook_id"))
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, sub, nil)
+}
+
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	scope, ok := h.scope(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteSubscription(c.Request.Context(), scope, c.Param("webhook_id")); err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	scope, ok := h.scope(c)
+	if !ok {
+		return
+	}
+
+	page, err := parsePage(c)
+	if err != nil {
+		RespondError(c, http.StatusBadRequest, "WEBHOOK.VALIDATION_FAILED", err.Error(), nil)
+		return
+	}
+
+	deliveries, meta, err := h.service.ListDeliveries(c.Request.Context(), scope, c.Param("webhook_id"), page)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusOK, deliveries, pageMeta(page, meta))
+}
+
+func (h *WebhookHandler) Replay(c *gin.Context) {
+	scope, ok := h.scope(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.service.Replay(c.Request.Context(), scope, c.Param("webhook_id"), c.Param("delivery_id"))
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+
+	RespondData(c, http.StatusAccepted, delivery, nil)
+}
+
+// scope returns the organization from the :id path parameter after checking
+// the caller owns it, or nil for the platform-wide admin routes, which are
+// guarded by the webhooks.manage permission instead.
+func (h *WebhookHandler) scope(c *gin.Context) (*string, bool) {
+	organizationID := c.Param("id")
+	if organizationID == "" {
+		return nil, true
+	}
+
+	if _, err := h.orgs.Authorize(c.Request.Context(), c.GetString(middleware.ContextUserID), organizationID, domain.OrgRoleOwner); err != nil {
+		respondOrgError(c, err)
+		return nil, false
+	}
+
+	return &organizationID, true
+}
+
+func respondWebhookError(c *gin.Context, err error) {
+	switch err {
+	case services.ErrWebhookNotFound:
+		RespondError(c, http.StatusNotFound, "WEBHOOK.NOT_FOUND", "webhook not found", nil)
+	case services.ErrWebhookDeliveryNotFound:
+		RespondError(c, http.StatusNotFound, "WEBHOOK.DELIVERY_NOT_FOUND", "delivery not found", nil)
+	case services.ErrWebhookInvalidURL:
+		RespondError(c, http.StatusBadRequest, "WEBHOOK.INVALID_URL", "url must be an absolute https URL", nil)
+	case services.ErrWebhookInvalidEvent:
+		RespondError(c, http.StatusBadRequest, "WEBHOOK.INVALID_EVENT", "unknown event type", gin.H{"allowed": domain.WebhookEventTypes})
+	case pagination.ErrInvalidCursor:
+		RespondError(c, http.StatusBadRequest, "WEBHOOK.VALIDATION_FAILED", err.Error(), nil)
+	default:
+		RespondError(c, http.StatusInternalServerError, "WEBHOOK.REQUEST_FAILED", "webhook request failed", nil)
+	}
+}
@@ -0,0 +1,365 @@
+package jwtkeys
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"invoiceflow/internal/config"
+	"invoiceflow/internal/db"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+var ErrUnknownKey = errors.New("unknown signing key")
+
+type Key struct {
+	ID        string
+	Algorithm string
+	CreatedAt time.Time
+	private   any
+	public    any
+}
+
+type KeySet struct {
+	mu        sync.RWMutex
+	alg       string
+	dir       string
+	retention time.Duration
+	signing   *Key
+	keys      map[string]*Key
+}
+
+func Load(cfg *config.Config) (*KeySet, error) {
+	ks := &KeySet{
+		alg:       cfg.JWTAlgorithm,
+		dir:       cfg.JWTKeyDir,
+		retention: time.Duration(cfg.JWTKeyRetentionHours) * time.Hour,
+		keys:      map[string]*Key{},
+	}
+
+	if ks.alg == AlgHS256 {
+		key := &Key{ID: "hs256", Algorithm: AlgHS256, private: []byte(cfg.JWTSecret), public: []byte(cfg.JWTSecret)}
+		ks.signing = key
+		ks.keys[key.ID] = key
+		return ks, nil
+	}
+
+	if err := ks.Reload(); err != nil {
+		return nil, err
+	}
+
+	if ks.SigningKeyID() == "" {
+		if _, err := ks.Rotate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return ks, nil
+}
+
+// Reload re-reads the key directory. Private keys (PKCS#8, or PKCS#1 for RSA)
+// can sign and verify; public-only PEM files are trusted for verification.
+// The newest private key matching the configured algorithm signs new tokens.
+func (ks *KeySet) Reload() error {
+	entries, err := os.ReadDir(ks.dir)
+	if err != nil {
+		return err
+	}
+
+	keys := map[string]*Key{}
+	var signing *Key
+	cutoff := time.Now().Add(-ks.retention)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".pem") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		key, err := readKeyFile(filepath.Join(ks.dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("jwt key %s: %w", entry.Name(), err)
+		}
+		key.ID = strings.TrimSuffix(strings.TrimSuffix(entry.Name(), ".pem"), ".pub")
+		key.CreatedAt = info.ModTime()
+
+		if key.private != nil && key.Algorithm == ks.alg && (signing == nil || newer(key, signing)) {
+			signing = key
+		}
+		if ks.retention > 0 && key.CreatedAt.Before(cutoff) {
+			continue
+		}
+		keys[key.ID] = key
+	}
+
+	if signing != nil {
+		keys[signing.ID] = signing
+	}
+
+	ks.mu.Lock()
+	ks.keys = keys
+	ks.signing = signing
+	ks.mu.Unlock()
+
+	return nil
+}
+
+// newer orders keys by file time, falling back to the id when two keys share
+// a timestamp: file times can be as coarse as a few milliseconds, and rotated
+// key ids sort by creation time.
+func newer(key, than *Key) bool {
+	if !key.CreatedAt.Equal(than.CreatedAt) {
+		return key.CreatedAt.After(than.CreatedAt)
+	}
+	return key.ID > than.ID
+}
+
+// Rotate generates a fresh private key of the configured algorithm, writes it
+// to the key directory and makes it the signing key. Older keys keep
+// verifying until they fall out of the retention window.
+func (ks *KeySet) Rotate() (string, error) {
+	if ks.alg == AlgHS256 {
+		return "", errors.New("HS256 keys cannot be rotated")
+	}
+
+	var private crypto.Signer
+	switch ks.alg {
+	case AlgRS256:
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return "", err
+		}
+		private = key
+	case AlgEdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return "", err
+		}
+		private = key
+	default:
+		return "", fmt.Errorf("unsupported jwt algorithm %q", ks.alg)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return "", err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	kid := fmt.Sprintf("%s-%s-%s", strings.ToLower(ks.alg), time.Now().UTC().Format("20060102T150405.000000000Z"), hex.EncodeToString(suffix))
+
+	path := filepath.Join(ks.dir, kid+".pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		return "", err
+	}
+
+	if err := ks.Reload(); err != nil {
+		return "", err
+	}
+
+	return kid, nil
+}
+
+// StartRotation reloads the key directory every minute, so instances sharing
+// it pick up keys rotated elsewhere, and rotates once the signing key is
+// older than interval. Rotation happens under lock, so only one instance
+// rotates and the others pick its key up on their next reload.
+func (ks *KeySet) StartRotation(ctx context.Context, interval time.Duration, lock db.Locker) {
+	if ks.alg == AlgHS256 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			if err := ks.Reload(); err != nil {
+				log.Printf("jwt key reload failed: %v", err)
+				continue
+			}
+
+			if interval <= 0 || !ks.rotationDue(interval) {
+				continue
+			}
+
+			if err := ks.rotateLocked(ctx, interval, lock); err != nil {
+				log.Printf("jwt key rotation failed: %v", err)
+			}
+		}
+	}()
+}
+
+func (ks *KeySet) rotationDue(interval time.Duration) bool {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	return ks.signing == nil || time.Since(ks.signing.CreatedAt) >= interval
+}
+
+// rotateLocked rotates unless another instance holds the lock or rotated
+// while this one was waiting for it.
+func (ks *KeySet) rotateLocked(ctx context.Context, interval time.Duration, lock db.Locker) error {
+	release, acquired, err := lock(ctx)
+	if err != nil || !acquired {
+		return err
+	}
+	defer release()
+
+	if err := ks.Reload(); err != nil {
+		return err
+	}
+	if !ks.rotationDue(interval) {
+		return nil
+	}
+
+	_, err = ks.Rotate()
+	return err
+}
+
+func (ks *KeySet) SigningKeyID() string {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	if ks.signing == nil {
+		return ""
+	}
+	return ks.signing.ID
+}
+
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	ks.mu.RLock()
+	key := ks.signing
+	ks.mu.RUnlock()
+
+	if key == nil {
+		return "", ErrUnknownKey
+	}
+
+	token := jwt.NewWithClaims(signingMethod(key.Algorithm), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.private)
+}
+
+func (ks *KeySet) Parse(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, claims, ks.keyfunc, jwt.WithValidMethods(ks.validMethods()))
+}
+
+func (ks *KeySet) keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	ks.mu.RLock()
+	key, ok := ks.keys[kid]
+	ks.mu.RUnlock()
+
+	if !ok && kid == "" && ks.alg == AlgHS256 {
+		key, ok = ks.signing, ks.signing != nil
+	}
+	if !ok || token.Method.Alg() != key.Algorithm {
+		return nil, ErrUnknownKey
+	}
+
+	return key.public, nil
+}
+
+func (ks *KeySet) validMethods() []string {
+	if ks.alg == AlgHS256 {
+		return []string{AlgHS256}
+	}
+	return []string{AlgRS256, AlgEdDSA}
+}
+
+func signingMethod(alg string) jwt.SigningMethod {
+	switch alg {
+	case AlgRS256:
+		return jwt.SigningMethodRS256
+	case AlgEdDSA:
+		return jwt.SigningMethodEdDSA
+	default:
+		return jwt.SigningMethodHS256
+	}
+}
+
+func readKeyFile(path string) (*Key, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return keyFromPrivate(parsed)
+	case "RSA PRIVATE KEY":
+		parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return keyFromPrivate(parsed)
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		switch pub := parsed.(type) {
+		case *rsa.PublicKey:
+			return &Key{Algorithm: AlgRS256, public: pub}, nil
+		case ed25519.PublicKey:
+			return &Key{Algorithm: AlgEdDSA, public: pub}, nil
+		}
+		return nil, errors.New("unsupported public key type")
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
+
+func keyFromPrivate(private any) (*Key, error) {
+	switch key := private.(type) {
+	case *rsa.PrivateKey:
+		return &Key{Algorithm: AlgRS256, private: key, public: &key.PublicKey}, nil
+	case ed25519.PrivateKey:
+		return &Key{Algorithm: AlgEdDSA, private: key, public: key.Public()}, nil
+	default:
+		return nil, errors.New("unsupported private key type")
+	}
+}
@@ -0,0 +1,76 @@
+package jwtkeys
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"invoiceflow/internal/config"
+	"invoiceflow/internal/db"
+)
+
+func fakeLock(acquired bool, held *int) db.Locker {
+	return func(context.Context) (func(), bool, error) {
+		if !acquired {
+			return nil, false, nil
+		}
+		*held++
+		return func() { *held-- }, true, nil
+	}
+}
+
+func loadEdDSA(t *testing.T) (*KeySet, string) {
+	t.Helper()
+	dir := t.TempDir()
+	ks, err := Load(&config.Config{JWTAlgorithm: AlgEdDSA, JWTKeyDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ks, dir
+}
+
+func keyFiles(t *testing.T, dir string) int {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(entries)
+}
+
+func TestRotateLockedSkipsWhenLockIsHeldElsewhere(t *testing.T) {
+	ks, dir := loadEdDSA(t)
+	held := 0
+
+	if err := ks.rotateLocked(context.Background(), time.Nanosecond, fakeLock(false, &held)); err != nil {
+		t.Fatal(err)
+	}
+	if got := keyFiles(t, dir); got != 1 {
+		t.Fatalf("key files = %d, want 1", got)
+	}
+}
+
+func TestRotateLockedRotatesOnceAndReleases(t *testing.T) {
+	ks, dir := loadEdDSA(t)
+	before := ks.SigningKeyID()
+	held := 0
+
+	if err := ks.rotateLocked(context.Background(), time.Nanosecond, fakeLock(true, &held)); err != nil {
+		t.Fatal(err)
+	}
+	if held != 0 {
+		t.Fatal("lock was not released")
+	}
+	if ks.SigningKeyID() == before || keyFiles(t, dir) != 2 {
+		t.Fatal("signing key was not rotated")
+	}
+
+	// A key another instance just rotated is not due yet.
+	if err := ks.rotateLocked(context.Background(), time.Hour, fakeLock(true, &held)); err != nil {
+		t.Fatal(err)
+	}
+	if got := keyFiles(t, dir); got != 2 {
+		t.Fatalf("key files = %d, want 2", got)
+	}
+}
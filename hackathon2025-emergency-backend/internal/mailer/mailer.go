@@ -0,0 +1,31 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+
+	"invoiceflow/internal/config"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+func New(cfg *config.Config) (Mailer, error) {
+	switch cfg.MailDriver {
+	case config.MailDriverSMTP:
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom), nil
+	case config.MailDriverFile:
+		return NewFileMailer(cfg.MailFileDir, cfg.MailFrom), nil
+	case config.MailDriverLog:
+		return NewLogMailer(), nil
+	default:
+		return nil, fmt.Errorf("unsupported mail driver %q", cfg.MailDriver)
+	}
+}
@@ -1,22 +1,49 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"strings"
+	"time"
 
-	"invoiceflow/internal/config"
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/jwtkeys"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 const (
-	ContextUserID    = "user_id"
-	ContextUserRole  = "user_role"
-	ContextUserEmail = "user_email"
+	ContextUserID     = "user_id"
+	ContextUserRole   = "user_role"
+	ContextUserEmail  = "user_email"
+	ContextSessionID  = "session_id"
+	ContextUserStatus = "user_status"
+	ContextUserPerms  = "user_permissions"
+	ContextMFA        = "mfa_verified"
+	ContextTokenExpAt = "token_expires_at"
+	ContextTokenVer   = "token_version"
 )
 
-func Auth(cfg *config.Config) gin.HandlerFunc {
+type SessionChecker interface {
+	CheckSession(ctx context.Context, sessionID string, userID string, tokenVersion int) (*domain.SessionState, error)
+}
+
+// TokenFromQuery lets clients that cannot set headers, such as the browser
+// EventSource, pass the access token as a query parameter. Must run before
+// Auth; an Authorization header takes precedence.
+func TokenFromQuery(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if token := c.Query(param); token != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		c.Next()
+	}
+}
+
+func Auth(keys *jwtkeys.KeySet, sessions SessionChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		header := c.GetHeader("Authorization")
 		if header == "" {
@@ -40,12 +67,7 @@ func Auth(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
-		token, err := jwt.Parse(parts[1], func(token *jwt.Token) (interface{}, error) {
-			if token.Method.Alg() != jwt.SigningMethodHS256.Alg() {
-				return nil, jwt.ErrTokenSignatureInvalid
-			}
-			return []byte(cfg.JWTSecret), nil
-		})
+		token, err := keys.Parse(parts[1], jwt.MapClaims{})
 		if err != nil || !token.Valid {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error": gin.H{
@@ -70,8 +92,12 @@ func Auth(cfg *config.Config) gin.HandlerFunc {
 		userID, _ := claims["sub"].(string)
 		role, _ := claims["role"].(string)
 		email, _ := claims["email"].(string)
+		sessionID, _ := claims["sid"].(string)
+		version, hasVersion := claims["ver"].(float64)
+		typ, _ := claims["typ"].(string)
 
-		if userID == "" || role == "" {
+		// MFA challenge tokens carry a typ and are only good at /auth/mfa/verify.
+		if userID == "" || role == "" || sessionID == "" || !hasVersion || typ != "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 				"error": gin.H{
 					"code":    "AUTH.UNAUTHORIZED",
@@ -81,9 +107,37 @@ func Auth(cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
+		state, err := sessions.CheckSession(c.Request.Context(), sessionID, userID, int(version))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": gin.H{
+					"code":    "AUTH.SESSION_REVOKED",
+					"message": "session is no longer valid",
+				},
+			})
+			return
+		}
+
 		c.Set(ContextUserID, userID)
-		c.Set(ContextUserRole, role)
+		// The stored role wins over the token claim.
+		c.Set(ContextUserRole, state.Role)
 		c.Set(ContextUserEmail, email)
+		c.Set(ContextSessionID, sessionID)
+		c.Set(ContextUserStatus, state.UserStatus)
+		c.Set(ContextUserPerms, []string(state.Permissions))
+		c.Set(ContextMFA, state.MFAVerified)
+		c.Set(ContextTokenVer, int(version))
+		if exp, ok := claims["exp"].(float64); ok {
+			c.Set(ContextTokenExpAt, time.Unix(int64(exp), 0))
+		}
+
+		actor := domain.ActorFromContext(c.Request.Context())
+		actor.UserID = userID
+		actor.Role = state.Role
+		if actor.IP == "" {
+			actor.IP = c.ClientIP()
+		}
+		c.Request = c.Request.WithContext(domain.WithActor(c.Request.Context(), actor))
 		c.Next()
 	}
 }
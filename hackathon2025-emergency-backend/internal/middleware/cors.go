@@ -27,7 +27,8 @@ func CORSMiddleware(allowedOrigins []string) gin.HandlerFunc {
 				c.Writer.Header().Add("Vary", "Origin")
 			}
 			c.Writer.Header().Set("Access-Control-Allow-Methods", "GET,POST,PUT,PATCH,DELETE,OPTIONS")
-			c.Writer.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+			c.Writer.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, X-Organization-ID, X-Request-ID, Idempotency-Key")
+			c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, Retry-After, Idempotent-Replayed")
 		}
 
 		if c.Request.Method == http.MethodOptions {
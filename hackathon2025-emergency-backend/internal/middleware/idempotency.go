@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"invoiceflow/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	HeaderIdempotencyKey      = "Idempotency-Key"
+	HeaderIdempotentReplayed  = "Idempotent-Replayed"
+	HeaderOrganizationID      = "X-Organization-ID"
+	maxIdempotencyKeyLength   = 255
+	maxIdempotentRequestBytes = 1 << 20
+	// A request still IN_PROGRESS after this long is assumed dead. Its key is
+	// not reclaimed, since the request may have committed before dying.
+	idempotencyStaleAfter = 5 * time.Minute
+)
+
+type IdempotencyStore interface {
+	Reserve(ctx context.Context, record *domain.IdempotencyRecord) (*domain.IdempotencyRecord, bool, error)
+	Complete(ctx context.Context, record *domain.IdempotencyRecord, status int, contentType string, body []byte) error
+	Release(ctx context.Context, record *domain.IdempotencyRecord) error
+}
+
+// Idempotency makes a mutating endpoint safe to retry. When the request
+// carries an Idempotency-Key, the first response for that key, user and
+// X-Organization-ID is stored and replayed for later requests with the same
+// method, path and body; a different request under the same key is rejected
+// with 422 and a concurrent duplicate with 409. A request that never finished
+// keeps answering 409 until the key expires rather than running twice. 5xx
+// responses are not stored so the client can retry. Must run after Auth.
+func Idempotency(store IdempotencyStore, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderIdempotencyKey)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			abortIdempotency(c, http.StatusBadRequest, "IDEMPOTENCY.INVALID_KEY", "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentRequestBytes+1))
+		if err != nil || len(body) > maxIdempotentRequestBytes {
+			abortIdempotency(c, http.StatusRequestEntityTooLarge, "IDEMPOTENCY.BODY_TOO_LARGE", "request body too large")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		userID := c.GetString(ContextUserID)
+		organizationID := c.GetHeader(HeaderOrganizationID)
+		record := &domain.IdempotencyRecord{
+			UserID:         userID,
+			OrganizationID: organizationID,
+			Key:            key,
+			Method:         c.Request.Method,
+			Path:           c.Request.URL.Path,
+			Fingerprint:    requestFingerprint(userID, organizationID, c.Request.Method, c.Request.URL.RequestURI(), body),
+			ExpiresAt:      time.Now().Add(ttl),
+		}
+
+		existing, reserved, err := store.Reserve(c.Request.Context(), record)
+		if err != nil {
+			abortIdempotency(c, http.StatusInternalServerError, "IDEMPOTENCY.UNAVAILABLE", "could not record idempotency key")
+			return
+		}
+
+		if !reserved {
+			switch {
+			case existing.Fingerprint != record.Fingerprint:
+				abortIdempotency(c, http.StatusUnprocessableEntity, "IDEMPOTENCY.KEY_REUSED", "Idempotency-Key was already used for a different request")
+			case existing.Status != domain.IdempotencyStatusCompleted && time.Since(existing.CreatedAt) > idempotencyStaleAfter:
+				abortIdempotency(c, http.StatusConflict, "IDEMPOTENCY.UNFINISHED", "a request with this Idempotency-Key did not finish and may have been applied; check its outcome before retrying with a new key")
+			case existing.Status != domain.IdempotencyStatusCompleted || existing.ResponseStatus == nil:
+				abortIdempotency(c, http.StatusConflict, "IDEMPOTENCY.IN_PROGRESS", "a request with this Idempotency-Key is still being processed")
+			default:
+				contentType := "application/json; charset=utf-8"
+				if existing.ResponseContentType != nil {
+					contentType = *existing.ResponseContentType
+				}
+				c.Header(HeaderIdempotentReplayed, "true")
+				c.Data(*existing.ResponseStatus, contentType, existing.ResponseBody)
+				c.Abort()
+			}
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// Store the outcome even if the client has gone away.
+		ctx := context.WithoutCancel(c.Request.Context())
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			if err := store.Release(ctx, record); err != nil {
+				log.Printf("idempotency release failed: %v", err)
+			}
+			return
+		}
+
+		if err := store.Complete(ctx, record, status, recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+			log.Printf("idempotency store failed: %v", err)
+		}
+	}
+}
+
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
+
+func requestFingerprint(userID string, organizationID string, method string, uri string, body []byte) string {
+	hash := sha256.New()
+	for _, part := range []string{userID, organizationID, method, uri} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func abortIdempotency(c *gin.Context, status int, code string, message string) {
+	c.AbortWithStatusJSON(status, gin.H{
+		"error": gin.H{
+			"code":    code,
+			"message": message,
+		},
+	})
+}
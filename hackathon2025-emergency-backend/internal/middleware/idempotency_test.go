@@ -0,0 +1,183 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"invoiceflow/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// memoryIdempotencyStore keeps records the way IdempotencyRepository does,
+// keyed by user, organization and key.
+type memoryIdempotencyStore struct {
+	records map[string]*domain.IdempotencyRecord
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: map[string]*domain.IdempotencyRecord{}}
+}
+
+func scopeOf(record *domain.IdempotencyRecord) string {
+	return record.UserID + "\x00" + record.OrganizationID + "\x00" + record.Key
+}
+
+func (s *memoryIdempotencyStore) Reserve(_ context.Context, record *domain.IdempotencyRecord) (*domain.IdempotencyRecord, bool, error) {
+	if existing, ok := s.records[scopeOf(record)]; ok {
+		return existing, false, nil
+	}
+	stored := *record
+	stored.Status = domain.IdempotencyStatusInProgress
+	stored.CreatedAt = time.Now()
+	s.records[scopeOf(record)] = &stored
+	return nil, true, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(_ context.Context, record *domain.IdempotencyRecord, status int, contentType string, body []byte) error {
+	stored := s.records[scopeOf(record)]
+	stored.Status = domain.IdempotencyStatusCompleted
+	stored.ResponseStatus = &status
+	stored.ResponseContentType = &contentType
+	stored.ResponseBody = body
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(_ context.Context, record *domain.IdempotencyRecord) error {
+	if stored, ok := s.records[scopeOf(record)]; ok && stored.Status == domain.IdempotencyStatusInProgress {
+		delete(s.records, scopeOf(record))
+	}
+	return nil
+}
+
+type idempotentServer struct {
+	router *gin.Engine
+	store  *memoryIdempotencyStore
+	runs   int
+	status int
+}
+
+func newIdempotentServer() *idempotentServer {
+	gin.SetMode(gin.TestMode)
+	server := &idempotentServer{store: newMemoryIdempotencyStore(), status: http.StatusCreated}
+	server.router = gin.New()
+	server.router.POST("/fund", func(c *gin.Context) {
+		c.Set(ContextUserID, c.GetHeader("X-Test-User"))
+	}, Idempotency(server.store, time.Hour), func(c *gin.Context) {
+		server.runs++
+		c.JSON(server.status, gin.H{"run": server.runs, "organization": c.GetHeader(HeaderOrganizationID)})
+	})
+	return server
+}
+
+func (s *idempotentServer) post(user string, organizationID string, key string, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/fund", strings.NewReader(body))
+	request.Header.Set("X-Test-User", user)
+	request.Header.Set(HeaderIdempotencyKey, key)
+	if organizationID != "" {
+		request.Header.Set(HeaderOrganizationID, organizationID)
+	}
+	recorder := httptest.NewRecorder()
+	s.router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestIdempotencyReplaysTheFirstResponse(t *testing.T) {
+	server := newIdempotentServer()
+
+	first := server.post("user-1", "org-a", "key-1", `{"amount":"100"}`)
+	second := server.post("user-1", "org-a", "key-1", `{"amount":"100"}`)
+
+	if server.runs != 1 {
+		t.Fatalf("handler ran %d times, want 1", server.runs)
+	}
+	if second.Code != first.Code || second.Body.String() != first.Body.String() {
+		t.Fatalf("replay = %d %s, want %d %s", second.Code, second.Body, first.Code, first.Body)
+	}
+	if second.Header().Get(HeaderIdempotentReplayed) != "true" {
+		t.Fatal("replay is not marked")
+	}
+}
+
+func TestIdempotencyRejectsADifferentBody(t *testing.T) {
+	server := newIdempotentServer()
+
+	server.post("user-1", "org-a", "key-1", `{"amount":"100"}`)
+	response := server.post("user-1", "org-a", "key-1", `{"amount":"999"}`)
+
+	if response.Code != http.StatusUnprocessableEntity || server.runs != 1 {
+		t.Fatalf("status = %d after %d runs, want 422 after 1", response.Code, server.runs)
+	}
+}
+
+func TestIdempotencyKeysAreScopedByOrganizationAndUser(t *testing.T) {
+	server := newIdempotentServer()
+
+	server.post("user-1", "org-a", "key-1", `{"amount":"100"}`)
+	otherOrg := server.post("user-1", "org-b", "key-1", `{"amount":"100"}`)
+	otherUser := server.post("user-2", "org-a", "key-1", `{"amount":"100"}`)
+
+	if server.runs != 3 {
+		t.Fatalf("handler ran %d times, want 3", server.runs)
+	}
+	if otherOrg.Header().Get(HeaderIdempotentReplayed) != "" || !strings.Contains(otherOrg.Body.String(), `"organization":"org-b"`) {
+		t.Fatalf("another organization got a replay: %s", otherOrg.Body)
+	}
+	if otherUser.Header().Get(HeaderIdempotentReplayed) != "" {
+		t.Fatal("another user got a replay")
+	}
+}
+
+func TestIdempotencyFingerprintCoversOrganizationAndUser(t *testing.T) {
+	base := requestFingerprint("user-1", "org-a", http.MethodPost, "/fund", []byte("{}"))
+
+	if requestFingerprint("user-1", "org-b", http.MethodPost, "/fund", []byte("{}")) == base {
+		t.Fatal("organization does not change the fingerprint")
+	}
+	if requestFingerprint("user-2", "org-a", http.MethodPost, "/fund", []byte("{}")) == base {
+		t.Fatal("user does not change the fingerprint")
+	}
+	if requestFingerprint("user-1", "", "org-a"+http.MethodPost, "/fund", []byte("{}")) == base {
+		t.Fatal("fields can be shifted across separators")
+	}
+}
+
+func TestIdempotencyNeverRerunsAnUnfinishedRequest(t *testing.T) {
+	server := newIdempotentServer()
+	record := &domain.IdempotencyRecord{UserID: "user-1", OrganizationID: "org-a", Key: "key-1"}
+	record.Fingerprint = requestFingerprint("user-1", "org-a", http.MethodPost, "/fund", []byte(`{"amount":"100"}`))
+	server.store.Reserve(context.Background(), record)
+
+	inFlight := server.post("user-1", "org-a", "key-1", `{"amount":"100"}`)
+	if inFlight.Code != http.StatusConflict || !strings.Contains(inFlight.Body.String(), "IDEMPOTENCY.IN_PROGRESS") {
+		t.Fatalf("in flight: %d %s", inFlight.Code, inFlight.Body)
+	}
+
+	// The process died after its handler may have committed.
+	server.store.records[scopeOf(record)].CreatedAt = time.Now().Add(-idempotencyStaleAfter - time.Minute)
+
+	stale := server.post("user-1", "org-a", "key-1", `{"amount":"100"}`)
+	if stale.Code != http.StatusConflict || !strings.Contains(stale.Body.String(), "IDEMPOTENCY.UNFINISHED") {
+		t.Fatalf("stale: %d %s", stale.Code, stale.Body)
+	}
+	if server.runs != 0 {
+		t.Fatalf("handler ran %d times, want 0", server.runs)
+	}
+}
+
+func TestIdempotencyReleasesKeyAfterServerError(t *testing.T) {
+	server := newIdempotentServer()
+	server.status = http.StatusInternalServerError
+
+	server.post("user-1", "org-a", "key-1", `{"amount":"100"}`)
+	server.status = http.StatusCreated
+	retry := server.post("user-1", "org-a", "key-1", `{"amount":"100"}`)
+
+	if retry.Code != http.StatusCreated || server.runs != 2 {
+		t.Fatalf("retry = %d after %d runs, want 201 after 2", retry.Code, server.runs)
+	}
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireMFA rejects sessions that were not established with a second factor.
+// Must run after Auth.
+func RequireMFA() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !c.GetBool(ContextMFA) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": gin.H{
+					"code":    "AUTH.MFA_REQUIRED",
+					"message": "this action requires two-factor authentication; enroll at /me/mfa and sign in again",
+				},
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequirePermission allows the request when the caller's role grants every
+// listed permission. Must run after Auth.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		granted := c.GetStringSlice(ContextUserPerms)
+		for _, permission := range permissions {
+			if !permissionGranted(permission, granted) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+					"error": gin.H{
+						"code":    "AUTH.FORBIDDEN",
+						"message": "missing permission",
+						"details": gin.H{"permission": permission},
+					},
+				})
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// RequireAnyPermission allows the request when the caller's role grants at
+// least one of the listed permissions. Must run after Auth.
+func RequireAnyPermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		granted := c.GetStringSlice(ContextUserPerms)
+		for _, permission := range permissions {
+			if permissionGranted(permission, granted) {
+				c.Next()
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": gin.H{
+				"code":    "AUTH.FORBIDDEN",
+				"message": "missing permission",
+				"details": gin.H{"permissions": permissions},
+			},
+		})
+	}
+}
+
+func HasPermission(c *gin.Context, permission string) bool {
+	return permissionGranted(permission, c.GetStringSlice(ContextUserPerms))
+}
+
+func permissionGranted(permission string, granted []string) bool {
+	for _, item := range granted {
+		if item == permission {
+			return true
+		}
+	}
+	return false
+}
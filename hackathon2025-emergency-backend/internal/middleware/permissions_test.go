@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func servePermissions(handler gin.HandlerFunc, granted ...string) int {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", func(c *gin.Context) {
+		c.Set(ContextUserPerms, granted)
+	}, handler, func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	return recorder.Code
+}
+
+func TestRequirePermissionNeedsEvery(t *testing.T) {
+	handler := RequirePermission("invoice.read", "invoice.create")
+
+	if code := servePermissions(handler, "invoice.read", "invoice.create"); code != http.StatusNoContent {
+		t.Fatalf("all granted: status = %d", code)
+	}
+	if code := servePermissions(handler, "invoice.read"); code != http.StatusForbidden {
+		t.Fatalf("one granted: status = %d", code)
+	}
+}
+
+func TestRequireAnyPermissionNeedsOne(t *testing.T) {
+	handler := RequireAnyPermission("invoice.read", "invoice.read_all")
+
+	if code := servePermissions(handler, "invoice.read_all"); code != http.StatusNoContent {
+		t.Fatalf("one granted: status = %d", code)
+	}
+	if code := servePermissions(handler, "invoice.create"); code != http.StatusForbidden {
+		t.Fatalf("none granted: status = %d", code)
+	}
+	if code := servePermissions(handler); code != http.StatusForbidden {
+		t.Fatalf("no permissions: status = %d", code)
+	}
+}
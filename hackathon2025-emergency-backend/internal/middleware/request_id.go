@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"regexp"
+
+	"invoiceflow/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	HeaderRequestID  = "X-Request-ID"
+	ContextRequestID = "request_id"
+)
+
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID tags every request with an id, reusing a well-formed X-Request-ID
+// from the caller, and seeds the request context's audit actor with it and
+// the client IP. Auth fills in the user once the token is checked.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(HeaderRequestID)
+		if !requestIDPattern.MatchString(id) {
+			id = uuid.NewString()
+		}
+
+		c.Set(ContextRequestID, id)
+		c.Writer.Header().Set(HeaderRequestID, id)
+		c.Request = c.Request.WithContext(domain.WithActor(c.Request.Context(), domain.Actor{
+			IP:        c.ClientIP(),
+			RequestID: id,
+		}))
+		c.Next()
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestIDReusesOnlyWellFormedIDs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(ContextRequestID))
+	})
+
+	cases := []struct {
+		name   string
+		header string
+		reused bool
+	}{
+		{"well formed", "req-2026.03.01:abc_DEF", true},
+		{"longest allowed", strings.Repeat("a", 128), true},
+		{"missing", "", false},
+		{"too long", strings.Repeat("a", 129), false},
+		{"spaces", "req 1", false},
+		{"log injection", "req-1\r\nforged: entry", false},
+		{"markup", "<script>", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.Header.Set(HeaderRequestID, tc.header)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, request)
+
+			id := recorder.Body.String()
+			if got := id == tc.header; got != tc.reused {
+				t.Fatalf("reused = %v for %q (id %q)", got, tc.header, id)
+			}
+			if !requestIDPattern.MatchString(id) {
+				t.Fatalf("assigned id %q is not well formed", id)
+			}
+			if recorder.Header().Get(HeaderRequestID) != id {
+				t.Fatal("response header does not carry the request id")
+			}
+		})
+	}
+}
@@ -6,25 +6,25 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func RequireRoles(roles ...string) gin.HandlerFunc {
+func RequireStatus(statuses ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		roleValue, ok := c.Get(ContextUserRole)
-		if !ok {
+		status := c.GetString(ContextUserStatus)
+		if status == "" {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
 				"error": gin.H{
 					"code":    "AUTH.FORBIDDEN",
-					"message": "missing role",
+					"message": "missing account status",
 				},
 			})
 			return
 		}
 
-		role, _ := roleValue.(string)
-		if !roleAllowed(role, roles) {
+		if !statusAllowed(status, statuses) {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
 				"error": gin.H{
-					"code":    "AUTH.FORBIDDEN",
-					"message": "insufficient role",
+					"code":    "AUTH.ACCOUNT_NOT_ACTIVE",
+					"message": "account is not active",
+					"details": gin.H{"status": status},
 				},
 			})
 			return
@@ -34,9 +34,9 @@ func RequireRoles(roles ...string) gin.HandlerFunc {
 	}
 }
 
-func roleAllowed(role string, allowed []string) bool {
+func statusAllowed(status string, allowed []string) bool {
 	for _, item := range allowed {
-		if role == item {
+		if status == item {
 			return true
 		}
 	}
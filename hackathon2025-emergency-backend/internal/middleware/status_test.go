@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"invoiceflow/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+func serveStatus(handler gin.HandlerFunc, status string) int {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", func(c *gin.Context) {
+		if status != "" {
+			c.Set(ContextUserStatus, status)
+		}
+	}, handler, func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	return recorder.Code
+}
+
+func TestRequireStatusBlocksAccountsThatAreNotActive(t *testing.T) {
+	handler := RequireStatus(domain.UserStatusActive)
+
+	cases := []struct {
+		status string
+		want   int
+	}{
+		{domain.UserStatusActive, http.StatusNoContent},
+		{domain.UserStatusPending, http.StatusForbidden},
+		{domain.UserStatusSuspended, http.StatusForbidden},
+		{"", http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		if code := serveStatus(handler, tc.status); code != tc.want {
+			t.Fatalf("status %q: code = %d, want %d", tc.status, code, tc.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// Package pagination defines the keyset cursors shared by the list
+// endpoints. A cursor names the sort it was issued for and the sort key and
+// id of the last row returned; clients treat it as opaque.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+type Cursor struct {
+	Sort string `json:"s"`
+	// Value is the last row's sort key in Postgres text form; nil when the
+	// key was NULL.
+	Value *string `json:"v"`
+	ID    string  `json:"id"`
+	// Priority is set when the list pins some rows ahead of the sort.
+	Priority *bool `json:"p,omitempty"`
+}
+
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func Decode(value string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// Page is a request for one page: up to Limit rows after the cursor, or from
+// the start when After is nil. IncludeTotal asks for the full count as well,
+// which costs an extra query.
+type Page struct {
+	Limit        int
+	After        *Cursor
+	IncludeTotal bool
+}
+
+// Meta describes a returned page. NextCursor is empty on the last page.
+type Meta struct {
+	NextCursor string
+	Total      *int
+}
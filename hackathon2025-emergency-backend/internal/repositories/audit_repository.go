@@ -0,0 +1,189 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/pagination"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// auditChainLock seeds the per-chain pg_advisory_xact_lock keys that
+// serialize appends, so each row links to the one committed before it in
+// its chain while other chains append in parallel.
+const auditChainLock = 0x617564697400
+
+type AuditRepository struct {
+	db *sqlx.DB
+}
+
+func NewAuditRepository(db *sqlx.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// LockChain takes the lock of chain for the rest of tx and returns the hash
+// of its latest event, or "" for an empty chain.
+func (r *AuditRepository) LockChain(ctx context.Context, tx *sqlx.Tx, chain string) (string, error) {
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtextextended($1, $2))", chain, auditChainLock); err != nil {
+		return "", err
+	}
+
+	var hash string
+	err := tx.GetContext(ctx, &hash, "SELECT hash FROM audit_events WHERE chain = $1 ORDER BY id DESC LIMIT 1", chain)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return hash, err
+}
+
+func (r *AuditRepository) Insert(ctx context.Context, tx *sqlx.Tx, event *domain.AuditEvent) error {
+	query := `
+    INSERT INTO audit_events (
+      chain, occurred_at, actor_id, actor_role, ip, request_id, entity_type, entity_id, action,
+      before, after, prev_hash, hash
+    )
+    VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13)
+    RETURNING id
+  `
+
+	return tx.GetContext(ctx, &event.ID, query,
+		event.Chain, event.OccurredAt, event.ActorID, event.ActorRole, event.IP, event.RequestID,
+		event.EntityType, event.EntityID, event.Action,
+		nullableJSON(event.Before), nullableJSON(event.After), event.PrevHash, event.Hash,
+	)
+}
+
+func (r *AuditRepository) List(ctx context.Context, filters AuditFilters) ([]domain.AuditEvent, pagination.Meta, error) {
+	conditions := []string{"1=1"}
+	args := []any{}
+
+	if filters.EntityType != "" {
+		args = append(args, filters.EntityType)
+		conditions = append(conditions, fmt.Sprintf("entity_type = $%d", len(args)))
+	}
+
+	if filters.EntityID != "" {
+		args = append(args, filters.EntityID)
+		conditions = append(conditions, fmt.Sprintf("entity_id = $%d", len(args)))
+	}
+
+	if filters.ActorID != "" {
+		args = append(args, filters.ActorID)
+		conditions = append(conditions, fmt.Sprintf("actor_id = $%d", len(args)))
+	}
+
+	if filters.Action != "" {
+		args = append(args, filters.Action)
+		conditions = append(conditions, fmt.Sprintf("action = $%d", len(args)))
+	}
+
+	if filters.RequestID != "" {
+		args = append(args, filters.RequestID)
+		conditions = append(conditions, fmt.Sprintf("request_id = $%d", len(args)))
+	}
+
+	if filters.From != nil {
+		args = append(args, *filters.From)
+		conditions = append(conditions, fmt.Sprintf("occurred_at >= $%d", len(args)))
+	}
+
+	if filters.To != nil {
+		args = append(args, *filters.To)
+		conditions = append(conditions, fmt.Sprintf("occurred_at < $%d", len(args)))
+	}
+
+	var meta pagination.Meta
+	if filters.Page.IncludeTotal {
+		var total int
+		if err := r.db.GetContext(ctx, &total, fmt.Sprintf("SELECT count(*) FROM audit_events WHERE %s", strings.Join(conditions, " AND ")), args...); err != nil {
+			return nil, meta, err
+		}
+		meta.Total = &total
+	}
+
+	if filters.Page.After != nil {
+		condition, afterArgs, err := auditOrder.after(filters.Page.After, args)
+		if err != nil {
+			return nil, meta, err
+		}
+		conditions = append(conditions, condition)
+		args = afterArgs
+	}
+
+	limit := pageLimit(filters.Page)
+	listQuery := fmt.Sprintf(`
+    SELECT id, chain, occurred_at, actor_id, actor_role, ip, request_id, entity_type, entity_id, action,
+      before, after, prev_hash, hash, %s
+    FROM audit_events
+    WHERE %s
+    ORDER BY %s
+    LIMIT %d
+  `, auditOrder.columns(), strings.Join(conditions, " AND "), auditOrder.orderBy(), limit+1)
+
+	var rows []struct {
+		domain.AuditEvent
+		cursorColumns
+	}
+	if err := r.db.SelectContext(ctx, &rows, listQuery, args...); err != nil {
+		return nil, meta, err
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		meta.NextCursor = auditOrder.cursorAt(rows[limit-1].cursorColumns)
+	}
+
+	events := make([]domain.AuditEvent, 0, len(rows))
+	for _, row := range rows {
+		events = append(events, row.AuditEvent)
+	}
+
+	return events, meta, nil
+}
+
+type AuditFilters struct {
+	EntityType string
+	EntityID   string
+	ActorID    string
+	Action     string
+	RequestID  string
+	From       *time.Time
+	To         *time.Time
+	Page       pagination.Page
+}
+
+// auditOrder lists the newest events first; ids follow the hash chain.
+var auditOrder = keyset{name: "id", expr: "id", exprType: "bigint", desc: true, idExpr: "id", idType: "bigint"}
+
+// ListAfter returns up to limit events with id greater than afterID in id
+// order, which is append order within each chain, for verification.
+func (r *AuditRepository) ListAfter(ctx context.Context, afterID int64, limit int) ([]domain.AuditEvent, error) {
+	query := `
+    SELECT id, chain, occurred_at, actor_id, actor_role, ip, request_id, entity_type, entity_id, action,
+      before, after, prev_hash, hash
+    FROM audit_events
+    WHERE id > $1
+    ORDER BY id
+    LIMIT $2
+  `
+
+	events := []domain.AuditEvent{}
+	if err := r.db.SelectContext(ctx, &events, query, afterID, limit); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func nullableJSON(value []byte) any {
+	if len(value) == 0 {
+		return nil
+	}
+	return string(value)
+}
@@ -0,0 +1,194 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/pagination"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type AuthEventRepository struct {
+	db *sqlx.DB
+}
+
+func NewAuthEventRepository(db *sqlx.DB) *AuthEventRepository {
+	return &AuthEventRepository{db: db}
+}
+
+func (r *AuthEventRepository) Record(ctx context.Context, event *domain.AuthEvent) error {
+	query := `
+    INSERT INTO auth_events (user_id, email, ip, user_agent, event, reason)
+    VALUES ($1,$2,$3,$4,$5,$6)
+  `
+
+	_, err := r.db.ExecContext(ctx, query, event.UserID, event.Email, event.IP, event.UserAgent, event.Event, event.Reason)
+	return err
+}
+
+func (r *AuthEventRepository) RecordTx(ctx context.Context, tx *sqlx.Tx, event *domain.AuthEvent) error {
+	query := `
+    INSERT INTO auth_events (user_id, email, ip, user_agent, event, reason)
+    VALUES ($1,$2,$3,$4,$5,$6)
+  `
+
+	_, err := tx.ExecContext(ctx, query, event.UserID, event.Email, event.IP, event.UserAgent, event.Event, event.Reason)
+	return err
+}
+
+func (r *AuthEventRepository) List(ctx context.Context, filters AuthEventFilters) ([]domain.AuthEvent, pagination.Meta, error) {
+	conditions := []string{"1=1"}
+	args := []any{}
+
+	if filters.UserID != "" {
+		args = append(args, filters.UserID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+
+	if filters.Email != "" {
+		args = append(args, strings.ToLower(filters.Email))
+		conditions = append(conditions, fmt.Sprintf("email = $%d", len(args)))
+	}
+
+	if filters.IP != "" {
+		args = append(args, filters.IP)
+		conditions = append(conditions, fmt.Sprintf("ip = $%d", len(args)))
+	}
+
+	if filters.Event != "" {
+		args = append(args, filters.Event)
+		conditions = append(conditions, fmt.Sprintf("event = $%d", len(args)))
+	}
+
+	var meta pagination.Meta
+	if filters.Page.IncludeTotal {
+		var total int
+		if err := r.db.GetContext(ctx, &total, fmt.Sprintf("SELECT count(*) FROM auth_events WHERE %s", strings.Join(conditions, " AND ")), args...); err != nil {
+			return nil, meta, err
+		}
+		meta.Total = &total
+	}
+
+	if filters.Page.After != nil {
+		condition, afterArgs, err := authEventOrder.after(filters.Page.After, args)
+		if err != nil {
+			return nil, meta, err
+		}
+		conditions = append(conditions, condition)
+		args = afterArgs
+	}
+
+	limit := pageLimit(filters.Page)
+	listQuery := fmt.Sprintf(`
+    SELECT id, user_id, email, ip, user_agent, event, reason, created_at, %s
+    FROM auth_events
+    WHERE %s
+    ORDER BY %s
+    LIMIT %d
+  `, authEventOrder.columns(), strings.Join(conditions, " AND "), authEventOrder.orderBy(), limit+1)
+
+	var rows []struct {
+		domain.AuthEvent
+		cursorColumns
+	}
+	if err := r.db.SelectContext(ctx, &rows, listQuery, args...); err != nil {
+		return nil, meta, err
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		meta.NextCursor = authEventOrder.cursorAt(rows[limit-1].cursorColumns)
+	}
+
+	events := make([]domain.AuthEvent, 0, len(rows))
+	for _, row := range rows {
+		events = append(events, row.AuthEvent)
+	}
+
+	return events, meta, nil
+}
+
+type AuthEventFilters struct {
+	UserID string
+	Email  string
+	IP     string
+	Event  string
+	Page   pagination.Page
+}
+
+var authEventOrder = keyset{name: "created_at", expr: "created_at", exprType: "timestamptz", desc: true, idExpr: "id", idType: "uuid"}
+
+// ReserveAttempt counts an attempt before its credentials are checked, so
+// concurrent attempts cannot all slip past the throttle. The count restarts
+// once the previous attempts fall outside window, and the key is locked for
+// lockout when it reaches maxFailures. Nothing is counted while the key is
+// locked or still inside its back-off delay (2^(failures-2) seconds from the
+// second failure, capped at maxDelay); reserved is then false and the
+// returned throttle is the state that refused the attempt.
+func (r *AuthEventRepository) ReserveAttempt(ctx context.Context, scope string, key string, window time.Duration, maxFailures int, lockout time.Duration, maxDelay time.Duration) (throttle *domain.LoginThrottle, reserved bool, err error) {
+	query := `
+    WITH reserved AS (
+      INSERT INTO login_throttles AS t (scope, key, failures, first_failed_at, last_failed_at)
+      VALUES ($1, $2, 1, now(), now())
+      ON CONFLICT (scope, key) DO UPDATE
+      SET failures = CASE WHEN t.first_failed_at < now() - make_interval(secs => $3) THEN 1 ELSE t.failures + 1 END,
+          first_failed_at = CASE WHEN t.first_failed_at < now() - make_interval(secs => $3) THEN now() ELSE t.first_failed_at END,
+          last_failed_at = now(),
+          locked_until = CASE
+            WHEN t.first_failed_at >= now() - make_interval(secs => $3) AND t.failures + 1 >= $4 THEN now() + make_interval(secs => $5)
+            ELSE t.locked_until
+          END
+      WHERE (t.locked_until IS NULL OR t.locked_until <= now())
+        AND (
+          t.first_failed_at < now() - make_interval(secs => $3)
+          OR t.failures < 2
+          OR t.last_failed_at + make_interval(secs => least(power(2, t.failures - 2), $6)) <= now()
+        )
+      RETURNING scope, key, failures, first_failed_at, last_failed_at, locked_until
+    )
+    SELECT scope, key, failures, first_failed_at, last_failed_at, locked_until, true AS reserved
+    FROM reserved
+    UNION ALL
+    SELECT scope, key, failures, first_failed_at, last_failed_at, locked_until, false AS reserved
+    FROM login_throttles
+    WHERE scope = $1 AND key = $2 AND NOT EXISTS (SELECT 1 FROM reserved)
+  `
+
+	var row struct {
+		domain.LoginThrottle
+		Reserved bool `db:"reserved"`
+	}
+	if err := r.db.GetContext(ctx, &row, query, scope, key, window.Seconds(), maxFailures, lockout.Seconds(), maxDelay.Seconds()); err != nil {
+		return nil, false, err
+	}
+
+	return &row.LoginThrottle, row.Reserved, nil
+}
+
+// ReleaseAttempt takes back a reserved attempt that did not fail, lifting a
+// lock that only the released attempt had triggered.
+func (r *AuthEventRepository) ReleaseAttempt(ctx context.Context, scope string, key string, maxFailures int) error {
+	query := `
+    UPDATE login_throttles
+    SET failures = failures - 1,
+        locked_until = CASE WHEN failures - 1 < $3 THEN NULL ELSE locked_until END
+    WHERE scope = $1 AND key = $2 AND failures > 0
+  `
+
+	_, err := r.db.ExecContext(ctx, query, scope, key, maxFailures)
+	return err
+}
+
+func (r *AuthEventRepository) ClearThrottle(ctx context.Context, scope string, key string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM login_throttles WHERE scope = $1 AND key = $2", scope, key)
+	return err
+}
+
+func (r *AuthEventRepository) ClearThrottleTx(ctx context.Context, tx *sqlx.Tx, scope string, key string) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM login_throttles WHERE scope = $1 AND key = $2", scope, key)
+	return err
+}
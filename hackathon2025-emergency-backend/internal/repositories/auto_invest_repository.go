@@ -0,0 +1,197 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/pagination"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type AutoInvestRepository struct {
+	db *sqlx.DB
+}
+
+func NewAutoInvestRepository(db *sqlx.DB) *AutoInvestRepository {
+	return &AutoInvestRepository{db: db}
+}
+
+const autoInvestStrategyColumns = `id, organization_id, investor_id, name, currency, max_per_invoice, budget, invested_amount,
+      risk_tiers, min_apr_percent, max_term_months, tags, emergency_lane, active, created_at, updated_at`
+
+func (r *AutoInvestRepository) Create(ctx context.Context, strategy *domain.AutoInvestStrategy) (*domain.AutoInvestStrategy, error) {
+	query := `
+    INSERT INTO auto_invest_strategies (organization_id, investor_id, name, currency, max_per_invoice, budget,
+      risk_tiers, min_apr_percent, max_term_months, tags, emergency_lane, active)
+    VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12)
+    RETURNING ` + autoInvestStrategyColumns
+
+	var created domain.AutoInvestStrategy
+	err := r.db.GetContext(ctx, &created, query,
+		strategy.OrganizationID, strategy.InvestorID, strategy.Name, strategy.Currency, strategy.MaxPerInvoice,
+		strategy.Budget, strategy.RiskTiers, strategy.MinAPRPercent, strategy.MaxTermMonths, strategy.Tags,
+		strategy.EmergencyLane, strategy.Active,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (r *AutoInvestRepository) ListByOrganization(ctx context.Context, organizationID string) ([]domain.AutoInvestStrategy, error) {
+	query := `
+    SELECT ` + autoInvestStrategyColumns + `
+    FROM auto_invest_strategies
+    WHERE organization_id = $1
+    ORDER BY created_at DESC
+  `
+
+	strategies := []domain.AutoInvestStrategy{}
+	if err := r.db.SelectContext(ctx, &strategies, query, organizationID); err != nil {
+		return nil, err
+	}
+
+	return strategies, nil
+}
+
+func (r *AutoInvestRepository) Get(ctx context.Context, organizationID string, id string) (*domain.AutoInvestStrategy, error) {
+	query := `
+    SELECT ` + autoInvestStrategyColumns + `
+    FROM auto_invest_strategies
+    WHERE id = $1 AND organization_id = $2
+  `
+
+	var strategy domain.AutoInvestStrategy
+	if err := r.db.GetContext(ctx, &strategy, query, id, organizationID); err != nil {
+		return nil, err
+	}
+
+	return &strategy, nil
+}
+
+// Update saves the strategy's settings. invested_amount is only changed by
+// the matcher, through AddInvested.
+func (r *AutoInvestRepository) Update(ctx context.Context, strategy *domain.AutoInvestStrategy) (*domain.AutoInvestStrategy, error) {
+	query := `
+    UPDATE auto_invest_strategies
+    SET name = $3, max_per_invoice = $4, budget = $5, risk_tiers = $6, min_apr_percent = $7, max_term_months = $8,
+      tags = $9, emergency_lane = $10, active = $11, updated_at = now()
+    WHERE id = $1 AND organization_id = $2
+    RETURNING ` + autoInvestStrategyColumns
+
+	var updated domain.AutoInvestStrategy
+	err := r.db.GetContext(ctx, &updated, query,
+		strategy.ID, strategy.OrganizationID, strategy.Name, strategy.MaxPerInvoice, strategy.Budget,
+		strategy.RiskTiers, strategy.MinAPRPercent, strategy.MaxTermMonths, strategy.Tags, strategy.EmergencyLane,
+		strategy.Active,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+func (r *AutoInvestRepository) Delete(ctx context.Context, organizationID string, id string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM auto_invest_strategies WHERE id = $1 AND organization_id = $2", id, organizationID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// CandidatesForUpdate locks the active strategies in currency with budget
+// left, oldest first, leaving out those of excludeOrganizationID and those
+// whose investor may no longer fund for their organization.
+func (r *AutoInvestRepository) CandidatesForUpdate(ctx context.Context, tx *sqlx.Tx, currency string, excludeOrganizationID string) ([]domain.AutoInvestStrategy, error) {
+	args := []any{currency, excludeOrganizationID}
+	query := `
+    SELECT ` + autoInvestStrategyColumns + `
+    FROM auto_invest_strategies
+    WHERE active AND currency = $1 AND organization_id <> $2 AND invested_amount < budget
+      AND ` + eligibleFunderCondition("auto_invest_strategies", &args) + `
+    ORDER BY created_at, id
+    FOR UPDATE
+  `
+
+	strategies := []domain.AutoInvestStrategy{}
+	if err := tx.SelectContext(ctx, &strategies, query, args...); err != nil {
+		return nil, err
+	}
+
+	return strategies, nil
+}
+
+func (r *AutoInvestRepository) AddInvested(ctx context.Context, tx *sqlx.Tx, id string, amount float64) error {
+	_, err := tx.ExecContext(ctx, "UPDATE auto_invest_strategies SET invested_amount = invested_amount + $2, updated_at = now() WHERE id = $1", id, amount)
+	return err
+}
+
+func (r *AutoInvestRepository) CreateAllocation(ctx context.Context, tx *sqlx.Tx, allocation *domain.AutoInvestAllocation) error {
+	query := `
+    INSERT INTO auto_invest_allocations (strategy_id, invoice_id, funding_id, amount)
+    VALUES ($1,$2,$3,$4)
+  `
+
+	_, err := tx.ExecContext(ctx, query, allocation.StrategyID, allocation.InvoiceID, allocation.FundingID, allocation.Amount)
+	return err
+}
+
+var autoInvestAllocationOrder = keyset{name: "created_at", expr: "created_at", exprType: "timestamptz", desc: true, idExpr: "id", idType: "uuid"}
+
+func (r *AutoInvestRepository) ListAllocations(ctx context.Context, strategyID string, page pagination.Page) ([]domain.AutoInvestAllocation, pagination.Meta, error) {
+	args := []any{strategyID}
+	conditions := []string{"strategy_id = $1"}
+
+	var meta pagination.Meta
+	if page.IncludeTotal {
+		var total int
+		if err := r.db.GetContext(ctx, &total, fmt.Sprintf("SELECT count(*) FROM auto_invest_allocations WHERE %s", strings.Join(conditions, " AND ")), args...); err != nil {
+			return nil, meta, err
+		}
+		meta.Total = &total
+	}
+
+	if page.After != nil {
+		condition, afterArgs, err := autoInvestAllocationOrder.after(page.After, args)
+		if err != nil {
+			return nil, meta, err
+		}
+		conditions = append(conditions, condition)
+		args = afterArgs
+	}
+
+	limit := pageLimit(page)
+	listQuery := fmt.Sprintf(`
+    SELECT id, strategy_id, invoice_id, funding_id, amount, created_at, %s
+    FROM auto_invest_allocations
+    WHERE %s
+    ORDER BY %s
+    LIMIT %d
+  `, autoInvestAllocationOrder.columns(), strings.Join(conditions, " AND "), autoInvestAllocationOrder.orderBy(), limit+1)
+
+	var rows []struct {
+		domain.AutoInvestAllocation
+		cursorColumns
+	}
+	if err := r.db.SelectContext(ctx, &rows, listQuery, args...); err != nil {
+		return nil, meta, err
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		meta.NextCursor = autoInvestAllocationOrder.cursorAt(rows[limit-1].cursorColumns)
+	}
+
+	allocations := make([]domain.AutoInvestAllocation, 0, len(rows))
+	for _, row := range rows {
+		allocations = append(allocations, row.AutoInvestAllocation)
+	}
+
+	return allocations, meta, nil
+}
@@ -2,9 +2,12 @@ package repositories
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	"invoiceflow/internal/domain"
+	"invoiceflow/internal/pagination"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -19,7 +22,7 @@ func NewChainRepository(db *sqlx.DB) *ChainRepository {
 
 func (r *ChainRepository) GetOnchainByInvoiceID(ctx context.Context, invoiceID string) (*domain.InvoiceOnChain, error) {
 	query := `
-    SELECT invoice_id, contract_address, token_id, mint_tx_hash, chain_status, minted_at, created_at, updated_at
+    SELECT invoice_id, chain_id, contract_address, token_id, mint_tx_hash, chain_status, minted_at, created_at, updated_at
     FROM invoice_onchain
     WHERE invoice_id = $1
   `
@@ -32,16 +35,17 @@ func (r *ChainRepository) GetOnchainByInvoiceID(ctx context.Context, invoiceID s
 	return &record, nil
 }
 
-func (r *ChainRepository) CreateOnchain(ctx context.Context, record *domain.InvoiceOnChain) (*domain.InvoiceOnChain, error) {
+func (r *ChainRepository) CreateOnchain(ctx context.Context, tx *sqlx.Tx, record *domain.InvoiceOnChain) (*domain.InvoiceOnChain, error) {
 	query := `
-    INSERT INTO invoice_onchain (invoice_id, contract_address, token_id, mint_tx_hash, chain_status, minted_at)
-    VALUES ($1,$2,$3,$4,$5,$6)
-    RETURNING invoice_id, contract_address, token_id, mint_tx_hash, chain_status, minted_at, created_at, updated_at
+    INSERT INTO invoice_onchain (invoice_id, chain_id, contract_address, token_id, mint_tx_hash, chain_status, minted_at)
+    VALUES ($1,$2,$3,$4,$5,$6,$7)
+    RETURNING invoice_id, chain_id, contract_address, token_id, mint_tx_hash, chain_status, minted_at, created_at, updated_at
   `
 
 	var created domain.InvoiceOnChain
-	if err := r.db.GetContext(ctx, &created, query,
+	if err := tx.GetContext(ctx, &created, query,
 		record.InvoiceID,
+		record.ChainID,
 		record.ContractAddress,
 		record.TokenID,
 		record.MintTxHash,
@@ -54,33 +58,46 @@ func (r *ChainRepository) CreateOnchain(ctx context.Context, record *domain.Invo
 	return &created, nil
 }
 
-func (r *ChainRepository) UpdateOnchainStatus(ctx context.Context, invoiceID string, status string, mintedAt *time.Time) (*domain.InvoiceOnChain, error) {
+func (r *ChainRepository) UpdateOnchainStatus(ctx context.Context, tx *sqlx.Tx, invoiceID string, status string, mintedAt *time.Time) (*domain.InvoiceOnChain, error) {
 	query := `
     UPDATE invoice_onchain
     SET chain_status = $2,
         minted_at = COALESCE($3, minted_at),
         updated_at = now()
     WHERE invoice_id = $1
-    RETURNING invoice_id, contract_address, token_id, mint_tx_hash, chain_status, minted_at, created_at, updated_at
+    RETURNING invoice_id, chain_id, contract_address, token_id, mint_tx_hash, chain_status, minted_at, created_at, updated_at
   `
 
 	var record domain.InvoiceOnChain
-	if err := r.db.GetContext(ctx, &record, query, invoiceID, status, mintedAt); err != nil {
+	if err := tx.GetContext(ctx, &record, query, invoiceID, status, mintedAt); err != nil {
 		return nil, err
 	}
 
 	return &record, nil
 }
 
-func (r *ChainRepository) CreateChainTx(ctx context.Context, tx *domain.ChainTx) (*domain.ChainTx, error) {
+func (r *ChainRepository) CreateChainTx(ctx context.Context, tx *sqlx.Tx, record *domain.ChainTx) (*domain.ChainTx, error) {
 	query := `
-    INSERT INTO chain_txs (tx_hash, type, status, error, receipt_json)
-    VALUES ($1,$2,$3,$4,$5)
-    RETURNING tx_hash, type, status, error, receipt_json, created_at, confirmed_at
+    INSERT INTO chain_txs (tx_hash, chain_id, invoice_id, type, status, error, receipt_json, gas_limit, max_fee_wei)
+    VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9::numeric)
+    RETURNING tx_hash, chain_id, invoice_id, type, status, error, receipt_json,
+      gas_limit, max_fee_wei::text AS max_fee_wei, gas_used,
+      effective_gas_price::text AS effective_gas_price, fee_wei::text AS fee_wei,
+      created_at, confirmed_at
   `
 
 	var created domain.ChainTx
-	if err := r.db.GetContext(ctx, &created, query, tx.TxHash, tx.Type, tx.Status, tx.Error, tx.ReceiptJSON); err != nil {
+	if err := tx.GetContext(ctx, &created, query,
+		record.TxHash,
+		record.ChainID,
+		record.InvoiceID,
+		record.Type,
+		record.Status,
+		record.Error,
+		record.ReceiptJSON,
+		record.GasLimit,
+		record.MaxFeeWei,
+	); err != nil {
 		return nil, err
 	}
 
@@ -89,7 +106,10 @@ func (r *ChainRepository) CreateChainTx(ctx context.Context, tx *domain.ChainTx)
 
 func (r *ChainRepository) GetChainTx(ctx context.Context, hash string) (*domain.ChainTx, error) {
 	query := `
-    SELECT tx_hash, type, status, error, receipt_json, created_at, confirmed_at
+    SELECT tx_hash, chain_id, invoice_id, type, status, error, receipt_json,
+      gas_limit, max_fee_wei::text AS max_fee_wei, gas_used,
+      effective_gas_price::text AS effective_gas_price, fee_wei::text AS fee_wei,
+      created_at, confirmed_at
     FROM chain_txs
     WHERE tx_hash = $1
   `
@@ -102,21 +122,140 @@ func (r *ChainRepository) GetChainTx(ctx context.Context, hash string) (*domain.
 	return &record, nil
 }
 
-func (r *ChainRepository) UpdateChainTx(ctx context.Context, hash string, status string, errMsg *string, receipt []byte, confirmedAt *time.Time) (*domain.ChainTx, error) {
+func (r *ChainRepository) UpdateChainTx(ctx context.Context, tx *sqlx.Tx, hash string, status string, errMsg *string, receipt []byte, cost *domain.ChainTxCost, confirmedAt *time.Time) (*domain.ChainTx, error) {
+	var gasUsed *int64
+	var effectiveGasPrice, feeWei *string
+	if cost != nil {
+		gasUsed = &cost.GasUsed
+		effectiveGasPrice = &cost.EffectiveGasPrice
+		feeWei = &cost.FeeWei
+	}
+
 	query := `
     UPDATE chain_txs
     SET status = $2,
         error = $3,
         receipt_json = $4,
-        confirmed_at = COALESCE($5, confirmed_at)
+        confirmed_at = COALESCE($5, confirmed_at),
+        gas_used = COALESCE($6, gas_used),
+        effective_gas_price = COALESCE($7::numeric, effective_gas_price),
+        fee_wei = COALESCE($8::numeric, fee_wei)
     WHERE tx_hash = $1
-    RETURNING tx_hash, type, status, error, receipt_json, created_at, confirmed_at
+    RETURNING tx_hash, chain_id, invoice_id, type, status, error, receipt_json,
+      gas_limit, max_fee_wei::text AS max_fee_wei, gas_used,
+      effective_gas_price::text AS effective_gas_price, fee_wei::text AS fee_wei,
+      created_at, confirmed_at
   `
 
 	var record domain.ChainTx
-	if err := r.db.GetContext(ctx, &record, query, hash, status, errMsg, receipt, confirmedAt); err != nil {
+	if err := tx.GetContext(ctx, &record, query, hash, status, errMsg, receipt, confirmedAt, gasUsed, effectiveGasPrice, feeWei); err != nil {
 		return nil, err
 	}
 
 	return &record, nil
 }
+
+func (r *ChainRepository) CostSummary(ctx context.Context) ([]domain.ChainCostSummary, error) {
+	query := `
+    SELECT chain_id,
+      count(*) AS tx_count,
+      count(*) FILTER (WHERE status = 'CONFIRMED') AS confirmed_count,
+      count(*) FILTER (WHERE status = 'FAILED') AS failed_count,
+      COALESCE(sum(gas_used), 0) AS gas_used,
+      COALESCE(sum(fee_wei), 0)::text AS fee_wei,
+      (COALESCE(sum(fee_wei), 0) / 1e18)::float8 AS fee_eth,
+      COALESCE(avg(fee_wei) / 1e18, 0)::float8 AS avg_fee_eth
+    FROM chain_txs
+    GROUP BY chain_id
+    ORDER BY chain_id
+  `
+
+	summary := []domain.ChainCostSummary{}
+	if err := r.db.SelectContext(ctx, &summary, query); err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+func (r *ChainRepository) ListInvoiceCosts(ctx context.Context, invoiceID string, page pagination.Page) ([]domain.InvoiceChainCost, pagination.Meta, error) {
+	args := []any{invoiceID}
+	conditions := []string{"1=1"}
+
+	var meta pagination.Meta
+	if page.IncludeTotal {
+		countQuery := `
+    SELECT count(DISTINCT (invoice_id, chain_id))
+    FROM chain_txs
+    WHERE ($1 = '' OR invoice_id::text = $1)
+  `
+		var total int
+		if err := r.db.GetContext(ctx, &total, countQuery, args...); err != nil {
+			return nil, meta, err
+		}
+		meta.Total = &total
+	}
+
+	if page.After != nil {
+		condition, afterArgs, err := chainCostOrder.after(page.After, args)
+		if err != nil {
+			return nil, meta, err
+		}
+		conditions = append(conditions, condition)
+		args = afterArgs
+	}
+
+	limit := pageLimit(page)
+	query := fmt.Sprintf(`
+    WITH costs AS (
+      SELECT t.invoice_id, i.title, t.chain_id,
+        count(*) AS tx_count,
+        COALESCE(sum(t.gas_used), 0) AS gas_used,
+        sum(t.fee_wei) AS fee_total
+      FROM chain_txs t
+      JOIN invoices i ON i.id = t.invoice_id
+      WHERE ($1 = '' OR t.invoice_id::text = $1)
+      GROUP BY t.invoice_id, i.title, t.chain_id
+    )
+    SELECT invoice_id, title, chain_id, tx_count, gas_used,
+      COALESCE(fee_total, 0)::text AS fee_wei,
+      (COALESCE(fee_total, 0) / 1e18)::float8 AS fee_eth,
+      %s
+    FROM costs
+    WHERE %s
+    ORDER BY %s
+    LIMIT %d
+  `, chainCostOrder.columns(), strings.Join(conditions, " AND "), chainCostOrder.orderBy(), limit+1)
+
+	var rows []struct {
+		domain.InvoiceChainCost
+		cursorColumns
+	}
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, meta, err
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		meta.NextCursor = chainCostOrder.cursorAt(rows[limit-1].cursorColumns)
+	}
+
+	costs := make([]domain.InvoiceChainCost, 0, len(rows))
+	for _, row := range rows {
+		costs = append(costs, row.InvoiceChainCost)
+	}
+
+	return costs, meta, nil
+}
+
+// chainCostOrder lists the most expensive invoice and chain pairs first; a
+// pair has no single id, so the tie-break joins both.
+var chainCostOrder = keyset{
+	name:     "fee",
+	expr:     "fee_total",
+	exprType: "numeric",
+	nullable: true,
+	desc:     true,
+	idExpr:   "invoice_id::text || ':' || chain_id::text",
+	idType:   "text",
+}
@@ -0,0 +1,162 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/pagination"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type EmergencyRepository struct {
+	db *sqlx.DB
+}
+
+func NewEmergencyRepository(db *sqlx.DB) *EmergencyRepository {
+	return &EmergencyRepository{db: db}
+}
+
+// OpenReview starts the review clock of an emergency-lane invoice, restarting
+// it if the invoice was reviewed before.
+func (r *EmergencyRepository) OpenReview(ctx context.Context, tx *sqlx.Tx, invoiceID string, dueAt time.Time) error {
+	query := `
+    INSERT INTO emergency_reviews (invoice_id, due_at)
+    VALUES ($1, $2)
+    ON CONFLICT (invoice_id) DO UPDATE
+    SET submitted_at = now(), due_at = EXCLUDED.due_at, escalations = 0, last_escalated_at = NULL, resolved_at = NULL
+  `
+
+	_, err := tx.ExecContext(ctx, query, invoiceID, dueAt)
+	return err
+}
+
+// OpenMissingReviews starts the review clock of submitted emergency-lane
+// invoices that have none, counting sla from their last update, and returns
+// how many it started.
+func (r *EmergencyRepository) OpenMissingReviews(ctx context.Context, sla time.Duration) (int64, error) {
+	query := `
+    INSERT INTO emergency_reviews (invoice_id, submitted_at, due_at)
+    SELECT i.id, i.updated_at, i.updated_at + $2 * interval '1 second'
+    FROM invoices i
+    WHERE i.emergency_lane AND i.status = $1
+      AND NOT EXISTS (SELECT 1 FROM emergency_reviews r WHERE r.invoice_id = i.id)
+    ON CONFLICT (invoice_id) DO NOTHING
+  `
+
+	result, err := r.db.ExecContext(ctx, query, domain.InvoiceStatusSubmitted, sla.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ResolveReview stops the review clock; invoices outside the emergency lane
+// have no review and are left alone.
+func (r *EmergencyRepository) ResolveReview(ctx context.Context, tx *sqlx.Tx, invoiceID string) error {
+	_, err := tx.ExecContext(ctx, "UPDATE emergency_reviews SET resolved_at = now() WHERE invoice_id = $1 AND resolved_at IS NULL", invoiceID)
+	return err
+}
+
+// emergencyQueueOrder puts the nearest deadline first.
+var emergencyQueueOrder = keyset{name: "due_at", expr: "r.due_at", exprType: "timestamptz", idExpr: "i.id", idType: "uuid"}
+
+func (r *EmergencyRepository) Queue(ctx context.Context, page pagination.Page) ([]domain.EmergencyQueueItem, pagination.Meta, error) {
+	args := []any{domain.InvoiceStatusSubmitted}
+	conditions := []string{"r.resolved_at IS NULL", "i.status = $1"}
+
+	var meta pagination.Meta
+	if page.IncludeTotal {
+		var total int
+		countQuery := fmt.Sprintf("SELECT count(*) FROM emergency_reviews r JOIN invoices i ON i.id = r.invoice_id WHERE %s", strings.Join(conditions, " AND "))
+		if err := r.db.GetContext(ctx, &total, countQuery, args...); err != nil {
+			return nil, meta, err
+		}
+		meta.Total = &total
+	}
+
+	if page.After != nil {
+		condition, afterArgs, err := emergencyQueueOrder.after(page.After, args)
+		if err != nil {
+			return nil, meta, err
+		}
+		conditions = append(conditions, condition)
+		args = afterArgs
+	}
+
+	limit := pageLimit(page)
+	query := fmt.Sprintf(`
+    SELECT i.id, i.issuer_id, i.organization_id, i.title, i.invoice_number, i.amount, i.currency, i.term_months,
+      i.due_date, i.risk_tier, i.apr_percent, i.funding_target, i.funded_amount, i.paid_amount, i.status,
+      i.emergency_lane, i.tags, i.debtor_name, i.created_at, i.updated_at,
+      r.submitted_at, r.due_at, r.due_at <= now() AS overdue, r.escalations, r.last_escalated_at, %s
+    FROM emergency_reviews r
+    JOIN invoices i ON i.id = r.invoice_id
+    WHERE %s
+    ORDER BY %s
+    LIMIT %d
+  `, emergencyQueueOrder.columns(), strings.Join(conditions, " AND "), emergencyQueueOrder.orderBy(), limit+1)
+
+	var rows []struct {
+		domain.EmergencyQueueItem
+		cursorColumns
+	}
+	if err := r.db.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, meta, err
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		meta.NextCursor = emergencyQueueOrder.cursorAt(rows[limit-1].cursorColumns)
+	}
+
+	items := make([]domain.EmergencyQueueItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, row.EmergencyQueueItem)
+	}
+
+	return items, meta, nil
+}
+
+// ClaimOverdue locks up to limit open reviews past their deadline that were
+// not escalated within the last interval, together with their invoices.
+// Reviews or invoices locked elsewhere, by another instance or by a review
+// decision in flight, are skipped until the next run.
+func (r *EmergencyRepository) ClaimOverdue(ctx context.Context, tx *sqlx.Tx, interval time.Duration, limit int) ([]domain.EmergencyReview, error) {
+	query := `
+    SELECT r.invoice_id, r.submitted_at, r.due_at, r.escalations, r.last_escalated_at, r.resolved_at
+    FROM emergency_reviews r
+    JOIN invoices i ON i.id = r.invoice_id
+    WHERE r.resolved_at IS NULL AND i.status = $1 AND r.due_at <= now()
+      AND (r.last_escalated_at IS NULL OR r.last_escalated_at <= now() - $2 * interval '1 second')
+    ORDER BY r.due_at
+    LIMIT $3
+    FOR UPDATE OF r, i SKIP LOCKED
+  `
+
+	reviews := []domain.EmergencyReview{}
+	if err := tx.SelectContext(ctx, &reviews, query, domain.InvoiceStatusSubmitted, interval.Seconds(), limit); err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
+
+func (r *EmergencyRepository) MarkEscalated(ctx context.Context, tx *sqlx.Tx, invoiceID string) (*domain.EmergencyReview, error) {
+	query := `
+    UPDATE emergency_reviews
+    SET escalations = escalations + 1, last_escalated_at = now()
+    WHERE invoice_id = $1
+    RETURNING invoice_id, submitted_at, due_at, escalations, last_escalated_at, resolved_at
+  `
+
+	var review domain.EmergencyReview
+	if err := tx.GetContext(ctx, &review, query, invoiceID); err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
@@ -2,8 +2,11 @@ package repositories
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"invoiceflow/internal/domain"
+	"invoiceflow/internal/pagination"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -16,22 +19,28 @@ func NewFundingRepository(db *sqlx.DB) *FundingRepository {
 	return &FundingRepository{db: db}
 }
 
+const fundingColumns = `id, invoice_id, investor_id, organization_id, amount, apr_percent, term_months, status, tx_hash,
+      created_at, confirmed_at, settled_at, source_funding_id`
+
 func (r *FundingRepository) Create(ctx context.Context, tx *sqlx.Tx, funding *domain.Funding) (*domain.Funding, error) {
 	query := `
-    INSERT INTO fundings (invoice_id, investor_id, amount, apr_percent, term_months, status, tx_hash)
-    VALUES ($1,$2,$3,$4,$5,$6,$7)
-    RETURNING id, invoice_id, investor_id, amount, apr_percent, term_months, status, tx_hash, created_at, confirmed_at, settled_at
-  `
+    INSERT INTO fundings (invoice_id, investor_id, organization_id, amount, apr_percent, term_months, status, tx_hash,
+      confirmed_at, source_funding_id)
+    VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+    RETURNING ` + fundingColumns
 
 	var created domain.Funding
 	if err := tx.GetContext(ctx, &created, query,
 		funding.InvoiceID,
 		funding.InvestorID,
+		funding.OrganizationID,
 		funding.Amount,
 		funding.APRPercent,
 		funding.TermMonths,
 		funding.Status,
 		funding.TxHash,
+		funding.ConfirmedAt,
+		funding.SourceFundingID,
 	); err != nil {
 		return nil, err
 	}
@@ -39,29 +48,114 @@ func (r *FundingRepository) Create(ctx context.Context, tx *sqlx.Tx, funding *do
 	return &created, nil
 }
 
-func (r *FundingRepository) ListByInvestor(ctx context.Context, investorID string, limit int, offset int) ([]domain.Funding, int, error) {
-	if limit <= 0 {
-		limit = 20
-	}
+func (r *FundingRepository) GetForUpdate(ctx context.Context, tx *sqlx.Tx, id string) (*domain.Funding, error) {
+	query := `
+    SELECT ` + fundingColumns + `
+    FROM fundings
+    WHERE id = $1
+    FOR UPDATE
+  `
 
-	var total int
-	if err := r.db.GetContext(ctx, &total, "SELECT count(*) FROM fundings WHERE investor_id = $1", investorID); err != nil {
-		return nil, 0, err
+	var funding domain.Funding
+	if err := tx.GetContext(ctx, &funding, query, id); err != nil {
+		return nil, err
 	}
 
+	return &funding, nil
+}
+
+// ConfirmForInvoice confirms the pending fundings of an invoice once it is
+// fully funded.
+func (r *FundingRepository) ConfirmForInvoice(ctx context.Context, tx *sqlx.Tx, invoiceID string) error {
+	_, err := tx.ExecContext(ctx, "UPDATE fundings SET status = $2, confirmed_at = now() WHERE invoice_id = $1 AND status = $3", invoiceID, domain.FundingStatusConfirmed, domain.FundingStatusPending)
+	return err
+}
+
+// ReduceAmount takes a sold principal off a position, moving it to status
+// once nothing is left of it.
+func (r *FundingRepository) ReduceAmount(ctx context.Context, tx *sqlx.Tx, id string, amount float64, status string) (*domain.Funding, error) {
 	query := `
-    SELECT id, invoice_id, investor_id, amount, apr_percent, term_months, status, tx_hash,
-      created_at, confirmed_at, settled_at
+    UPDATE fundings
+    SET amount = amount - $2, status = CASE WHEN amount - $2 <= 0 THEN $3 ELSE status END
+    WHERE id = $1
+    RETURNING ` + fundingColumns
+
+	var updated domain.Funding
+	if err := tx.GetContext(ctx, &updated, query, id, amount, status); err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+// ListByOrganizations lists fundings placed on behalf of any of the given
+// organizations.
+func (r *FundingRepository) ListByOrganizations(ctx context.Context, organizationIDs []string, page pagination.Page) ([]domain.Funding, pagination.Meta, error) {
+	args := []any{}
+	placeholders := []string{"NULL"}
+	for _, id := range organizationIDs {
+		args = append(args, id)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+	}
+	conditions := []string{fmt.Sprintf("organization_id IN (%s)", strings.Join(placeholders, ","))}
+
+	var meta pagination.Meta
+	if page.IncludeTotal {
+		var total int
+		if err := r.db.GetContext(ctx, &total, fmt.Sprintf("SELECT count(*) FROM fundings WHERE %s", strings.Join(conditions, " AND ")), args...); err != nil {
+			return nil, meta, err
+		}
+		meta.Total = &total
+	}
+
+	if page.After != nil {
+		condition, afterArgs, err := fundingOrder.after(page.After, args)
+		if err != nil {
+			return nil, meta, err
+		}
+		conditions = append(conditions, condition)
+		args = afterArgs
+	}
+
+	limit := pageLimit(page)
+	listQuery := fmt.Sprintf(`
+    SELECT %s, %s
     FROM fundings
-    WHERE investor_id = $1
-    ORDER BY created_at DESC
-    LIMIT $2 OFFSET $3
-  `
+    WHERE %s
+    ORDER BY %s
+    LIMIT %d
+  `, fundingColumns, fundingOrder.columns(), strings.Join(conditions, " AND "), fundingOrder.orderBy(), limit+1)
+
+	var rows []struct {
+		domain.Funding
+		cursorColumns
+	}
+	if err := r.db.SelectContext(ctx, &rows, listQuery, args...); err != nil {
+		return nil, meta, err
+	}
 
-	fundings := []domain.Funding{}
-	if err := r.db.SelectContext(ctx, &fundings, query, investorID, limit, offset); err != nil {
-		return nil, 0, err
+	if len(rows) > limit {
+		rows = rows[:limit]
+		meta.NextCursor = fundingOrder.cursorAt(rows[limit-1].cursorColumns)
+	}
+
+	fundings := make([]domain.Funding, 0, len(rows))
+	for _, row := range rows {
+		fundings = append(fundings, row.Funding)
+	}
+
+	return fundings, meta, nil
+}
+
+var fundingOrder = keyset{name: "created_at", expr: "created_at", exprType: "timestamptz", desc: true, idExpr: "id", idType: "uuid"}
+
+// OrganizationIDsForInvoice returns the organizations holding a funding in
+// the invoice.
+func (r *FundingRepository) OrganizationIDsForInvoice(ctx context.Context, tx *sqlx.Tx, invoiceID string) ([]string, error) {
+	ids := []string{}
+	if err := tx.SelectContext(ctx, &ids, "SELECT DISTINCT organization_id FROM fundings WHERE invoice_id = $1", invoiceID); err != nil {
+		return nil, err
 	}
 
-	return fundings, total, nil
+	return ids, nil
 }
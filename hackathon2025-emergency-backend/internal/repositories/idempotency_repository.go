@@ -0,0 +1,96 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"invoiceflow/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type IdempotencyRepository struct {
+	db *sqlx.DB
+}
+
+func NewIdempotencyRepository(db *sqlx.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Reserve claims (user, organization, key) for a new request. It returns
+// reserved=true when the caller now owns the key; otherwise it returns the
+// record already held for it. Expired keys of the user are dropped first. An
+// IN_PROGRESS key is never taken over: its request may have committed before
+// dying, so running it again could repeat the write.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, record *domain.IdempotencyRecord) (*domain.IdempotencyRecord, bool, error) {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE user_id = $1 AND expires_at < now()", record.UserID); err != nil {
+		return nil, false, err
+	}
+
+	query := `
+    INSERT INTO idempotency_keys (user_id, organization_id, key, method, path, fingerprint, status, expires_at)
+    VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
+    ON CONFLICT (user_id, organization_id, key) DO NOTHING
+    RETURNING user_id
+  `
+
+	var owner string
+	err := r.db.GetContext(ctx, &owner, query,
+		record.UserID, record.OrganizationID, record.Key, record.Method, record.Path, record.Fingerprint,
+		domain.IdempotencyStatusInProgress, record.ExpiresAt,
+	)
+	if err == nil {
+		return nil, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, err
+	}
+
+	existing, err := r.Get(ctx, record.UserID, record.OrganizationID, record.Key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return existing, false, nil
+}
+
+func (r *IdempotencyRepository) Get(ctx context.Context, userID string, organizationID string, key string) (*domain.IdempotencyRecord, error) {
+	query := `
+    SELECT user_id, organization_id, key, method, path, fingerprint, status, response_status,
+      response_content_type, response_body, created_at, completed_at, expires_at
+    FROM idempotency_keys
+    WHERE user_id = $1 AND organization_id = $2 AND key = $3
+  `
+
+	var record domain.IdempotencyRecord
+	if err := r.db.GetContext(ctx, &record, query, userID, organizationID, key); err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+// Complete stores the response to replay for the key.
+func (r *IdempotencyRepository) Complete(ctx context.Context, record *domain.IdempotencyRecord, status int, contentType string, body []byte) error {
+	query := `
+    UPDATE idempotency_keys
+    SET status = $4, response_status = $5, response_content_type = $6, response_body = $7, completed_at = now()
+    WHERE user_id = $1 AND organization_id = $2 AND key = $3
+  `
+
+	_, err := r.db.ExecContext(ctx, query, record.UserID, record.OrganizationID, record.Key,
+		domain.IdempotencyStatusCompleted, status, contentType, body)
+	return err
+}
+
+// Release forgets an unfinished key so the client may retry with it.
+func (r *IdempotencyRepository) Release(ctx context.Context, record *domain.IdempotencyRecord) error {
+	query := `
+    DELETE FROM idempotency_keys
+    WHERE user_id = $1 AND organization_id = $2 AND key = $3 AND status = $4
+  `
+
+	_, err := r.db.ExecContext(ctx, query, record.UserID, record.OrganizationID, record.Key, domain.IdempotencyStatusInProgress)
+	return err
+}
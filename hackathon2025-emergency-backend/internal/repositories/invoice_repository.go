@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"invoiceflow/internal/domain"
+	"invoiceflow/internal/pagination"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -21,18 +23,19 @@ func NewInvoiceRepository(db *sqlx.DB) *InvoiceRepository {
 func (r *InvoiceRepository) Create(ctx context.Context, invoice *domain.Invoice) (*domain.Invoice, error) {
 	query := `
     INSERT INTO invoices (
-      issuer_id, title, invoice_number, amount, currency, term_months, due_date,
-      risk_tier, apr_percent, funding_target, funded_amount, status, emergency_lane, tags
+      issuer_id, organization_id, title, invoice_number, amount, currency, term_months, due_date,
+      risk_tier, apr_percent, funding_target, funded_amount, status, emergency_lane, tags, debtor_name
     )
-    VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14)
-    RETURNING id, issuer_id, title, invoice_number, amount, currency, term_months, due_date,
-      risk_tier, apr_percent, funding_target, funded_amount, status, emergency_lane, tags,
+    VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16)
+    RETURNING id, issuer_id, organization_id, title, invoice_number, amount, currency, term_months, due_date,
+      risk_tier, apr_percent, funding_target, funded_amount, paid_amount, status, emergency_lane, tags, debtor_name,
       created_at, updated_at
   `
 
 	var created domain.Invoice
 	err := r.db.GetContext(ctx, &created, query,
 		invoice.IssuerID,
+		invoice.OrganizationID,
 		invoice.Title,
 		invoice.InvoiceNumber,
 		invoice.Amount,
@@ -46,6 +49,7 @@ func (r *InvoiceRepository) Create(ctx context.Context, invoice *domain.Invoice)
 		invoice.Status,
 		invoice.EmergencyLane,
 		invoice.Tags,
+		invoice.DebtorName,
 	)
 	if err != nil {
 		return nil, err
@@ -69,8 +73,8 @@ func getInvoice(ctx context.Context, ext sqlx.ExtContext, id string, forUpdate b
 	}
 
 	query := fmt.Sprintf(`
-    SELECT id, issuer_id, title, invoice_number, amount, currency, term_months, due_date,
-      risk_tier, apr_percent, funding_target, funded_amount, status, emergency_lane, tags,
+    SELECT id, issuer_id, organization_id, title, invoice_number, amount, currency, term_months, due_date,
+      risk_tier, apr_percent, funding_target, funded_amount, paid_amount, status, emergency_lane, tags, debtor_name,
       created_at, updated_at
     FROM invoices
     WHERE id = $1%s
@@ -84,7 +88,7 @@ func getInvoice(ctx context.Context, ext sqlx.ExtContext, id string, forUpdate b
 	return &invoice, nil
 }
 
-func (r *InvoiceRepository) List(ctx context.Context, filters InvoiceFilters) ([]domain.Invoice, int, error) {
+func (r *InvoiceRepository) List(ctx context.Context, filters InvoiceFilters) ([]domain.Invoice, pagination.Meta, error) {
 	conditions := []string{"1=1"}
 	args := []any{}
 
@@ -93,90 +97,288 @@ func (r *InvoiceRepository) List(ctx context.Context, filters InvoiceFilters) ([
 		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
 	}
 
-	if filters.IssuerID != "" {
-		args = append(args, filters.IssuerID)
-		conditions = append(conditions, fmt.Sprintf("issuer_id = $%d", len(args)))
+	if condition, scoped := invoiceScopeCondition(filters.OrganizationIDs, filters.ListedStatuses, &args); scoped {
+		conditions = append(conditions, condition)
 	}
 
-	if filters.AllowedStatuses != nil && len(filters.AllowedStatuses) > 0 {
+	if len(filters.RiskTiers) > 0 {
 		placeholders := []string{}
-		for _, status := range filters.AllowedStatuses {
-			args = append(args, status)
+		for _, tier := range filters.RiskTiers {
+			args = append(args, tier)
 			placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
 		}
-		conditions = append(conditions, fmt.Sprintf("status IN (%s)", strings.Join(placeholders, ",")))
+		conditions = append(conditions, fmt.Sprintf("risk_tier IN (%s)", strings.Join(placeholders, ",")))
 	}
 
-	where := strings.Join(conditions, " AND ")
+	if filters.MinAPR != nil {
+		args = append(args, *filters.MinAPR)
+		conditions = append(conditions, fmt.Sprintf("apr_percent >= $%d", len(args)))
+	}
+
+	if filters.MaxAPR != nil {
+		args = append(args, *filters.MaxAPR)
+		conditions = append(conditions, fmt.Sprintf("apr_percent <= $%d", len(args)))
+	}
+
+	if filters.MinAmount != nil {
+		args = append(args, *filters.MinAmount)
+		conditions = append(conditions, fmt.Sprintf("amount >= $%d", len(args)))
+	}
+
+	if filters.MaxAmount != nil {
+		args = append(args, *filters.MaxAmount)
+		conditions = append(conditions, fmt.Sprintf("amount <= $%d", len(args)))
+	}
+
+	if filters.MinTermMonths != nil {
+		args = append(args, *filters.MinTermMonths)
+		conditions = append(conditions, fmt.Sprintf("term_months >= $%d", len(args)))
+	}
+
+	if filters.MaxTermMonths != nil {
+		args = append(args, *filters.MaxTermMonths)
+		conditions = append(conditions, fmt.Sprintf("term_months <= $%d", len(args)))
+	}
+
+	if filters.DueFrom != nil {
+		args = append(args, *filters.DueFrom)
+		conditions = append(conditions, fmt.Sprintf("due_date >= $%d", len(args)))
+	}
+
+	if filters.DueTo != nil {
+		args = append(args, *filters.DueTo)
+		conditions = append(conditions, fmt.Sprintf("due_date <= $%d", len(args)))
+	}
+
+	if filters.MinRemaining != nil {
+		args = append(args, *filters.MinRemaining)
+		conditions = append(conditions, fmt.Sprintf("funding_target - funded_amount >= $%d", len(args)))
+	}
+
+	if filters.MaxRemaining != nil {
+		args = append(args, *filters.MaxRemaining)
+		conditions = append(conditions, fmt.Sprintf("funding_target - funded_amount <= $%d", len(args)))
+	}
+
+	if filters.Currency != "" {
+		args = append(args, filters.Currency)
+		conditions = append(conditions, fmt.Sprintf("currency = $%d", len(args)))
+	}
 
-	countQuery := fmt.Sprintf("SELECT count(*) FROM invoices WHERE %s", where)
-	var total int
-	if err := r.db.GetContext(ctx, &total, countQuery, args...); err != nil {
-		return nil, 0, err
+	if filters.EmergencyLane != nil {
+		args = append(args, *filters.EmergencyLane)
+		conditions = append(conditions, fmt.Sprintf("emergency_lane = $%d", len(args)))
+	}
+
+	if len(filters.Tags) > 0 {
+		args = append(args, domain.StringSlice(filters.Tags))
+		conditions = append(conditions, fmt.Sprintf("tags @> $%d", len(args)))
+	}
+
+	order, ok := invoiceSorts[filters.Sort]
+	if !ok {
+		order = invoiceSorts[InvoiceSortNewest]
+	}
+
+	if filters.Query != "" {
+		args = append(args, filters.Query)
+		conditions = append(conditions, fmt.Sprintf("title_search @@ websearch_to_tsquery('english', $%d)", len(args)))
+		if filters.Sort == "" {
+			order = keyset{
+				name:     invoiceSortRelevance,
+				expr:     fmt.Sprintf("ts_rank(title_search, websearch_to_tsquery('english', $%d))", len(args)),
+				exprType: "real",
+				desc:     true,
+				idExpr:   "id",
+				idType:   "uuid",
+			}
+		}
 	}
 
-	limit := filters.Limit
-	offset := filters.Offset
-	if limit <= 0 {
-		limit = 20
+	if filters.PrioritizeEmergency {
+		order.priority = invoiceEmergencyOpen
 	}
 
+	var meta pagination.Meta
+	if filters.Page.IncludeTotal {
+		countQuery := fmt.Sprintf("SELECT count(*) FROM invoices WHERE %s", strings.Join(conditions, " AND "))
+		var total int
+		if err := r.db.GetContext(ctx, &total, countQuery, args...); err != nil {
+			return nil, meta, err
+		}
+		meta.Total = &total
+	}
+
+	if filters.Page.After != nil {
+		condition, afterArgs, err := order.after(filters.Page.After, args)
+		if err != nil {
+			return nil, meta, err
+		}
+		conditions = append(conditions, condition)
+		args = afterArgs
+	}
+
+	limit := pageLimit(filters.Page)
 	listQuery := fmt.Sprintf(`
-    SELECT id, issuer_id, title, invoice_number, amount, currency, term_months, due_date,
-      risk_tier, apr_percent, funding_target, funded_amount, status, emergency_lane, tags,
-      created_at, updated_at
+    SELECT id, issuer_id, organization_id, title, invoice_number, amount, currency, term_months, due_date,
+      risk_tier, apr_percent, funding_target, funded_amount, paid_amount, status, emergency_lane, tags, debtor_name,
+      created_at, updated_at, %s
     FROM invoices
     WHERE %s
-    ORDER BY created_at DESC
-    LIMIT %d OFFSET %d
-  `, where, limit, offset)
+    ORDER BY %s
+    LIMIT %d
+  `, order.columns(), strings.Join(conditions, " AND "), order.orderBy(), limit+1)
 
-	invoices := []domain.Invoice{}
-	if err := r.db.SelectContext(ctx, &invoices, listQuery, args...); err != nil {
-		return nil, 0, err
+	var rows []struct {
+		domain.Invoice
+		cursorColumns
+	}
+	if err := r.db.SelectContext(ctx, &rows, listQuery, args...); err != nil {
+		return nil, meta, err
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		meta.NextCursor = order.cursorAt(rows[limit-1].cursorColumns)
 	}
 
-	return invoices, total, nil
+	invoices := make([]domain.Invoice, 0, len(rows))
+	for _, row := range rows {
+		invoices = append(invoices, row.Invoice)
+	}
+
+	return invoices, meta, nil
+}
+
+// invoiceScopeCondition matches invoices of the organizations or in the
+// listed statuses. NULL keeps an empty IN list valid SQL, and matching
+// nothing, for callers without memberships.
+func invoiceScopeCondition(organizationIDs []string, listedStatuses []string, args *[]any) (string, bool) {
+	scopes := []string{}
+	for _, scope := range []struct {
+		column string
+		values []string
+	}{
+		{"organization_id", organizationIDs},
+		{"status", listedStatuses},
+	} {
+		if scope.values == nil {
+			continue
+		}
+		placeholders := []string{"NULL"}
+		for _, value := range scope.values {
+			*args = append(*args, value)
+			placeholders = append(placeholders, fmt.Sprintf("$%d", len(*args)))
+		}
+		scopes = append(scopes, fmt.Sprintf("%s IN (%s)", scope.column, strings.Join(placeholders, ",")))
+	}
+
+	if len(scopes) == 0 {
+		return "", false
+	}
+	return "(" + strings.Join(scopes, " OR ") + ")", true
 }
 
 type InvoiceFilters struct {
-	Status          string
-	IssuerID        string
-	AllowedStatuses []string
-	Limit           int
-	Offset          int
+	Status string
+	// OrganizationIDs and ListedStatuses scope the list: an invoice is
+	// included when it belongs to one of the organizations or is in one of
+	// the listed statuses. Both nil lists every invoice.
+	OrganizationIDs []string
+	ListedStatuses  []string
+	RiskTiers       []string
+	MinAPR          *float64
+	MaxAPR          *float64
+	MinAmount       *float64
+	MaxAmount       *float64
+	MinTermMonths   *int
+	MaxTermMonths   *int
+	DueFrom         *time.Time
+	DueTo           *time.Time
+	Currency        string
+	EmergencyLane   *bool
+	// Tags must all be present on the invoice.
+	Tags         []string
+	MinRemaining *float64
+	MaxRemaining *float64
+	// Query is a full-text search on the title, in web search syntax.
+	Query string
+	Sort  string
+	// PrioritizeEmergency lists emergency-lane invoices still open for
+	// funding ahead of the rest, whatever the sort.
+	PrioritizeEmergency bool
+	Page                pagination.Page
+}
+
+const (
+	InvoiceSortNewest            = "newest"
+	InvoiceSortAPRDesc           = "-apr"
+	InvoiceSortAPRAsc            = "apr"
+	InvoiceSortDueDateAsc        = "due_date"
+	InvoiceSortDueDateDesc       = "-due_date"
+	InvoiceSortPercentFundedAsc  = "percent_funded"
+	InvoiceSortPercentFundedDesc = "-percent_funded"
+
+	// invoiceSortRelevance is the order of a title search without an
+	// explicit sort; clients cannot ask for it directly.
+	invoiceSortRelevance = "relevance"
+)
+
+const invoicePercentFunded = "funded_amount / NULLIF(funding_target, 0)"
+
+var invoiceEmergencyOpen = fmt.Sprintf("emergency_lane AND status IN ('%s', '%s')", domain.InvoiceStatusApproved, domain.InvoiceStatusTokenized)
+
+// invoiceSorts maps each accepted sort to its keyset; id breaks ties so
+// pages are stable.
+var invoiceSorts = map[string]keyset{
+	InvoiceSortNewest:            {name: InvoiceSortNewest, expr: "created_at", exprType: "timestamptz", desc: true, idExpr: "id", idType: "uuid"},
+	InvoiceSortAPRAsc:            {name: InvoiceSortAPRAsc, expr: "apr_percent", exprType: "numeric", nullable: true, idExpr: "id", idType: "uuid"},
+	InvoiceSortAPRDesc:           {name: InvoiceSortAPRDesc, expr: "apr_percent", exprType: "numeric", nullable: true, desc: true, idExpr: "id", idType: "uuid"},
+	InvoiceSortDueDateAsc:        {name: InvoiceSortDueDateAsc, expr: "due_date", exprType: "date", idExpr: "id", idType: "uuid"},
+	InvoiceSortDueDateDesc:       {name: InvoiceSortDueDateDesc, expr: "due_date", exprType: "date", desc: true, idExpr: "id", idType: "uuid"},
+	InvoiceSortPercentFundedAsc:  {name: InvoiceSortPercentFundedAsc, expr: invoicePercentFunded, exprType: "numeric", nullable: true, idExpr: "id", idType: "uuid"},
+	InvoiceSortPercentFundedDesc: {name: InvoiceSortPercentFundedDesc, expr: invoicePercentFunded, exprType: "numeric", nullable: true, desc: true, idExpr: "id", idType: "uuid"},
+}
+
+func ValidInvoiceSort(sort string) bool {
+	_, ok := invoiceSorts[sort]
+	return ok
 }
 
-func (r *InvoiceRepository) UpdateStatus(ctx context.Context, id string, status string) (*domain.Invoice, error) {
+// UpdateStatus also stamps submitted_at and closed_at, which the dashboard
+// rollups measure lifecycle times from.
+func (r *InvoiceRepository) UpdateStatus(ctx context.Context, tx *sqlx.Tx, id string, status string) (*domain.Invoice, error) {
 	query := `
     UPDATE invoices
-    SET status = $2, updated_at = now()
+    SET status = $2, updated_at = now(),
+        submitted_at = CASE WHEN $2 = $3 THEN now() ELSE submitted_at END,
+        closed_at = CASE WHEN $2 = $4 THEN now() ELSE closed_at END
     WHERE id = $1
-    RETURNING id, issuer_id, title, invoice_number, amount, currency, term_months, due_date,
-      risk_tier, apr_percent, funding_target, funded_amount, status, emergency_lane, tags,
+    RETURNING id, issuer_id, organization_id, title, invoice_number, amount, currency, term_months, due_date,
+      risk_tier, apr_percent, funding_target, funded_amount, paid_amount, status, emergency_lane, tags, debtor_name,
       created_at, updated_at
   `
 
 	var invoice domain.Invoice
-	if err := r.db.GetContext(ctx, &invoice, query, id, status); err != nil {
+	if err := tx.GetContext(ctx, &invoice, query, id, status, domain.InvoiceStatusSubmitted, domain.InvoiceStatusDefaulted); err != nil {
 		return nil, err
 	}
 
 	return &invoice, nil
 }
 
-func (r *InvoiceRepository) Approve(ctx context.Context, id string, riskTier string, aprPercent float64) (*domain.Invoice, error) {
+func (r *InvoiceRepository) Approve(ctx context.Context, tx *sqlx.Tx, id string, riskTier string, aprPercent float64) (*domain.Invoice, error) {
 	query := `
     UPDATE invoices
-    SET status = $2, risk_tier = $3, apr_percent = $4, updated_at = now()
+    SET status = $2, risk_tier = $3, apr_percent = $4, approved_at = now(), updated_at = now()
     WHERE id = $1
-    RETURNING id, issuer_id, title, invoice_number, amount, currency, term_months, due_date,
-      risk_tier, apr_percent, funding_target, funded_amount, status, emergency_lane, tags,
+    RETURNING id, issuer_id, organization_id, title, invoice_number, amount, currency, term_months, due_date,
+      risk_tier, apr_percent, funding_target, funded_amount, paid_amount, status, emergency_lane, tags, debtor_name,
       created_at, updated_at
   `
 
 	var invoice domain.Invoice
-	if err := r.db.GetContext(ctx, &invoice, query, id, domain.InvoiceStatusApproved, riskTier, aprPercent); err != nil {
+	if err := tx.GetContext(ctx, &invoice, query, id, domain.InvoiceStatusApproved, riskTier, aprPercent); err != nil {
 		return nil, err
 	}
 
@@ -186,33 +388,36 @@ func (r *InvoiceRepository) Approve(ctx context.Context, id string, riskTier str
 func (r *InvoiceRepository) UpdateFunding(ctx context.Context, tx *sqlx.Tx, id string, fundedAmount float64, status string) (*domain.Invoice, error) {
 	query := `
     UPDATE invoices
-    SET funded_amount = $2, status = $3, updated_at = now()
+    SET funded_amount = $2, status = $3, updated_at = now(),
+        funded_at = CASE WHEN $3 = $4 THEN COALESCE(funded_at, now()) ELSE funded_at END
     WHERE id = $1
-    RETURNING id, issuer_id, title, invoice_number, amount, currency, term_months, due_date,
-      risk_tier, apr_percent, funding_target, funded_amount, status, emergency_lane, tags,
+    RETURNING id, issuer_id, organization_id, title, invoice_number, amount, currency, term_months, due_date,
+      risk_tier, apr_percent, funding_target, funded_amount, paid_amount, status, emergency_lane, tags, debtor_name,
       created_at, updated_at
   `
 
 	var invoice domain.Invoice
-	if err := tx.GetContext(ctx, &invoice, query, id, fundedAmount, status); err != nil {
+	if err := tx.GetContext(ctx, &invoice, query, id, fundedAmount, status, domain.InvoiceStatusFunded); err != nil {
 		return nil, err
 	}
 
 	return &invoice, nil
 }
 
-func (r *InvoiceRepository) MarkPaid(ctx context.Context, id string, status string) (*domain.Invoice, error) {
+// MarkPaid adds amount to the invoice's running paid total.
+func (r *InvoiceRepository) MarkPaid(ctx context.Context, tx *sqlx.Tx, id string, amount float64, status string) (*domain.Invoice, error) {
 	query := `
     UPDATE invoices
-    SET status = $2, updated_at = now()
+    SET paid_amount = paid_amount + $3, status = $2, updated_at = now(),
+        closed_at = CASE WHEN $2 = $4 THEN now() ELSE closed_at END
     WHERE id = $1
-    RETURNING id, issuer_id, title, invoice_number, amount, currency, term_months, due_date,
-      risk_tier, apr_percent, funding_target, funded_amount, status, emergency_lane, tags,
+    RETURNING id, issuer_id, organization_id, title, invoice_number, amount, currency, term_months, due_date,
+      risk_tier, apr_percent, funding_target, funded_amount, paid_amount, status, emergency_lane, tags, debtor_name,
       created_at, updated_at
   `
 
 	var invoice domain.Invoice
-	if err := r.db.GetContext(ctx, &invoice, query, id, status); err != nil {
+	if err := tx.GetContext(ctx, &invoice, query, id, status, amount, domain.InvoiceStatusPaid); err != nil {
 		return nil, err
 	}
 
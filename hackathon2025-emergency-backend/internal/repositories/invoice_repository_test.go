@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInvoiceScopeCondition(t *testing.T) {
+	cases := []struct {
+		name      string
+		orgs      []string
+		listed    []string
+		condition string
+		scoped    bool
+		args      []any
+	}{
+		{"unscoped", nil, nil, "", false, []any{"PENDING"}},
+		{"no memberships matches nothing", []string{}, nil, "(organization_id IN (NULL))", true, []any{"PENDING"}},
+		{"organizations", []string{"org-1", "org-2"}, nil, "(organization_id IN (NULL,$2,$3))", true, []any{"PENDING", "org-1", "org-2"}},
+		{"listed", nil, []string{"APPROVED"}, "(status IN (NULL,$2))", true, []any{"PENDING", "APPROVED"}},
+		{"organizations or listed", []string{"org-1"}, []string{"APPROVED", "FUNDED"}, "(organization_id IN (NULL,$2) OR status IN (NULL,$3,$4))", true, []any{"PENDING", "org-1", "APPROVED", "FUNDED"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			args := []any{"PENDING"}
+			condition, scoped := invoiceScopeCondition(tc.orgs, tc.listed, &args)
+			if condition != tc.condition || scoped != tc.scoped {
+				t.Fatalf("got (%q, %v), want (%q, %v)", condition, scoped, tc.condition, tc.scoped)
+			}
+			if !reflect.DeepEqual(args, tc.args) {
+				t.Fatalf("args = %v, want %v", args, tc.args)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"invoiceflow/internal/pagination"
+
+	"github.com/google/uuid"
+)
+
+// maxCursorFieldLength bounds the cursor fields accepted from clients.
+const maxCursorFieldLength = 256
+
+// keyset orders a list by expr, with idExpr as the tie-break, and pages
+// through it by remembering where the previous page ended instead of using
+// OFFSET. exprType and idType are the SQL types the text form of a cursor is
+// cast back to. priority, when set, is a boolean expression whose true rows
+// come before all others.
+type keyset struct {
+	name     string
+	expr     string
+	exprType string
+	nullable bool
+	desc     bool
+	idExpr   string
+	idType   string
+	priority string
+}
+
+// cursorColumns are selected alongside each row so the next cursor can be
+// built from the last one.
+type cursorColumns struct {
+	CursorValue    *string `db:"cursor_value"`
+	CursorID       string  `db:"cursor_id"`
+	CursorPriority *bool   `db:"cursor_priority"`
+}
+
+func (k keyset) columns() string {
+	columns := fmt.Sprintf("(%s)::text AS cursor_value, (%s)::text AS cursor_id", k.expr, k.idExpr)
+	if k.priority != "" {
+		columns += fmt.Sprintf(", (%s) AS cursor_priority", k.priority)
+	}
+	return columns
+}
+
+func (k keyset) orderBy() string {
+	direction := "ASC"
+	if k.desc {
+		direction = "DESC"
+	}
+	order := fmt.Sprintf("%s %s NULLS LAST, %s %s", k.expr, direction, k.idExpr, direction)
+	if k.priority != "" {
+		order = fmt.Sprintf("(%s) DESC, %s", k.priority, order)
+	}
+	return order
+}
+
+// after returns the condition selecting the rows that follow cursor in this
+// order. NULL keys sort last in both directions.
+func (k keyset) after(cursor *pagination.Cursor, args []any) (string, []any, error) {
+	if cursor.Sort != k.name || (cursor.Priority != nil) != (k.priority != "") {
+		return "", nil, pagination.ErrInvalidCursor
+	}
+
+	if k.priority != "" {
+		args = append(args, *cursor.Priority)
+		priority := fmt.Sprintf("$%d::boolean", len(args))
+		condition, keyArgs, err := k.afterKey(cursor, args)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("((%s) < %s OR ((%s) = %s AND %s))", k.priority, priority, k.priority, priority, condition), keyArgs, nil
+	}
+
+	return k.afterKey(cursor, args)
+}
+
+// afterKey is the part of after that compares the sort key and id. The
+// cursor comes from the client, so its fields are checked against the
+// keyset's types before Postgres casts them.
+func (k keyset) afterKey(cursor *pagination.Cursor, args []any) (string, []any, error) {
+	if !castable(k.idType, cursor.ID) || (cursor.Value != nil && !castable(k.exprType, *cursor.Value)) {
+		return "", nil, pagination.ErrInvalidCursor
+	}
+
+	op := ">"
+	if k.desc {
+		op = "<"
+	}
+
+	args = append(args, cursor.ID)
+	id := fmt.Sprintf("$%d::text::%s", len(args), k.idType)
+
+	if cursor.Value == nil {
+		return fmt.Sprintf("(%s IS NULL AND %s %s %s)", k.expr, k.idExpr, op, id), args, nil
+	}
+
+	args = append(args, *cursor.Value)
+	value := fmt.Sprintf("$%d::text::%s", len(args), k.exprType)
+
+	condition := fmt.Sprintf("(%s %s %s OR (%s = %s AND %s %s %s)", k.expr, op, value, k.expr, value, k.idExpr, op, id)
+	if k.nullable {
+		condition += fmt.Sprintf(" OR %s IS NULL", k.expr)
+	}
+	return condition + ")", args, nil
+}
+
+var (
+	numericText = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+	realText    = regexp.MustCompile(`^-?([0-9]+(\.[0-9]*)?|\.[0-9]+)(e[-+]?[0-9]+)?$`)
+)
+
+// timestamptzLayouts are the text forms Postgres gives timestamptz with the
+// ISO DateStyle, for whole-hour and other offsets.
+var timestamptzLayouts = []string{
+	"2006-01-02 15:04:05.999999-07",
+	"2006-01-02 15:04:05.999999-07:00",
+	"2006-01-02 15:04:05.999999-07:00:00",
+}
+
+// castable reports whether value, the text form of a cursor field, can be
+// cast to sqlType. Unknown types are refused.
+func castable(sqlType string, value string) bool {
+	if len(value) > maxCursorFieldLength {
+		return false
+	}
+
+	switch sqlType {
+	case "uuid":
+		_, err := uuid.Parse(value)
+		return err == nil && len(value) == 36
+	case "bigint":
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err == nil
+	case "numeric":
+		return numericText.MatchString(value)
+	case "real":
+		if !realText.MatchString(value) {
+			return false
+		}
+		_, err := strconv.ParseFloat(value, 32)
+		return err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, value)
+		return err == nil
+	case "timestamptz":
+		for _, layout := range timestamptzLayouts {
+			if _, err := time.Parse(layout, value); err == nil {
+				return true
+			}
+		}
+		return false
+	case "text":
+		return value != ""
+	default:
+		return false
+	}
+}
+
+func (k keyset) cursorAt(row cursorColumns) string {
+	return pagination.Cursor{Sort: k.name, Value: row.CursorValue, ID: row.CursorID, Priority: row.CursorPriority}.Encode()
+}
+
+func pageLimit(page pagination.Page) int {
+	if page.Limit <= 0 || page.Limit > pagination.MaxLimit {
+		return pagination.DefaultLimit
+	}
+	return page.Limit
+}
@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"invoiceflow/internal/pagination"
+)
+
+func TestCastable(t *testing.T) {
+	cases := []struct {
+		sqlType string
+		value   string
+		want    bool
+	}{
+		{"uuid", "0b8f6c0e-6d1e-4c53-9f0b-8f3a1f2b3c4d", true},
+		{"uuid", "not-a-uuid", false},
+		{"uuid", "{0b8f6c0e-6d1e-4c53-9f0b-8f3a1f2b3c4d}", false},
+		{"bigint", "9223372036854775807", true},
+		{"bigint", "9223372036854775808", false},
+		{"bigint", "1; DROP TABLE users", false},
+		{"numeric", "-12.50", true},
+		{"numeric", "12", true},
+		{"numeric", "1e5", false},
+		{"numeric", "NaN", false},
+		{"real", "0.0607927", true},
+		{"real", "1e-20", true},
+		{"real", "0x1p-2", false},
+		{"real", "1e+50", false},
+		{"date", "2026-03-01", true},
+		{"date", "2026-02-30", false},
+		{"timestamptz", "2026-03-01 12:34:56.123456+00", true},
+		{"timestamptz", "2026-03-01 12:34:56+05:30", true},
+		{"timestamptz", "2026-03-01", false},
+		{"timestamptz", "yesterday", false},
+		{"text", "inv:1", true},
+		{"text", "", false},
+		{"text", strings.Repeat("a", maxCursorFieldLength+1), false},
+		{"interval", "1 day", false},
+	}
+
+	for _, tc := range cases {
+		if got := castable(tc.sqlType, tc.value); got != tc.want {
+			t.Errorf("castable(%s, %q) = %v, want %v", tc.sqlType, tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestKeysetAfterRejectsUncastableCursor(t *testing.T) {
+	order := keyset{name: "created_at", expr: "created_at", exprType: "timestamptz", desc: true, idExpr: "id", idType: "uuid"}
+	value := "2026-03-01 12:34:56.123456+00"
+	bad := "2026-13-45"
+
+	cases := map[string]*pagination.Cursor{
+		"bad id":                 {Sort: "created_at", Value: &value, ID: "42"},
+		"bad value":              {Sort: "created_at", Value: &bad, ID: "0b8f6c0e-6d1e-4c53-9f0b-8f3a1f2b3c4d"},
+		"bad id with null value": {Sort: "created_at", ID: "x"},
+	}
+	for name, cursor := range cases {
+		if _, _, err := order.after(cursor, nil); !errors.Is(err, pagination.ErrInvalidCursor) {
+			t.Errorf("%s: err = %v, want ErrInvalidCursor", name, err)
+		}
+	}
+
+	cursor := &pagination.Cursor{Sort: "created_at", Value: &value, ID: "0b8f6c0e-6d1e-4c53-9f0b-8f3a1f2b3c4d"}
+	condition, args, err := order.after(cursor, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "(created_at < $2::text::timestamptz OR (created_at = $2::text::timestamptz AND id < $1::text::uuid))"; condition != want {
+		t.Fatalf("condition = %s, want %s", condition, want)
+	}
+	if len(args) != 2 {
+		t.Fatalf("args = %v", args)
+	}
+}
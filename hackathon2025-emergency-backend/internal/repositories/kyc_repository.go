@@ -0,0 +1,180 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/pagination"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type KYCRepository struct {
+	db *sqlx.DB
+}
+
+func NewKYCRepository(db *sqlx.DB) *KYCRepository {
+	return &KYCRepository{db: db}
+}
+
+func (r *KYCRepository) Create(ctx context.Context, submission *domain.KYCSubmission) (*domain.KYCSubmission, error) {
+	query := `
+    INSERT INTO kyc_submissions (user_id, kind, status, details, documents)
+    VALUES ($1,$2,$3,$4,$5)
+    RETURNING id, user_id, kind, status, details, documents, reviewer_id, review_note, submitted_at, reviewed_at
+  `
+
+	var created domain.KYCSubmission
+	if err := r.db.GetContext(ctx, &created, query,
+		submission.UserID,
+		submission.Kind,
+		submission.Status,
+		submission.Details,
+		submission.Documents,
+	); err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (r *KYCRepository) GetLatestByUser(ctx context.Context, userID string) (*domain.KYCSubmission, error) {
+	query := `
+    SELECT id, user_id, kind, status, details, documents, reviewer_id, review_note, submitted_at, reviewed_at
+    FROM kyc_submissions
+    WHERE user_id = $1
+    ORDER BY submitted_at DESC
+    LIMIT 1
+  `
+
+	var submission domain.KYCSubmission
+	if err := r.db.GetContext(ctx, &submission, query, userID); err != nil {
+		return nil, err
+	}
+
+	return &submission, nil
+}
+
+func (r *KYCRepository) GetByID(ctx context.Context, id string) (*domain.KYCQueueItem, error) {
+	query := `
+    SELECT k.id, k.user_id, k.kind, k.status, k.details, k.documents, k.reviewer_id, k.review_note,
+      k.submitted_at, k.reviewed_at, u.name AS user_name, u.email AS user_email, u.role AS user_role
+    FROM kyc_submissions k
+    JOIN users u ON u.id = k.user_id
+    WHERE k.id = $1
+  `
+
+	var item domain.KYCQueueItem
+	if err := r.db.GetContext(ctx, &item, query, id); err != nil {
+		return nil, err
+	}
+
+	return &item, nil
+}
+
+func (r *KYCRepository) GetByIDForUpdate(ctx context.Context, tx *sqlx.Tx, id string) (*domain.KYCSubmission, error) {
+	query := `
+    SELECT id, user_id, kind, status, details, documents, reviewer_id, review_note, submitted_at, reviewed_at
+    FROM kyc_submissions
+    WHERE id = $1
+    FOR UPDATE
+  `
+
+	var submission domain.KYCSubmission
+	if err := tx.GetContext(ctx, &submission, query, id); err != nil {
+		return nil, err
+	}
+
+	return &submission, nil
+}
+
+func (r *KYCRepository) List(ctx context.Context, filters KYCFilters) ([]domain.KYCQueueItem, pagination.Meta, error) {
+	conditions := []string{"1=1"}
+	args := []any{}
+
+	if filters.Status != "" {
+		args = append(args, filters.Status)
+		conditions = append(conditions, fmt.Sprintf("k.status = $%d", len(args)))
+	}
+
+	if filters.Kind != "" {
+		args = append(args, filters.Kind)
+		conditions = append(conditions, fmt.Sprintf("k.kind = $%d", len(args)))
+	}
+
+	var meta pagination.Meta
+	if filters.Page.IncludeTotal {
+		var total int
+		if err := r.db.GetContext(ctx, &total, fmt.Sprintf("SELECT count(*) FROM kyc_submissions k WHERE %s", strings.Join(conditions, " AND ")), args...); err != nil {
+			return nil, meta, err
+		}
+		meta.Total = &total
+	}
+
+	if filters.Page.After != nil {
+		condition, afterArgs, err := kycOrder.after(filters.Page.After, args)
+		if err != nil {
+			return nil, meta, err
+		}
+		conditions = append(conditions, condition)
+		args = afterArgs
+	}
+
+	limit := pageLimit(filters.Page)
+	listQuery := fmt.Sprintf(`
+    SELECT k.id, k.user_id, k.kind, k.status, k.details, k.documents, k.reviewer_id, k.review_note,
+      k.submitted_at, k.reviewed_at, u.name AS user_name, u.email AS user_email, u.role AS user_role, %s
+    FROM kyc_submissions k
+    JOIN users u ON u.id = k.user_id
+    WHERE %s
+    ORDER BY %s
+    LIMIT %d
+  `, kycOrder.columns(), strings.Join(conditions, " AND "), kycOrder.orderBy(), limit+1)
+
+	var rows []struct {
+		domain.KYCQueueItem
+		cursorColumns
+	}
+	if err := r.db.SelectContext(ctx, &rows, listQuery, args...); err != nil {
+		return nil, meta, err
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		meta.NextCursor = kycOrder.cursorAt(rows[limit-1].cursorColumns)
+	}
+
+	items := make([]domain.KYCQueueItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, row.KYCQueueItem)
+	}
+
+	return items, meta, nil
+}
+
+type KYCFilters struct {
+	Status string
+	Kind   string
+	Page   pagination.Page
+}
+
+// kycOrder serves the review queue oldest first.
+var kycOrder = keyset{name: "submitted_at", expr: "k.submitted_at", exprType: "timestamptz", idExpr: "k.id", idType: "uuid"}
+
+func (r *KYCRepository) Review(ctx context.Context, tx *sqlx.Tx, id string, status string, reviewerID string, note *string) (*domain.KYCSubmission, error) {
+	query := `
+    UPDATE kyc_submissions
+    SET status = $2, reviewer_id = $3, review_note = $4, reviewed_at = now()
+    WHERE id = $1
+    RETURNING id, user_id, kind, status, details, documents, reviewer_id, review_note, submitted_at, reviewed_at
+  `
+
+	var submission domain.KYCSubmission
+	if err := tx.GetContext(ctx, &submission, query, id, status, reviewerID, note); err != nil {
+		return nil, err
+	}
+
+	return &submission, nil
+}
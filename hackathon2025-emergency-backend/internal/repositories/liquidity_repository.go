@@ -0,0 +1,162 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"invoiceflow/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type LiquidityRepository struct {
+	db *sqlx.DB
+}
+
+func NewLiquidityRepository(db *sqlx.DB) *LiquidityRepository {
+	return &LiquidityRepository{db: db}
+}
+
+const liquidityCommitmentColumns = `id, organization_id, investor_id, currency, committed_amount, available_amount,
+      max_per_invoice, min_apr_percent, status, created_at, updated_at`
+
+func (r *LiquidityRepository) Create(ctx context.Context, tx *sqlx.Tx, commitment *domain.LiquidityCommitment) (*domain.LiquidityCommitment, error) {
+	query := `
+    INSERT INTO liquidity_commitments (organization_id, investor_id, currency, committed_amount, available_amount,
+      max_per_invoice, min_apr_percent)
+    VALUES ($1,$2,$3,$4,$4,$5,$6)
+    RETURNING ` + liquidityCommitmentColumns
+
+	var created domain.LiquidityCommitment
+	err := tx.GetContext(ctx, &created, query,
+		commitment.OrganizationID, commitment.InvestorID, commitment.Currency, commitment.CommittedAmount,
+		commitment.MaxPerInvoice, commitment.MinAPRPercent,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (r *LiquidityRepository) ListByOrganization(ctx context.Context, organizationID string) ([]domain.LiquidityCommitment, error) {
+	query := `
+    SELECT ` + liquidityCommitmentColumns + `
+    FROM liquidity_commitments
+    WHERE organization_id = $1
+    ORDER BY created_at DESC
+  `
+
+	commitments := []domain.LiquidityCommitment{}
+	if err := r.db.SelectContext(ctx, &commitments, query, organizationID); err != nil {
+		return nil, err
+	}
+
+	return commitments, nil
+}
+
+func (r *LiquidityRepository) GetForUpdate(ctx context.Context, tx *sqlx.Tx, organizationID string, id string) (*domain.LiquidityCommitment, error) {
+	query := `
+    SELECT ` + liquidityCommitmentColumns + `
+    FROM liquidity_commitments
+    WHERE id = $1 AND organization_id = $2
+    FOR UPDATE
+  `
+
+	var commitment domain.LiquidityCommitment
+	if err := tx.GetContext(ctx, &commitment, query, id, organizationID); err != nil {
+		return nil, err
+	}
+
+	return &commitment, nil
+}
+
+// Withdraw releases what is left of the commitment; fundings already drawn
+// from it stand.
+func (r *LiquidityRepository) Withdraw(ctx context.Context, tx *sqlx.Tx, id string) (*domain.LiquidityCommitment, error) {
+	query := `
+    UPDATE liquidity_commitments
+    SET status = $2, available_amount = 0, updated_at = now()
+    WHERE id = $1
+    RETURNING ` + liquidityCommitmentColumns
+
+	var commitment domain.LiquidityCommitment
+	if err := tx.GetContext(ctx, &commitment, query, id, domain.LiquidityCommitmentWithdrawn); err != nil {
+		return nil, err
+	}
+
+	return &commitment, nil
+}
+
+// ActiveForUpdate locks the active commitments in currency, oldest first,
+// leaving out those of excludeOrganizationID and those whose investor may no
+// longer fund for their organization.
+func (r *LiquidityRepository) ActiveForUpdate(ctx context.Context, tx *sqlx.Tx, currency string, excludeOrganizationID string) ([]domain.LiquidityCommitment, error) {
+	args := []any{currency, domain.LiquidityCommitmentActive, excludeOrganizationID}
+	query := `
+    SELECT ` + liquidityCommitmentColumns + `
+    FROM liquidity_commitments
+    WHERE currency = $1 AND status = $2 AND available_amount > 0 AND organization_id <> $3
+      AND ` + eligibleFunderCondition("liquidity_commitments", &args) + `
+    ORDER BY created_at, id
+    FOR UPDATE
+  `
+
+	commitments := []domain.LiquidityCommitment{}
+	if err := tx.SelectContext(ctx, &commitments, query, args...); err != nil {
+		return nil, err
+	}
+
+	return commitments, nil
+}
+
+// eligibleFunderCondition matches rows of table whose investor_id is still
+// an active user and at least an editor of the row's organization_id, which
+// is still an investor organization: the checks a funding request passes,
+// repeated for funds committed earlier.
+func eligibleFunderCondition(table string, args *[]any) string {
+	*args = append(*args, domain.UserStatusActive, domain.OrgRoleEditor, domain.OrgRoleOwner, domain.OrgKindInvestor)
+	n := len(*args)
+	return fmt.Sprintf(`EXISTS (
+        SELECT 1
+        FROM users u
+        JOIN organization_members m ON m.user_id = u.id
+        JOIN organizations o ON o.id = m.organization_id
+        WHERE u.id = %[1]s.investor_id AND m.organization_id = %[1]s.organization_id
+          AND u.status = $%[2]d AND m.role IN ($%[3]d, $%[4]d) AND o.kind = $%[5]d
+      )`, table, n-3, n-2, n-1, n)
+}
+
+// Draw takes amount from the commitment, marking it exhausted once nothing
+// is left.
+func (r *LiquidityRepository) Draw(ctx context.Context, tx *sqlx.Tx, id string, amount float64) error {
+	query := `
+    UPDATE liquidity_commitments
+    SET available_amount = available_amount - $2,
+      status = CASE WHEN available_amount - $2 <= 0 THEN $3 ELSE status END,
+      updated_at = now()
+    WHERE id = $1
+  `
+
+	_, err := tx.ExecContext(ctx, query, id, amount, domain.LiquidityCommitmentExhausted)
+	return err
+}
+
+func (r *LiquidityRepository) Balances(ctx context.Context) ([]domain.LiquidityPoolBalance, error) {
+	query := `
+    SELECT currency, count(*) AS commitments,
+      COALESCE(sum(committed_amount), 0) AS committed_amount,
+      COALESCE(sum(available_amount), 0) AS available_amount
+    FROM liquidity_commitments
+    WHERE status = $1
+    GROUP BY currency
+    ORDER BY currency
+  `
+
+	balances := []domain.LiquidityPoolBalance{}
+	if err := r.db.SelectContext(ctx, &balances, query, domain.LiquidityCommitmentActive); err != nil {
+		return nil, err
+	}
+
+	return balances, nil
+}
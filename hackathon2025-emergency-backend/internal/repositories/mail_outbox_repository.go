@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"invoiceflow/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type MailOutboxRepository struct {
+	db *sqlx.DB
+}
+
+func NewMailOutboxRepository(db *sqlx.DB) *MailOutboxRepository {
+	return &MailOutboxRepository{db: db}
+}
+
+const outboxMailColumns = `id, sequence, event_id, recipient, subject, body, status, attempts, next_attempt_at,
+      last_error, created_at, sent_at`
+
+// Insert queues mail. Mail for an event is queued once per recipient; a
+// repeat is ignored.
+func (r *MailOutboxRepository) Insert(ctx context.Context, tx *sqlx.Tx, mail *domain.OutboxMail) error {
+	query := `
+    INSERT INTO mail_outbox (event_id, recipient, subject, body)
+    VALUES ($1,$2,$3,$4)
+    ON CONFLICT (recipient, event_id) WHERE event_id IS NOT NULL DO NOTHING
+  `
+
+	_, err := tx.ExecContext(ctx, query, mail.EventID, mail.Recipient, mail.Subject, mail.Body)
+	return err
+}
+
+// ClaimNext locks the oldest due pending mail for the rest of tx. Rows held
+// by other relays are skipped, so each mail is sent by one at a time.
+func (r *MailOutboxRepository) ClaimNext(ctx context.Context, tx *sqlx.Tx) (*domain.OutboxMail, error) {
+	query := `
+    SELECT ` + outboxMailColumns + `
+    FROM mail_outbox
+    WHERE status = $1 AND next_attempt_at <= now()
+    ORDER BY sequence
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+  `
+
+	var mail domain.OutboxMail
+	if err := tx.GetContext(ctx, &mail, query, domain.MailStatusPending); err != nil {
+		return nil, err
+	}
+
+	return &mail, nil
+}
+
+func (r *MailOutboxRepository) MarkSent(ctx context.Context, tx *sqlx.Tx, id string) error {
+	query := `
+    UPDATE mail_outbox
+    SET status = $2, attempts = attempts + 1, last_error = NULL, sent_at = now()
+    WHERE id = $1
+  `
+
+	_, err := tx.ExecContext(ctx, query, id, domain.MailStatusSent)
+	return err
+}
+
+// MarkAttemptFailed records a failed send and schedules the next one at
+// retryAt, or gives up when retryAt is nil.
+func (r *MailOutboxRepository) MarkAttemptFailed(ctx context.Context, tx *sqlx.Tx, id string, errMsg string, retryAt *time.Time) error {
+	status := domain.MailStatusPending
+	if retryAt == nil {
+		status = domain.MailStatusFailed
+	}
+
+	query := `
+    UPDATE mail_outbox
+    SET status = $2, attempts = attempts + 1, last_error = $3, next_attempt_at = COALESCE($4, next_attempt_at)
+    WHERE id = $1
+  `
+
+	_, err := tx.ExecContext(ctx, query, id, status, errMsg, retryAt)
+	return err
+}
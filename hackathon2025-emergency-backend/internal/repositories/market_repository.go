@@ -0,0 +1,293 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/pagination"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type MarketRepository struct {
+	db *sqlx.DB
+}
+
+func NewMarketRepository(db *sqlx.DB) *MarketRepository {
+	return &MarketRepository{db: db}
+}
+
+const marketListingColumns = `id, funding_id, invoice_id, organization_id, seller_id, currency, principal, remaining_principal,
+      unit_price, status, created_at, updated_at`
+
+const positionTransferColumns = `id, listing_id, invoice_id, from_funding_id, to_funding_id, seller_organization_id,
+      buyer_organization_id, buyer_id, principal, price, created_at`
+
+func (r *MarketRepository) CreateListing(ctx context.Context, tx *sqlx.Tx, listing *domain.MarketListing) (*domain.MarketListing, error) {
+	query := `
+    INSERT INTO market_listings (funding_id, invoice_id, organization_id, seller_id, currency, principal,
+      remaining_principal, unit_price)
+    VALUES ($1,$2,$3,$4,$5,$6,$6,$7)
+    RETURNING ` + marketListingColumns
+
+	var created domain.MarketListing
+	err := tx.GetContext(ctx, &created, query,
+		listing.FundingID, listing.InvoiceID, listing.OrganizationID, listing.SellerID, listing.Currency,
+		listing.Principal, listing.UnitPrice,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (r *MarketRepository) ActiveListingForFunding(ctx context.Context, tx *sqlx.Tx, fundingID string) (*domain.MarketListing, error) {
+	query := `
+    SELECT ` + marketListingColumns + `
+    FROM market_listings
+    WHERE funding_id = $1 AND status = $2
+  `
+
+	var listing domain.MarketListing
+	if err := tx.GetContext(ctx, &listing, query, fundingID, domain.MarketListingActive); err != nil {
+		return nil, err
+	}
+
+	return &listing, nil
+}
+
+func (r *MarketRepository) GetListing(ctx context.Context, id string) (*domain.MarketListing, error) {
+	query := `
+    SELECT ` + marketListingColumns + `
+    FROM market_listings
+    WHERE id = $1
+  `
+
+	var listing domain.MarketListing
+	if err := r.db.GetContext(ctx, &listing, query, id); err != nil {
+		return nil, err
+	}
+
+	return &listing, nil
+}
+
+func (r *MarketRepository) GetListingForUpdate(ctx context.Context, tx *sqlx.Tx, id string) (*domain.MarketListing, error) {
+	query := `
+    SELECT ` + marketListingColumns + `
+    FROM market_listings
+    WHERE id = $1
+    FOR UPDATE
+  `
+
+	var listing domain.MarketListing
+	if err := tx.GetContext(ctx, &listing, query, id); err != nil {
+		return nil, err
+	}
+
+	return &listing, nil
+}
+
+// TakeFromListing takes a purchased principal off a listing, filling it
+// once nothing is left.
+func (r *MarketRepository) TakeFromListing(ctx context.Context, tx *sqlx.Tx, id string, principal float64) (*domain.MarketListing, error) {
+	query := `
+    UPDATE market_listings
+    SET remaining_principal = remaining_principal - $2,
+        status = CASE WHEN remaining_principal - $2 <= 0 THEN $3 ELSE status END,
+        updated_at = now()
+    WHERE id = $1
+    RETURNING ` + marketListingColumns
+
+	var updated domain.MarketListing
+	if err := tx.GetContext(ctx, &updated, query, id, principal, domain.MarketListingFilled); err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+func (r *MarketRepository) UpdateListingStatus(ctx context.Context, tx *sqlx.Tx, id string, status string) (*domain.MarketListing, error) {
+	query := `
+    UPDATE market_listings
+    SET status = $2, updated_at = now()
+    WHERE id = $1
+    RETURNING ` + marketListingColumns
+
+	var updated domain.MarketListing
+	if err := tx.GetContext(ctx, &updated, query, id, status); err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+// ListingFilters narrows the listings shown. Without an organization only
+// active listings are returned, as the public market view.
+type ListingFilters struct {
+	InvoiceID       string
+	Currency        string
+	OrganizationIDs []string
+	Page            pagination.Page
+}
+
+var listingOrder = keyset{name: "created_at", expr: "created_at", exprType: "timestamptz", desc: true, idExpr: "id", idType: "uuid"}
+
+func (r *MarketRepository) ListListings(ctx context.Context, filters ListingFilters) ([]domain.MarketListing, pagination.Meta, error) {
+	args := []any{}
+	conditions := []string{}
+
+	if filters.OrganizationIDs != nil {
+		placeholders := []string{"NULL"}
+		for _, id := range filters.OrganizationIDs {
+			args = append(args, id)
+			placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+		}
+		conditions = append(conditions, fmt.Sprintf("organization_id IN (%s)", strings.Join(placeholders, ",")))
+	} else {
+		args = append(args, domain.MarketListingActive)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+	if filters.InvoiceID != "" {
+		args = append(args, filters.InvoiceID)
+		conditions = append(conditions, fmt.Sprintf("invoice_id = $%d", len(args)))
+	}
+	if filters.Currency != "" {
+		args = append(args, filters.Currency)
+		conditions = append(conditions, fmt.Sprintf("currency = $%d", len(args)))
+	}
+
+	var meta pagination.Meta
+	if filters.Page.IncludeTotal {
+		var total int
+		if err := r.db.GetContext(ctx, &total, fmt.Sprintf("SELECT count(*) FROM market_listings WHERE %s", strings.Join(conditions, " AND ")), args...); err != nil {
+			return nil, meta, err
+		}
+		meta.Total = &total
+	}
+
+	if filters.Page.After != nil {
+		condition, afterArgs, err := listingOrder.after(filters.Page.After, args)
+		if err != nil {
+			return nil, meta, err
+		}
+		conditions = append(conditions, condition)
+		args = afterArgs
+	}
+
+	limit := pageLimit(filters.Page)
+	listQuery := fmt.Sprintf(`
+    SELECT %s, %s
+    FROM market_listings
+    WHERE %s
+    ORDER BY %s
+    LIMIT %d
+  `, marketListingColumns, listingOrder.columns(), strings.Join(conditions, " AND "), listingOrder.orderBy(), limit+1)
+
+	var rows []struct {
+		domain.MarketListing
+		cursorColumns
+	}
+	if err := r.db.SelectContext(ctx, &rows, listQuery, args...); err != nil {
+		return nil, meta, err
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		meta.NextCursor = listingOrder.cursorAt(rows[limit-1].cursorColumns)
+	}
+
+	listings := make([]domain.MarketListing, 0, len(rows))
+	for _, row := range rows {
+		listings = append(listings, row.MarketListing)
+	}
+
+	return listings, meta, nil
+}
+
+func (r *MarketRepository) CreateTransfer(ctx context.Context, tx *sqlx.Tx, transfer *domain.PositionTransfer) (*domain.PositionTransfer, error) {
+	query := `
+    INSERT INTO position_transfers (listing_id, invoice_id, from_funding_id, to_funding_id, seller_organization_id,
+      buyer_organization_id, buyer_id, principal, price)
+    VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
+    RETURNING ` + positionTransferColumns
+
+	var created domain.PositionTransfer
+	err := tx.GetContext(ctx, &created, query,
+		transfer.ListingID, transfer.InvoiceID, transfer.FromFundingID, transfer.ToFundingID,
+		transfer.SellerOrganizationID, transfer.BuyerOrganizationID, transfer.BuyerID, transfer.Principal, transfer.Price,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+var transferOrder = keyset{name: "created_at", expr: "created_at", exprType: "timestamptz", desc: true, idExpr: "id", idType: "uuid"}
+
+// ListTransfers lists the transfers any of the given organizations bought
+// or sold, optionally for one invoice.
+func (r *MarketRepository) ListTransfers(ctx context.Context, organizationIDs []string, invoiceID string, page pagination.Page) ([]domain.PositionTransfer, pagination.Meta, error) {
+	args := []any{}
+	placeholders := []string{"NULL"}
+	for _, id := range organizationIDs {
+		args = append(args, id)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+	}
+	in := strings.Join(placeholders, ",")
+	conditions := []string{fmt.Sprintf("(seller_organization_id IN (%s) OR buyer_organization_id IN (%s))", in, in)}
+	if invoiceID != "" {
+		args = append(args, invoiceID)
+		conditions = append(conditions, fmt.Sprintf("invoice_id = $%d", len(args)))
+	}
+
+	var meta pagination.Meta
+	if page.IncludeTotal {
+		var total int
+		if err := r.db.GetContext(ctx, &total, fmt.Sprintf("SELECT count(*) FROM position_transfers WHERE %s", strings.Join(conditions, " AND ")), args...); err != nil {
+			return nil, meta, err
+		}
+		meta.Total = &total
+	}
+
+	if page.After != nil {
+		condition, afterArgs, err := transferOrder.after(page.After, args)
+		if err != nil {
+			return nil, meta, err
+		}
+		conditions = append(conditions, condition)
+		args = afterArgs
+	}
+
+	limit := pageLimit(page)
+	listQuery := fmt.Sprintf(`
+    SELECT %s, %s
+    FROM position_transfers
+    WHERE %s
+    ORDER BY %s
+    LIMIT %d
+  `, positionTransferColumns, transferOrder.columns(), strings.Join(conditions, " AND "), transferOrder.orderBy(), limit+1)
+
+	var rows []struct {
+		domain.PositionTransfer
+		cursorColumns
+	}
+	if err := r.db.SelectContext(ctx, &rows, listQuery, args...); err != nil {
+		return nil, meta, err
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		meta.NextCursor = transferOrder.cursorAt(rows[limit-1].cursorColumns)
+	}
+
+	transfers := make([]domain.PositionTransfer, 0, len(rows))
+	for _, row := range rows {
+		transfers = append(transfers, row.PositionTransfer)
+	}
+
+	return transfers, meta, nil
+}
@@ -0,0 +1,183 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"invoiceflow/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// MetricsRepository reads the daily rollups behind the admin dashboard.
+// Ranges are [from, to) in UTC days.
+type MetricsRepository struct {
+	db *sqlx.DB
+}
+
+func NewMetricsRepository(db *sqlx.DB) *MetricsRepository {
+	return &MetricsRepository{db: db}
+}
+
+var metricsRollups = []string{"metrics_daily_funding", "metrics_daily_invoices", "metrics_daily_mints"}
+
+// Refresh rebuilds the rollups. CONCURRENTLY keeps them readable while they
+// are rebuilt.
+func (r *MetricsRepository) Refresh(ctx context.Context) error {
+	for _, view := range metricsRollups {
+		if _, err := r.db.ExecContext(ctx, "REFRESH MATERIALIZED VIEW CONCURRENTLY "+view); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type FundingVolumeBucket struct {
+	Bucket   time.Time `db:"bucket"`
+	Fundings int       `db:"fundings"`
+	Volume   float64   `db:"volume"`
+}
+
+// FundingVolume sums funding volume in one currency per day, week or month.
+func (r *MetricsRepository) FundingVolume(ctx context.Context, currency string, interval string, from time.Time, to time.Time) ([]FundingVolumeBucket, error) {
+	query := `
+    SELECT date_trunc($1, day::timestamp)::date AS bucket, sum(fundings)::int AS fundings, sum(volume) AS volume
+    FROM metrics_daily_funding
+    WHERE currency = $2 AND day >= $3 AND day < $4
+    GROUP BY 1
+    ORDER BY 1
+  `
+
+	buckets := []FundingVolumeBucket{}
+	if err := r.db.SelectContext(ctx, &buckets, query, interval, currency, from, to); err != nil {
+		return nil, err
+	}
+
+	return buckets, nil
+}
+
+// TopFundingCurrency is the currency with the most funding volume in the
+// range, or "" when nothing was funded.
+func (r *MetricsRepository) TopFundingCurrency(ctx context.Context, from time.Time, to time.Time) (string, error) {
+	query := `
+    SELECT COALESCE((
+      SELECT currency
+      FROM metrics_daily_funding
+      WHERE day >= $1 AND day < $2
+      GROUP BY currency
+      ORDER BY sum(volume) DESC, currency
+      LIMIT 1
+    ), '')
+  `
+
+	var currency string
+	if err := r.db.GetContext(ctx, &currency, query, from, to); err != nil {
+		return "", err
+	}
+
+	return currency, nil
+}
+
+// InvoiceLifecycle counts invoice transitions in a range. The sample
+// counts are the transitions whose previous step has a timestamp.
+type InvoiceLifecycle struct {
+	Submitted       int     `db:"submitted"`
+	Approved        int     `db:"approved"`
+	Funded          int     `db:"funded"`
+	Paid            int     `db:"paid"`
+	Defaulted       int     `db:"defaulted"`
+	ApprovalSamples int     `db:"approval_samples"`
+	ApprovalSeconds float64 `db:"approval_seconds"`
+	FundingSamples  int     `db:"funding_samples"`
+	FundingSeconds  float64 `db:"funding_seconds"`
+	APRSum          float64 `db:"apr_sum"`
+}
+
+func (r *MetricsRepository) InvoiceLifecycle(ctx context.Context, from time.Time, to time.Time) (*InvoiceLifecycle, error) {
+	query := `
+    SELECT COALESCE(sum(submitted), 0)::int AS submitted,
+           COALESCE(sum(approved), 0)::int AS approved,
+           COALESCE(sum(funded), 0)::int AS funded,
+           COALESCE(sum(paid), 0)::int AS paid,
+           COALESCE(sum(defaulted), 0)::int AS defaulted,
+           COALESCE(sum(approval_samples), 0)::int AS approval_samples,
+           COALESCE(sum(approval_seconds), 0) AS approval_seconds,
+           COALESCE(sum(funding_samples), 0)::int AS funding_samples,
+           COALESCE(sum(funding_seconds), 0) AS funding_seconds,
+           COALESCE(sum(apr_sum), 0) AS apr_sum
+    FROM metrics_daily_invoices
+    WHERE day >= $1 AND day < $2
+  `
+
+	var lifecycle InvoiceLifecycle
+	if err := r.db.GetContext(ctx, &lifecycle, query, from, to); err != nil {
+		return nil, err
+	}
+
+	return &lifecycle, nil
+}
+
+type MintOutcomes struct {
+	Mints     int `db:"mints"`
+	Confirmed int `db:"confirmed"`
+	Failed    int `db:"failed"`
+}
+
+func (r *MetricsRepository) MintOutcomes(ctx context.Context, from time.Time, to time.Time) (*MintOutcomes, error) {
+	query := `
+    SELECT COALESCE(sum(mints), 0)::int AS mints,
+           COALESCE(sum(confirmed), 0)::int AS confirmed,
+           COALESCE(sum(failed), 0)::int AS failed
+    FROM metrics_daily_mints
+    WHERE day >= $1 AND day < $2
+  `
+
+	var outcomes MintOutcomes
+	if err := r.db.GetContext(ctx, &outcomes, query, from, to); err != nil {
+		return nil, err
+	}
+
+	return &outcomes, nil
+}
+
+// OpenRisk counts funded invoices not yet repaid that are past their due date
+// (overdue) or due before horizon (at risk, which includes overdue). It reads
+// invoices directly, so it is always current.
+func (r *MetricsRepository) OpenRisk(ctx context.Context, today time.Time, horizon time.Time) (atRisk int, overdue int, err error) {
+	query := `
+    SELECT count(*) FILTER (WHERE due_date < $2) AS at_risk,
+           count(*) FILTER (WHERE due_date < $1) AS overdue
+    FROM invoices
+    WHERE status IN ($3, $4)
+  `
+
+	var row struct {
+		AtRisk  int `db:"at_risk"`
+		Overdue int `db:"overdue"`
+	}
+	if err := r.db.GetContext(ctx, &row, query, today, horizon, domain.InvoiceStatusFunded, domain.InvoiceStatusPartiallyPaid); err != nil {
+		return 0, 0, err
+	}
+
+	return row.AtRisk, row.Overdue, nil
+}
+
+// ActiveSMEs counts active SME users registered before each cutoff.
+func (r *MetricsRepository) ActiveSMEs(ctx context.Context, cutoff time.Time, previousCutoff time.Time) (count int, previous int, err error) {
+	query := `
+    SELECT count(*) FILTER (WHERE created_at < $3) AS registered,
+           count(*) FILTER (WHERE created_at < $4) AS registered_before
+    FROM users
+    WHERE role = $1 AND status = $2
+  `
+
+	var row struct {
+		Registered       int `db:"registered"`
+		RegisteredBefore int `db:"registered_before"`
+	}
+	if err := r.db.GetContext(ctx, &row, query, domain.RoleSME, domain.UserStatusActive, cutoff, previousCutoff); err != nil {
+		return 0, 0, err
+	}
+
+	return row.Registered, row.RegisteredBefore, nil
+}
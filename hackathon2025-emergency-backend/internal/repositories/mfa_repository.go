@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"context"
+
+	"invoiceflow/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type MFARepository struct {
+	db *sqlx.DB
+}
+
+func NewMFARepository(db *sqlx.DB) *MFARepository {
+	return &MFARepository{db: db}
+}
+
+func (r *MFARepository) GetByUser(ctx context.Context, userID string) (*domain.UserMFA, error) {
+	query := `
+    SELECT user_id, secret_encrypted, enabled_at, last_used_step, created_at, updated_at
+    FROM user_mfa
+    WHERE user_id = $1
+  `
+
+	var mfa domain.UserMFA
+	if err := r.db.GetContext(ctx, &mfa, query, userID); err != nil {
+		return nil, err
+	}
+
+	return &mfa, nil
+}
+
+func (r *MFARepository) GetByUserForUpdate(ctx context.Context, tx *sqlx.Tx, userID string) (*domain.UserMFA, error) {
+	query := `
+    SELECT user_id, secret_encrypted, enabled_at, last_used_step, created_at, updated_at
+    FROM user_mfa
+    WHERE user_id = $1
+    FOR UPDATE
+  `
+
+	var mfa domain.UserMFA
+	if err := tx.GetContext(ctx, &mfa, query, userID); err != nil {
+		return nil, err
+	}
+
+	return &mfa, nil
+}
+
+// SavePending stores a new, not yet activated secret, replacing any earlier
+// unfinished enrollment.
+func (r *MFARepository) SavePending(ctx context.Context, tx *sqlx.Tx, userID string, secret []byte) error {
+	query := `
+    INSERT INTO user_mfa (user_id, secret_encrypted)
+    VALUES ($1, $2)
+    ON CONFLICT (user_id) DO UPDATE
+    SET secret_encrypted = EXCLUDED.secret_encrypted, enabled_at = NULL, last_used_step = 0, updated_at = now()
+  `
+
+	_, err := tx.ExecContext(ctx, query, userID, secret)
+	return err
+}
+
+func (r *MFARepository) Enable(ctx context.Context, tx *sqlx.Tx, userID string, step int64) error {
+	_, err := tx.ExecContext(ctx, "UPDATE user_mfa SET enabled_at = now(), last_used_step = $2, updated_at = now() WHERE user_id = $1", userID, step)
+	return err
+}
+
+func (r *MFARepository) UpdateLastStep(ctx context.Context, tx *sqlx.Tx, userID string, step int64) error {
+	_, err := tx.ExecContext(ctx, "UPDATE user_mfa SET last_used_step = $2, updated_at = now() WHERE user_id = $1", userID, step)
+	return err
+}
+
+func (r *MFARepository) Delete(ctx context.Context, tx *sqlx.Tx, userID string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, "DELETE FROM user_mfa WHERE user_id = $1", userID)
+	return err
+}
+
+func (r *MFARepository) ReplaceRecoveryCodes(ctx context.Context, tx *sqlx.Tx, userID string, hashes []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id = $1", userID); err != nil {
+		return err
+	}
+
+	for _, hash := range hashes {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)", userID, hash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UseRecoveryCode burns the matching unused code and reports whether one was
+// found.
+func (r *MFARepository) UseRecoveryCode(ctx context.Context, tx *sqlx.Tx, userID string, hash string) (bool, error) {
+	result, err := tx.ExecContext(ctx, `
+    UPDATE mfa_recovery_codes
+    SET used_at = now()
+    WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+  `, userID, hash)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func (r *MFARepository) CountUnusedRecoveryCodes(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count, "SELECT count(*) FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL", userID)
+	return count, err
+}
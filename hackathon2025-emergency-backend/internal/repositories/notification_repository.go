@@ -0,0 +1,224 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/pagination"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type NotificationRepository struct {
+	db *sqlx.DB
+}
+
+func NewNotificationRepository(db *sqlx.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+const notificationColumns = `id, user_id, event_id, event_type, entity_type, entity_id, title, body, read_at, created_at`
+
+// Create stores the notification unless the user already has one for the
+// event, in which case it returns nil.
+func (r *NotificationRepository) Create(ctx context.Context, tx *sqlx.Tx, notification *domain.Notification) (*domain.Notification, error) {
+	query := `
+    INSERT INTO notifications (user_id, event_id, event_type, entity_type, entity_id, title, body)
+    VALUES ($1,$2,$3,$4,$5,$6,$7)
+    ON CONFLICT (user_id, event_id) DO NOTHING
+    RETURNING ` + notificationColumns
+
+	var created domain.Notification
+	err := tx.GetContext(ctx, &created, query,
+		notification.UserID, notification.EventID, notification.EventType, notification.EntityType,
+		notification.EntityID, notification.Title, notification.Body,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (r *NotificationRepository) List(ctx context.Context, userID string, unreadOnly bool, page pagination.Page) ([]domain.Notification, pagination.Meta, error) {
+	args := []any{userID}
+	conditions := []string{"user_id = $1"}
+	if unreadOnly {
+		conditions = append(conditions, "read_at IS NULL")
+	}
+
+	var meta pagination.Meta
+	if page.IncludeTotal {
+		var total int
+		if err := r.db.GetContext(ctx, &total, fmt.Sprintf("SELECT count(*) FROM notifications WHERE %s", strings.Join(conditions, " AND ")), args...); err != nil {
+			return nil, meta, err
+		}
+		meta.Total = &total
+	}
+
+	if page.After != nil {
+		condition, afterArgs, err := notificationOrder.after(page.After, args)
+		if err != nil {
+			return nil, meta, err
+		}
+		conditions = append(conditions, condition)
+		args = afterArgs
+	}
+
+	limit := pageLimit(page)
+	listQuery := fmt.Sprintf(`
+    SELECT %s, %s
+    FROM notifications
+    WHERE %s
+    ORDER BY %s
+    LIMIT %d
+  `, notificationColumns, notificationOrder.columns(), strings.Join(conditions, " AND "), notificationOrder.orderBy(), limit+1)
+
+	var rows []struct {
+		domain.Notification
+		cursorColumns
+	}
+	if err := r.db.SelectContext(ctx, &rows, listQuery, args...); err != nil {
+		return nil, meta, err
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		meta.NextCursor = notificationOrder.cursorAt(rows[limit-1].cursorColumns)
+	}
+
+	notifications := make([]domain.Notification, 0, len(rows))
+	for _, row := range rows {
+		notifications = append(notifications, row.Notification)
+	}
+
+	return notifications, meta, nil
+}
+
+var notificationOrder = keyset{name: "created_at", expr: "created_at", exprType: "timestamptz", desc: true, idExpr: "id", idType: "uuid"}
+
+func (r *NotificationRepository) CountUnread(ctx context.Context, userID string) (int, error) {
+	var count int
+	if err := r.db.GetContext(ctx, &count, "SELECT count(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL", userID); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *NotificationRepository) MarkRead(ctx context.Context, userID string, id string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, "UPDATE notifications SET read_at = COALESCE(read_at, now()) WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *NotificationRepository) MarkAllRead(ctx context.Context, userID string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, "UPDATE notifications SET read_at = now() WHERE user_id = $1 AND read_at IS NULL", userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *NotificationRepository) ListPreferences(ctx context.Context, userID string) ([]domain.NotificationPreference, error) {
+	prefs := []domain.NotificationPreference{}
+	if err := r.db.SelectContext(ctx, &prefs, "SELECT user_id, event_type, in_app, email FROM notification_preferences WHERE user_id = $1", userID); err != nil {
+		return nil, err
+	}
+
+	return prefs, nil
+}
+
+// PreferencesForEvent returns the stored preferences of the users for one
+// event type, keyed by user id. Users without a row are absent.
+func (r *NotificationRepository) PreferencesForEvent(ctx context.Context, tx *sqlx.Tx, userIDs []string, eventType string) (map[string]domain.NotificationPreference, error) {
+	args := []any{eventType}
+	placeholders := []string{"NULL"}
+	for _, id := range userIDs {
+		args = append(args, id)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+	}
+
+	query := fmt.Sprintf(`
+    SELECT user_id, event_type, in_app, email
+    FROM notification_preferences
+    WHERE event_type = $1 AND user_id IN (%s)
+  `, strings.Join(placeholders, ","))
+
+	rows := []domain.NotificationPreference{}
+	if err := tx.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+
+	prefs := make(map[string]domain.NotificationPreference, len(rows))
+	for _, pref := range rows {
+		prefs[pref.UserID] = pref
+	}
+
+	return prefs, nil
+}
+
+func (r *NotificationRepository) UpsertPreference(ctx context.Context, tx *sqlx.Tx, pref domain.NotificationPreference) error {
+	query := `
+    INSERT INTO notification_preferences (user_id, event_type, in_app, email)
+    VALUES ($1,$2,$3,$4)
+    ON CONFLICT (user_id, event_type) DO UPDATE
+    SET in_app = EXCLUDED.in_app, email = EXCLUDED.email, updated_at = now()
+  `
+
+	_, err := tx.ExecContext(ctx, query, pref.UserID, pref.EventType, pref.InApp, pref.Email)
+	return err
+}
+
+// OrganizationRecipients returns the members of any of the organizations,
+// each once, leaving out suspended users.
+func (r *NotificationRepository) OrganizationRecipients(ctx context.Context, tx *sqlx.Tx, organizationIDs []string) ([]domain.NotificationRecipient, error) {
+	args := []any{domain.UserStatusSuspended}
+	placeholders := []string{"NULL"}
+	for _, id := range organizationIDs {
+		args = append(args, id)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+	}
+
+	query := fmt.Sprintf(`
+    SELECT DISTINCT u.id, u.name, u.email
+    FROM organization_members m
+    JOIN users u ON u.id = m.user_id
+    WHERE m.organization_id IN (%s) AND u.status <> $1
+  `, strings.Join(placeholders, ","))
+
+	recipients := []domain.NotificationRecipient{}
+	if err := tx.SelectContext(ctx, &recipients, query, args...); err != nil {
+		return nil, err
+	}
+
+	return recipients, nil
+}
+
+// PermissionRecipients returns the active users whose role grants permission.
+func (r *NotificationRepository) PermissionRecipients(ctx context.Context, tx *sqlx.Tx, permission string) ([]domain.NotificationRecipient, error) {
+	query := `
+    SELECT u.id, u.name, u.email
+    FROM users u
+    JOIN role_permissions rp ON rp.role = u.role
+    WHERE rp.permission = $1 AND u.status = $2
+  `
+
+	recipients := []domain.NotificationRecipient{}
+	if err := tx.SelectContext(ctx, &recipients, query, permission, domain.UserStatusActive); err != nil {
+		return nil, err
+	}
+
+	return recipients, nil
+}
@@ -0,0 +1,218 @@
+package repositories
+
+import (
+	"context"
+
+	"invoiceflow/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type OrganizationRepository struct {
+	db *sqlx.DB
+}
+
+func NewOrganizationRepository(db *sqlx.DB) *OrganizationRepository {
+	return &OrganizationRepository{db: db}
+}
+
+func (r *OrganizationRepository) Create(ctx context.Context, tx *sqlx.Tx, org *domain.Organization) (*domain.Organization, error) {
+	query := `
+    INSERT INTO organizations (name, kind, personal, created_by)
+    VALUES ($1,$2,$3,$4)
+    RETURNING id, name, kind, personal, created_by, created_at, updated_at
+  `
+
+	var created domain.Organization
+	if err := tx.GetContext(ctx, &created, query, org.Name, org.Kind, org.Personal, org.CreatedBy); err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// AddMember fails with a unique violation when the user is already a member.
+func (r *OrganizationRepository) AddMember(ctx context.Context, tx *sqlx.Tx, organizationID string, userID string, role string) error {
+	query := `
+    INSERT INTO organization_members (organization_id, user_id, role)
+    VALUES ($1,$2,$3)
+  `
+
+	_, err := tx.ExecContext(ctx, query, organizationID, userID, role)
+	return err
+}
+
+func (r *OrganizationRepository) GetMembership(ctx context.Context, organizationID string, userID string) (*domain.Membership, error) {
+	query := `
+    SELECT o.id, o.name, o.kind, o.personal, o.created_by, o.created_at, o.updated_at, m.role AS member_role
+    FROM organizations o
+    JOIN organization_members m ON m.organization_id = o.id
+    WHERE o.id = $1 AND m.user_id = $2
+  `
+
+	var membership domain.Membership
+	if err := r.db.GetContext(ctx, &membership, query, organizationID, userID); err != nil {
+		return nil, err
+	}
+
+	return &membership, nil
+}
+
+func (r *OrganizationRepository) GetPersonalMembership(ctx context.Context, userID string) (*domain.Membership, error) {
+	query := `
+    SELECT o.id, o.name, o.kind, o.personal, o.created_by, o.created_at, o.updated_at, m.role AS member_role
+    FROM organizations o
+    JOIN organization_members m ON m.organization_id = o.id
+    WHERE o.personal AND o.created_by = $1 AND m.user_id = $1
+  `
+
+	var membership domain.Membership
+	if err := r.db.GetContext(ctx, &membership, query, userID); err != nil {
+		return nil, err
+	}
+
+	return &membership, nil
+}
+
+func (r *OrganizationRepository) ListForUser(ctx context.Context, userID string) ([]domain.Membership, error) {
+	query := `
+    SELECT o.id, o.name, o.kind, o.personal, o.created_by, o.created_at, o.updated_at, m.role AS member_role
+    FROM organizations o
+    JOIN organization_members m ON m.organization_id = o.id
+    WHERE m.user_id = $1
+    ORDER BY o.personal DESC, o.name
+  `
+
+	memberships := []domain.Membership{}
+	if err := r.db.SelectContext(ctx, &memberships, query, userID); err != nil {
+		return nil, err
+	}
+
+	return memberships, nil
+}
+
+func (r *OrganizationRepository) ListMemberOrganizationIDs(ctx context.Context, userID string) ([]string, error) {
+	ids := []string{}
+	if err := r.db.SelectContext(ctx, &ids, "SELECT organization_id FROM organization_members WHERE user_id = $1", userID); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (r *OrganizationRepository) ListMembers(ctx context.Context, organizationID string) ([]domain.OrganizationMember, error) {
+	query := `
+    SELECT m.organization_id, m.user_id, m.role, u.name, u.email, m.created_at
+    FROM organization_members m
+    JOIN users u ON u.id = m.user_id
+    WHERE m.organization_id = $1
+    ORDER BY m.created_at
+  `
+
+	members := []domain.OrganizationMember{}
+	if err := r.db.SelectContext(ctx, &members, query, organizationID); err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// LockMembers locks the organization's member rows so owner-count checks and
+// the change that depends on them cannot interleave.
+func (r *OrganizationRepository) LockMembers(ctx context.Context, tx *sqlx.Tx, organizationID string) ([]domain.OrganizationMember, error) {
+	query := `
+    SELECT organization_id, user_id, role, '' AS name, '' AS email, created_at
+    FROM organization_members
+    WHERE organization_id = $1
+    FOR UPDATE
+  `
+
+	members := []domain.OrganizationMember{}
+	if err := tx.SelectContext(ctx, &members, query, organizationID); err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+func (r *OrganizationRepository) UpdateMemberRole(ctx context.Context, tx *sqlx.Tx, organizationID string, userID string, role string) error {
+	_, err := tx.ExecContext(ctx, "UPDATE organization_members SET role = $3 WHERE organization_id = $1 AND user_id = $2", organizationID, userID, role)
+	return err
+}
+
+func (r *OrganizationRepository) RemoveMember(ctx context.Context, tx *sqlx.Tx, organizationID string, userID string) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2", organizationID, userID)
+	return err
+}
+
+func (r *OrganizationRepository) CreateInvitation(ctx context.Context, tx *sqlx.Tx, invitation *domain.OrganizationInvitation) (*domain.OrganizationInvitation, error) {
+	query := `
+    INSERT INTO organization_invitations (organization_id, email, role, token_hash, invited_by, expires_at)
+    VALUES ($1,$2,$3,$4,$5,$6)
+    RETURNING id, organization_id, email, role, token_hash, invited_by, expires_at, accepted_at, revoked_at, created_at
+  `
+
+	var created domain.OrganizationInvitation
+	if err := tx.GetContext(ctx, &created, query,
+		invitation.OrganizationID,
+		invitation.Email,
+		invitation.Role,
+		invitation.TokenHash,
+		invitation.InvitedBy,
+		invitation.ExpiresAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (r *OrganizationRepository) ListInvitations(ctx context.Context, organizationID string) ([]domain.OrganizationInvitation, error) {
+	query := `
+    SELECT id, organization_id, email, role, token_hash, invited_by, expires_at, accepted_at, revoked_at, created_at
+    FROM organization_invitations
+    WHERE organization_id = $1
+    ORDER BY created_at DESC
+  `
+
+	invitations := []domain.OrganizationInvitation{}
+	if err := r.db.SelectContext(ctx, &invitations, query, organizationID); err != nil {
+		return nil, err
+	}
+
+	return invitations, nil
+}
+
+func (r *OrganizationRepository) GetInvitationByHashForUpdate(ctx context.Context, tx *sqlx.Tx, hash string) (*domain.OrganizationInvitation, error) {
+	query := `
+    SELECT id, organization_id, email, role, token_hash, invited_by, expires_at, accepted_at, revoked_at, created_at
+    FROM organization_invitations
+    WHERE token_hash = $1
+    FOR UPDATE
+  `
+
+	var invitation domain.OrganizationInvitation
+	if err := tx.GetContext(ctx, &invitation, query, hash); err != nil {
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+func (r *OrganizationRepository) MarkInvitationAccepted(ctx context.Context, tx *sqlx.Tx, id string) error {
+	_, err := tx.ExecContext(ctx, "UPDATE organization_invitations SET accepted_at = now() WHERE id = $1", id)
+	return err
+}
+
+func (r *OrganizationRepository) RevokeInvitation(ctx context.Context, organizationID string, id string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+    UPDATE organization_invitations
+    SET revoked_at = now()
+    WHERE id = $1 AND organization_id = $2 AND accepted_at IS NULL AND revoked_at IS NULL
+  `, id, organizationID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
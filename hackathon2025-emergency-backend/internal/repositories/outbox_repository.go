@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"invoiceflow/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type OutboxRepository struct {
+	db *sqlx.DB
+}
+
+func NewOutboxRepository(db *sqlx.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+const outboxEventColumns = `id, sequence, event_type, aggregate_type, aggregate_id, organization_ids, payload, status,
+      attempts, next_attempt_at, last_error, occurred_at, published_at`
+
+func (r *OutboxRepository) Insert(ctx context.Context, tx *sqlx.Tx, event *domain.Event) (*domain.Event, error) {
+	query := `
+    INSERT INTO outbox_events (event_type, aggregate_type, aggregate_id, organization_ids, payload)
+    VALUES ($1,$2,$3,$4,$5)
+    RETURNING ` + outboxEventColumns
+
+	var created domain.Event
+	if err := tx.GetContext(ctx, &created, query, event.Type, event.AggregateType, event.AggregateID, event.OrganizationIDs, string(event.Payload)); err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// ClaimNext locks the oldest due pending event for the rest of tx. Rows held
+// by other relays are skipped, so each event is handled by one at a time.
+func (r *OutboxRepository) ClaimNext(ctx context.Context, tx *sqlx.Tx) (*domain.Event, error) {
+	query := `
+    SELECT ` + outboxEventColumns + `
+    FROM outbox_events
+    WHERE status = $1 AND next_attempt_at <= now()
+    ORDER BY sequence
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+  `
+
+	var event domain.Event
+	if err := tx.GetContext(ctx, &event, query, domain.EventStatusPending); err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// MarkPublished records that the event's deliveries have been queued.
+func (r *OutboxRepository) MarkPublished(ctx context.Context, tx *sqlx.Tx, id string) error {
+	query := `
+    UPDATE outbox_events
+    SET status = $2, attempts = attempts + 1, last_error = NULL, published_at = now()
+    WHERE id = $1
+  `
+
+	_, err := tx.ExecContext(ctx, query, id, domain.EventStatusPublished)
+	return err
+}
+
+func (r *OutboxRepository) Get(ctx context.Context, tx *sqlx.Tx, id string) (*domain.Event, error) {
+	query := `
+    SELECT ` + outboxEventColumns + `
+    FROM outbox_events
+    WHERE id = $1
+  `
+
+	var event domain.Event
+	if err := tx.GetContext(ctx, &event, query, id); err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+const eventDeliveryColumns = `event_id, subscriber, status, attempts, next_attempt_at, last_error, created_at, delivered_at`
+
+// CreateDelivery queues the event for subscriber; queuing it twice is a no-op.
+func (r *OutboxRepository) CreateDelivery(ctx context.Context, tx *sqlx.Tx, eventID string, subscriber string) error {
+	query := `
+    INSERT INTO event_deliveries (event_id, subscriber)
+    VALUES ($1,$2)
+    ON CONFLICT (event_id, subscriber) DO NOTHING
+  `
+
+	_, err := tx.ExecContext(ctx, query, eventID, subscriber)
+	return err
+}
+
+// ClaimNextDelivery locks the due pending delivery of the oldest event for
+// the rest of tx, skipping rows held by other relays.
+func (r *OutboxRepository) ClaimNextDelivery(ctx context.Context, tx *sqlx.Tx) (*domain.EventDelivery, error) {
+	query := `
+    SELECT d.event_id, d.subscriber, d.status, d.attempts, d.next_attempt_at, d.last_error, d.created_at, d.delivered_at
+    FROM event_deliveries d
+    JOIN outbox_events e ON e.id = d.event_id
+    WHERE d.status = $1 AND d.next_attempt_at <= now()
+    ORDER BY e.sequence, d.subscriber
+    LIMIT 1
+    FOR UPDATE OF d SKIP LOCKED
+  `
+
+	var delivery domain.EventDelivery
+	if err := tx.GetContext(ctx, &delivery, query, domain.EventDeliveryPending); err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
+
+func (r *OutboxRepository) MarkDelivered(ctx context.Context, tx *sqlx.Tx, eventID string, subscriber string) error {
+	query := `
+    UPDATE event_deliveries
+    SET status = $3, attempts = attempts + 1, last_error = NULL, delivered_at = now()
+    WHERE event_id = $1 AND subscriber = $2
+  `
+
+	_, err := tx.ExecContext(ctx, query, eventID, subscriber, domain.EventDeliveryDelivered)
+	return err
+}
+
+// MarkDeliveryFailed records a failed attempt of one subscriber and
+// schedules the next one at retryAt, or gives up when retryAt is nil.
+func (r *OutboxRepository) MarkDeliveryFailed(ctx context.Context, eventID string, subscriber string, errMsg string, retryAt *time.Time) error {
+	status := domain.EventDeliveryPending
+	if retryAt == nil {
+		status = domain.EventDeliveryFailed
+	}
+
+	query := `
+    UPDATE event_deliveries
+    SET status = $3, attempts = attempts + 1, last_error = $4, next_attempt_at = COALESCE($5, next_attempt_at)
+    WHERE event_id = $1 AND subscriber = $2
+  `
+
+	_, err := r.db.ExecContext(ctx, query, eventID, subscriber, status, errMsg, retryAt)
+	return err
+}
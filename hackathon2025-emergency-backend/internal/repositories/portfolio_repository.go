@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"invoiceflow/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type PortfolioRepository struct {
+	db *sqlx.DB
+}
+
+func NewPortfolioRepository(db *sqlx.DB) *PortfolioRepository {
+	return &PortfolioRepository{db: db}
+}
+
+func organizationPlaceholders(organizationIDs []string) (string, []any) {
+	args := []any{}
+	placeholders := []string{"NULL"}
+	for _, id := range organizationIDs {
+		args = append(args, id)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+	}
+	return strings.Join(placeholders, ","), args
+}
+
+// Positions returns every funding held by the organizations, live or closed,
+// with its invoice and purchase price.
+func (r *PortfolioRepository) Positions(ctx context.Context, organizationIDs []string) ([]domain.PortfolioPosition, error) {
+	in, args := organizationPlaceholders(organizationIDs)
+	args = append(args, domain.FundingStatusCanceled, domain.FundingStatusRefunded, domain.FundingStatusPending, domain.FundingStatusConfirmed)
+	n := len(args)
+	query := fmt.Sprintf(`
+    SELECT f.id, f.invoice_id, f.investor_id, f.organization_id, f.amount, f.apr_percent, f.term_months, f.status,
+      f.tx_hash, f.created_at, f.confirmed_at, f.settled_at, f.source_funding_id,
+      i.currency, i.due_date, i.risk_tier, i.debtor_name, o.name AS sme_name,
+      i.status AS invoice_status, i.paid_amount, i.closed_at,
+      COALESCE((
+        SELECT sum(p.amount + round(p.amount * p.apr_percent / 100 * p.term_months / 12, 2))
+        FROM fundings p
+        WHERE p.invoice_id = f.invoice_id AND p.status IN ($%[3]d, $%[4]d) AND p.amount > 0
+      ), 0) AS invoice_owed,
+      t.price AS purchase_price
+    FROM fundings f
+    JOIN invoices i ON i.id = f.invoice_id
+    JOIN organizations o ON o.id = i.organization_id
+    LEFT JOIN position_transfers t ON t.to_funding_id = f.id
+    WHERE f.organization_id IN (%[5]s) AND f.status NOT IN ($%[1]d, $%[2]d)
+    ORDER BY f.created_at, f.id
+  `, n-3, n-2, n-1, n, in)
+
+	positions := []domain.PortfolioPosition{}
+	if err := r.db.SelectContext(ctx, &positions, query, args...); err != nil {
+		return nil, err
+	}
+
+	return positions, nil
+}
+
+// Sales returns the secondary-market sales out of positions the
+// organizations held.
+func (r *PortfolioRepository) Sales(ctx context.Context, organizationIDs []string) ([]domain.PositionSale, error) {
+	in, args := organizationPlaceholders(organizationIDs)
+	query := fmt.Sprintf(`
+    SELECT from_funding_id, principal, price, created_at
+    FROM position_transfers
+    WHERE seller_organization_id IN (%s)
+    ORDER BY created_at
+  `, in)
+
+	sales := []domain.PositionSale{}
+	if err := r.db.SelectContext(ctx, &sales, query, args...); err != nil {
+		return nil, err
+	}
+
+	return sales, nil
+}
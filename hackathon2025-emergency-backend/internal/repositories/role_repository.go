@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"context"
+
+	"invoiceflow/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type RoleRepository struct {
+	db *sqlx.DB
+}
+
+func NewRoleRepository(db *sqlx.DB) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+func (r *RoleRepository) List(ctx context.Context) ([]domain.Role, error) {
+	query := `
+    SELECT r.name, r.description, r.is_system, r.created_at, r.updated_at,
+      COALESCE(jsonb_agg(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '[]'::jsonb) AS permissions
+    FROM roles r
+    LEFT JOIN role_permissions rp ON rp.role = r.name
+    GROUP BY r.name
+    ORDER BY r.name
+  `
+
+	roles := []domain.Role{}
+	if err := r.db.SelectContext(ctx, &roles, query); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (r *RoleRepository) Get(ctx context.Context, name string) (*domain.Role, error) {
+	query := `
+    SELECT r.name, r.description, r.is_system, r.created_at, r.updated_at,
+      COALESCE(jsonb_agg(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '[]'::jsonb) AS permissions
+    FROM roles r
+    LEFT JOIN role_permissions rp ON rp.role = r.name
+    WHERE r.name = $1
+    GROUP BY r.name
+  `
+
+	var role domain.Role
+	if err := r.db.GetContext(ctx, &role, query, name); err != nil {
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+func (r *RoleRepository) Exists(ctx context.Context, name string) (bool, error) {
+	var exists bool
+	err := r.db.GetContext(ctx, &exists, "SELECT EXISTS (SELECT 1 FROM roles WHERE name = $1)", name)
+	return exists, err
+}
+
+func (r *RoleRepository) PermissionsFor(ctx context.Context, role string) ([]string, error) {
+	permissions := []string{}
+	if err := r.db.SelectContext(ctx, &permissions, "SELECT permission FROM role_permissions WHERE role = $1 ORDER BY permission", role); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (r *RoleRepository) ListPermissions(ctx context.Context) ([]domain.Permission, error) {
+	permissions := []domain.Permission{}
+	if err := r.db.SelectContext(ctx, &permissions, "SELECT name, description FROM permissions ORDER BY name"); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (r *RoleRepository) Upsert(ctx context.Context, tx *sqlx.Tx, name string, description string) error {
+	query := `
+    INSERT INTO roles (name, description)
+    VALUES ($1, $2)
+    ON CONFLICT (name) DO UPDATE SET description = EXCLUDED.description, updated_at = now()
+  `
+
+	_, err := tx.ExecContext(ctx, query, name, description)
+	return err
+}
+
+func (r *RoleRepository) ReplacePermissions(ctx context.Context, tx *sqlx.Tx, role string, permissions []string) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM role_permissions WHERE role = $1", role); err != nil {
+		return err
+	}
+
+	for _, permission := range permissions {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO role_permissions (role, permission) VALUES ($1, $2)", role, permission); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *RoleRepository) Delete(ctx context.Context, tx *sqlx.Tx, name string) (int64, error) {
+	result, err := tx.ExecContext(ctx, "DELETE FROM roles WHERE name = $1 AND NOT is_system", name)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"invoiceflow/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type SessionRepository struct {
+	db *sqlx.DB
+}
+
+func NewSessionRepository(db *sqlx.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+func (r *SessionRepository) Create(ctx context.Context, session *domain.Session) (*domain.Session, error) {
+	query := `
+    INSERT INTO sessions (user_id, refresh_token_hash, user_agent, ip, mfa_verified, expires_at)
+    VALUES ($1,$2,$3,$4,$5,$6)
+    RETURNING id, user_id, refresh_token_hash, previous_token_hash, user_agent, ip, mfa_verified, expires_at,
+      last_used_at, revoked_at, created_at
+  `
+
+	var created domain.Session
+	if err := r.db.GetContext(ctx, &created, query,
+		session.UserID,
+		session.RefreshTokenHash,
+		session.UserAgent,
+		session.IP,
+		session.MFAVerified,
+		session.ExpiresAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (r *SessionRepository) GetByTokenHashForUpdate(ctx context.Context, tx *sqlx.Tx, hash string) (*domain.Session, error) {
+	query := `
+    SELECT id, user_id, refresh_token_hash, previous_token_hash, user_agent, ip, mfa_verified, expires_at,
+      last_used_at, revoked_at, created_at
+    FROM sessions
+    WHERE refresh_token_hash = $1
+    FOR UPDATE
+  `
+
+	var session domain.Session
+	if err := tx.GetContext(ctx, &session, query, hash); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (r *SessionRepository) GetByPreviousTokenHash(ctx context.Context, tx *sqlx.Tx, hash string) (*domain.Session, error) {
+	query := `
+    SELECT id, user_id, refresh_token_hash, previous_token_hash, user_agent, ip, mfa_verified, expires_at,
+      last_used_at, revoked_at, created_at
+    FROM sessions
+    WHERE previous_token_hash = $1
+    FOR UPDATE
+  `
+
+	var session domain.Session
+	if err := tx.GetContext(ctx, &session, query, hash); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func (r *SessionRepository) Rotate(ctx context.Context, tx *sqlx.Tx, id string, newHash string, expiresAt time.Time) error {
+	query := `
+    UPDATE sessions
+    SET previous_token_hash = refresh_token_hash,
+        refresh_token_hash = $2,
+        expires_at = $3,
+        last_used_at = now()
+    WHERE id = $1
+  `
+
+	_, err := tx.ExecContext(ctx, query, id, newHash, expiresAt)
+	return err
+}
+
+func (r *SessionRepository) Revoke(ctx context.Context, id string, userID string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE sessions SET revoked_at = now() WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL", id, userID)
+	return err
+}
+
+func (r *SessionRepository) RevokeTx(ctx context.Context, tx *sqlx.Tx, id string) error {
+	_, err := tx.ExecContext(ctx, "UPDATE sessions SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL", id)
+	return err
+}
+
+func (r *SessionRepository) RevokeAllForUser(ctx context.Context, tx *sqlx.Tx, userID string) error {
+	_, err := tx.ExecContext(ctx, "UPDATE sessions SET revoked_at = now() WHERE user_id = $1 AND revoked_at IS NULL", userID)
+	return err
+}
+
+func (r *SessionRepository) MarkMFAVerified(ctx context.Context, tx *sqlx.Tx, id string) error {
+	_, err := tx.ExecContext(ctx, "UPDATE sessions SET mfa_verified = true WHERE id = $1", id)
+	return err
+}
+
+// ClearMFAVerified drops the second-factor mark from every session of the
+// user, so none of them passes RequireMFA until it verifies again.
+func (r *SessionRepository) ClearMFAVerified(ctx context.Context, tx *sqlx.Tx, userID string) error {
+	_, err := tx.ExecContext(ctx, "UPDATE sessions SET mfa_verified = false WHERE user_id = $1 AND mfa_verified", userID)
+	return err
+}
+
+func (r *SessionRepository) GetState(ctx context.Context, id string, userID string) (*domain.SessionState, error) {
+	query := `
+    SELECT u.status, u.role, u.token_version, s.mfa_verified, s.expires_at, s.revoked_at,
+      COALESCE((SELECT jsonb_agg(rp.permission) FROM role_permissions rp WHERE rp.role = u.role), '[]'::jsonb) AS permissions
+    FROM sessions s
+    JOIN users u ON u.id = s.user_id
+    WHERE s.id = $1 AND s.user_id = $2
+  `
+
+	var state domain.SessionState
+	if err := r.db.GetContext(ctx, &state, query, id, userID); err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
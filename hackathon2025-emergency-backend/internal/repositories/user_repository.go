@@ -2,8 +2,12 @@ package repositories
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
+	"invoiceflow/internal/db"
 	"invoiceflow/internal/domain"
+	"invoiceflow/internal/pagination"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -20,7 +24,7 @@ func (r *UserRepository) Create(ctx context.Context, user *domain.User) (*domain
 	query := `
     INSERT INTO users (role, name, email, password_hash, status)
     VALUES ($1, $2, $3, $4, $5)
-    RETURNING id, role, name, email, password_hash, status, created_at, updated_at
+    RETURNING id, role, name, email, password_hash, status, token_version, email_verified_at, created_at, updated_at
   `
 
 	var created domain.User
@@ -34,7 +38,7 @@ func (r *UserRepository) Create(ctx context.Context, user *domain.User) (*domain
 
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	query := `
-    SELECT id, role, name, email, password_hash, status, created_at, updated_at
+    SELECT id, role, name, email, password_hash, status, token_version, email_verified_at, created_at, updated_at
     FROM users
     WHERE email = $1
   `
@@ -49,7 +53,7 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.
 
 func (r *UserRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
 	query := `
-    SELECT id, role, name, email, password_hash, status, created_at, updated_at
+    SELECT id, role, name, email, password_hash, status, token_version, email_verified_at, created_at, updated_at
     FROM users
     WHERE id = $1
   `
@@ -61,3 +65,212 @@ func (r *UserRepository) GetByID(ctx context.Context, id string) (*domain.User,
 
 	return &user, nil
 }
+
+func (r *UserRepository) BumpTokenVersion(ctx context.Context, tx *sqlx.Tx, id string) error {
+	_, err := tx.ExecContext(ctx, "UPDATE users SET token_version = token_version + 1, updated_at = now() WHERE id = $1", id)
+	return err
+}
+
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, tx *sqlx.Tx, id string) error {
+	_, err := tx.ExecContext(ctx, "UPDATE users SET email_verified_at = COALESCE(email_verified_at, now()), updated_at = now() WHERE id = $1", id)
+	return err
+}
+
+func (r *UserRepository) UpdatePassword(ctx context.Context, tx *sqlx.Tx, id string, passwordHash string) error {
+	_, err := tx.ExecContext(ctx, "UPDATE users SET password_hash = $2, updated_at = now() WHERE id = $1", id, passwordHash)
+	return err
+}
+
+func (r *UserRepository) GetByIDForUpdate(ctx context.Context, tx *sqlx.Tx, id string) (*domain.User, error) {
+	query := `
+    SELECT id, role, name, email, password_hash, status, token_version, email_verified_at, created_at, updated_at
+    FROM users
+    WHERE id = $1
+    FOR UPDATE
+  `
+
+	var user domain.User
+	if err := tx.GetContext(ctx, &user, query, id); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (r *UserRepository) UpdateStatus(ctx context.Context, tx *sqlx.Tx, id string, status string) (*domain.User, error) {
+	query := `
+    UPDATE users
+    SET status = $2, updated_at = now()
+    WHERE id = $1
+    RETURNING id, role, name, email, password_hash, status, token_version, email_verified_at, created_at, updated_at
+  `
+
+	var user domain.User
+	if err := tx.GetContext(ctx, &user, query, id, status); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (r *UserRepository) CreateTx(ctx context.Context, tx *sqlx.Tx, user *domain.User) (*domain.User, error) {
+	query := `
+    INSERT INTO users (role, name, email, password_hash, status)
+    VALUES ($1, $2, $3, $4, $5)
+    RETURNING id, role, name, email, password_hash, status, token_version, email_verified_at, created_at, updated_at
+  `
+
+	var created domain.User
+	if err := tx.GetContext(ctx, &created, query, user.Role, user.Name, user.Email, user.PasswordHash, user.Status); err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (r *UserRepository) List(ctx context.Context, filters UserFilters) ([]domain.User, pagination.Meta, error) {
+	conditions := []string{"1=1"}
+	args := []any{}
+
+	if filters.Query != "" {
+		args = append(args, "%"+filters.Query+"%")
+		conditions = append(conditions, fmt.Sprintf("(name ILIKE $%d OR email ILIKE $%d)", len(args), len(args)))
+	}
+
+	if filters.Role != "" {
+		args = append(args, filters.Role)
+		conditions = append(conditions, fmt.Sprintf("role = $%d", len(args)))
+	}
+
+	if filters.Status != "" {
+		args = append(args, filters.Status)
+		conditions = append(conditions, fmt.Sprintf("status = $%d", len(args)))
+	}
+
+	var meta pagination.Meta
+	if filters.Page.IncludeTotal {
+		var total int
+		if err := r.db.GetContext(ctx, &total, fmt.Sprintf("SELECT count(*) FROM users WHERE %s", strings.Join(conditions, " AND ")), args...); err != nil {
+			return nil, meta, err
+		}
+		meta.Total = &total
+	}
+
+	if filters.Page.After != nil {
+		condition, afterArgs, err := userOrder.after(filters.Page.After, args)
+		if err != nil {
+			return nil, meta, err
+		}
+		conditions = append(conditions, condition)
+		args = afterArgs
+	}
+
+	limit := pageLimit(filters.Page)
+	listQuery := fmt.Sprintf(`
+    SELECT id, role, name, email, password_hash, status, token_version, email_verified_at, created_at, updated_at, %s
+    FROM users
+    WHERE %s
+    ORDER BY %s
+    LIMIT %d
+  `, userOrder.columns(), strings.Join(conditions, " AND "), userOrder.orderBy(), limit+1)
+
+	var rows []struct {
+		domain.User
+		cursorColumns
+	}
+	if err := r.db.SelectContext(ctx, &rows, listQuery, args...); err != nil {
+		return nil, meta, err
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		meta.NextCursor = userOrder.cursorAt(rows[limit-1].cursorColumns)
+	}
+
+	users := make([]domain.User, 0, len(rows))
+	for _, row := range rows {
+		users = append(users, row.User)
+	}
+
+	return users, meta, nil
+}
+
+type UserFilters struct {
+	Query  string
+	Role   string
+	Status string
+	Page   pagination.Page
+}
+
+var userOrder = keyset{name: "created_at", expr: "created_at", exprType: "timestamptz", desc: true, idExpr: "id", idType: "uuid"}
+
+func (r *UserRepository) UpdateRole(ctx context.Context, tx *sqlx.Tx, id string, role string) (*domain.User, error) {
+	query := `
+    UPDATE users
+    SET role = $2, updated_at = now()
+    WHERE id = $1
+    RETURNING id, role, name, email, password_hash, status, token_version, email_verified_at, created_at, updated_at
+  `
+
+	var user domain.User
+	if err := tx.GetContext(ctx, &user, query, id, role); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// CountByRoleLocked counts users with role under the bootstrap lock, held
+// until tx ends.
+func (r *UserRepository) CountByRoleLocked(ctx context.Context, tx *sqlx.Tx, role string) (int, error) {
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", db.LockAdminBootstrap); err != nil {
+		return 0, err
+	}
+
+	var count int
+	err := tx.GetContext(ctx, &count, "SELECT count(*) FROM users WHERE role = $1", role)
+	return count, err
+}
+
+func (r *UserRepository) RecordAdminAction(ctx context.Context, tx *sqlx.Tx, action *domain.UserAdminAction) error {
+	query := `
+    INSERT INTO user_admin_actions (user_id, actor_id, action, from_value, to_value, reason)
+    VALUES ($1,$2,$3,$4,$5,$6)
+  `
+
+	_, err := tx.ExecContext(ctx, query, action.UserID, action.ActorID, action.Action, action.FromValue, action.ToValue, action.Reason)
+	return err
+}
+
+func (r *UserRepository) LastAdminAction(ctx context.Context, tx *sqlx.Tx, userID string, action string) (*domain.UserAdminAction, error) {
+	query := `
+    SELECT id, user_id, actor_id, action, from_value, to_value, reason, created_at
+    FROM user_admin_actions
+    WHERE user_id = $1 AND action = $2
+    ORDER BY created_at DESC
+    LIMIT 1
+  `
+
+	var record domain.UserAdminAction
+	if err := tx.GetContext(ctx, &record, query, userID, action); err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (r *UserRepository) ListAdminActions(ctx context.Context, userID string) ([]domain.UserAdminAction, error) {
+	query := `
+    SELECT id, user_id, actor_id, action, from_value, to_value, reason, created_at
+    FROM user_admin_actions
+    WHERE user_id = $1
+    ORDER BY created_at DESC
+  `
+
+	actions := []domain.UserAdminAction{}
+	if err := r.db.SelectContext(ctx, &actions, query, userID); err != nil {
+		return nil, err
+	}
+
+	return actions, nil
+}
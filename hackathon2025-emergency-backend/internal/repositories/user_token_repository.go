@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"context"
+
+	"invoiceflow/internal/domain"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type UserTokenRepository struct {
+	db *sqlx.DB
+}
+
+func NewUserTokenRepository(db *sqlx.DB) *UserTokenRepository {
+	return &UserTokenRepository{db: db}
+}
+
+func (r *UserTokenRepository) Create(ctx context.Context, tx *sqlx.Tx, token *domain.UserToken) error {
+	query := `
+    INSERT INTO user_tokens (user_id, purpose, token_hash, expires_at)
+    VALUES ($1,$2,$3,$4)
+  `
+
+	_, err := tx.ExecContext(ctx, query, token.UserID, token.Purpose, token.TokenHash, token.ExpiresAt)
+	return err
+}
+
+func (r *UserTokenRepository) InvalidateForUser(ctx context.Context, tx *sqlx.Tx, userID string, purpose string) error {
+	query := `
+    UPDATE user_tokens
+    SET used_at = now()
+    WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL
+  `
+
+	_, err := tx.ExecContext(ctx, query, userID, purpose)
+	return err
+}
+
+func (r *UserTokenRepository) GetByHashForUpdate(ctx context.Context, tx *sqlx.Tx, purpose string, hash string) (*domain.UserToken, error) {
+	query := `
+    SELECT id, user_id, purpose, token_hash, expires_at, used_at, created_at
+    FROM user_tokens
+    WHERE purpose = $1 AND token_hash = $2
+    FOR UPDATE
+  `
+
+	var token domain.UserToken
+	if err := tx.GetContext(ctx, &token, query, purpose, hash); err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (r *UserTokenRepository) MarkUsed(ctx context.Context, tx *sqlx.Tx, id string) error {
+	_, err := tx.ExecContext(ctx, "UPDATE user_tokens SET used_at = now() WHERE id = $1", id)
+	return err
+}
@@ -0,0 +1,277 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/pagination"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type WebhookRepository struct {
+	db *sqlx.DB
+}
+
+func NewWebhookRepository(db *sqlx.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+const webhookSubscriptionColumns = `id, organization_id, url, description, event_types, secret, active, created_by, created_at, updated_at`
+
+const webhookDeliveryColumns = `id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+      last_attempt_at, last_status_code, last_error, delivered_at, replay_of, created_at`
+
+// scopeCondition restricts subscriptions to one organization, or to platform
+// subscriptions when organizationID is nil.
+func scopeCondition(column string, organizationID *string, args []any) (string, []any) {
+	if organizationID == nil {
+		return column + " IS NULL", args
+	}
+	args = append(args, *organizationID)
+	return fmt.Sprintf("%s = $%d", column, len(args)), args
+}
+
+func (r *WebhookRepository) CreateSubscription(ctx context.Context, sub *domain.WebhookSubscription) (*domain.WebhookSubscription, error) {
+	query := `
+    INSERT INTO webhook_subscriptions (organization_id, url, description, event_types, secret, active, created_by)
+    VALUES ($1,$2,$3,$4,$5,$6,$7)
+    RETURNING ` + webhookSubscriptionColumns
+
+	var created domain.WebhookSubscription
+	if err := r.db.GetContext(ctx, &created, query, sub.OrganizationID, sub.URL, sub.Description, sub.EventTypes, sub.Secret, sub.Active, sub.CreatedBy); err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (r *WebhookRepository) ListSubscriptions(ctx context.Context, organizationID *string) ([]domain.WebhookSubscription, error) {
+	where, args := scopeCondition("organization_id", organizationID, nil)
+	query := fmt.Sprintf(`
+    SELECT %s
+    FROM webhook_subscriptions
+    WHERE %s
+    ORDER BY created_at DESC
+  `, webhookSubscriptionColumns, where)
+
+	subs := []domain.WebhookSubscription{}
+	if err := r.db.SelectContext(ctx, &subs, query, args...); err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+func (r *WebhookRepository) GetSubscription(ctx context.Context, organizationID *string, id string) (*domain.WebhookSubscription, error) {
+	where, args := scopeCondition("organization_id", organizationID, []any{id})
+	query := fmt.Sprintf(`
+    SELECT %s
+    FROM webhook_subscriptions
+    WHERE id = $1 AND %s
+  `, webhookSubscriptionColumns, where)
+
+	var sub domain.WebhookSubscription
+	if err := r.db.GetContext(ctx, &sub, query, args...); err != nil {
+		return nil, err
+	}
+
+	return &sub, nil
+}
+
+func (r *WebhookRepository) UpdateSubscription(ctx context.Context, sub *domain.WebhookSubscription) (*domain.WebhookSubscription, error) {
+	query := `
+    UPDATE webhook_subscriptions
+    SET url = $2, description = $3, event_types = $4, secret = $5, active = $6, updated_at = now()
+    WHERE id = $1
+    RETURNING ` + webhookSubscriptionColumns
+
+	var updated domain.WebhookSubscription
+	if err := r.db.GetContext(ctx, &updated, query, sub.ID, sub.URL, sub.Description, sub.EventTypes, sub.Secret, sub.Active); err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, organizationID *string, id string) (int64, error) {
+	where, args := scopeCondition("organization_id", organizationID, []any{id})
+	result, err := r.db.ExecContext(ctx, fmt.Sprintf("DELETE FROM webhook_subscriptions WHERE id = $1 AND %s", where), args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// MatchSubscriptions returns the active subscriptions that want eventType
+// for any of the organizations: theirs, plus every platform subscription.
+func (r *WebhookRepository) MatchSubscriptions(ctx context.Context, tx *sqlx.Tx, eventType string, organizationIDs []string) ([]domain.WebhookSubscription, error) {
+	args := []any{eventType}
+	placeholders := []string{"NULL"}
+	for _, id := range organizationIDs {
+		args = append(args, id)
+		placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+	}
+
+	query := fmt.Sprintf(`
+    SELECT %s
+    FROM webhook_subscriptions
+    WHERE active
+      AND event_types ? $1
+      AND (organization_id IS NULL OR organization_id IN (%s))
+  `, webhookSubscriptionColumns, strings.Join(placeholders, ","))
+
+	subs := []domain.WebhookSubscription{}
+	if err := tx.SelectContext(ctx, &subs, query, args...); err != nil {
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, tx *sqlx.Tx, delivery *domain.WebhookDelivery) (*domain.WebhookDelivery, error) {
+	query := `
+    INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, replay_of)
+    VALUES ($1,$2,$3,$4,$5)
+    RETURNING ` + webhookDeliveryColumns
+
+	var created domain.WebhookDelivery
+	if err := tx.GetContext(ctx, &created, query, delivery.SubscriptionID, delivery.EventID, delivery.EventType, string(delivery.Payload), delivery.ReplayOf); err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// ClaimDue leases up to limit due deliveries to this worker by pushing their
+// next attempt lease into the future, so the HTTP calls happen outside any
+// transaction and other workers skip them.
+func (r *WebhookRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]domain.WebhookDispatch, error) {
+	query := `
+    WITH due AS (
+      SELECT d.id
+      FROM webhook_deliveries d
+      JOIN webhook_subscriptions s ON s.id = d.subscription_id
+      WHERE d.status = $1 AND d.next_attempt_at <= now() AND s.active
+      ORDER BY d.next_attempt_at
+      LIMIT $2
+      FOR UPDATE OF d SKIP LOCKED
+    )
+    UPDATE webhook_deliveries d
+    SET attempts = d.attempts + 1, last_attempt_at = now(), next_attempt_at = now() + make_interval(secs => $3)
+    FROM due, webhook_subscriptions s
+    WHERE d.id = due.id AND s.id = d.subscription_id
+    RETURNING d.id, d.subscription_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+      d.next_attempt_at, d.last_attempt_at, d.last_status_code, d.last_error, d.delivered_at,
+      d.replay_of, d.created_at, s.url, s.secret
+  `
+
+	dispatches := []domain.WebhookDispatch{}
+	if err := r.db.SelectContext(ctx, &dispatches, query, domain.WebhookDeliveryPending, limit, lease.Seconds()); err != nil {
+		return nil, err
+	}
+
+	return dispatches, nil
+}
+
+func (r *WebhookRepository) MarkSucceeded(ctx context.Context, id string, statusCode int) error {
+	query := `
+    UPDATE webhook_deliveries
+    SET status = $2, last_status_code = $3, last_error = NULL, delivered_at = now()
+    WHERE id = $1
+  `
+
+	_, err := r.db.ExecContext(ctx, query, id, domain.WebhookDeliverySucceeded, statusCode)
+	return err
+}
+
+// MarkAttemptFailed records a failed attempt and schedules the next one at
+// retryAt, or gives up when retryAt is nil.
+func (r *WebhookRepository) MarkAttemptFailed(ctx context.Context, id string, statusCode *int, errMsg string, retryAt *time.Time) error {
+	status := domain.WebhookDeliveryPending
+	if retryAt == nil {
+		status = domain.WebhookDeliveryFailed
+	}
+
+	query := `
+    UPDATE webhook_deliveries
+    SET status = $2, last_status_code = $3, last_error = $4, next_attempt_at = COALESCE($5, next_attempt_at)
+    WHERE id = $1
+  `
+
+	_, err := r.db.ExecContext(ctx, query, id, status, statusCode, errMsg, retryAt)
+	return err
+}
+
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, subscriptionID string, page pagination.Page) ([]domain.WebhookDelivery, pagination.Meta, error) {
+	args := []any{subscriptionID}
+	conditions := []string{"subscription_id = $1"}
+
+	var meta pagination.Meta
+	if page.IncludeTotal {
+		var total int
+		if err := r.db.GetContext(ctx, &total, fmt.Sprintf("SELECT count(*) FROM webhook_deliveries WHERE %s", strings.Join(conditions, " AND ")), args...); err != nil {
+			return nil, meta, err
+		}
+		meta.Total = &total
+	}
+
+	if page.After != nil {
+		condition, afterArgs, err := deliveryOrder.after(page.After, args)
+		if err != nil {
+			return nil, meta, err
+		}
+		conditions = append(conditions, condition)
+		args = afterArgs
+	}
+
+	limit := pageLimit(page)
+	listQuery := fmt.Sprintf(`
+    SELECT %s, %s
+    FROM webhook_deliveries
+    WHERE %s
+    ORDER BY %s
+    LIMIT %d
+  `, webhookDeliveryColumns, deliveryOrder.columns(), strings.Join(conditions, " AND "), deliveryOrder.orderBy(), limit+1)
+
+	var rows []struct {
+		domain.WebhookDelivery
+		cursorColumns
+	}
+	if err := r.db.SelectContext(ctx, &rows, listQuery, args...); err != nil {
+		return nil, meta, err
+	}
+
+	if len(rows) > limit {
+		rows = rows[:limit]
+		meta.NextCursor = deliveryOrder.cursorAt(rows[limit-1].cursorColumns)
+	}
+
+	deliveries := make([]domain.WebhookDelivery, 0, len(rows))
+	for _, row := range rows {
+		deliveries = append(deliveries, row.WebhookDelivery)
+	}
+
+	return deliveries, meta, nil
+}
+
+var deliveryOrder = keyset{name: "created_at", expr: "created_at", exprType: "timestamptz", desc: true, idExpr: "id", idType: "uuid"}
+
+func (r *WebhookRepository) GetDelivery(ctx context.Context, subscriptionID string, id string) (*domain.WebhookDelivery, error) {
+	query := `
+    SELECT ` + webhookDeliveryColumns + `
+    FROM webhook_deliveries
+    WHERE id = $1 AND subscription_id = $2
+  `
+
+	var delivery domain.WebhookDelivery
+	if err := r.db.GetContext(ctx, &delivery, query, id, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
@@ -1,66 +1,262 @@
 package routes
 
 import (
+	"context"
+	"log"
+
 	"invoiceflow/internal/config"
 	"invoiceflow/internal/domain"
+	"invoiceflow/internal/events"
 	"invoiceflow/internal/handlers"
+	"invoiceflow/internal/jwtkeys"
+	"invoiceflow/internal/mailer"
 	"invoiceflow/internal/middleware"
 	"invoiceflow/internal/repositories"
 	"invoiceflow/internal/services"
+	"invoiceflow/internal/stream"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 )
 
-func Register(router *gin.Engine, cfg *config.Config, db *sqlx.DB) {
+// Register wires the services and routes. Background workers run until ctx,
+// the server's lifetime, is cancelled.
+func Register(ctx context.Context, router *gin.Engine, cfg *config.Config, db *sqlx.DB, keys *jwtkeys.KeySet) {
 	userRepo := repositories.NewUserRepository(db)
 	invoiceRepo := repositories.NewInvoiceRepository(db)
 	fundingRepo := repositories.NewFundingRepository(db)
 	chainRepo := repositories.NewChainRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
+	kycRepo := repositories.NewKYCRepository(db)
+	roleRepo := repositories.NewRoleRepository(db)
+	userTokenRepo := repositories.NewUserTokenRepository(db)
+	mfaRepo := repositories.NewMFARepository(db)
+	authEventRepo := repositories.NewAuthEventRepository(db)
+	orgRepo := repositories.NewOrganizationRepository(db)
+	auditRepo := repositories.NewAuditRepository(db)
+	idempotencyRepo := repositories.NewIdempotencyRepository(db)
+	webhookRepo := repositories.NewWebhookRepository(db)
+	outboxRepo := repositories.NewOutboxRepository(db)
+	notificationRepo := repositories.NewNotificationRepository(db)
+	emergencyRepo := repositories.NewEmergencyRepository(db)
+	liquidityRepo := repositories.NewLiquidityRepository(db)
+	autoInvestRepo := repositories.NewAutoInvestRepository(db)
+	marketRepo := repositories.NewMarketRepository(db)
+	portfolioRepo := repositories.NewPortfolioRepository(db)
+	metricsRepo := repositories.NewMetricsRepository(db)
+	mailOutboxRepo := repositories.NewMailOutboxRepository(db)
+
+	mail, err := mailer.New(cfg)
+	if err != nil {
+		log.Fatalf("mailer setup failed: %v", err)
+	}
 
-	authService := services.NewAuthService(cfg, userRepo)
-	invoiceService := services.NewInvoiceService(invoiceRepo)
-	fundingService := services.NewFundingService(db, fundingRepo, invoiceRepo)
-	adminService := services.NewAdminService(db, invoiceRepo)
+	bus := events.NewBus(db, outboxRepo)
+	hub := stream.NewHub()
+	auditService := services.NewAuditService(db, auditRepo, cfg.AuditHMACKey)
+	webhookService := services.NewWebhookService(cfg, db, webhookRepo)
+	mfaService := services.NewMFAService(cfg, db, mfaRepo, sessionRepo, roleRepo)
+	loginGuard := services.NewLoginGuard(cfg, authEventRepo)
+	mailOutbox := services.NewMailOutbox(db, mailOutboxRepo, mail)
+	notificationService := services.NewNotificationService(db, notificationRepo,
+		services.NewInAppChannel(notificationRepo),
+		services.NewEmailChannel(mailOutbox, cfg.AppBaseURL),
+	)
+	orgService := services.NewOrganizationService(cfg, db, orgRepo, userRepo, mailOutbox)
+	authService := services.NewAuthService(cfg, keys, db, userRepo, sessionRepo, roleRepo, userTokenRepo, mailOutbox, mfaService, loginGuard, orgService)
+	invoiceService := services.NewInvoiceService(cfg, db, invoiceRepo, emergencyRepo, orgService, bus)
+	fundingService := services.NewFundingService(db, fundingRepo, invoiceRepo, auditService, bus)
+	adminService := services.NewAdminService(db, invoiceRepo, chainRepo, fundingRepo, emergencyRepo, metricsRepo, auditService, bus)
+	emergencyService := services.NewEmergencyService(cfg, db, emergencyRepo, invoiceRepo, bus)
+	metricsService := services.NewMetricsService(cfg, db, metricsRepo)
+	liquidityService := services.NewLiquidityService(db, liquidityRepo, invoiceRepo, fundingService, auditService)
+	autoInvestService := services.NewAutoInvestService(db, autoInvestRepo, invoiceRepo, fundingService)
+	kycService := services.NewKYCService(db, kycRepo, userRepo, auditService)
+	userAdminService := services.NewUserAdminService(db, userRepo, sessionRepo, roleRepo, authEventRepo, orgService, auditService)
+	roleService := services.NewRoleService(db, roleRepo, auditService)
 
-	chainService, _ := services.NewChainService(cfg, chainRepo, invoiceRepo)
+	chainService, _ := services.NewChainService(cfg, db, chainRepo, invoiceRepo, fundingRepo, auditService, bus)
+	marketService := services.NewMarketService(cfg, db, marketRepo, fundingRepo, invoiceRepo, chainRepo, auditService, bus)
+	portfolioService := services.NewPortfolioService(portfolioRepo)
+
+	bus.Subscribe("notifications", notificationService.HandleEvent, domain.NotificationEventTypes...)
+	bus.Subscribe("webhooks", webhookService.HandleEvent, domain.WebhookEventTypes...)
+	bus.Subscribe("stream", hub.HandleEvent)
+	bus.Subscribe("liquidity", liquidityService.HandleEvent, domain.EventInvoiceApproved)
+	bus.Subscribe("auto-invest", autoInvestService.HandleEvent, domain.EventInvoiceApproved)
+	bus.Start(ctx)
+	mailOutbox.Start(ctx)
+	hub.Start(ctx, cfg.DBURL)
+	webhookService.Start(ctx)
+	emergencyService.Start(ctx)
+	metricsService.Start(ctx)
 
 	authHandler := handlers.NewAuthHandler(authService)
-	invoiceHandler := handlers.NewInvoiceHandler(invoiceService)
-	fundingHandler := handlers.NewFundingHandler(fundingService)
+	invoiceHandler := handlers.NewInvoiceHandler(invoiceService, orgService)
+	fundingHandler := handlers.NewFundingHandler(fundingService, orgService)
 	adminHandler := handlers.NewAdminHandler(adminService)
-	chainHandler := handlers.NewChainHandler(chainService, invoiceService)
+	chainHandler := handlers.NewChainHandler(chainService, invoiceService, orgService)
+	kycHandler := handlers.NewKYCHandler(kycService)
+	userAdminHandler := handlers.NewUserAdminHandler(userAdminService)
+	roleHandler := handlers.NewRoleHandler(roleService)
+	mfaHandler := handlers.NewMFAHandler(mfaService, authService)
+	orgHandler := handlers.NewOrganizationHandler(orgService, authService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService, orgService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService)
+	streamHandler := handlers.NewStreamHandler(hub, orgService, authService)
+	emergencyHandler := handlers.NewEmergencyHandler(emergencyService, liquidityService, orgService)
+	autoInvestHandler := handlers.NewAutoInvestHandler(autoInvestService, orgService)
+	marketHandler := handlers.NewMarketHandler(marketService, orgService)
+	portfolioHandler := handlers.NewPortfolioHandler(portfolioService, orgService)
+
+	requireAuth := middleware.Auth(keys, authService)
+	active := middleware.RequireStatus(domain.UserStatusActive)
+	can := middleware.RequirePermission
+	canAny := middleware.RequireAnyPermission
+	idempotent := middleware.Idempotency(idempotencyRepo, cfg.IdempotencyTTL)
 
 	router.GET("/health", handlers.Health(db))
+	router.GET("/.well-known/jwks.json", handlers.JWKS(keys))
+	router.GET("/stream", middleware.TokenFromQuery("access_token"), requireAuth, active,
+		canAny(domain.PermInvoiceRead, domain.PermInvoiceReadListed, domain.PermInvoiceReadAll), streamHandler.Stream)
 
 	auth := router.Group("/auth")
 	{
 		auth.POST("/register", authHandler.Register)
 		auth.POST("/login", authHandler.Login)
-		auth.GET("/me", middleware.Auth(cfg), authHandler.Me)
+		auth.POST("/mfa/verify", authHandler.VerifyMFA)
+		auth.POST("/refresh", authHandler.Refresh)
+		auth.POST("/verify-email", authHandler.VerifyEmail)
+		auth.POST("/verify-email/resend", requireAuth, authHandler.ResendVerification)
+		auth.POST("/forgot-password", authHandler.ForgotPassword)
+		auth.POST("/reset-password", authHandler.ResetPassword)
+		auth.POST("/logout", requireAuth, authHandler.Logout)
+		auth.GET("/me", requireAuth, authHandler.Me)
 	}
 
 	api := router.Group("/")
-	api.Use(middleware.Auth(cfg))
+	api.Use(requireAuth)
 	{
-		api.POST("/invoices", middleware.RequireRoles(domain.RoleSME), invoiceHandler.Create)
-		api.GET("/invoices", invoiceHandler.List)
-		api.GET("/invoices/:id", invoiceHandler.GetByID)
-		api.POST("/invoices/:id/submit", middleware.RequireRoles(domain.RoleSME), invoiceHandler.Submit)
+		api.POST("/invoices", can(domain.PermInvoiceCreate), active, idempotent, invoiceHandler.Create)
+		api.GET("/invoices", canAny(domain.PermInvoiceRead, domain.PermInvoiceReadListed, domain.PermInvoiceReadAll), invoiceHandler.List)
+		api.GET("/invoices/:id", canAny(domain.PermInvoiceRead, domain.PermInvoiceReadListed, domain.PermInvoiceReadAll), invoiceHandler.GetByID)
+
+		api.GET("/me/mfa", mfaHandler.Status)
+		api.POST("/me/mfa/enroll", mfaHandler.Enroll)
+		api.POST("/me/mfa/activate", mfaHandler.Activate)
+		api.POST("/me/mfa/disable", mfaHandler.Disable)
+		api.POST("/me/mfa/recovery-codes", mfaHandler.RegenerateRecoveryCodes)
+
+		api.GET("/me/notifications", notificationHandler.List)
+		api.GET("/me/notifications/unread-count", notificationHandler.UnreadCount)
+		api.POST("/me/notifications/read-all", notificationHandler.MarkAllRead)
+		api.POST("/me/notifications/:id/read", notificationHandler.MarkRead)
+		api.GET("/me/notification-preferences", notificationHandler.GetPreferences)
+		api.PUT("/me/notification-preferences", notificationHandler.UpdatePreferences)
+
+		api.GET("/orgs", orgHandler.List)
+		api.POST("/orgs", active, orgHandler.Create)
+		api.GET("/orgs/:id/members", orgHandler.ListMembers)
+		api.PATCH("/orgs/:id/members/:user_id", active, orgHandler.UpdateMember)
+		api.DELETE("/orgs/:id/members/:user_id", orgHandler.RemoveMember)
+		api.GET("/orgs/:id/invitations", orgHandler.ListInvitations)
+		api.POST("/orgs/:id/invitations", active, orgHandler.Invite)
+		api.DELETE("/orgs/:id/invitations/:invitation_id", active, orgHandler.RevokeInvitation)
+		api.POST("/invitations/accept", orgHandler.AcceptInvitation)
+
+		api.GET("/orgs/:id/webhooks", webhookHandler.List)
+		api.POST("/orgs/:id/webhooks", active, webhookHandler.Create)
+		api.GET("/orgs/:id/webhooks/:webhook_id", webhookHandler.Get)
+		api.PATCH("/orgs/:id/webhooks/:webhook_id", active, webhookHandler.Update)
+		api.DELETE("/orgs/:id/webhooks/:webhook_id", webhookHandler.Delete)
+		api.POST("/orgs/:id/webhooks/:webhook_id/rotate-secret", active, webhookHandler.RotateSecret)
+		api.GET("/orgs/:id/webhooks/:webhook_id/deliveries", webhookHandler.ListDeliveries)
+		api.POST("/orgs/:id/webhooks/:webhook_id/deliveries/:delivery_id/replay", active, webhookHandler.Replay)
+
+		api.GET("/orgs/:id/liquidity-commitments", emergencyHandler.ListCommitments)
+		api.POST("/orgs/:id/liquidity-commitments", can(domain.PermFundingCreate), active, idempotent, emergencyHandler.Commit)
+		api.POST("/orgs/:id/liquidity-commitments/:commitment_id/withdraw", can(domain.PermFundingCreate), active, emergencyHandler.Withdraw)
+
+		api.GET("/orgs/:id/auto-invest-strategies", autoInvestHandler.List)
+		api.POST("/orgs/:id/auto-invest-strategies", can(domain.PermFundingCreate), active, autoInvestHandler.Create)
+		api.GET("/orgs/:id/auto-invest-strategies/:strategy_id", autoInvestHandler.Get)
+		api.PATCH("/orgs/:id/auto-invest-strategies/:strategy_id", can(domain.PermFundingCreate), active, autoInvestHandler.Update)
+		api.DELETE("/orgs/:id/auto-invest-strategies/:strategy_id", can(domain.PermFundingCreate), autoInvestHandler.Delete)
+		api.GET("/orgs/:id/auto-invest-strategies/:strategy_id/allocations", autoInvestHandler.ListAllocations)
 
-		api.POST("/invoices/:id/fund", middleware.RequireRoles(domain.RoleInvestor), fundingHandler.FundInvoice)
-		api.GET("/me/fundings", middleware.RequireRoles(domain.RoleInvestor), fundingHandler.ListMyFundings)
+		api.POST("/me/kyc", can(domain.PermKYCSubmit), kycHandler.Submit)
+		api.GET("/me/kyc", can(domain.PermKYCSubmit), kycHandler.GetMine)
 
-		api.POST("/invoices/:id/tokenize", middleware.RequireRoles(domain.RoleAdmin, domain.RoleSME), chainHandler.Tokenize)
-		api.GET("/invoices/:id/onchain", middleware.RequireRoles(domain.RoleAdmin, domain.RoleSME), chainHandler.GetOnchain)
-		api.POST("/invoices/:id/onchain/refresh", middleware.RequireRoles(domain.RoleAdmin, domain.RoleSME), chainHandler.RefreshOnchain)
+		api.POST("/invoices/:id/submit", can(domain.PermInvoiceSubmit), active, invoiceHandler.Submit)
+
+		api.POST("/invoices/:id/fund", can(domain.PermFundingCreate), active, idempotent, fundingHandler.FundInvoice)
+		api.GET("/me/fundings", can(domain.PermFundingReadOwn), active, fundingHandler.ListMyFundings)
+
+		api.GET("/market/listings", can(domain.PermFundingReadOwn), active, marketHandler.ListListings)
+		api.GET("/market/listings/:id", can(domain.PermFundingReadOwn), active, marketHandler.GetListing)
+		api.POST("/market/listings", can(domain.PermFundingCreate), active, idempotent, marketHandler.CreateListing)
+		api.POST("/market/listings/:id/cancel", can(domain.PermFundingCreate), active, marketHandler.CancelListing)
+		api.POST("/market/listings/:id/buy", can(domain.PermFundingCreate), active, idempotent, marketHandler.Buy)
+		api.GET("/me/listings", can(domain.PermFundingReadOwn), active, marketHandler.ListMyListings)
+		api.GET("/me/transfers", can(domain.PermFundingReadOwn), active, marketHandler.ListMyTransfers)
+		api.GET("/me/portfolio", can(domain.PermFundingReadOwn), active, portfolioHandler.Get)
+
+		api.GET("/chain/profiles", can(domain.PermChainRead), active, chainHandler.ListProfiles)
+		api.POST("/invoices/:id/tokenize", can(domain.PermChainTokenize), active, idempotent, chainHandler.Tokenize)
+		api.GET("/invoices/:id/onchain", can(domain.PermChainRead), active, chainHandler.GetOnchain)
+		api.POST("/invoices/:id/onchain/refresh", can(domain.PermChainRefresh), active, chainHandler.RefreshOnchain)
+
+		// Roles are told they need MFA from domain.AdminPermissions, so the
+		// group only accepts permissions listed there.
+		adminCan := func(permission string) gin.HandlerFunc {
+			if !domain.IsAdminPermission(permission) {
+				panic("admin route checks " + permission + ", which is not in domain.AdminPermissions")
+			}
+			return can(permission)
+		}
 
 		admin := api.Group("/admin")
-		admin.Use(middleware.RequireRoles(domain.RoleAdmin))
+		admin.Use(active, middleware.RequireMFA())
 		{
-			admin.POST("/invoices/:id/approve", adminHandler.ApproveInvoice)
-			admin.POST("/invoices/:id/mark-paid", adminHandler.MarkPaid)
-			admin.GET("/dashboard/metrics", adminHandler.DashboardMetrics)
+			admin.POST("/invoices/:id/approve", adminCan(domain.PermInvoiceApprove), idempotent, adminHandler.ApproveInvoice)
+			admin.POST("/invoices/:id/mark-paid", adminCan(domain.PermInvoiceMarkPaid), idempotent, adminHandler.MarkPaid)
+			admin.POST("/invoices/:id/mark-defaulted", adminCan(domain.PermInvoiceMarkDefaulted), idempotent, adminHandler.MarkDefaulted)
+			admin.GET("/dashboard/metrics", adminCan(domain.PermMetricsRead), adminHandler.DashboardMetrics)
+			admin.GET("/chain/costs", adminCan(domain.PermMetricsRead), adminHandler.ChainCosts)
+			admin.GET("/emergency/queue", adminCan(domain.PermInvoiceApprove), emergencyHandler.Queue)
+			admin.GET("/emergency/liquidity", adminCan(domain.PermMetricsRead), emergencyHandler.PoolBalances)
+
+			admin.GET("/kyc", adminCan(domain.PermKYCRead), kycHandler.List)
+			admin.GET("/kyc/:id", adminCan(domain.PermKYCRead), kycHandler.Get)
+			admin.POST("/kyc/:id/approve", adminCan(domain.PermKYCReview), kycHandler.Approve)
+			admin.POST("/kyc/:id/reject", adminCan(domain.PermKYCReview), kycHandler.Reject)
+
+			admin.GET("/users", adminCan(domain.PermUsersRead), userAdminHandler.List)
+			admin.POST("/users", adminCan(domain.PermUsersManage), userAdminHandler.CreateAdmin)
+			admin.GET("/users/:id", adminCan(domain.PermUsersRead), userAdminHandler.Get)
+			admin.POST("/users/:id/suspend", adminCan(domain.PermUsersManage), userAdminHandler.Suspend)
+			admin.POST("/users/:id/reactivate", adminCan(domain.PermUsersManage), userAdminHandler.Reactivate)
+			admin.POST("/users/:id/unlock", adminCan(domain.PermUsersManage), userAdminHandler.Unlock)
+			admin.PATCH("/users/:id/role", adminCan(domain.PermUsersManage), userAdminHandler.ChangeRole)
+			admin.GET("/auth-events", adminCan(domain.PermUsersRead), userAdminHandler.AuthEvents)
+
+			admin.GET("/roles", adminCan(domain.PermUsersRead), roleHandler.List)
+			admin.GET("/permissions", adminCan(domain.PermUsersRead), roleHandler.ListPermissions)
+			admin.PUT("/roles/:name", adminCan(domain.PermRolesManage), roleHandler.Put)
+			admin.DELETE("/roles/:name", adminCan(domain.PermRolesManage), roleHandler.Delete)
+
+			admin.GET("/audit", adminCan(domain.PermAuditRead), auditHandler.List)
+			admin.GET("/audit/verify", adminCan(domain.PermAuditRead), auditHandler.Verify)
+
+			admin.GET("/webhooks", adminCan(domain.PermWebhooksManage), webhookHandler.List)
+			admin.POST("/webhooks", adminCan(domain.PermWebhooksManage), webhookHandler.Create)
+			admin.GET("/webhooks/:webhook_id", adminCan(domain.PermWebhooksManage), webhookHandler.Get)
+			admin.PATCH("/webhooks/:webhook_id", adminCan(domain.PermWebhooksManage), webhookHandler.Update)
+			admin.DELETE("/webhooks/:webhook_id", adminCan(domain.PermWebhooksManage), webhookHandler.Delete)
+			admin.POST("/webhooks/:webhook_id/rotate-secret", adminCan(domain.PermWebhooksManage), webhookHandler.RotateSecret)
+			admin.GET("/webhooks/:webhook_id/deliveries", adminCan(domain.PermWebhooksManage), webhookHandler.ListDeliveries)
+			admin.POST("/webhooks/:webhook_id/deliveries/:delivery_id/replay", adminCan(domain.PermWebhooksManage), webhookHandler.Replay)
 		}
 	}
 }
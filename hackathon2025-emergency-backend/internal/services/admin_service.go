@@ -3,24 +3,53 @@ package services
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
 
 	"invoiceflow/internal/domain"
+	"invoiceflow/internal/events"
+	"invoiceflow/internal/pagination"
 	"invoiceflow/internal/repositories"
 
 	"github.com/jmoiron/sqlx"
 )
 
 type AdminService struct {
-	db          *sqlx.DB
-	invoiceRepo *repositories.InvoiceRepository
+	db            *sqlx.DB
+	invoiceRepo   *repositories.InvoiceRepository
+	chainRepo     *repositories.ChainRepository
+	fundingRepo   *repositories.FundingRepository
+	emergencyRepo *repositories.EmergencyRepository
+	metricsRepo   metricsSource
+	audit         *AuditService
+	bus           *events.Bus
 }
 
-func NewAdminService(db *sqlx.DB, invoiceRepo *repositories.InvoiceRepository) *AdminService {
-	return &AdminService{db: db, invoiceRepo: invoiceRepo}
+// metricsSource is the part of MetricsRepository the dashboard reads.
+type metricsSource interface {
+	FundingVolume(ctx context.Context, currency string, interval string, from time.Time, to time.Time) ([]repositories.FundingVolumeBucket, error)
+	TopFundingCurrency(ctx context.Context, from time.Time, to time.Time) (string, error)
+	InvoiceLifecycle(ctx context.Context, from time.Time, to time.Time) (*repositories.InvoiceLifecycle, error)
+	MintOutcomes(ctx context.Context, from time.Time, to time.Time) (*repositories.MintOutcomes, error)
+	OpenRisk(ctx context.Context, today time.Time, horizon time.Time) (atRisk int, overdue int, err error)
+	ActiveSMEs(ctx context.Context, cutoff time.Time, previousCutoff time.Time) (count int, previous int, err error)
+}
+
+func NewAdminService(db *sqlx.DB, invoiceRepo *repositories.InvoiceRepository, chainRepo *repositories.ChainRepository, fundingRepo *repositories.FundingRepository, emergencyRepo *repositories.EmergencyRepository, metricsRepo *repositories.MetricsRepository, audit *AuditService, bus *events.Bus) *AdminService {
+	return &AdminService{db: db, invoiceRepo: invoiceRepo, chainRepo: chainRepo, fundingRepo: fundingRepo, emergencyRepo: emergencyRepo, metricsRepo: metricsRepo, audit: audit, bus: bus}
 }
 
 func (s *AdminService) ApproveInvoice(ctx context.Context, invoiceID string, riskTier string, aprPercent float64) (*domain.Invoice, error) {
-	invoice, err := s.invoiceRepo.GetByID(ctx, invoiceID)
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	invoice, err := s.invoiceRepo.GetByIDForUpdate(ctx, tx, invoiceID)
 	if err != nil {
 		return nil, err
 	}
@@ -29,11 +58,42 @@ func (s *AdminService) ApproveInvoice(ctx context.Context, invoiceID string, ris
 		return nil, ErrInvoiceInvalidStatus
 	}
 
-	return s.invoiceRepo.Approve(ctx, invoiceID, riskTier, aprPercent)
+	approved, err := s.invoiceRepo.Approve(ctx, tx, invoiceID, riskTier, aprPercent)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.emergencyRepo.ResolveReview(ctx, tx, invoiceID); err != nil {
+		return nil, err
+	}
+
+	if err := s.bus.Publish(ctx, tx, domain.EventInvoiceApproved, domain.AggregateInvoice, invoiceID, []string{approved.OrganizationID}, map[string]any{
+		"invoice": approved,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(ctx, tx, domain.AuditEntityInvoice, invoiceID, domain.AuditActionInvoiceApprove, invoice, approved); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return approved, nil
 }
 
+// MarkPaid records a repayment of amount. Payments accumulate in
+// paid_amount; the invoice is PAID once they cover its face amount.
 func (s *AdminService) MarkPaid(ctx context.Context, invoiceID string, amount float64) (*domain.Invoice, error) {
-	invoice, err := s.invoiceRepo.GetByID(ctx, invoiceID)
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	invoice, err := s.invoiceRepo.GetByIDForUpdate(ctx, tx, invoiceID)
 	if err != nil {
 		return nil, err
 	}
@@ -43,17 +103,131 @@ func (s *AdminService) MarkPaid(ctx context.Context, invoiceID string, amount fl
 	}
 
 	status := domain.InvoiceStatusPartiallyPaid
-	if amount >= invoice.Amount {
+	if invoice.PaidAmount+amount >= invoice.Amount {
 		status = domain.InvoiceStatusPaid
 	}
 
-	return s.invoiceRepo.MarkPaid(ctx, invoiceID, status)
+	paid, err := s.invoiceRepo.MarkPaid(ctx, tx, invoiceID, amount, status)
+	if err != nil {
+		return nil, err
+	}
+
+	orgIDs, err := s.fundingRepo.OrganizationIDsForInvoice(ctx, tx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	event := domain.EventInvoicePartiallyPaid
+	if status == domain.InvoiceStatusPaid {
+		event = domain.EventInvoicePaid
+	}
+	if err := s.bus.Publish(ctx, tx, event, domain.AggregateInvoice, invoiceID, append(orgIDs, paid.OrganizationID), map[string]any{
+		"invoice": paid,
+		"amount":  amount,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(ctx, tx, domain.AuditEntityInvoice, invoiceID, domain.AuditActionInvoiceMarkPaid, invoice, paid); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return paid, nil
+}
+
+// MarkDefaulted closes a funded invoice whose issuer stopped repaying.
+func (s *AdminService) MarkDefaulted(ctx context.Context, invoiceID string) (*domain.Invoice, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	invoice, err := s.invoiceRepo.GetByIDForUpdate(ctx, tx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if invoice.Status != domain.InvoiceStatusFunded && invoice.Status != domain.InvoiceStatusPartiallyPaid {
+		return nil, ErrInvoiceInvalidStatus
+	}
+
+	defaulted, err := s.invoiceRepo.UpdateStatus(ctx, tx, invoiceID, domain.InvoiceStatusDefaulted)
+	if err != nil {
+		return nil, err
+	}
+
+	orgIDs, err := s.fundingRepo.OrganizationIDsForInvoice(ctx, tx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.bus.Publish(ctx, tx, domain.EventInvoiceDefaulted, domain.AggregateInvoice, invoiceID, append(orgIDs, defaulted.OrganizationID), map[string]any{
+		"invoice": defaulted,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(ctx, tx, domain.AuditEntityInvoice, invoiceID, domain.AuditActionInvoiceMarkDefaulted, invoice, defaulted); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return defaulted, nil
+}
+
+var ErrMetricsRangeInvalid = errors.New("invalid metrics range")
+
+const (
+	MetricsIntervalDay   = "day"
+	MetricsIntervalWeek  = "week"
+	MetricsIntervalMonth = "month"
+
+	metricsDefaultDays = 30
+	metricsMaxBuckets  = 366
+	atRiskWindowDays   = 7
+)
+
+// MetricsRange is the period the dashboard covers. From and To are inclusive
+// UTC days; zero values default to the last 30 days by day. Currency picks
+// the funding volume series and defaults to the most funded one.
+type MetricsRange struct {
+	From     time.Time
+	To       time.Time
+	Interval string
+	Currency string
 }
 
 type DashboardMetrics struct {
-	Stats            []StatMetric         `json:"stats"`
-	FundingVolume    FundingVolumeMetrics `json:"funding_volume"`
-	RiskDistribution []RiskDistribution   `json:"risk_distribution"`
+	Period           MetricsPeriod             `json:"period"`
+	Stats            []StatMetric              `json:"stats"`
+	FundingVolume    FundingVolumeMetrics      `json:"funding_volume"`
+	Lifecycle        LifecycleMetrics          `json:"lifecycle"`
+	Risk             RiskMetrics               `json:"risk"`
+	RiskDistribution []RiskDistribution        `json:"risk_distribution"`
+	ChainCosts       []domain.ChainCostSummary `json:"chain_costs"`
+}
+
+// MetricsPeriod echoes the resolved range and the equally long period just
+// before it, which deltas compare against.
+type MetricsPeriod struct {
+	From         string `json:"from"`
+	To           string `json:"to"`
+	Interval     string `json:"interval"`
+	PreviousFrom string `json:"previous_from"`
+	PreviousTo   string `json:"previous_to"`
+}
+
+type ChainCosts struct {
+	Chains   []domain.ChainCostSummary `json:"chains"`
+	Invoices []domain.InvoiceChainCost `json:"invoices"`
 }
 
 type StatMetric struct {
@@ -65,14 +239,43 @@ type StatMetric struct {
 }
 
 type FundingVolumeMetrics struct {
-	TotalAmount float64            `json:"total_amount"`
-	ChangePct   float64            `json:"change_pct"`
-	Series      []FundingDataPoint `json:"series"`
+	Currency       string             `json:"currency"`
+	TotalAmount    float64            `json:"total_amount"`
+	PreviousAmount float64            `json:"previous_amount"`
+	ChangePct      float64            `json:"change_pct"`
+	Series         []FundingDataPoint `json:"series"`
 }
 
 type FundingDataPoint struct {
-	Label  string  `json:"label"`
-	Amount float64 `json:"amount"`
+	Label    string  `json:"label"`
+	Amount   float64 `json:"amount"`
+	Fundings int     `json:"fundings"`
+}
+
+// LifecycleMetrics counts the invoice transitions that happened in a period;
+// the average APR is over the invoices approved in it. Rates and averages are
+// null when there is nothing to measure.
+type LifecycleMetrics struct {
+	Submitted          int               `json:"submitted"`
+	Approved           int               `json:"approved"`
+	Funded             int               `json:"funded"`
+	Paid               int               `json:"paid"`
+	Defaulted          int               `json:"defaulted"`
+	AvgAPRPercent      *float64          `json:"avg_apr_percent"`
+	DefaultRate        *float64          `json:"default_rate"`
+	AvgHoursToApproval *float64          `json:"avg_hours_to_approval"`
+	AvgHoursToFunded   *float64          `json:"avg_hours_to_funded"`
+	MintSuccessRate    *float64          `json:"mint_success_rate"`
+	Previous           *LifecycleMetrics `json:"previous,omitempty"`
+}
+
+// RiskMetrics are funded invoices not yet repaid, as of now whatever the
+// range: overdue ones are past their due date, at-risk ones are overdue or due
+// within WindowDays.
+type RiskMetrics struct {
+	AtRisk     int `json:"at_risk"`
+	Overdue    int `json:"overdue"`
+	WindowDays int `json:"window_days"`
 }
 
 type RiskDistribution struct {
@@ -80,19 +283,53 @@ type RiskDistribution struct {
 	Ratio float64 `json:"ratio"`
 }
 
-func (s *AdminService) GetDashboardMetrics(ctx context.Context) (*DashboardMetrics, error) {
-	var activeSMEs int
-	if err := s.db.GetContext(ctx, &activeSMEs, "SELECT count(*) FROM users WHERE role = $1 AND status = $2", domain.RoleSME, domain.UserStatusActive); err != nil {
+// GetDashboardMetrics builds the admin dashboard for a range. Volumes,
+// lifecycle counts and mint outcomes come from the daily rollups, so they lag
+// by up to the refresh interval; risk counts are read live.
+func (s *AdminService) GetDashboardMetrics(ctx context.Context, metricsRange MetricsRange) (*DashboardMetrics, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	if metricsRange.Interval == "" {
+		metricsRange.Interval = MetricsIntervalDay
+	}
+
+	from, end, previousFrom, err := metricsWindow(metricsRange, today)
+	if err != nil {
+		return nil, err
+	}
+	buckets, err := metricsBuckets(from, end, metricsRange.Interval)
+	if err != nil {
 		return nil, err
 	}
 
-	var fundedInvoices int
-	if err := s.db.GetContext(ctx, &fundedInvoices, "SELECT count(*) FROM invoices WHERE status IN ($1, $2)", domain.InvoiceStatusFunded, domain.InvoiceStatusPaid); err != nil {
+	currency := strings.ToUpper(metricsRange.Currency)
+	if currency == "" {
+		if currency, err = s.metricsRepo.TopFundingCurrency(ctx, from, end); err != nil {
+			return nil, err
+		}
+	}
+
+	volume, err := s.fundingVolume(ctx, currency, metricsRange.Interval, buckets, from, end, previousFrom)
+	if err != nil {
 		return nil, err
 	}
 
-	var avgAPR sql.NullFloat64
-	if err := s.db.GetContext(ctx, &avgAPR, "SELECT avg(apr_percent) FROM invoices WHERE apr_percent IS NOT NULL"); err != nil {
+	lifecycle, err := s.lifecycle(ctx, from, end)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.lifecycle(ctx, previousFrom, from)
+	if err != nil {
+		return nil, err
+	}
+	lifecycle.Previous = previous
+
+	atRisk, overdue, err := s.metricsRepo.OpenRisk(ctx, today, today.AddDate(0, 0, atRiskWindowDays+1))
+	if err != nil {
+		return nil, err
+	}
+
+	activeSMEs, previousSMEs, err := s.metricsRepo.ActiveSMEs(ctx, end, from)
+	if err != nil {
 		return nil, err
 	}
 
@@ -121,49 +358,239 @@ func (s *AdminService) GetDashboardMetrics(ctx context.Context) (*DashboardMetri
 		}
 	}
 
-	avgAprValue := 0.0
-	if avgAPR.Valid {
-		avgAprValue = avgAPR.Float64
+	chainCosts, err := s.chainRepo.CostSummary(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	aprDelta := ""
+	if lifecycle.AvgAPRPercent != nil && previous.AvgAPRPercent != nil {
+		aprDelta = fmt.Sprintf("%+.2f pp", *lifecycle.AvgAPRPercent-*previous.AvgAPRPercent)
 	}
 
 	metrics := &DashboardMetrics{
+		Period: MetricsPeriod{
+			From:         from.Format(metricsDateLayout),
+			To:           end.AddDate(0, 0, -1).Format(metricsDateLayout),
+			Interval:     metricsRange.Interval,
+			PreviousFrom: previousFrom.Format(metricsDateLayout),
+			PreviousTo:   from.AddDate(0, 0, -1).Format(metricsDateLayout),
+		},
 		Stats: []StatMetric{
 			{
 				Label: "Active SMEs",
 				Value: activeSMEs,
-				Delta: "",
+				Delta: formatDelta(float64(activeSMEs), float64(previousSMEs)),
 				Icon:  "building",
 				Tone:  "primary",
 			},
 			{
 				Label: "Funded invoices",
-				Value: fundedInvoices,
-				Delta: "",
+				Value: lifecycle.Funded,
+				Delta: formatDelta(float64(lifecycle.Funded), float64(previous.Funded)),
 				Icon:  "coins",
 				Tone:  "success",
 			},
 			{
 				Label: "Avg. APR",
-				Value: avgAprValue,
-				Delta: "",
+				Value: lifecycle.AvgAPRPercent,
+				Delta: aprDelta,
 				Icon:  "trend",
 				Tone:  "warning",
 			},
 			{
 				Label: "At-risk",
-				Value: 0,
-				Delta: "",
+				Value: atRisk,
+				Delta: fmt.Sprintf("%d overdue", overdue),
 				Icon:  "alert",
 				Tone:  "danger",
 			},
 		},
-		FundingVolume: FundingVolumeMetrics{
-			TotalAmount: 0,
-			ChangePct:   0,
-			Series:      []FundingDataPoint{},
+		FundingVolume: *volume,
+		Lifecycle:     *lifecycle,
+		Risk: RiskMetrics{
+			AtRisk:     atRisk,
+			Overdue:    overdue,
+			WindowDays: atRiskWindowDays,
 		},
 		RiskDistribution: distribution,
+		ChainCosts:       chainCosts,
 	}
 
 	return metrics, nil
 }
+
+const (
+	metricsDateLayout  = "2006-01-02"
+	metricsMonthLayout = "2006-01"
+)
+
+// fundingVolume is the series for a currency with a point for every bucket,
+// empty ones included, and its total against the previous period.
+func (s *AdminService) fundingVolume(ctx context.Context, currency string, interval string, buckets []time.Time, from time.Time, end time.Time, previousFrom time.Time) (*FundingVolumeMetrics, error) {
+	volume := &FundingVolumeMetrics{Currency: currency, Series: []FundingDataPoint{}}
+	if currency == "" {
+		return volume, nil
+	}
+
+	rows, err := s.metricsRepo.FundingVolume(ctx, currency, interval, from, end)
+	if err != nil {
+		return nil, err
+	}
+	previousRows, err := s.metricsRepo.FundingVolume(ctx, currency, interval, previousFrom, from)
+	if err != nil {
+		return nil, err
+	}
+
+	byBucket := map[string]repositories.FundingVolumeBucket{}
+	for _, row := range rows {
+		byBucket[row.Bucket.Format(metricsDateLayout)] = row
+		volume.TotalAmount += row.Volume
+	}
+	for _, row := range previousRows {
+		volume.PreviousAmount += row.Volume
+	}
+
+	layout := metricsDateLayout
+	if interval == MetricsIntervalMonth {
+		layout = metricsMonthLayout
+	}
+	for _, bucket := range buckets {
+		row := byBucket[bucket.Format(metricsDateLayout)]
+		volume.Series = append(volume.Series, FundingDataPoint{
+			Label:    bucket.Format(layout),
+			Amount:   roundCents(row.Volume),
+			Fundings: row.Fundings,
+		})
+	}
+
+	volume.TotalAmount = roundCents(volume.TotalAmount)
+	volume.PreviousAmount = roundCents(volume.PreviousAmount)
+	if volume.PreviousAmount > 0 {
+		volume.ChangePct = math.Round((volume.TotalAmount-volume.PreviousAmount)/volume.PreviousAmount*1000) / 10
+	}
+
+	return volume, nil
+}
+
+func (s *AdminService) lifecycle(ctx context.Context, from time.Time, end time.Time) (*LifecycleMetrics, error) {
+	counts, err := s.metricsRepo.InvoiceLifecycle(ctx, from, end)
+	if err != nil {
+		return nil, err
+	}
+
+	mints, err := s.metricsRepo.MintOutcomes(ctx, from, end)
+	if err != nil {
+		return nil, err
+	}
+
+	lifecycle := &LifecycleMetrics{
+		Submitted: counts.Submitted,
+		Approved:  counts.Approved,
+		Funded:    counts.Funded,
+		Paid:      counts.Paid,
+		Defaulted: counts.Defaulted,
+	}
+	if counts.Approved > 0 {
+		apr := roundCents(counts.APRSum / float64(counts.Approved))
+		lifecycle.AvgAPRPercent = &apr
+	}
+	if closed := counts.Paid + counts.Defaulted; closed > 0 {
+		lifecycle.DefaultRate = ratio(float64(counts.Defaulted), float64(closed))
+	}
+	if counts.ApprovalSamples > 0 {
+		lifecycle.AvgHoursToApproval = hours(counts.ApprovalSeconds / float64(counts.ApprovalSamples))
+	}
+	if counts.FundingSamples > 0 {
+		lifecycle.AvgHoursToFunded = hours(counts.FundingSeconds / float64(counts.FundingSamples))
+	}
+	// Mints still pending have no outcome yet.
+	if settled := mints.Confirmed + mints.Failed; settled > 0 {
+		lifecycle.MintSuccessRate = ratio(float64(mints.Confirmed), float64(settled))
+	}
+
+	return lifecycle, nil
+}
+
+// metricsWindow resolves a range to the days [from, end), defaulting to the
+// last metricsDefaultDays up to today, and the previous period of the same
+// length that ends where it starts.
+func metricsWindow(metricsRange MetricsRange, today time.Time) (from time.Time, end time.Time, previousFrom time.Time, err error) {
+	if metricsRange.To.IsZero() {
+		metricsRange.To = today
+	}
+	if metricsRange.From.IsZero() {
+		metricsRange.From = metricsRange.To.AddDate(0, 0, 1-metricsDefaultDays)
+	}
+
+	from = metricsRange.From.UTC().Truncate(24 * time.Hour)
+	end = metricsRange.To.UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	if !end.After(from) {
+		return time.Time{}, time.Time{}, time.Time{}, ErrMetricsRangeInvalid
+	}
+	return from, end, from.Add(-end.Sub(from)), nil
+}
+
+// metricsBuckets lists the start of every bucket overlapping [from, end),
+// aligned the way Postgres date_trunc aligns them: weeks start on Monday.
+func metricsBuckets(from time.Time, end time.Time, interval string) ([]time.Time, error) {
+	var bucket time.Time
+	var step func(time.Time) time.Time
+	switch interval {
+	case MetricsIntervalDay:
+		bucket = from
+		step = func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }
+	case MetricsIntervalWeek:
+		bucket = from.AddDate(0, 0, -((int(from.Weekday()) + 6) % 7))
+		step = func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }
+	case MetricsIntervalMonth:
+		bucket = time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+		step = func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }
+	default:
+		return nil, ErrMetricsRangeInvalid
+	}
+
+	var buckets []time.Time
+	for ; bucket.Before(end); bucket = step(bucket) {
+		if len(buckets) == metricsMaxBuckets {
+			return nil, ErrMetricsRangeInvalid
+		}
+		buckets = append(buckets, bucket)
+	}
+	return buckets, nil
+}
+
+// formatDelta is the change from previous to current as a signed percentage.
+func formatDelta(current float64, previous float64) string {
+	if previous == 0 {
+		if current == 0 {
+			return "+0.0%"
+		}
+		return "new"
+	}
+	return fmt.Sprintf("%+.1f%%", (current-previous)/previous*100)
+}
+
+func ratio(part float64, total float64) *float64 {
+	value := math.Round(part/total*1e4) / 1e4
+	return &value
+}
+
+func hours(seconds float64) *float64 {
+	value := math.Round(seconds/3600*10) / 10
+	return &value
+}
+
+func (s *AdminService) GetChainCosts(ctx context.Context, invoiceID string, page pagination.Page) (*ChainCosts, pagination.Meta, error) {
+	summary, err := s.chainRepo.CostSummary(ctx)
+	if err != nil {
+		return nil, pagination.Meta{}, err
+	}
+
+	invoices, meta, err := s.chainRepo.ListInvoiceCosts(ctx, invoiceID, page)
+	if err != nil {
+		return nil, meta, err
+	}
+
+	return &ChainCosts{Chains: summary, Invoices: invoices}, meta, nil
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"invoiceflow/internal/repositories"
+)
+
+func TestMetricsWindow(t *testing.T) {
+	today := day("2026-10-19")
+
+	cases := []struct {
+		name                    string
+		metricsRange            MetricsRange
+		from, end, previousFrom string
+		err                     error
+	}{
+		{"defaults to the last 30 days", MetricsRange{}, "2026-09-20", "2026-10-20", "2026-08-21", nil},
+		{"single day", MetricsRange{From: day("2026-10-01"), To: day("2026-10-01")}, "2026-10-01", "2026-10-02", "2026-09-30", nil},
+		{"previous period ends where the range starts", MetricsRange{From: day("2026-03-01"), To: day("2026-03-31")}, "2026-03-01", "2026-04-01", "2026-01-29", nil},
+		{"to before from", MetricsRange{From: day("2026-10-02"), To: day("2026-10-01")}, "", "", "", ErrMetricsRangeInvalid},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			from, end, previousFrom, err := metricsWindow(tc.metricsRange, today)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("err = %v, want %v", err, tc.err)
+			}
+			if tc.err != nil {
+				return
+			}
+			got := []string{from.Format(metricsDateLayout), end.Format(metricsDateLayout), previousFrom.Format(metricsDateLayout)}
+			want := []string{tc.from, tc.end, tc.previousFrom}
+			if got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+				t.Fatalf("window = %v, want %v", got, want)
+			}
+			if from.Sub(previousFrom) != end.Sub(from) {
+				t.Fatalf("previous period lasts %v, range lasts %v", from.Sub(previousFrom), end.Sub(from))
+			}
+		})
+	}
+}
+
+func TestMetricsBuckets(t *testing.T) {
+	cases := []struct {
+		name     string
+		from     string
+		end      string
+		interval string
+		want     []string
+	}{
+		{"days", "2026-10-01", "2026-10-04", MetricsIntervalDay, []string{"2026-10-01", "2026-10-02", "2026-10-03"}},
+		{"weeks from a Wednesday start on the Monday before", "2026-10-14", "2026-10-27", MetricsIntervalWeek, []string{"2026-10-12", "2026-10-19", "2026-10-26"}},
+		{"weeks from a Sunday start six days earlier", "2026-10-18", "2026-10-20", MetricsIntervalWeek, []string{"2026-10-12", "2026-10-19"}},
+		{"weeks from a Monday start on it", "2026-10-19", "2026-10-20", MetricsIntervalWeek, []string{"2026-10-19"}},
+		{"months start on the first", "2026-01-15", "2026-03-02", MetricsIntervalMonth, []string{"2026-01-01", "2026-02-01", "2026-03-01"}},
+		{"month ending on a boundary", "2026-01-01", "2026-02-01", MetricsIntervalMonth, []string{"2026-01-01"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			buckets, err := metricsBuckets(day(tc.from), day(tc.end), tc.interval)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(buckets) != len(tc.want) {
+				t.Fatalf("got %d buckets, want %v", len(buckets), tc.want)
+			}
+			for i, bucket := range buckets {
+				if got := bucket.Format(metricsDateLayout); got != tc.want[i] {
+					t.Fatalf("bucket %d = %s, want %s", i, got, tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMetricsBucketsRejectsBadRanges(t *testing.T) {
+	if _, err := metricsBuckets(day("2026-01-01"), day("2026-01-02"), "hour"); !errors.Is(err, ErrMetricsRangeInvalid) {
+		t.Fatalf("unknown interval: err = %v, want ErrMetricsRangeInvalid", err)
+	}
+	if _, err := metricsBuckets(day("2024-01-01"), day("2026-01-01"), MetricsIntervalDay); !errors.Is(err, ErrMetricsRangeInvalid) {
+		t.Fatalf("too many buckets: err = %v, want ErrMetricsRangeInvalid", err)
+	}
+}
+
+func TestFormatDelta(t *testing.T) {
+	cases := []struct {
+		current, previous float64
+		want              string
+	}{
+		{0, 0, "+0.0%"},
+		{5, 0, "new"},
+		{150, 100, "+50.0%"},
+		{50, 100, "-50.0%"},
+		{100, 100, "+0.0%"},
+		{1, 3, "-66.7%"},
+	}
+
+	for _, tc := range cases {
+		if got := formatDelta(tc.current, tc.previous); got != tc.want {
+			t.Fatalf("formatDelta(%v, %v) = %q, want %q", tc.current, tc.previous, got, tc.want)
+		}
+	}
+}
+
+// memoryMetrics serves fixed rollups and records the ranges it was asked for.
+type memoryMetrics struct {
+	volume    map[time.Time][]repositories.FundingVolumeBucket
+	lifecycle repositories.InvoiceLifecycle
+	mints     repositories.MintOutcomes
+	asked     [][2]time.Time
+}
+
+func (m *memoryMetrics) FundingVolume(ctx context.Context, currency string, interval string, from time.Time, to time.Time) ([]repositories.FundingVolumeBucket, error) {
+	m.asked = append(m.asked, [2]time.Time{from, to})
+	return m.volume[from], nil
+}
+
+func (m *memoryMetrics) TopFundingCurrency(ctx context.Context, from time.Time, to time.Time) (string, error) {
+	return "EUR", nil
+}
+
+func (m *memoryMetrics) InvoiceLifecycle(ctx context.Context, from time.Time, to time.Time) (*repositories.InvoiceLifecycle, error) {
+	lifecycle := m.lifecycle
+	return &lifecycle, nil
+}
+
+func (m *memoryMetrics) MintOutcomes(ctx context.Context, from time.Time, to time.Time) (*repositories.MintOutcomes, error) {
+	mints := m.mints
+	return &mints, nil
+}
+
+func (m *memoryMetrics) OpenRisk(ctx context.Context, today time.Time, horizon time.Time) (int, int, error) {
+	return 0, 0, nil
+}
+
+func (m *memoryMetrics) ActiveSMEs(ctx context.Context, cutoff time.Time, previousCutoff time.Time) (int, int, error) {
+	return 0, 0, nil
+}
+
+func TestFundingVolume(t *testing.T) {
+	from, end, previousFrom := day("2026-01-01"), day("2026-04-01"), day("2025-10-03")
+	metrics := &memoryMetrics{volume: map[time.Time][]repositories.FundingVolumeBucket{
+		from: {
+			{Bucket: day("2026-01-01"), Fundings: 2, Volume: 999.999},
+			{Bucket: day("2026-03-01"), Fundings: 1, Volume: 500},
+		},
+		previousFrom: {
+			{Bucket: day("2025-11-01"), Fundings: 4, Volume: 1200},
+		},
+	}}
+	service := &AdminService{metricsRepo: metrics}
+
+	buckets, err := metricsBuckets(from, end, MetricsIntervalMonth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	volume, err := service.fundingVolume(context.Background(), "EUR", MetricsIntervalMonth, buckets, from, end, previousFrom)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(metrics.asked) != 2 || metrics.asked[0] != [2]time.Time{from, end} || metrics.asked[1] != [2]time.Time{previousFrom, from} {
+		t.Fatalf("asked for %v, want the range and then the previous period", metrics.asked)
+	}
+
+	want := []FundingDataPoint{
+		{Label: "2026-01", Amount: 1000, Fundings: 2},
+		{Label: "2026-02", Amount: 0, Fundings: 0},
+		{Label: "2026-03", Amount: 500, Fundings: 1},
+	}
+	if len(volume.Series) != len(want) {
+		t.Fatalf("series = %+v, want %+v", volume.Series, want)
+	}
+	for i := range want {
+		if volume.Series[i] != want[i] {
+			t.Fatalf("point %d = %+v, want %+v", i, volume.Series[i], want[i])
+		}
+	}
+	if volume.TotalAmount != 1500 || volume.PreviousAmount != 1200 || volume.ChangePct != 25 {
+		t.Fatalf("total = %v, previous = %v, change = %v, want 1500, 1200, 25", volume.TotalAmount, volume.PreviousAmount, volume.ChangePct)
+	}
+}
+
+func TestFundingVolumeWithoutHistory(t *testing.T) {
+	from, end := day("2026-10-01"), day("2026-10-03")
+	service := &AdminService{metricsRepo: &memoryMetrics{}}
+	buckets, err := metricsBuckets(from, end, MetricsIntervalDay)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	volume, err := service.fundingVolume(context.Background(), "EUR", MetricsIntervalDay, buckets, from, end, day("2026-09-29"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if volume.ChangePct != 0 || len(volume.Series) != 2 || volume.Series[0].Label != "2026-10-01" {
+		t.Fatalf("volume = %+v, want two empty days and no change", volume)
+	}
+
+	volume, err = service.fundingVolume(context.Background(), "", MetricsIntervalDay, buckets, from, end, day("2026-09-29"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(volume.Series) != 0 {
+		t.Fatalf("series = %+v, want none without a currency", volume.Series)
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	t.Run("rates are null with nothing to measure", func(t *testing.T) {
+		service := &AdminService{metricsRepo: &memoryMetrics{
+			lifecycle: repositories.InvoiceLifecycle{Submitted: 3, Funded: 1},
+			mints:     repositories.MintOutcomes{Mints: 2},
+		}}
+
+		lifecycle, err := service.lifecycle(context.Background(), day("2026-10-01"), day("2026-10-02"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lifecycle.Submitted != 3 || lifecycle.Funded != 1 {
+			t.Fatalf("counts = %+v", lifecycle)
+		}
+		if lifecycle.AvgAPRPercent != nil || lifecycle.DefaultRate != nil || lifecycle.AvgHoursToApproval != nil ||
+			lifecycle.AvgHoursToFunded != nil || lifecycle.MintSuccessRate != nil {
+			t.Fatalf("lifecycle = %+v, want null rates and averages", lifecycle)
+		}
+	})
+
+	t.Run("rates and averages", func(t *testing.T) {
+		service := &AdminService{metricsRepo: &memoryMetrics{
+			lifecycle: repositories.InvoiceLifecycle{
+				Approved:        3,
+				APRSum:          36.5,
+				Paid:            2,
+				Defaulted:       1,
+				ApprovalSamples: 2,
+				ApprovalSeconds: 2 * 5400,
+				FundingSamples:  1,
+				FundingSeconds:  36000,
+			},
+			// The pending mint has no outcome and does not count.
+			mints: repositories.MintOutcomes{Mints: 4, Confirmed: 2, Failed: 1},
+		}}
+
+		lifecycle, err := service.lifecycle(context.Background(), day("2026-10-01"), day("2026-10-02"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		checks := []struct {
+			name string
+			got  *float64
+			want float64
+		}{
+			{"avg apr", lifecycle.AvgAPRPercent, 12.17},
+			{"default rate", lifecycle.DefaultRate, 0.3333},
+			{"hours to approval", lifecycle.AvgHoursToApproval, 1.5},
+			{"hours to funded", lifecycle.AvgHoursToFunded, 10},
+			{"mint success rate", lifecycle.MintSuccessRate, 0.6667},
+		}
+		for _, check := range checks {
+			if check.got == nil || *check.got != check.want {
+				t.Fatalf("%s = %v, want %v", check.name, check.got, check.want)
+			}
+		}
+	})
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/pagination"
+	"invoiceflow/internal/repositories"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const auditVerifyBatch = 500
+
+type AuditService struct {
+	db   *sqlx.DB
+	repo *repositories.AuditRepository
+	key  []byte
+}
+
+// NewAuditService seals the log with key, the AUDIT_HMAC_KEY.
+func NewAuditService(db *sqlx.DB, repo *repositories.AuditRepository, key []byte) *AuditService {
+	return &AuditService{db: db, repo: repo, key: key}
+}
+
+// Record appends an event for the action tx is about to commit, attributed
+// to the actor in ctx, to the chain of its entity type. It takes that
+// chain's lock, which is held until tx ends, so call it as the last
+// statement before Commit.
+func (s *AuditService) Record(ctx context.Context, tx *sqlx.Tx, entityType string, entityID string, action string, before any, after any) error {
+	beforeJSON, err := auditJSON(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := auditJSON(after)
+	if err != nil {
+		return err
+	}
+
+	actor := domain.ActorFromContext(ctx)
+	event := &domain.AuditEvent{
+		Chain: entityType,
+		// Postgres keeps microseconds; truncate so the hash survives a round trip.
+		OccurredAt: time.Now().UTC().Truncate(time.Microsecond),
+		ActorID:    nullableString(actor.UserID),
+		ActorRole:  nullableString(actor.Role),
+		IP:         nullableString(actor.IP),
+		RequestID:  nullableString(actor.RequestID),
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+		Before:     beforeJSON,
+		After:      afterJSON,
+	}
+
+	prev, err := s.repo.LockChain(ctx, tx, event.Chain)
+	if err != nil {
+		return err
+	}
+
+	event.PrevHash = prev
+	event.Hash, err = auditHash(s.key, event)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Insert(ctx, tx, event)
+}
+
+func (s *AuditService) List(ctx context.Context, filters repositories.AuditFilters) ([]domain.AuditEvent, pagination.Meta, error) {
+	return s.repo.List(ctx, filters)
+}
+
+// Verify walks the whole log in id order, recomputing each hash and checking
+// it links to its predecessor in its chain. BrokenAt is the first event that
+// fails.
+func (s *AuditService) Verify(ctx context.Context) (*domain.AuditVerification, error) {
+	result := &domain.AuditVerification{Valid: true}
+	walk := newAuditWalk(s.key)
+	var lastID int64
+
+	for {
+		events, err := s.repo.ListAfter(ctx, lastID, auditVerifyBatch)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range events {
+			event := &events[i]
+			ok, err := walk.next(event)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				result.Valid = false
+				result.BrokenAt = &event.ID
+				break
+			}
+
+			result.Checked++
+			result.LastHash = event.Hash
+			lastID = event.ID
+		}
+
+		if !result.Valid || len(events) < auditVerifyBatch {
+			break
+		}
+	}
+
+	result.CheckedAt = time.Now().UTC().Format(time.RFC3339)
+	return result, nil
+}
+
+// auditWalk checks events in id order against the latest hash seen in each
+// chain.
+type auditWalk struct {
+	key  []byte
+	last map[string]string
+}
+
+func newAuditWalk(key []byte) *auditWalk {
+	return &auditWalk{key: key, last: map[string]string{}}
+}
+
+// next reports whether event has an intact hash and links to the previous
+// event of its chain.
+func (w *auditWalk) next(event *domain.AuditEvent) (bool, error) {
+	hash, err := auditHash(w.key, event)
+	if err != nil {
+		return false, err
+	}
+	if event.PrevHash != w.last[event.Chain] || !hmac.Equal([]byte(hash), []byte(event.Hash)) {
+		return false, nil
+	}
+
+	w.last[event.Chain] = event.Hash
+	return true, nil
+}
+
+// auditHash is HMAC-SHA256 under key over the previous hash and a
+// fixed-order JSON encoding of the event's content, chain included. Legacy
+// chain rows predate the key and use plain SHA-256 without the chain.
+func auditHash(key []byte, event *domain.AuditEvent) (string, error) {
+	legacy := event.Chain == domain.AuditLegacyChain
+	chain := event.Chain
+	if legacy {
+		chain = ""
+	}
+
+	payload, err := json.Marshal(struct {
+		Chain      string          `json:"chain,omitempty"`
+		PrevHash   string          `json:"prev_hash"`
+		OccurredAt string          `json:"occurred_at"`
+		ActorID    *string         `json:"actor_id"`
+		ActorRole  *string         `json:"actor_role"`
+		IP         *string         `json:"ip"`
+		RequestID  *string         `json:"request_id"`
+		EntityType string          `json:"entity_type"`
+		EntityID   string          `json:"entity_id"`
+		Action     string          `json:"action"`
+		Before     json.RawMessage `json:"before"`
+		After      json.RawMessage `json:"after"`
+	}{
+		Chain:      chain,
+		PrevHash:   event.PrevHash,
+		OccurredAt: event.OccurredAt.UTC().Format(time.RFC3339Nano),
+		ActorID:    event.ActorID,
+		ActorRole:  event.ActorRole,
+		IP:         event.IP,
+		RequestID:  event.RequestID,
+		EntityType: event.EntityType,
+		EntityID:   event.EntityID,
+		Action:     event.Action,
+		Before:     event.Before,
+		After:      event.After,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if legacy {
+		sum := sha256.Sum256(payload)
+		return hex.EncodeToString(sum[:]), nil
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+func auditJSON(value any) (json.RawMessage, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	if string(encoded) == "null" {
+		return nil, nil
+	}
+
+	return encoded, nil
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"invoiceflow/internal/domain"
+)
+
+var testAuditKey = []byte("0123456789abcdef0123456789abcdef")
+
+// sealedChain builds events the way Record does, interleaving two chains.
+func sealedChain(t *testing.T, key []byte) []domain.AuditEvent {
+	t.Helper()
+
+	last := map[string]string{}
+	events := []domain.AuditEvent{}
+	for i, chain := range []string{domain.AuditEntityInvoice, domain.AuditEntityFunding, domain.AuditEntityInvoice, domain.AuditEntityFunding} {
+		event := domain.AuditEvent{
+			ID:         int64(i + 1),
+			Chain:      chain,
+			OccurredAt: time.Date(2026, 3, 1, 12, i, 0, 0, time.UTC),
+			EntityType: chain,
+			EntityID:   "entity-1",
+			Action:     "test.action",
+			After:      json.RawMessage(`{"step":1}`),
+			PrevHash:   last[chain],
+		}
+		hash, err := auditHash(key, &event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		event.Hash = hash
+		last[chain] = hash
+		events = append(events, event)
+	}
+	return events
+}
+
+func walkAll(t *testing.T, key []byte, events []domain.AuditEvent) int64 {
+	t.Helper()
+
+	walk := newAuditWalk(key)
+	for i := range events {
+		ok, err := walk.next(&events[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			return events[i].ID
+		}
+	}
+	return 0
+}
+
+func TestAuditWalkAcceptsInterleavedChains(t *testing.T) {
+	if broken := walkAll(t, testAuditKey, sealedChain(t, testAuditKey)); broken != 0 {
+		t.Fatalf("intact log broken at %d", broken)
+	}
+}
+
+func TestAuditWalkDetectsTampering(t *testing.T) {
+	events := sealedChain(t, testAuditKey)
+	events[2].After = json.RawMessage(`{"step":2}`)
+
+	if broken := walkAll(t, testAuditKey, events); broken != 3 {
+		t.Fatalf("broken at %d, want 3", broken)
+	}
+}
+
+func TestAuditWalkDetectsRemovedRow(t *testing.T) {
+	events := sealedChain(t, testAuditKey)
+	events = append(events[:1], events[2:]...)
+
+	if broken := walkAll(t, testAuditKey, events); broken != 4 {
+		t.Fatalf("broken at %d, want 4", broken)
+	}
+}
+
+func TestAuditHashNeedsTheKey(t *testing.T) {
+	events := sealedChain(t, testAuditKey)
+
+	// Rewriting a row and recomputing its hash without the key does not
+	// verify.
+	forged := events[0]
+	forged.After = json.RawMessage(`{"step":9}`)
+	hash, err := auditHash([]byte("guessed-key-guessed-key-guessed!"), &forged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged.Hash = hash
+	events[0] = forged
+
+	if broken := walkAll(t, testAuditKey, events); broken != 1 {
+		t.Fatalf("broken at %d, want 1", broken)
+	}
+}
+
+func TestAuditHashLegacyChainIsUnkeyed(t *testing.T) {
+	event := domain.AuditEvent{
+		Chain:      domain.AuditLegacyChain,
+		OccurredAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		EntityType: domain.AuditEntityInvoice,
+		EntityID:   "entity-1",
+		Action:     "test.action",
+	}
+
+	first, err := auditHash(testAuditKey, &event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := auditHash([]byte("another-key"), &event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Fatal("legacy hash depends on the key")
+	}
+}
@@ -2,31 +2,95 @@ package services
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"fmt"
+	"net/url"
 	"time"
 
 	"invoiceflow/internal/config"
 	"invoiceflow/internal/domain"
+	"invoiceflow/internal/jwtkeys"
+	"invoiceflow/internal/mailer"
 	"invoiceflow/internal/repositories"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
 	"golang.org/x/crypto/bcrypt"
 )
 
 var (
-	ErrEmailExists        = errors.New("email already exists")
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrUserSuspended      = errors.New("user suspended")
+	ErrEmailExists         = errors.New("email already exists")
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrUserSuspended       = errors.New("user suspended")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrSessionRevoked      = errors.New("session revoked")
+	ErrInvalidUserToken    = errors.New("invalid or expired token")
+	ErrInvalidMFAChallenge = errors.New("invalid mfa challenge")
+)
+
+const (
+	emailVerificationTTL = 48 * time.Hour
+	passwordResetTTL     = time.Hour
+	mfaChallengeTTL      = 5 * time.Minute
+	mfaChallengeType     = "mfa_challenge"
 )
 
 type AuthService struct {
-	cfg  *config.Config
-	repo *repositories.UserRepository
+	cfg         *config.Config
+	keys        *jwtkeys.KeySet
+	db          *sqlx.DB
+	repo        authUsers
+	sessionRepo authSessions
+	roleRepo    rolePermissions
+	tokenRepo   *repositories.UserTokenRepository
+	mail        *MailOutbox
+	mfa         *MFAService
+	guard       *LoginGuard
+	orgs        *OrganizationService
+}
+
+// authUsers is the part of UserRepository the auth flows use.
+type authUsers interface {
+	CreateTx(ctx context.Context, tx *sqlx.Tx, user *domain.User) (*domain.User, error)
+	GetByEmail(ctx context.Context, email string) (*domain.User, error)
+	GetByID(ctx context.Context, id string) (*domain.User, error)
+	MarkEmailVerified(ctx context.Context, tx *sqlx.Tx, id string) error
+	UpdatePassword(ctx context.Context, tx *sqlx.Tx, id string, passwordHash string) error
+	BumpTokenVersion(ctx context.Context, tx *sqlx.Tx, id string) error
+}
+
+// authSessions is the part of SessionRepository the auth flows use.
+type authSessions interface {
+	Create(ctx context.Context, session *domain.Session) (*domain.Session, error)
+	GetByTokenHashForUpdate(ctx context.Context, tx *sqlx.Tx, hash string) (*domain.Session, error)
+	GetByPreviousTokenHash(ctx context.Context, tx *sqlx.Tx, hash string) (*domain.Session, error)
+	Rotate(ctx context.Context, tx *sqlx.Tx, id string, newHash string, expiresAt time.Time) error
+	Revoke(ctx context.Context, id string, userID string) error
+	RevokeTx(ctx context.Context, tx *sqlx.Tx, id string) error
+	RevokeAllForUser(ctx context.Context, tx *sqlx.Tx, userID string) error
+	GetState(ctx context.Context, id string, userID string) (*domain.SessionState, error)
+}
+
+type rolePermissions interface {
+	PermissionsFor(ctx context.Context, role string) ([]string, error)
 }
 
-func NewAuthService(cfg *config.Config, repo *repositories.UserRepository) *AuthService {
-	return &AuthService{cfg: cfg, repo: repo}
+func NewAuthService(cfg *config.Config, keys *jwtkeys.KeySet, db *sqlx.DB, repo *repositories.UserRepository, sessionRepo *repositories.SessionRepository, roleRepo *repositories.RoleRepository, tokenRepo *repositories.UserTokenRepository, mail *MailOutbox, mfa *MFAService, guard *LoginGuard, orgs *OrganizationService) *AuthService {
+	return &AuthService{cfg: cfg, keys: keys, db: db, repo: repo, sessionRepo: sessionRepo, roleRepo: roleRepo, tokenRepo: tokenRepo, mail: mail, mfa: mfa, guard: guard, orgs: orgs}
+}
+
+type SessionMeta struct {
+	IP        string
+	UserAgent string
+}
+
+type AuthTokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
 }
 
 func (s *AuthService) Register(ctx context.Context, name string, email string, password string, role string) (*domain.User, error) {
@@ -44,10 +108,16 @@ func (s *AuthService) Register(ctx context.Context, name string, email string, p
 		Name:         name,
 		Email:        email,
 		PasswordHash: string(hashed),
-		Status:       domain.UserStatusActive,
+		Status:       domain.UserStatusPending,
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
 	}
+	defer tx.Rollback()
 
-	created, err := s.repo.Create(ctx, user)
+	created, err := s.repo.CreateTx(ctx, tx, user)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
@@ -56,45 +126,539 @@ func (s *AuthService) Register(ctx context.Context, name string, email string, p
 		return nil, err
 	}
 
+	if _, err := s.orgs.CreatePersonal(ctx, tx, created); err != nil {
+		return nil, err
+	}
+
+	// The mail is queued with the account; a mail outage does not block
+	// sign-up, the outbox retries it.
+	if err := s.queueEmailVerification(ctx, tx, created); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.mail.Flush()
+
 	return created, nil
 }
 
-func (s *AuthService) Login(ctx context.Context, email string, password string) (string, *domain.User, error) {
+func (s *AuthService) SendEmailVerification(ctx context.Context, user *domain.User) error {
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.queueEmailVerification(ctx, tx, user); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.mail.Flush()
+
+	return nil
+}
+
+func (s *AuthService) queueEmailVerification(ctx context.Context, tx *sqlx.Tx, user *domain.User) error {
+	token, err := s.issueUserToken(ctx, tx, user.ID, domain.TokenPurposeEmailVerification, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mail.Enqueue(ctx, tx, mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your InvoiceFlow email address",
+		Body: fmt.Sprintf("Hi %s,\n\nConfirm your email address by opening the link below:\n\n%s\n\nThe link expires in %d hours.\n",
+			user.Name, s.link("/verify-email", token), int(emailVerificationTTL.Hours())),
+	})
+}
+
+func (s *AuthService) VerifyEmail(ctx context.Context, token string) (*domain.User, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	userToken, err := s.consumeUserToken(ctx, tx, domain.TokenPurposeEmailVerification, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.MarkEmailVerified(ctx, tx, userToken.UserID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(ctx, userToken.UserID)
+}
+
+// ForgotPassword queues a reset link when the address belongs to an account.
+// Nothing talks to the mail server during the request, so neither the
+// response nor its timing reveals whether the address is registered.
+func (s *AuthService) ForgotPassword(ctx context.Context, email string) error {
+	user, err := s.repo.GetByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if user.Status == domain.UserStatusSuspended {
+		return nil
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	token, err := s.issueUserToken(ctx, tx, user.ID, domain.TokenPurposePasswordReset, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	if err := s.mail.Enqueue(ctx, tx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your InvoiceFlow password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password for this account. If it was you, open the link below:\n\n%s\n\nThe link expires in %d minutes. If you did not ask for this, ignore this email.\n",
+			user.Name, s.link("/reset-password", token), int(passwordResetTTL.Minutes())),
+	}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	s.mail.Flush()
+
+	return nil
+}
+
+// ResetPassword sets a new password and signs the user out everywhere. Since
+// the link arrived by email it also counts as email verification.
+func (s *AuthService) ResetPassword(ctx context.Context, token string, password string) error {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	userToken, err := s.consumeUserToken(ctx, tx, domain.TokenPurposePasswordReset, token)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.UpdatePassword(ctx, tx, userToken.UserID, string(hashed)); err != nil {
+		return err
+	}
+
+	if err := s.repo.MarkEmailVerified(ctx, tx, userToken.UserID); err != nil {
+		return err
+	}
+
+	if err := revokeUserSessions(ctx, tx, s.repo, s.sessionRepo, userToken.UserID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *AuthService) issueUserToken(ctx context.Context, tx *sqlx.Tx, userID string, purpose string, ttl time.Duration) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	// Only the most recently emailed link stays valid.
+	if err := s.tokenRepo.InvalidateForUser(ctx, tx, userID, purpose); err != nil {
+		return "", err
+	}
+
+	if err := s.tokenRepo.Create(ctx, tx, &domain.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	}); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (s *AuthService) consumeUserToken(ctx context.Context, tx *sqlx.Tx, purpose string, token string) (*domain.UserToken, error) {
+	userToken, err := s.tokenRepo.GetByHashForUpdate(ctx, tx, purpose, hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvalidUserToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if userToken.UsedAt != nil || time.Now().After(userToken.ExpiresAt) {
+		return nil, ErrInvalidUserToken
+	}
+
+	if err := s.tokenRepo.MarkUsed(ctx, tx, userToken.ID); err != nil {
+		return nil, err
+	}
+
+	return userToken, nil
+}
+
+func (s *AuthService) link(path string, token string) string {
+	return s.cfg.AppBaseURL + path + "?token=" + url.QueryEscape(token)
+}
+
+// LoginResult carries either a full token pair or, for accounts with MFA
+// enabled, a challenge that must be exchanged at VerifyMFA.
+type LoginResult struct {
+	Tokens    *AuthTokens
+	User      *domain.User
+	Challenge *MFAChallenge
+}
+
+type MFAChallenge struct {
+	Token     string `json:"mfa_token"`
+	ExpiresIn int    `json:"expires_in"`
+}
+
+// Login reserves the attempt with the LoginGuard before any bcrypt work, so
+// locked-out or backing-off callers cost one indexed upsert and concurrent
+// guesses cannot all run before the first one is counted.
+func (s *AuthService) Login(ctx context.Context, email string, password string, meta SessionMeta) (*LoginResult, error) {
+	attempt, err := s.guard.Reserve(ctx, email, meta)
+	if err != nil {
+		var throttleErr *ThrottleError
+		if errors.As(err, &throttleErr) {
+			s.guard.Record(ctx, "", email, meta, domain.AuthEventThrottled, err.Error())
+		}
+		return nil, err
+	}
+
 	user, err := s.repo.GetByEmail(ctx, email)
+	if errors.Is(err, sql.ErrNoRows) {
+		equalizeTiming(password)
+		s.guard.Failure(ctx, attempt, "", domain.AuthEventLoginFailure, "unknown email")
+		return nil, ErrInvalidCredentials
+	}
 	if err != nil {
-		return "", nil, ErrInvalidCredentials
+		s.guard.Release(ctx, attempt)
+		return nil, err
 	}
 
 	if user.Status == domain.UserStatusSuspended {
-		return "", nil, ErrUserSuspended
+		s.guard.Release(ctx, attempt)
+		s.guard.Record(ctx, user.ID, email, meta, domain.AuthEventLoginFailure, "suspended")
+		return nil, ErrUserSuspended
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return "", nil, ErrInvalidCredentials
+		s.guard.Failure(ctx, attempt, user.ID, domain.AuthEventLoginFailure, "bad password")
+		return nil, ErrInvalidCredentials
+	}
+
+	mfaEnabled, err := s.mfa.Enabled(ctx, user.ID)
+	if err != nil {
+		s.guard.Release(ctx, attempt)
+		return nil, err
+	}
+
+	// The failure count is only cleared once the second factor also passes,
+	// otherwise a stolen password would reset MFA guessing. Until then the
+	// correct password just takes its own attempt back.
+	if mfaEnabled {
+		s.guard.Release(ctx, attempt)
+		challenge, err := s.signChallenge(user)
+		if err != nil {
+			return nil, err
+		}
+		s.guard.Record(ctx, user.ID, email, meta, domain.AuthEventMFAChallenge, "")
+		return &LoginResult{Challenge: challenge}, nil
+	}
+
+	if err := s.guard.Success(ctx, attempt, user.ID, domain.AuthEventLoginSuccess); err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.startSession(ctx, user, meta, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoginResult{Tokens: tokens, User: user}, nil
+}
+
+// VerifyMFA completes a login started with an MFA challenge.
+func (s *AuthService) VerifyMFA(ctx context.Context, challengeToken string, code string, meta SessionMeta) (*AuthTokens, *domain.User, error) {
+	token, err := s.keys.Parse(challengeToken, jwt.MapClaims{})
+	if err != nil || !token.Valid {
+		return nil, nil, ErrInvalidMFAChallenge
+	}
+
+	claims, _ := token.Claims.(jwt.MapClaims)
+	typ, _ := claims["typ"].(string)
+	userID, _ := claims["sub"].(string)
+	if typ != mfaChallengeType || userID == "" {
+		return nil, nil, ErrInvalidMFAChallenge
+	}
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, nil, ErrInvalidMFAChallenge
+	}
+	if user.Status == domain.UserStatusSuspended {
+		return nil, nil, ErrUserSuspended
+	}
+
+	attempt, err := s.guard.Reserve(ctx, user.Email, meta)
+	if err != nil {
+		var throttleErr *ThrottleError
+		if errors.As(err, &throttleErr) {
+			s.guard.Record(ctx, user.ID, user.Email, meta, domain.AuthEventThrottled, err.Error())
+		}
+		return nil, nil, err
+	}
+
+	if err := s.mfa.Verify(ctx, user.ID, code); err != nil {
+		if errors.Is(err, ErrMFAInvalidCode) {
+			s.guard.Failure(ctx, attempt, user.ID, domain.AuthEventMFAFailure, "bad code")
+		} else {
+			s.guard.Release(ctx, attempt)
+		}
+		return nil, nil, err
+	}
+
+	if err := s.guard.Success(ctx, attempt, user.ID, domain.AuthEventMFASuccess); err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err := s.startSession(ctx, user, meta, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tokens, user, nil
+}
+
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string, meta SessionMeta) (*AuthTokens, *domain.User, error) {
+	hash := hashToken(refreshToken)
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	session, err := s.sessionRepo.GetByTokenHashForUpdate(ctx, tx, hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		// A rotated-out token being presented again means it leaked: kill the
+		// whole session so neither holder can keep refreshing.
+		reused, reuseErr := s.sessionRepo.GetByPreviousTokenHash(ctx, tx, hash)
+		if reuseErr == nil {
+			if err := s.sessionRepo.RevokeTx(ctx, tx, reused.ID); err != nil {
+				return nil, nil, err
+			}
+			if err := tx.Commit(); err != nil {
+				return nil, nil, err
+			}
+			return nil, nil, ErrSessionRevoked
+		}
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if session.RevokedAt != nil {
+		return nil, nil, ErrSessionRevoked
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.repo.GetByID(ctx, session.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.Status == domain.UserStatusSuspended {
+		return nil, nil, ErrUserSuspended
+	}
+
+	newRefresh, err := randomToken()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.sessionRepo.Rotate(ctx, tx, session.ID, hashToken(newRefresh), time.Now().Add(s.refreshTTL())); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	accessToken, err := s.signToken(ctx, user, session.ID, session.MFAVerified)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	token, err := s.signToken(user)
+	return s.tokens(accessToken, newRefresh), user, nil
+}
+
+func (s *AuthService) Logout(ctx context.Context, userID string, sessionID string, allSessions bool) error {
+	if !allSessions {
+		return s.sessionRepo.Revoke(ctx, sessionID, userID)
+	}
+
+	return s.RevokeAllSessions(ctx, userID)
+}
+
+// RevokeAllSessions invalidates every refresh token and, by bumping the
+// token version, every access token already issued to the user.
+func (s *AuthService) RevokeAllSessions(ctx context.Context, userID string) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return "", nil, err
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := revokeUserSessions(ctx, tx, s.repo, s.sessionRepo, userID); err != nil {
+		return err
 	}
 
-	return token, user, nil
+	return tx.Commit()
+}
+
+// CheckSession validates the session behind an access token and returns the
+// user's current status, role and permissions, so permission changes apply to
+// tokens that are already in flight.
+func (s *AuthService) CheckSession(ctx context.Context, sessionID string, userID string, tokenVersion int) (*domain.SessionState, error) {
+	state, err := s.sessionRepo.GetState(ctx, sessionID, userID)
+	if err != nil {
+		return nil, ErrSessionRevoked
+	}
+
+	if state.RevokedAt != nil || time.Now().After(state.ExpiresAt) || state.TokenVersion != tokenVersion {
+		return nil, ErrSessionRevoked
+	}
+
+	if state.UserStatus == domain.UserStatusSuspended {
+		return nil, ErrUserSuspended
+	}
+
+	return state, nil
 }
 
 func (s *AuthService) GetUser(ctx context.Context, id string) (*domain.User, error) {
 	return s.repo.GetByID(ctx, id)
 }
 
-func (s *AuthService) signToken(user *domain.User) (string, error) {
+func (s *AuthService) startSession(ctx context.Context, user *domain.User, meta SessionMeta, mfaVerified bool) (*AuthTokens, error) {
+	refreshToken, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &domain.Session{
+		UserID:           user.ID,
+		RefreshTokenHash: hashToken(refreshToken),
+		UserAgent:        nullableString(meta.UserAgent),
+		IP:               nullableString(meta.IP),
+		MFAVerified:      mfaVerified,
+		ExpiresAt:        time.Now().Add(s.refreshTTL()),
+	}
+
+	created, err := s.sessionRepo.Create(ctx, session)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.signToken(ctx, user, created.ID, mfaVerified)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.tokens(accessToken, refreshToken), nil
+}
+
+func (s *AuthService) tokens(accessToken string, refreshToken string) *AuthTokens {
+	return &AuthTokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    s.cfg.JWTTTLMinutes * 60,
+	}
+}
+
+func (s *AuthService) refreshTTL() time.Duration {
+	return time.Duration(s.cfg.RefreshTTLHours) * time.Hour
+}
+
+// signToken embeds the role's permissions for services that only verify the
+// token against the JWKS; this API re-reads them per request in CheckSession.
+func (s *AuthService) signToken(ctx context.Context, user *domain.User, sessionID string, mfaVerified bool) (string, error) {
+	permissions, err := s.roleRepo.PermissionsFor(ctx, user.Role)
+	if err != nil {
+		return "", err
+	}
+
 	ttl := time.Duration(s.cfg.JWTTTLMinutes) * time.Minute
 	claims := jwt.MapClaims{
 		"sub":   user.ID,
 		"role":  user.Role,
+		"perms": permissions,
 		"email": user.Email,
+		"sid":   sessionID,
+		"mfa":   mfaVerified,
+		"ver":   user.TokenVersion,
 		"exp":   time.Now().Add(ttl).Unix(),
 		"iat":   time.Now().Unix(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.cfg.JWTSecret))
+	return s.keys.Sign(claims)
+}
+
+// signChallenge issues the short-lived token returned by Login for MFA
+// accounts. It carries no session, so the Auth middleware refuses it.
+func (s *AuthService) signChallenge(user *domain.User) (*MFAChallenge, error) {
+	claims := jwt.MapClaims{
+		"sub": user.ID,
+		"typ": mfaChallengeType,
+		"exp": time.Now().Add(mfaChallengeTTL).Unix(),
+		"iat": time.Now().Unix(),
+	}
+
+	token, err := s.keys.Sign(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MFAChallenge{Token: token, ExpiresIn: int(mfaChallengeTTL.Seconds())}, nil
+}
+
+func revokeUserSessions(ctx context.Context, tx *sqlx.Tx, userRepo authUsers, sessionRepo authSessions, userID string) error {
+	if err := userRepo.BumpTokenVersion(ctx, tx, userID); err != nil {
+		return err
+	}
+
+	return sessionRepo.RevokeAllForUser(ctx, tx, userID)
 }
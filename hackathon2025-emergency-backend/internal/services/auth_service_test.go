@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"invoiceflow/internal/config"
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/jwtkeys"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+// txOnlyDriver opens connections that can begin, commit and roll back
+// transactions and nothing else; the stores below do the rest in memory.
+type txOnlyDriver struct{}
+
+func (txOnlyDriver) Open(string) (driver.Conn, error) { return txOnlyConn{}, nil }
+
+type txOnlyConnector struct{}
+
+func (txOnlyConnector) Connect(context.Context) (driver.Conn, error) { return txOnlyConn{}, nil }
+func (txOnlyConnector) Driver() driver.Driver                        { return txOnlyDriver{} }
+
+type txOnlyConn struct{}
+
+func (txOnlyConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("no queries") }
+func (txOnlyConn) Close() error                        { return nil }
+func (txOnlyConn) Begin() (driver.Tx, error)           { return txOnlyConn{}, nil }
+func (txOnlyConn) Commit() error                       { return nil }
+func (txOnlyConn) Rollback() error                     { return nil }
+
+type memoryUsers struct {
+	users map[string]*domain.User
+}
+
+func (m *memoryUsers) CreateTx(ctx context.Context, tx *sqlx.Tx, user *domain.User) (*domain.User, error) {
+	m.users[user.ID] = user
+	return user, nil
+}
+
+func (m *memoryUsers) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	for _, user := range m.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *memoryUsers) GetByID(ctx context.Context, id string) (*domain.User, error) {
+	if user, ok := m.users[id]; ok {
+		return user, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *memoryUsers) MarkEmailVerified(ctx context.Context, tx *sqlx.Tx, id string) error {
+	return nil
+}
+
+func (m *memoryUsers) UpdatePassword(ctx context.Context, tx *sqlx.Tx, id string, passwordHash string) error {
+	m.users[id].PasswordHash = passwordHash
+	return nil
+}
+
+func (m *memoryUsers) BumpTokenVersion(ctx context.Context, tx *sqlx.Tx, id string) error {
+	m.users[id].TokenVersion++
+	return nil
+}
+
+type memorySessions struct {
+	users    *memoryUsers
+	sessions map[string]*domain.Session
+}
+
+func (m *memorySessions) Create(ctx context.Context, session *domain.Session) (*domain.Session, error) {
+	m.sessions[session.ID] = session
+	return session, nil
+}
+
+func (m *memorySessions) GetByTokenHashForUpdate(ctx context.Context, tx *sqlx.Tx, hash string) (*domain.Session, error) {
+	for _, session := range m.sessions {
+		if session.RefreshTokenHash == hash {
+			copied := *session
+			return &copied, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *memorySessions) GetByPreviousTokenHash(ctx context.Context, tx *sqlx.Tx, hash string) (*domain.Session, error) {
+	for _, session := range m.sessions {
+		if session.PreviousTokenHash != nil && *session.PreviousTokenHash == hash {
+			copied := *session
+			return &copied, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *memorySessions) Rotate(ctx context.Context, tx *sqlx.Tx, id string, newHash string, expiresAt time.Time) error {
+	session := m.sessions[id]
+	previous := session.RefreshTokenHash
+	session.PreviousTokenHash = &previous
+	session.RefreshTokenHash = newHash
+	session.ExpiresAt = expiresAt
+	return nil
+}
+
+func (m *memorySessions) Revoke(ctx context.Context, id string, userID string) error {
+	return m.RevokeTx(ctx, nil, id)
+}
+
+func (m *memorySessions) RevokeTx(ctx context.Context, tx *sqlx.Tx, id string) error {
+	now := time.Now()
+	m.sessions[id].RevokedAt = &now
+	return nil
+}
+
+func (m *memorySessions) RevokeAllForUser(ctx context.Context, tx *sqlx.Tx, userID string) error {
+	for id, session := range m.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			m.RevokeTx(ctx, tx, id)
+		}
+	}
+	return nil
+}
+
+func (m *memorySessions) GetState(ctx context.Context, id string, userID string) (*domain.SessionState, error) {
+	session, ok := m.sessions[id]
+	if !ok || session.UserID != userID {
+		return nil, sql.ErrNoRows
+	}
+	user := m.users.users[userID]
+	return &domain.SessionState{
+		UserStatus:   user.Status,
+		Role:         user.Role,
+		TokenVersion: user.TokenVersion,
+		MFAVerified:  session.MFAVerified,
+		ExpiresAt:    session.ExpiresAt,
+		RevokedAt:    session.RevokedAt,
+	}, nil
+}
+
+type noPermissions struct{}
+
+func (noPermissions) PermissionsFor(ctx context.Context, role string) ([]string, error) {
+	return nil, nil
+}
+
+// newTestAuth returns an AuthService with one active investor holding one
+// session whose refresh token is returned.
+func newTestAuth(t *testing.T) (*AuthService, *memorySessions, string) {
+	t.Helper()
+
+	cfg := &config.Config{JWTAlgorithm: jwtkeys.AlgHS256, JWTSecret: "test-secret", JWTTTLMinutes: 15, RefreshTTLHours: 24}
+	keys, err := jwtkeys.Load(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users := &memoryUsers{users: map[string]*domain.User{
+		"user-1": {ID: "user-1", Role: domain.RoleInvestor, Email: "ada@example.com", Status: domain.UserStatusActive, TokenVersion: 1},
+	}}
+	sessions := &memorySessions{users: users, sessions: map[string]*domain.Session{}}
+
+	refreshToken := "refresh-1"
+	sessions.sessions["session-1"] = &domain.Session{
+		ID:               "session-1",
+		UserID:           "user-1",
+		RefreshTokenHash: hashToken(refreshToken),
+		ExpiresAt:        time.Now().Add(time.Hour),
+	}
+
+	database := sqlx.NewDb(sql.OpenDB(txOnlyConnector{}), "txonly")
+	t.Cleanup(func() { database.Close() })
+
+	return &AuthService{cfg: cfg, keys: keys, db: database, repo: users, sessionRepo: sessions, roleRepo: noPermissions{}}, sessions, refreshToken
+}
+
+func TestRefreshRotatesTheToken(t *testing.T) {
+	service, sessions, first := newTestAuth(t)
+	ctx := context.Background()
+
+	tokens, user, err := service.Refresh(ctx, first, SessionMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != "user-1" {
+		t.Fatalf("user = %s, want user-1", user.ID)
+	}
+	if tokens.RefreshToken == "" || tokens.RefreshToken == first {
+		t.Fatalf("refresh token = %q, want a new one", tokens.RefreshToken)
+	}
+	session := sessions.sessions["session-1"]
+	if session.RefreshTokenHash != hashToken(tokens.RefreshToken) || session.PreviousTokenHash == nil || *session.PreviousTokenHash != hashToken(first) {
+		t.Fatal("session does not hold the new token with the old one as previous")
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := service.keys.Parse(tokens.AccessToken, claims); err != nil {
+		t.Fatal(err)
+	}
+	if claims["sid"] != "session-1" || claims["ver"] != float64(1) {
+		t.Fatalf("claims = %v, want sid session-1 and ver 1", claims)
+	}
+
+	if _, _, err := service.Refresh(ctx, tokens.RefreshToken, SessionMeta{}); err != nil {
+		t.Fatalf("refreshing with the new token: %v", err)
+	}
+}
+
+func TestRefreshReuseRevokesTheSession(t *testing.T) {
+	service, sessions, first := newTestAuth(t)
+	ctx := context.Background()
+
+	tokens, _, err := service.Refresh(ctx, first, SessionMeta{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := service.Refresh(ctx, first, SessionMeta{}); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("reused token: err = %v, want ErrSessionRevoked", err)
+	}
+	if sessions.sessions["session-1"].RevokedAt == nil {
+		t.Fatal("session was not revoked")
+	}
+
+	// The legitimate holder is signed out too.
+	if _, _, err := service.Refresh(ctx, tokens.RefreshToken, SessionMeta{}); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("current token after reuse: err = %v, want ErrSessionRevoked", err)
+	}
+}
+
+func TestRefreshRejectsUnknownExpiredAndSuspended(t *testing.T) {
+	ctx := context.Background()
+
+	service, _, _ := newTestAuth(t)
+	if _, _, err := service.Refresh(ctx, "never-issued", SessionMeta{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("unknown token: err = %v, want ErrInvalidRefreshToken", err)
+	}
+
+	service, sessions, first := newTestAuth(t)
+	sessions.sessions["session-1"].ExpiresAt = time.Now().Add(-time.Minute)
+	if _, _, err := service.Refresh(ctx, first, SessionMeta{}); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("expired session: err = %v, want ErrInvalidRefreshToken", err)
+	}
+
+	service, sessions, first = newTestAuth(t)
+	sessions.users.users["user-1"].Status = domain.UserStatusSuspended
+	if _, _, err := service.Refresh(ctx, first, SessionMeta{}); !errors.Is(err, ErrUserSuspended) {
+		t.Fatalf("suspended user: err = %v, want ErrUserSuspended", err)
+	}
+}
+
+func TestCheckSessionFollowsTokenVersion(t *testing.T) {
+	service, sessions, _ := newTestAuth(t)
+	ctx := context.Background()
+
+	if _, err := service.CheckSession(ctx, "session-1", "user-1", 1); err != nil {
+		t.Fatalf("current token: %v", err)
+	}
+	if _, err := service.CheckSession(ctx, "session-1", "user-2", 1); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("other user's session: err = %v, want ErrSessionRevoked", err)
+	}
+
+	if err := service.RevokeAllSessions(ctx, "user-1"); err != nil {
+		t.Fatal(err)
+	}
+	if version := sessions.users.users["user-1"].TokenVersion; version != 2 {
+		t.Fatalf("token version = %d, want 2", version)
+	}
+	if _, err := service.CheckSession(ctx, "session-1", "user-1", 1); !errors.Is(err, ErrSessionRevoked) {
+		t.Fatalf("token issued before revocation: err = %v, want ErrSessionRevoked", err)
+	}
+
+	// A newer session carrying the bumped version passes.
+	sessions.sessions["session-2"] = &domain.Session{ID: "session-2", UserID: "user-1", ExpiresAt: time.Now().Add(time.Hour)}
+	if _, err := service.CheckSession(ctx, "session-2", "user-1", 2); err != nil {
+		t.Fatalf("token issued after revocation: %v", err)
+	}
+
+	sessions.users.users["user-1"].Status = domain.UserStatusSuspended
+	if _, err := service.CheckSession(ctx, "session-2", "user-1", 2); !errors.Is(err, ErrUserSuspended) {
+		t.Fatalf("suspended user: err = %v, want ErrUserSuspended", err)
+	}
+}
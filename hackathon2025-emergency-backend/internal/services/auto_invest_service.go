@@ -0,0 +1,377 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"strings"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/pagination"
+	"invoiceflow/internal/repositories"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrStrategyNotFound = errors.New("auto-invest strategy not found")
+	ErrStrategyInvalid  = errors.New("invalid auto-invest strategy")
+)
+
+// AutoInvestService manages investors' saved auto-invest strategies and
+// places fundings for them when an invoice opens for funding.
+type AutoInvestService struct {
+	db          *sqlx.DB
+	repo        *repositories.AutoInvestRepository
+	invoiceRepo *repositories.InvoiceRepository
+	fundings    *FundingService
+}
+
+func NewAutoInvestService(db *sqlx.DB, repo *repositories.AutoInvestRepository, invoiceRepo *repositories.InvoiceRepository, fundings *FundingService) *AutoInvestService {
+	return &AutoInvestService{db: db, repo: repo, invoiceRepo: invoiceRepo, fundings: fundings}
+}
+
+// StrategyInput carries the fields of a create or update; nil fields are
+// left unchanged on update. The currency is fixed once a strategy exists.
+type StrategyInput struct {
+	Name          *string
+	Currency      *string
+	MaxPerInvoice *float64
+	Budget        *float64
+	RiskTiers     []string
+	MinAPRPercent *float64
+	MaxTermMonths *int
+	Tags          []string
+	EmergencyLane *string
+	Active        *bool
+}
+
+func (s *AutoInvestService) List(ctx context.Context, organizationID string) ([]domain.AutoInvestStrategy, error) {
+	return s.repo.ListByOrganization(ctx, organizationID)
+}
+
+func (s *AutoInvestService) Get(ctx context.Context, organizationID string, id string) (*domain.AutoInvestStrategy, error) {
+	strategy, err := s.repo.Get(ctx, organizationID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrStrategyNotFound
+	}
+	return strategy, err
+}
+
+// Create saves a strategy for investorID on behalf of the organization;
+// callers check the investor may act for it.
+func (s *AutoInvestService) Create(ctx context.Context, organizationID string, investorID string, input StrategyInput) (*domain.AutoInvestStrategy, error) {
+	if input.Name == nil || input.Currency == nil || input.MaxPerInvoice == nil || input.Budget == nil {
+		return nil, ErrStrategyInvalid
+	}
+
+	strategy := &domain.AutoInvestStrategy{
+		OrganizationID: organizationID,
+		InvestorID:     investorID,
+		Currency:       strings.ToUpper(strings.TrimSpace(*input.Currency)),
+		RiskTiers:      domain.StringSlice{},
+		Tags:           domain.StringSlice{},
+		EmergencyLane:  domain.AutoInvestEmergencyInclude,
+		Active:         true,
+	}
+	if strategy.Currency == "" {
+		return nil, ErrStrategyInvalid
+	}
+	if err := applyStrategyInput(strategy, input); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Create(ctx, strategy)
+}
+
+func (s *AutoInvestService) Update(ctx context.Context, organizationID string, id string, input StrategyInput) (*domain.AutoInvestStrategy, error) {
+	strategy, err := s.Get(ctx, organizationID, id)
+	if err != nil {
+		return nil, err
+	}
+	if input.Currency != nil && !strings.EqualFold(strings.TrimSpace(*input.Currency), strategy.Currency) {
+		return nil, ErrStrategyInvalid
+	}
+
+	if err := applyStrategyInput(strategy, input); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.repo.Update(ctx, strategy)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrStrategyNotFound
+	}
+	return updated, err
+}
+
+func (s *AutoInvestService) Delete(ctx context.Context, organizationID string, id string) error {
+	deleted, err := s.repo.Delete(ctx, organizationID, id)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrStrategyNotFound
+	}
+	return nil
+}
+
+func (s *AutoInvestService) ListAllocations(ctx context.Context, organizationID string, strategyID string, page pagination.Page) ([]domain.AutoInvestAllocation, pagination.Meta, error) {
+	if _, err := s.Get(ctx, organizationID, strategyID); err != nil {
+		return nil, pagination.Meta{}, err
+	}
+
+	return s.repo.ListAllocations(ctx, strategyID, page)
+}
+
+func applyStrategyInput(strategy *domain.AutoInvestStrategy, input StrategyInput) error {
+	if input.Name != nil {
+		strategy.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.MaxPerInvoice != nil {
+		strategy.MaxPerInvoice = *input.MaxPerInvoice
+	}
+	if input.Budget != nil {
+		strategy.Budget = *input.Budget
+	}
+	if input.RiskTiers != nil {
+		strategy.RiskTiers = uniqueStrings(input.RiskTiers)
+	}
+	if input.MinAPRPercent != nil {
+		strategy.MinAPRPercent = input.MinAPRPercent
+	}
+	if input.MaxTermMonths != nil {
+		strategy.MaxTermMonths = input.MaxTermMonths
+	}
+	if input.Tags != nil {
+		strategy.Tags = uniqueStrings(input.Tags)
+	}
+	if input.EmergencyLane != nil {
+		strategy.EmergencyLane = *input.EmergencyLane
+	}
+	if input.Active != nil {
+		strategy.Active = *input.Active
+	}
+
+	if strategy.Name == "" || strategy.MaxPerInvoice <= 0 || strategy.Budget <= 0 {
+		return ErrStrategyInvalid
+	}
+	// The budget may be lowered, but not below what was already invested.
+	if strategy.Budget < strategy.InvestedAmount {
+		return ErrStrategyInvalid
+	}
+	if strategy.MaxTermMonths != nil && *strategy.MaxTermMonths <= 0 {
+		return ErrStrategyInvalid
+	}
+	if !domain.ValidAutoInvestEmergencyLane(strategy.EmergencyLane) {
+		return ErrStrategyInvalid
+	}
+	return nil
+}
+
+// HandleEvent is the event bus subscriber that runs the matcher when an
+// invoice is approved. It is registered after the liquidity pool, so
+// emergency-lane invoices are offered to strategies only for what the pool
+// left. Matching strategies share the remaining target fairly in cents, and
+// each share is an ordinary funding for the strategy's investor; a strategy
+// whose funding fails is skipped.
+func (s *AutoInvestService) HandleEvent(ctx context.Context, tx *sqlx.Tx, event *domain.Event) error {
+	var payload struct {
+		Invoice *domain.Invoice `json:"invoice"`
+	}
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return err
+	}
+	if payload.Invoice == nil {
+		return nil
+	}
+
+	invoice, err := s.invoiceRepo.GetByIDForUpdate(ctx, tx, payload.Invoice.ID)
+	if err != nil {
+		return err
+	}
+	if invoice.APRPercent == nil {
+		return nil
+	}
+	if invoice.Status != domain.InvoiceStatusApproved && invoice.Status != domain.InvoiceStatusTokenized {
+		return nil
+	}
+
+	remaining := toCents(invoice.FundingTarget - invoice.FundedAmount)
+	if remaining <= 0 {
+		return nil
+	}
+
+	candidates, err := s.repo.CandidatesForUpdate(ctx, tx, invoice.Currency, invoice.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	for _, allocation := range autoInvestAllocations(candidates, invoice, remaining) {
+		if err := s.allocate(ctx, tx, invoice, allocation.strategy, allocation.amount); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type autoInvestAllocation struct {
+	strategy *domain.AutoInvestStrategy
+	amount   float64
+}
+
+// autoInvestAllocations shares remaining cents fairly between the candidates
+// that match the invoice, each up to its per-invoice cap and unspent budget.
+func autoInvestAllocations(candidates []domain.AutoInvestStrategy, invoice *domain.Invoice, remaining int64) []autoInvestAllocation {
+	var matched []*domain.AutoInvestStrategy
+	var caps []int64
+	for i := range candidates {
+		strategy := &candidates[i]
+		if !strategyMatches(strategy, invoice) {
+			continue
+		}
+		matched = append(matched, strategy)
+		caps = append(caps, min(toCents(strategy.MaxPerInvoice), toCents(strategy.Budget)-toCents(strategy.InvestedAmount)))
+	}
+
+	allocations := []autoInvestAllocation{}
+	for i, share := range fairShares(caps, remaining) {
+		if share > 0 {
+			allocations = append(allocations, autoInvestAllocation{strategy: matched[i], amount: float64(share) / 100})
+		}
+	}
+	return allocations
+}
+
+// allocate places one strategy's share under a savepoint, so a strategy
+// whose funding fails is logged and skipped without undoing the others.
+func (s *AutoInvestService) allocate(ctx context.Context, tx *sqlx.Tx, invoice *domain.Invoice, strategy *domain.AutoInvestStrategy, amount float64) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT auto_invest_allocation"); err != nil {
+		return err
+	}
+
+	if err := s.placeAllocation(ctx, tx, invoice, strategy, amount); err != nil {
+		log.Printf("auto-invest: strategy %s skipped on invoice %s: %v", strategy.ID, invoice.ID, err)
+		_, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT auto_invest_allocation")
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT auto_invest_allocation")
+	return err
+}
+
+func (s *AutoInvestService) placeAllocation(ctx context.Context, tx *sqlx.Tx, invoice *domain.Invoice, strategy *domain.AutoInvestStrategy, amount float64) error {
+	funding, _, err := s.fundings.CreateFundingTx(ctx, tx, invoice.ID, strategy.InvestorID, strategy.OrganizationID, amount, *invoice.APRPercent, invoice.TermMonths)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.AddInvested(ctx, tx, strategy.ID, amount); err != nil {
+		return err
+	}
+
+	return s.repo.CreateAllocation(ctx, tx, &domain.AutoInvestAllocation{
+		StrategyID: strategy.ID,
+		InvoiceID:  invoice.ID,
+		FundingID:  funding.ID,
+		Amount:     amount,
+	})
+}
+
+// strategyMatches applies a strategy's filters to an invoice. Empty risk
+// tiers or tags accept any invoice.
+func strategyMatches(strategy *domain.AutoInvestStrategy, invoice *domain.Invoice) bool {
+	if len(strategy.RiskTiers) > 0 && (invoice.RiskTier == nil || !containsString(strategy.RiskTiers, *invoice.RiskTier)) {
+		return false
+	}
+	if strategy.MinAPRPercent != nil && *invoice.APRPercent < *strategy.MinAPRPercent {
+		return false
+	}
+	if strategy.MaxTermMonths != nil && invoice.TermMonths > *strategy.MaxTermMonths {
+		return false
+	}
+	if len(strategy.Tags) > 0 {
+		tagged := false
+		for _, tag := range invoice.Tags {
+			if containsString(strategy.Tags, tag) {
+				tagged = true
+				break
+			}
+		}
+		if !tagged {
+			return false
+		}
+	}
+
+	switch strategy.EmergencyLane {
+	case domain.AutoInvestEmergencyOnly:
+		return invoice.EmergencyLane
+	case domain.AutoInvestEmergencyExclude:
+		return !invoice.EmergencyLane
+	default:
+		return true
+	}
+}
+
+// fairShares splits total cents between strategies with the given caps:
+// every strategy gets an equal share, and what a capped strategy cannot take
+// is spread over the others. Cents that do not divide evenly go to the
+// earliest strategies, which are the oldest.
+func fairShares(caps []int64, total int64) []int64 {
+	shares := make([]int64, len(caps))
+
+	for total > 0 {
+		var open []int
+		for i := range caps {
+			if shares[i] < caps[i] {
+				open = append(open, i)
+			}
+		}
+		if len(open) == 0 {
+			break
+		}
+
+		share := total / int64(len(open))
+		if share == 0 {
+			for _, i := range open {
+				if total == 0 {
+					break
+				}
+				shares[i]++
+				total--
+			}
+			break
+		}
+
+		for _, i := range open {
+			amount := share
+			if room := caps[i] - shares[i]; room < amount {
+				amount = room
+			}
+			shares[i] += amount
+			total -= amount
+		}
+	}
+
+	return shares
+}
+
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
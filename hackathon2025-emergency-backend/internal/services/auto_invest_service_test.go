@@ -0,0 +1,103 @@
+package services
+
+import (
+	"testing"
+
+	"invoiceflow/internal/domain"
+)
+
+func TestFairShares(t *testing.T) {
+	cases := []struct {
+		name  string
+		caps  []int64
+		total int64
+		want  []int64
+	}{
+		{"equal split", []int64{1000, 1000}, 1000, []int64{500, 500}},
+		{"capped share spread over the rest", []int64{100, 1000, 1000}, 1000, []int64{100, 450, 450}},
+		{"odd cents to the oldest", []int64{1000, 1000, 1000}, 100, []int64{34, 33, 33}},
+		{"caps below total", []int64{200, 300}, 1000, []int64{200, 300}},
+		{"nothing to place", []int64{100}, 0, []int64{0}},
+		{"no strategies", nil, 1000, []int64{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := fairShares(tc.caps, tc.total)
+			if len(got) != len(tc.want) {
+				t.Fatalf("fairShares = %v, want %v", got, tc.want)
+			}
+			for i := range tc.want {
+				if got[i] != tc.want[i] {
+					t.Fatalf("fairShares = %v, want %v", got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestStrategyMatches(t *testing.T) {
+	float := func(v float64) *float64 { return &v }
+	integer := func(v int) *int { return &v }
+	tier := "B"
+	invoice := &domain.Invoice{APRPercent: float(10), TermMonths: 3, RiskTier: &tier, Tags: []string{"food"}}
+	emergency := *invoice
+	emergency.EmergencyLane = true
+
+	cases := []struct {
+		name     string
+		strategy domain.AutoInvestStrategy
+		invoice  *domain.Invoice
+		want     bool
+	}{
+		{"no filters", domain.AutoInvestStrategy{}, invoice, true},
+		{"risk tier listed", domain.AutoInvestStrategy{RiskTiers: []string{"A", "B"}}, invoice, true},
+		{"risk tier not listed", domain.AutoInvestStrategy{RiskTiers: []string{"A"}}, invoice, false},
+		{"apr at minimum", domain.AutoInvestStrategy{MinAPRPercent: float(10)}, invoice, true},
+		{"apr below minimum", domain.AutoInvestStrategy{MinAPRPercent: float(11)}, invoice, false},
+		{"term too long", domain.AutoInvestStrategy{MaxTermMonths: integer(2)}, invoice, false},
+		{"one tag shared", domain.AutoInvestStrategy{Tags: []string{"logistics", "food"}}, invoice, true},
+		{"no tag shared", domain.AutoInvestStrategy{Tags: []string{"logistics"}}, invoice, false},
+		{"emergency only, regular invoice", domain.AutoInvestStrategy{EmergencyLane: domain.AutoInvestEmergencyOnly}, invoice, false},
+		{"emergency only, emergency invoice", domain.AutoInvestStrategy{EmergencyLane: domain.AutoInvestEmergencyOnly}, &emergency, true},
+		{"emergency excluded", domain.AutoInvestStrategy{EmergencyLane: domain.AutoInvestEmergencyExclude}, &emergency, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := strategyMatches(&tc.strategy, tc.invoice); got != tc.want {
+				t.Fatalf("strategyMatches = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAutoInvestAllocationsFillAnInvoiceExactly(t *testing.T) {
+	apr, minAPR := 10.0, 12.0
+	invoice := &domain.Invoice{FundingTarget: 1000, FundedAmount: 70.68, APRPercent: &apr, TermMonths: 3}
+	candidates := []domain.AutoInvestStrategy{
+		{ID: "a", MaxPerInvoice: 500, Budget: 5000},
+		{ID: "b", MaxPerInvoice: 500, Budget: 5000, InvestedAmount: 4800.1},
+		{ID: "c", MaxPerInvoice: 1000, Budget: 5000},
+		{ID: "d", MaxPerInvoice: 1000, Budget: 5000, MinAPRPercent: &minAPR},
+	}
+
+	allocations := autoInvestAllocations(candidates, invoice, toCents(invoice.FundingTarget-invoice.FundedAmount))
+
+	if len(allocations) != 3 {
+		t.Fatalf("got %d allocations, want 3", len(allocations))
+	}
+	for i, allocation := range allocations {
+		fits, fills := fundingFits(invoice, allocation.amount)
+		if !fits {
+			t.Fatalf("allocation %s of %v does not fit %v funded of %v", allocation.strategy.ID, allocation.amount, invoice.FundedAmount, invoice.FundingTarget)
+		}
+		if last := i == len(allocations)-1; fills != last {
+			t.Fatalf("allocation %s fills = %v, want %v", allocation.strategy.ID, fills, last)
+		}
+		invoice.FundedAmount = float64(toCents(invoice.FundedAmount)+toCents(allocation.amount)) / 100
+	}
+	if invoice.FundedAmount != invoice.FundingTarget {
+		t.Fatalf("funded %v of %v", invoice.FundedAmount, invoice.FundingTarget)
+	}
+}
@@ -7,52 +7,102 @@ import (
 	"encoding/json"
 	"errors"
 	"math/big"
-	"strings"
 	"time"
 
 	"invoiceflow/internal/blockchain"
 	"invoiceflow/internal/config"
 	"invoiceflow/internal/domain"
+	"invoiceflow/internal/events"
 	"invoiceflow/internal/repositories"
 
 	"github.com/ethereum/go-ethereum"
-	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 )
 
 var (
-	ErrChainDisabled = errors.New("chain disabled")
+	ErrChainDisabled       = errors.New("chain disabled")
+	ErrChainProfileUnknown = errors.New("unknown chain profile")
+	ErrChainSignerMissing  = errors.New("chain signer not configured")
+	ErrChainFeeTooHigh     = errors.New("estimated chain fee exceeds cap")
 )
 
 type ChainService struct {
 	cfg         *config.Config
-	chainClient *blockchain.Client
+	db          *sqlx.DB
+	registry    *blockchain.Registry
+	signer      blockchain.Signer
 	chainRepo   *repositories.ChainRepository
 	invoiceRepo *repositories.InvoiceRepository
+	fundingRepo *repositories.FundingRepository
+	audit       *AuditService
+	bus         *events.Bus
 }
 
-func NewChainService(cfg *config.Config, chainRepo *repositories.ChainRepository, invoiceRepo *repositories.InvoiceRepository) (*ChainService, error) {
-	var client *blockchain.Client
+func NewChainService(cfg *config.Config, db *sqlx.DB, chainRepo *repositories.ChainRepository, invoiceRepo *repositories.InvoiceRepository, fundingRepo *repositories.FundingRepository, audit *AuditService, bus *events.Bus) (*ChainService, error) {
+	var registry *blockchain.Registry
+	var signer blockchain.Signer
 	if cfg.EnableChain {
-		c, err := blockchain.New(cfg)
+		r, err := blockchain.NewRegistry(cfg)
 		if err != nil {
 			return nil, err
 		}
-		client = c
+		registry = r
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		signer, err = blockchain.NewSigner(ctx, cfg)
+		if err != nil && !errors.Is(err, blockchain.ErrSignerNotConfigured) {
+			registry.Close()
+			return nil, err
+		}
 	}
 
 	return &ChainService{
 		cfg:         cfg,
-		chainClient: client,
+		db:          db,
+		registry:    registry,
+		signer:      signer,
 		chainRepo:   chainRepo,
 		invoiceRepo: invoiceRepo,
+		fundingRepo: fundingRepo,
+		audit:       audit,
+		bus:         bus,
 	}, nil
 }
 
-func (s *ChainService) TokenizeInvoice(ctx context.Context, invoiceID string) (*domain.InvoiceOnChain, *domain.ChainTx, bool, error) {
+type ChainProfileInfo struct {
+	ID              string `json:"id"`
+	ChainID         int64  `json:"chain_id"`
+	ContractAddress string `json:"contract_address"`
+	Confirmations   uint64 `json:"confirmations"`
+	Default         bool   `json:"default"`
+}
+
+func (s *ChainService) ListProfiles() ([]ChainProfileInfo, error) {
+	if !s.cfg.EnableChain || s.registry == nil {
+		return nil, ErrChainDisabled
+	}
+
+	defaultClient := s.registry.Default()
+	profiles := []ChainProfileInfo{}
+	for _, client := range s.registry.Clients() {
+		profiles = append(profiles, ChainProfileInfo{
+			ID:              client.Profile,
+			ChainID:         client.ChainID.Int64(),
+			ContractAddress: client.Contract.Hex(),
+			Confirmations:   client.Confirmations,
+			Default:         client == defaultClient,
+		})
+	}
+
+	return profiles, nil
+}
+
+func (s *ChainService) TokenizeInvoice(ctx context.Context, invoiceID string, profileID string) (*domain.InvoiceOnChain, *domain.ChainTx, bool, error) {
 	if !s.cfg.EnableChain {
 		return nil, nil, false, ErrChainDisabled
 	}
@@ -78,15 +128,21 @@ func (s *ChainService) TokenizeInvoice(ctx context.Context, invoiceID string) (*
 		return nil, nil, false, ErrInvoiceInvalidStatus
 	}
 
-	if s.chainClient == nil {
+	if s.registry == nil {
 		return nil, nil, false, ErrChainDisabled
 	}
 
-	auth, fromAddress, err := s.buildTransactor()
+	client, err := s.registry.Get(profileID)
 	if err != nil {
-		return nil, nil, false, err
+		return nil, nil, false, ErrChainProfileUnknown
 	}
 
+	if s.signer == nil {
+		return nil, nil, false, ErrChainSignerMissing
+	}
+	auth := blockchain.NewTransactor(ctx, s.signer, client.ChainID)
+	fromAddress := s.signer.Address()
+
 	tokenID, err := tokenIDFromInvoice(invoice.ID)
 	if err != nil {
 		return nil, nil, false, err
@@ -97,10 +153,19 @@ func (s *ChainService) TokenizeInvoice(ctx context.Context, invoiceID string) (*
 		return nil, nil, false, err
 	}
 
-	nft, err := blockchain.NewInvoiceNFT(s.chainClient)
+	nft, err := blockchain.NewInvoiceNFT(client)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	quote, err := nft.QuoteMint(ctx, fromAddress, fromAddress, tokenID, tokenURI)
 	if err != nil {
 		return nil, nil, false, err
 	}
+	if err := checkFeeCap(quote, client.MaxTxFeeWei); err != nil {
+		return nil, nil, false, err
+	}
+	quote.Apply(auth)
 
 	tx, err := nft.Mint(ctx, auth, fromAddress, tokenID, tokenURI)
 	if err != nil {
@@ -112,29 +177,55 @@ func (s *ChainService) TokenizeInvoice(ctx context.Context, invoiceID string) (*
 
 	onchain := &domain.InvoiceOnChain{
 		InvoiceID:       invoice.ID,
-		ContractAddress: s.chainClient.Contract.Hex(),
+		ChainID:         client.ChainID.Int64(),
+		ContractAddress: client.Contract.Hex(),
 		TokenID:         &tokenIDStr,
 		MintTxHash:      &txHash,
 		ChainStatus:     domain.ChainStatusPending,
 	}
 
-	createdOnchain, err := s.chainRepo.CreateOnchain(ctx, onchain)
+	// The mint is already broadcast; record it, its chain tx and the audit
+	// event together so none of them exists without the others.
+	dbTx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return nil, nil, false, err
 	}
+	defer dbTx.Rollback()
 
+	createdOnchain, err := s.chainRepo.CreateOnchain(ctx, dbTx, onchain)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	gasLimit := int64(quote.GasLimit)
+	maxFee := quote.MaxCost.String()
 	chainTx := &domain.ChainTx{
 		TxHash:      txHash,
+		ChainID:     client.ChainID.Int64(),
+		InvoiceID:   &invoice.ID,
 		Type:        "MINT",
 		Status:      domain.ChainStatusPending,
 		ReceiptJSON: []byte("null"),
+		GasLimit:    &gasLimit,
+		MaxFeeWei:   &maxFee,
 	}
 
-	createdTx, err := s.chainRepo.CreateChainTx(ctx, chainTx)
+	createdTx, err := s.chainRepo.CreateChainTx(ctx, dbTx, chainTx)
 	if err != nil {
 		return nil, nil, false, err
 	}
 
+	if err := s.audit.Record(ctx, dbTx, domain.AuditEntityInvoice, invoice.ID, domain.AuditActionChainTokenize, nil, map[string]any{
+		"onchain":  createdOnchain,
+		"chain_tx": createdTx,
+	}); err != nil {
+		return nil, nil, false, err
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return nil, nil, false, err
+	}
+
 	return createdOnchain, createdTx, false, nil
 }
 
@@ -160,11 +251,16 @@ func (s *ChainService) RefreshOnchain(ctx context.Context, invoiceID string) (*d
 		return record, nil, nil
 	}
 
-	if s.chainClient == nil {
+	if s.registry == nil {
 		return nil, nil, ErrChainDisabled
 	}
 
-	receipt, err := s.chainClient.RPC.TransactionReceipt(ctx, common.HexToHash(*record.MintTxHash))
+	client, err := s.registry.ForContract(record.ChainID, record.ContractAddress)
+	if err != nil {
+		return nil, nil, ErrChainProfileUnknown
+	}
+
+	receipt, err := client.RPC.TransactionReceipt(ctx, common.HexToHash(*record.MintTxHash))
 	if err != nil {
 		if errors.Is(err, ethereum.NotFound) {
 			tx, _ := s.chainRepo.GetChainTx(ctx, *record.MintTxHash)
@@ -173,6 +269,17 @@ func (s *ChainService) RefreshOnchain(ctx context.Context, invoiceID string) (*d
 		return nil, nil, err
 	}
 
+	if receipt.Status == 1 {
+		head, err := client.RPC.BlockNumber(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		if receipt.BlockNumber == nil || head+1 < receipt.BlockNumber.Uint64()+client.Confirmations {
+			tx, _ := s.chainRepo.GetChainTx(ctx, *record.MintTxHash)
+			return record, tx, nil
+		}
+	}
+
 	receiptJSON, err := json.Marshal(receipt)
 	if err != nil {
 		return nil, nil, err
@@ -186,7 +293,13 @@ func (s *ChainService) RefreshOnchain(ctx context.Context, invoiceID string) (*d
 		mintedAt = &now
 	}
 
-	updatedOnchain, err := s.chainRepo.UpdateOnchainStatus(ctx, invoiceID, status, mintedAt)
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	updatedOnchain, err := s.chainRepo.UpdateOnchainStatus(ctx, tx, invoiceID, status, mintedAt)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -197,37 +310,50 @@ func (s *ChainService) RefreshOnchain(ctx context.Context, invoiceID string) (*d
 		errMsg = &msg
 	}
 
-	updatedTx, err := s.chainRepo.UpdateChainTx(ctx, *record.MintTxHash, status, errMsg, receiptJSON, &now)
+	updatedTx, err := s.chainRepo.UpdateChainTx(ctx, tx, *record.MintTxHash, status, errMsg, receiptJSON, receiptCost(receipt), &now)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	if status == domain.ChainStatusConfirmed {
-		_, _ = s.invoiceRepo.UpdateStatus(ctx, invoiceID, domain.InvoiceStatusTokenized)
+	invoice, err := s.invoiceRepo.GetByIDForUpdate(ctx, tx, invoiceID)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return updatedOnchain, updatedTx, nil
-}
-
-func (s *ChainService) buildTransactor() (*bind.TransactOpts, common.Address, error) {
-	if s.cfg.ChainPrivateKey == "" {
-		return nil, common.Address{}, errors.New("CHAIN_PRIVATE_KEY is required to mint")
-	}
+	if status == domain.ChainStatusConfirmed {
+		// Only an approved invoice moves to TOKENIZED; one that was funded
+		// while the mint confirmed keeps its status.
+		if invoice.Status == domain.InvoiceStatusApproved {
+			if invoice, err = s.invoiceRepo.UpdateStatus(ctx, tx, invoiceID, domain.InvoiceStatusTokenized); err != nil {
+				return nil, nil, err
+			}
+		}
 
-	keyHex := strings.TrimPrefix(s.cfg.ChainPrivateKey, "0x")
-	key, err := crypto.HexToECDSA(keyHex)
-	if err != nil {
-		return nil, common.Address{}, err
+		orgIDs, err := s.fundingRepo.OrganizationIDsForInvoice(ctx, tx, invoiceID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := s.bus.Publish(ctx, tx, domain.EventInvoiceTokenized, domain.AggregateInvoice, invoiceID, append(orgIDs, invoice.OrganizationID), map[string]any{
+			"invoice": invoice,
+			"onchain": updatedOnchain,
+		}); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		if err := s.bus.Publish(ctx, tx, domain.EventChainMintFailed, domain.AggregateInvoice, invoiceID, []string{invoice.OrganizationID}, map[string]any{
+			"invoice":  invoice,
+			"onchain":  updatedOnchain,
+			"chain_tx": updatedTx,
+		}); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	from := crypto.PubkeyToAddress(key.PublicKey)
-	auth, err := bind.NewKeyedTransactorWithChainID(key, s.chainClient.ChainID)
-	if err != nil {
-		return nil, common.Address{}, err
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
 	}
 
-	auth.Context = context.Background()
-	return auth, from, nil
+	return updatedOnchain, updatedTx, nil
 }
 
 func tokenIDFromInvoice(invoiceID string) (*big.Int, error) {
@@ -261,8 +387,31 @@ func buildTokenURI(invoice *domain.Invoice) (string, error) {
 }
 
 func (s *ChainService) Client() *ethclient.Client {
-	if s.chainClient == nil {
+	if s.registry == nil {
 		return nil
 	}
-	return s.chainClient.RPC
+	return s.registry.Default().RPC
+}
+
+// checkFeeCap refuses a transaction whose worst-case fee exceeds limit. A nil
+// limit means the profile sets none.
+func checkFeeCap(quote *blockchain.FeeQuote, limit *big.Int) error {
+	if limit != nil && quote.MaxCost.Cmp(limit) > 0 {
+		return ErrChainFeeTooHigh
+	}
+	return nil
+}
+
+// receiptCost is what a mined transaction actually paid, or nil when the
+// node did not report an effective gas price.
+func receiptCost(receipt *types.Receipt) *domain.ChainTxCost {
+	fee := blockchain.ReceiptFee(receipt)
+	if fee == nil {
+		return nil
+	}
+	return &domain.ChainTxCost{
+		GasUsed:           int64(receipt.GasUsed),
+		EffectiveGasPrice: receipt.EffectiveGasPrice.String(),
+		FeeWei:            fee.String(),
+	}
 }
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"invoiceflow/internal/blockchain"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestCheckFeeCap(t *testing.T) {
+	limit := big.NewInt(10_000_000_000_000_000)
+
+	cases := []struct {
+		name    string
+		maxCost *big.Int
+		limit   *big.Int
+		want    error
+	}{
+		{"below the cap", big.NewInt(2_520_000_000_000_000), limit, nil},
+		{"exactly the cap", new(big.Int).Set(limit), limit, nil},
+		{"one wei above the cap", new(big.Int).Add(limit, big.NewInt(1)), limit, ErrChainFeeTooHigh},
+		{"no cap configured", new(big.Int).Mul(limit, big.NewInt(1000)), nil, nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := checkFeeCap(&blockchain.FeeQuote{MaxCost: tc.maxCost}, tc.limit); !errors.Is(err, tc.want) {
+				t.Fatalf("err = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestReceiptCost(t *testing.T) {
+	cost := receiptCost(&types.Receipt{GasUsed: 84_000, EffectiveGasPrice: big.NewInt(12_000_000_000)})
+	if cost == nil {
+		t.Fatal("no cost recorded")
+	}
+	if cost.GasUsed != 84_000 || cost.EffectiveGasPrice != "12000000000" || cost.FeeWei != "1008000000000000" {
+		t.Fatalf("cost = %+v", cost)
+	}
+
+	if cost := receiptCost(&types.Receipt{GasUsed: 84_000}); cost != nil {
+		t.Fatalf("cost = %+v, want none without an effective gas price", cost)
+	}
+}
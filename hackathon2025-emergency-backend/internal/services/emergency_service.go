@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"invoiceflow/internal/config"
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/events"
+	"invoiceflow/internal/pagination"
+	"invoiceflow/internal/repositories"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	emergencyEscalationInterval = time.Minute
+	emergencyEscalationBatch    = 50
+)
+
+// EmergencyService runs the review side of the emergency lane: the SLA-ordered
+// queue and the worker that escalates reviews running past their deadline.
+type EmergencyService struct {
+	cfg           *config.Config
+	db            *sqlx.DB
+	emergencyRepo *repositories.EmergencyRepository
+	invoiceRepo   *repositories.InvoiceRepository
+	bus           *events.Bus
+}
+
+func NewEmergencyService(cfg *config.Config, db *sqlx.DB, emergencyRepo *repositories.EmergencyRepository, invoiceRepo *repositories.InvoiceRepository, bus *events.Bus) *EmergencyService {
+	return &EmergencyService{cfg: cfg, db: db, emergencyRepo: emergencyRepo, invoiceRepo: invoiceRepo, bus: bus}
+}
+
+func (s *EmergencyService) Queue(ctx context.Context, page pagination.Page) ([]domain.EmergencyQueueItem, pagination.Meta, error) {
+	return s.emergencyRepo.Queue(ctx, page)
+}
+
+// Start checks for overdue reviews every minute until ctx is cancelled. An
+// overdue review is escalated once its deadline passes and again every SLA
+// period after that until the invoice is reviewed. Emergency-lane invoices
+// submitted before reviews were tracked get one first, due one SLA after
+// their last update.
+func (s *EmergencyService) Start(ctx context.Context) {
+	go func() {
+		if opened, err := s.emergencyRepo.OpenMissingReviews(ctx, s.cfg.EmergencyReviewSLA); err != nil {
+			log.Printf("opening emergency reviews failed: %v", err)
+		} else if opened > 0 {
+			log.Printf("opened %d emergency reviews", opened)
+		}
+
+		ticker := time.NewTicker(emergencyEscalationInterval)
+		defer ticker.Stop()
+		for {
+			s.escalateOverdue(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *EmergencyService) escalateOverdue(ctx context.Context) {
+	for {
+		escalated, err := s.escalateBatch(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("emergency escalation failed: %v", err)
+			}
+			return
+		}
+		if escalated < emergencyEscalationBatch {
+			return
+		}
+	}
+}
+
+func (s *EmergencyService) escalateBatch(ctx context.Context) (int, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	reviews, err := s.emergencyRepo.ClaimOverdue(ctx, tx, s.cfg.EmergencyReviewSLA, emergencyEscalationBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, review := range reviews {
+		escalated, err := s.emergencyRepo.MarkEscalated(ctx, tx, review.InvoiceID)
+		if err != nil {
+			return 0, err
+		}
+
+		// Already locked by ClaimOverdue.
+		invoice, err := s.invoiceRepo.GetByIDForUpdate(ctx, tx, review.InvoiceID)
+		if err != nil {
+			return 0, err
+		}
+
+		// No organizations: escalations go to the reviewers only.
+		if err := s.bus.Publish(ctx, tx, domain.EventInvoiceEmergencyEscalated, domain.AggregateInvoice, review.InvoiceID, nil, map[string]any{
+			"invoice": invoice,
+			"review":  escalated,
+		}); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return len(reviews), nil
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 
 	"invoiceflow/internal/domain"
+	"invoiceflow/internal/events"
+	"invoiceflow/internal/pagination"
 	"invoiceflow/internal/repositories"
 
 	"github.com/jmoiron/sqlx"
@@ -19,23 +21,42 @@ type FundingService struct {
 	db          *sqlx.DB
 	fundingRepo *repositories.FundingRepository
 	invoiceRepo *repositories.InvoiceRepository
+	audit       *AuditService
+	bus         *events.Bus
 }
 
-func NewFundingService(db *sqlx.DB, fundingRepo *repositories.FundingRepository, invoiceRepo *repositories.InvoiceRepository) *FundingService {
-	return &FundingService{db: db, fundingRepo: fundingRepo, invoiceRepo: invoiceRepo}
+func NewFundingService(db *sqlx.DB, fundingRepo *repositories.FundingRepository, invoiceRepo *repositories.InvoiceRepository, audit *AuditService, bus *events.Bus) *FundingService {
+	return &FundingService{db: db, fundingRepo: fundingRepo, invoiceRepo: invoiceRepo, audit: audit, bus: bus}
 }
 
-func (s *FundingService) CreateFunding(ctx context.Context, invoiceID string, investorID string, amount float64, aprPercent float64, termMonths int) (*domain.Funding, *domain.Invoice, error) {
-	if amount <= 0 {
-		return nil, nil, ErrFundingAmountInvalid
-	}
-
+// CreateFunding records a funding placed by investorID on behalf of the
+// organization; callers check the investor may act for it.
+func (s *FundingService) CreateFunding(ctx context.Context, invoiceID string, investorID string, organizationID string, amount float64, aprPercent float64, termMonths int) (*domain.Funding, *domain.Invoice, error) {
 	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return nil, nil, err
 	}
 	defer tx.Rollback()
 
+	created, updatedInvoice, err := s.CreateFundingTx(ctx, tx, invoiceID, investorID, organizationID, amount, aprPercent, termMonths)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	return created, updatedInvoice, nil
+}
+
+// CreateFundingTx is CreateFunding inside the caller's transaction, for
+// fundings placed on an investor's behalf by the platform.
+func (s *FundingService) CreateFundingTx(ctx context.Context, tx *sqlx.Tx, invoiceID string, investorID string, organizationID string, amount float64, aprPercent float64, termMonths int) (*domain.Funding, *domain.Invoice, error) {
+	if toCents(amount) <= 0 {
+		return nil, nil, ErrFundingAmountInvalid
+	}
+
 	invoice, err := s.invoiceRepo.GetByIDForUpdate(ctx, tx, invoiceID)
 	if err != nil {
 		return nil, nil, err
@@ -45,18 +66,20 @@ func (s *FundingService) CreateFunding(ctx context.Context, invoiceID string, in
 		return nil, nil, ErrInvoiceInvalidStatus
 	}
 
-	remaining := invoice.FundingTarget - invoice.FundedAmount
-	if amount > remaining {
+	fits, fills := fundingFits(invoice, amount)
+	if !fits {
 		return nil, nil, ErrFundingExceedsTarget
 	}
+	amount = roundCents(amount)
 
 	funding := &domain.Funding{
-		InvoiceID:  invoiceID,
-		InvestorID: investorID,
-		Amount:     amount,
-		APRPercent: aprPercent,
-		TermMonths: termMonths,
-		Status:     domain.FundingStatusPending,
+		InvoiceID:      invoiceID,
+		InvestorID:     investorID,
+		OrganizationID: organizationID,
+		Amount:         amount,
+		APRPercent:     aprPercent,
+		TermMonths:     termMonths,
+		Status:         domain.FundingStatusPending,
 	}
 
 	created, err := s.fundingRepo.Create(ctx, tx, funding)
@@ -64,9 +87,9 @@ func (s *FundingService) CreateFunding(ctx context.Context, invoiceID string, in
 		return nil, nil, err
 	}
 
-	newFunded := invoice.FundedAmount + amount
+	newFunded := float64(toCents(invoice.FundedAmount)+toCents(amount)) / 100
 	newStatus := invoice.Status
-	if newFunded >= invoice.FundingTarget {
+	if fills {
 		newStatus = domain.InvoiceStatusFunded
 	}
 
@@ -75,13 +98,51 @@ func (s *FundingService) CreateFunding(ctx context.Context, invoiceID string, in
 		return nil, nil, err
 	}
 
-	if err := tx.Commit(); err != nil {
+	if err := s.bus.Publish(ctx, tx, domain.EventFundingCreated, domain.AggregateFunding, created.ID, []string{created.OrganizationID, updatedInvoice.OrganizationID}, map[string]any{
+		"funding": created,
+		"invoice": updatedInvoice,
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	if newStatus == domain.InvoiceStatusFunded && invoice.Status != domain.InvoiceStatusFunded {
+		// Positions are confirmed, and may be sold on the secondary market,
+		// once the invoice is fully funded.
+		if err := s.fundingRepo.ConfirmForInvoice(ctx, tx, invoiceID); err != nil {
+			return nil, nil, err
+		}
+
+		orgIDs, err := s.fundingRepo.OrganizationIDsForInvoice(ctx, tx, invoiceID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := s.bus.Publish(ctx, tx, domain.EventInvoiceFunded, domain.AggregateInvoice, invoiceID, append(orgIDs, updatedInvoice.OrganizationID), map[string]any{
+			"invoice": updatedInvoice,
+		}); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := s.audit.Record(ctx, tx, domain.AuditEntityFunding, created.ID, domain.AuditActionFundingCreate, nil, map[string]any{
+		"funding": created,
+		"invoice": updatedInvoice,
+	}); err != nil {
 		return nil, nil, err
 	}
 
 	return created, updatedInvoice, nil
 }
 
-func (s *FundingService) ListInvestorFundings(ctx context.Context, investorID string, limit int, offset int) ([]domain.Funding, int, error) {
-	return s.fundingRepo.ListByInvestor(ctx, investorID, limit, offset)
+func (s *FundingService) ListOrganizationFundings(ctx context.Context, organizationIDs []string, page pagination.Page) ([]domain.Funding, pagination.Meta, error) {
+	return s.fundingRepo.ListByOrganizations(ctx, organizationIDs, page)
+}
+
+// fundingFits reports whether amount fits in what is left of the invoice's
+// target and whether it fills it. Amounts are compared in cents, so a share
+// worked out in cents fills the invoice whatever float residue the stored
+// amounts carry.
+func fundingFits(invoice *domain.Invoice, amount float64) (fits bool, fills bool) {
+	remaining := toCents(invoice.FundingTarget) - toCents(invoice.FundedAmount)
+	cents := toCents(amount)
+	return cents <= remaining, cents >= remaining
 }
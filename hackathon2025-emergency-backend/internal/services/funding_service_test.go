@@ -0,0 +1,29 @@
+package services
+
+import (
+	"testing"
+
+	"invoiceflow/internal/domain"
+)
+
+func TestFundingFitsComparesCents(t *testing.T) {
+	invoice := &domain.Invoice{FundingTarget: 1000, FundedAmount: 70.68}
+
+	cases := []struct {
+		amount float64
+		fits   bool
+		fills  bool
+	}{
+		{929.32, true, true},
+		{invoice.FundingTarget - invoice.FundedAmount, true, true},
+		{929.31, true, false},
+		{929.33, false, true},
+	}
+
+	for _, tc := range cases {
+		fits, fills := fundingFits(invoice, tc.amount)
+		if fits != tc.fits || fills != tc.fills {
+			t.Fatalf("fundingFits(%v) = %v, %v; want %v, %v", tc.amount, fits, fills, tc.fits, tc.fills)
+		}
+	}
+}
@@ -3,29 +3,63 @@ package services
 import (
 	"context"
 	"errors"
+	"time"
 
+	"invoiceflow/internal/config"
 	"invoiceflow/internal/domain"
+	"invoiceflow/internal/events"
+	"invoiceflow/internal/pagination"
 	"invoiceflow/internal/repositories"
+
+	"github.com/jmoiron/sqlx"
 )
 
 var (
 	ErrInvoiceAccessDenied  = errors.New("invoice access denied")
 	ErrInvoiceInvalidStatus = errors.New("invalid invoice status")
+	ErrInvoiceEmergencyCap  = errors.New("invoice exceeds emergency lane caps")
 )
 
 type InvoiceService struct {
-	repo *repositories.InvoiceRepository
+	cfg           *config.Config
+	db            *sqlx.DB
+	repo          *repositories.InvoiceRepository
+	emergencyRepo *repositories.EmergencyRepository
+	orgs          *OrganizationService
+	bus           *events.Bus
 }
 
-func NewInvoiceService(repo *repositories.InvoiceRepository) *InvoiceService {
-	return &InvoiceService{repo: repo}
+func NewInvoiceService(cfg *config.Config, db *sqlx.DB, repo *repositories.InvoiceRepository, emergencyRepo *repositories.EmergencyRepository, orgs *OrganizationService, bus *events.Bus) *InvoiceService {
+	return &InvoiceService{cfg: cfg, db: db, repo: repo, emergencyRepo: emergencyRepo, orgs: orgs, bus: bus}
 }
 
 func (s *InvoiceService) Create(ctx context.Context, invoice *domain.Invoice) (*domain.Invoice, error) {
+	if err := s.checkEmergencyCaps(invoice); err != nil {
+		return nil, err
+	}
+
 	return s.repo.Create(ctx, invoice)
 }
 
-func (s *InvoiceService) List(ctx context.Context, filters repositories.InvoiceFilters) ([]domain.Invoice, int, error) {
+// EmergencyCaps returns the largest amount and term an emergency-lane
+// invoice may have.
+func (s *InvoiceService) EmergencyCaps() (float64, int) {
+	return s.cfg.EmergencyMaxAmount, s.cfg.EmergencyMaxTermMonths
+}
+
+// checkEmergencyCaps keeps the emergency lane to small, short-term invoices.
+// It runs again on submit, as the caps may have been lowered since.
+func (s *InvoiceService) checkEmergencyCaps(invoice *domain.Invoice) error {
+	if !invoice.EmergencyLane {
+		return nil
+	}
+	if invoice.Amount > s.cfg.EmergencyMaxAmount || invoice.TermMonths > s.cfg.EmergencyMaxTermMonths {
+		return ErrInvoiceEmergencyCap
+	}
+	return nil
+}
+
+func (s *InvoiceService) List(ctx context.Context, filters repositories.InvoiceFilters) ([]domain.Invoice, pagination.Meta, error) {
 	return s.repo.List(ctx, filters)
 }
 
@@ -33,19 +67,55 @@ func (s *InvoiceService) GetByID(ctx context.Context, id string) (*domain.Invoic
 	return s.repo.GetByID(ctx, id)
 }
 
-func (s *InvoiceService) Submit(ctx context.Context, invoiceID string, issuerID string) (*domain.Invoice, error) {
-	invoice, err := s.repo.GetByID(ctx, invoiceID)
+// Submit sends a draft for review on behalf of an editor or owner of the
+// issuing organization.
+func (s *InvoiceService) Submit(ctx context.Context, invoiceID string, userID string) (*domain.Invoice, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	if invoice.IssuerID != issuerID {
-		return nil, ErrInvoiceAccessDenied
+	invoice, err := s.repo.GetByIDForUpdate(ctx, tx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.orgs.Authorize(ctx, userID, invoice.OrganizationID, domain.OrgRoleEditor); err != nil {
+		if errors.Is(err, ErrOrgNotFound) || errors.Is(err, ErrOrgAccessDenied) {
+			return nil, ErrInvoiceAccessDenied
+		}
+		return nil, err
 	}
 
 	if invoice.Status != domain.InvoiceStatusDraft {
 		return nil, ErrInvoiceInvalidStatus
 	}
 
-	return s.repo.UpdateStatus(ctx, invoiceID, domain.InvoiceStatusSubmitted)
+	if err := s.checkEmergencyCaps(invoice); err != nil {
+		return nil, err
+	}
+
+	submitted, err := s.repo.UpdateStatus(ctx, tx, invoiceID, domain.InvoiceStatusSubmitted)
+	if err != nil {
+		return nil, err
+	}
+
+	if submitted.EmergencyLane {
+		if err := s.emergencyRepo.OpenReview(ctx, tx, invoiceID, time.Now().Add(s.cfg.EmergencyReviewSLA)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.bus.Publish(ctx, tx, domain.EventInvoiceSubmitted, domain.AggregateInvoice, invoiceID, []string{submitted.OrganizationID}, map[string]any{
+		"invoice": submitted,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return submitted, nil
 }
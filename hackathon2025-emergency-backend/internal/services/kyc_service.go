@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/pagination"
+	"invoiceflow/internal/repositories"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrKYCNotFound         = errors.New("kyc submission not found")
+	ErrKYCAlreadySubmitted = errors.New("kyc submission already pending review")
+	ErrKYCNotRequired      = errors.New("kyc not required")
+	ErrKYCInvalidStatus    = errors.New("invalid kyc submission status")
+	ErrKYCDocumentsMissing = errors.New("kyc documents missing")
+)
+
+type KYCService struct {
+	db       *sqlx.DB
+	kycRepo  kycSubmissions
+	userRepo kycUsers
+	audit    auditRecorder
+}
+
+// kycSubmissions is KYCRepository as the service uses it.
+type kycSubmissions interface {
+	Create(ctx context.Context, submission *domain.KYCSubmission) (*domain.KYCSubmission, error)
+	GetLatestByUser(ctx context.Context, userID string) (*domain.KYCSubmission, error)
+	GetByID(ctx context.Context, id string) (*domain.KYCQueueItem, error)
+	GetByIDForUpdate(ctx context.Context, tx *sqlx.Tx, id string) (*domain.KYCSubmission, error)
+	List(ctx context.Context, filters repositories.KYCFilters) ([]domain.KYCQueueItem, pagination.Meta, error)
+	Review(ctx context.Context, tx *sqlx.Tx, id string, status string, reviewerID string, note *string) (*domain.KYCSubmission, error)
+}
+
+type kycUsers interface {
+	GetByID(ctx context.Context, id string) (*domain.User, error)
+	GetByIDForUpdate(ctx context.Context, tx *sqlx.Tx, id string) (*domain.User, error)
+	UpdateStatus(ctx context.Context, tx *sqlx.Tx, id string, status string) (*domain.User, error)
+}
+
+type auditRecorder interface {
+	Record(ctx context.Context, tx *sqlx.Tx, entityType string, entityID string, action string, before any, after any) error
+}
+
+func NewKYCService(db *sqlx.DB, kycRepo *repositories.KYCRepository, userRepo *repositories.UserRepository, audit *AuditService) *KYCService {
+	return &KYCService{db: db, kycRepo: kycRepo, userRepo: userRepo, audit: audit}
+}
+
+func (s *KYCService) Submit(ctx context.Context, userID string, details domain.JSONObject, documents domain.KYCDocuments) (*domain.KYCSubmission, error) {
+	if len(documents) == 0 {
+		return nil, ErrKYCDocumentsMissing
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.Status != domain.UserStatusPending {
+		return nil, ErrKYCNotRequired
+	}
+
+	kind := domain.KYCKindIndividual
+	if user.Role == domain.RoleSME {
+		kind = domain.KYCKindBusiness
+	}
+
+	submission := &domain.KYCSubmission{
+		UserID:    userID,
+		Kind:      kind,
+		Status:    domain.KYCStatusSubmitted,
+		Details:   details,
+		Documents: documents,
+	}
+
+	created, err := s.kycRepo.Create(ctx, submission)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrKYCAlreadySubmitted
+		}
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (s *KYCService) GetLatest(ctx context.Context, userID string) (*domain.KYCSubmission, error) {
+	submission, err := s.kycRepo.GetLatestByUser(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrKYCNotFound
+	}
+	return submission, err
+}
+
+func (s *KYCService) List(ctx context.Context, filters repositories.KYCFilters) ([]domain.KYCQueueItem, pagination.Meta, error) {
+	return s.kycRepo.List(ctx, filters)
+}
+
+func (s *KYCService) Get(ctx context.Context, id string) (*domain.KYCQueueItem, error) {
+	item, err := s.kycRepo.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrKYCNotFound
+	}
+	return item, err
+}
+
+func (s *KYCService) Approve(ctx context.Context, id string, reviewerID string, note string) (*domain.KYCSubmission, error) {
+	return s.review(ctx, id, reviewerID, domain.KYCStatusApproved, note)
+}
+
+func (s *KYCService) Reject(ctx context.Context, id string, reviewerID string, reason string) (*domain.KYCSubmission, error) {
+	return s.review(ctx, id, reviewerID, domain.KYCStatusRejected, reason)
+}
+
+func (s *KYCService) review(ctx context.Context, id string, reviewerID string, status string, note string) (*domain.KYCSubmission, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	submission, err := s.kycRepo.GetByIDForUpdate(ctx, tx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrKYCNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if submission.Status != domain.KYCStatusSubmitted {
+		return nil, ErrKYCInvalidStatus
+	}
+
+	user, err := s.userRepo.GetByIDForUpdate(ctx, tx, submission.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	reviewed, err := s.kycRepo.Review(ctx, tx, id, status, reviewerID, nullableString(note))
+	if err != nil {
+		return nil, err
+	}
+
+	// A suspension issued while the review was pending wins over approval.
+	if status == domain.KYCStatusApproved && user.Status == domain.UserStatusPending {
+		if _, err := s.userRepo.UpdateStatus(ctx, tx, user.ID, domain.UserStatusActive); err != nil {
+			return nil, err
+		}
+	}
+
+	action := domain.AuditActionKYCReject
+	if status == domain.KYCStatusApproved {
+		action = domain.AuditActionKYCApprove
+	}
+	if err := s.audit.Record(ctx, tx, domain.AuditEntityKYCSubmission, id, action, submission, reviewed); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return reviewed, nil
+}
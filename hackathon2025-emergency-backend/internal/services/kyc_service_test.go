@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/pagination"
+	"invoiceflow/internal/repositories"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type memoryKYC struct {
+	submissions map[string]*domain.KYCSubmission
+}
+
+func (m *memoryKYC) Create(ctx context.Context, submission *domain.KYCSubmission) (*domain.KYCSubmission, error) {
+	m.submissions[submission.ID] = submission
+	return submission, nil
+}
+
+func (m *memoryKYC) GetLatestByUser(ctx context.Context, userID string) (*domain.KYCSubmission, error) {
+	return nil, sql.ErrNoRows
+}
+
+func (m *memoryKYC) GetByID(ctx context.Context, id string) (*domain.KYCQueueItem, error) {
+	return nil, sql.ErrNoRows
+}
+
+func (m *memoryKYC) GetByIDForUpdate(ctx context.Context, tx *sqlx.Tx, id string) (*domain.KYCSubmission, error) {
+	if submission, ok := m.submissions[id]; ok {
+		copied := *submission
+		return &copied, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *memoryKYC) List(ctx context.Context, filters repositories.KYCFilters) ([]domain.KYCQueueItem, pagination.Meta, error) {
+	return nil, pagination.Meta{}, nil
+}
+
+func (m *memoryKYC) Review(ctx context.Context, tx *sqlx.Tx, id string, status string, reviewerID string, note *string) (*domain.KYCSubmission, error) {
+	submission := m.submissions[id]
+	submission.Status = status
+	submission.ReviewerID = &reviewerID
+	submission.ReviewNote = note
+	copied := *submission
+	return &copied, nil
+}
+
+type memoryKYCUsers struct {
+	users map[string]*domain.User
+}
+
+func (m *memoryKYCUsers) GetByID(ctx context.Context, id string) (*domain.User, error) {
+	if user, ok := m.users[id]; ok {
+		return user, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (m *memoryKYCUsers) GetByIDForUpdate(ctx context.Context, tx *sqlx.Tx, id string) (*domain.User, error) {
+	return m.GetByID(ctx, id)
+}
+
+func (m *memoryKYCUsers) UpdateStatus(ctx context.Context, tx *sqlx.Tx, id string, status string) (*domain.User, error) {
+	m.users[id].Status = status
+	return m.users[id], nil
+}
+
+type recordedAudit struct {
+	actions []string
+}
+
+func (r *recordedAudit) Record(ctx context.Context, tx *sqlx.Tx, entityType string, entityID string, action string, before any, after any) error {
+	r.actions = append(r.actions, action)
+	return nil
+}
+
+func newTestKYC(t *testing.T, userStatus string) (*KYCService, *memoryKYCUsers, *recordedAudit) {
+	t.Helper()
+
+	database := sqlx.NewDb(sql.OpenDB(txOnlyConnector{}), "txonly")
+	t.Cleanup(func() { database.Close() })
+
+	users := &memoryKYCUsers{users: map[string]*domain.User{
+		"user-1": {ID: "user-1", Role: domain.RoleSME, Status: userStatus},
+	}}
+	submissions := &memoryKYC{submissions: map[string]*domain.KYCSubmission{
+		"kyc-1": {ID: "kyc-1", UserID: "user-1", Status: domain.KYCStatusSubmitted},
+	}}
+	audit := &recordedAudit{}
+
+	return &KYCService{db: database, kycRepo: submissions, userRepo: users, audit: audit}, users, audit
+}
+
+func TestKYCApprovalActivatesPendingUser(t *testing.T) {
+	service, users, audit := newTestKYC(t, domain.UserStatusPending)
+
+	reviewed, err := service.Approve(context.Background(), "kyc-1", "reviewer-1", "documents match")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reviewed.Status != domain.KYCStatusApproved {
+		t.Fatalf("submission status = %s, want APPROVED", reviewed.Status)
+	}
+	if status := users.users["user-1"].Status; status != domain.UserStatusActive {
+		t.Fatalf("user status = %s, want ACTIVE", status)
+	}
+	if len(audit.actions) != 1 || audit.actions[0] != domain.AuditActionKYCApprove {
+		t.Fatalf("audit = %v, want one approval", audit.actions)
+	}
+
+	if _, err := service.Approve(context.Background(), "kyc-1", "reviewer-1", ""); !errors.Is(err, ErrKYCInvalidStatus) {
+		t.Fatalf("second review: err = %v, want ErrKYCInvalidStatus", err)
+	}
+}
+
+func TestKYCRejectionKeepsUserPending(t *testing.T) {
+	service, users, _ := newTestKYC(t, domain.UserStatusPending)
+
+	if _, err := service.Reject(context.Background(), "kyc-1", "reviewer-1", "blurry scan"); err != nil {
+		t.Fatal(err)
+	}
+	if status := users.users["user-1"].Status; status != domain.UserStatusPending {
+		t.Fatalf("user status = %s, want PENDING", status)
+	}
+}
+
+func TestKYCApprovalDoesNotLiftSuspension(t *testing.T) {
+	service, users, _ := newTestKYC(t, domain.UserStatusSuspended)
+
+	if _, err := service.Approve(context.Background(), "kyc-1", "reviewer-1", ""); err != nil {
+		t.Fatal(err)
+	}
+	if status := users.users["user-1"].Status; status != domain.UserStatusSuspended {
+		t.Fatalf("user status = %s, want SUSPENDED", status)
+	}
+}
+
+func TestKYCReviewOfUnknownSubmission(t *testing.T) {
+	service, _, _ := newTestKYC(t, domain.UserStatusPending)
+
+	if _, err := service.Approve(context.Background(), "kyc-404", "reviewer-1", ""); !errors.Is(err, ErrKYCNotFound) {
+		t.Fatalf("err = %v, want ErrKYCNotFound", err)
+	}
+}
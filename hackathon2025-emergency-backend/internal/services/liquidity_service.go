@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/repositories"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrCommitmentNotFound = errors.New("liquidity commitment not found")
+	ErrCommitmentInvalid  = errors.New("invalid liquidity commitment")
+	ErrCommitmentClosed   = errors.New("liquidity commitment is no longer active")
+)
+
+// LiquidityService manages the emergency liquidity pool: investors
+// pre-commit funds, and approved emergency-lane invoices are funded from
+// those commitments at once instead of waiting on the marketplace.
+type LiquidityService struct {
+	db          *sqlx.DB
+	repo        *repositories.LiquidityRepository
+	invoiceRepo *repositories.InvoiceRepository
+	fundings    *FundingService
+	audit       *AuditService
+}
+
+func NewLiquidityService(db *sqlx.DB, repo *repositories.LiquidityRepository, invoiceRepo *repositories.InvoiceRepository, fundings *FundingService, audit *AuditService) *LiquidityService {
+	return &LiquidityService{db: db, repo: repo, invoiceRepo: invoiceRepo, fundings: fundings, audit: audit}
+}
+
+type CommitmentInput struct {
+	Currency      string
+	Amount        float64
+	MaxPerInvoice *float64
+	MinAPRPercent *float64
+}
+
+func (s *LiquidityService) List(ctx context.Context, organizationID string) ([]domain.LiquidityCommitment, error) {
+	return s.repo.ListByOrganization(ctx, organizationID)
+}
+
+func (s *LiquidityService) Balances(ctx context.Context) ([]domain.LiquidityPoolBalance, error) {
+	return s.repo.Balances(ctx)
+}
+
+// Commit adds a commitment by investorID on behalf of the organization;
+// callers check the investor may act for it.
+func (s *LiquidityService) Commit(ctx context.Context, organizationID string, investorID string, input CommitmentInput) (*domain.LiquidityCommitment, error) {
+	currency := strings.ToUpper(strings.TrimSpace(input.Currency))
+	if currency == "" || input.Amount <= 0 {
+		return nil, ErrCommitmentInvalid
+	}
+	if input.MaxPerInvoice != nil && *input.MaxPerInvoice <= 0 {
+		return nil, ErrCommitmentInvalid
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	created, err := s.repo.Create(ctx, tx, &domain.LiquidityCommitment{
+		OrganizationID:  organizationID,
+		InvestorID:      investorID,
+		Currency:        currency,
+		CommittedAmount: input.Amount,
+		MaxPerInvoice:   input.MaxPerInvoice,
+		MinAPRPercent:   input.MinAPRPercent,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(ctx, tx, domain.AuditEntityLiquidityCommitment, created.ID, domain.AuditActionLiquidityCommit, nil, created); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// Withdraw releases the unused part of an active commitment.
+func (s *LiquidityService) Withdraw(ctx context.Context, organizationID string, id string) (*domain.LiquidityCommitment, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	commitment, err := s.repo.GetForUpdate(ctx, tx, organizationID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCommitmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if commitment.Status != domain.LiquidityCommitmentActive {
+		return nil, ErrCommitmentClosed
+	}
+
+	withdrawn, err := s.repo.Withdraw(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(ctx, tx, domain.AuditEntityLiquidityCommitment, id, domain.AuditActionLiquidityWithdraw, commitment, withdrawn); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return withdrawn, nil
+}
+
+// HandleEvent is the event bus subscriber that funds an approved
+// emergency-lane invoice from the pool, oldest commitment first, until it is
+// fully funded or no commitment in its currency is left. The invoice's own
+// organization never funds it, and commitments whose investor is no longer
+// an active editor of the committing organization are passed over. Each draw
+// is an ordinary funding for the committing investor.
+func (s *LiquidityService) HandleEvent(ctx context.Context, tx *sqlx.Tx, event *domain.Event) error {
+	var payload struct {
+		Invoice *domain.Invoice `json:"invoice"`
+	}
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return err
+	}
+	if payload.Invoice == nil || !payload.Invoice.EmergencyLane {
+		return nil
+	}
+
+	invoice, err := s.invoiceRepo.GetByIDForUpdate(ctx, tx, payload.Invoice.ID)
+	if err != nil {
+		return err
+	}
+	if invoice.APRPercent == nil {
+		return nil
+	}
+	if invoice.Status != domain.InvoiceStatusApproved && invoice.Status != domain.InvoiceStatusTokenized {
+		return nil
+	}
+
+	commitments, err := s.repo.ActiveForUpdate(ctx, tx, invoice.Currency, invoice.OrganizationID)
+	if err != nil {
+		return err
+	}
+
+	for _, draw := range liquidityDraws(commitments, toCents(invoice.FundingTarget)-toCents(invoice.FundedAmount), *invoice.APRPercent) {
+		if err := s.repo.Draw(ctx, tx, draw.commitment.ID, draw.amount); err != nil {
+			return err
+		}
+
+		if _, _, err := s.fundings.CreateFundingTx(ctx, tx, invoice.ID, draw.commitment.InvestorID, draw.commitment.OrganizationID, draw.amount, *invoice.APRPercent, invoice.TermMonths); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type liquidityDraw struct {
+	commitment *domain.LiquidityCommitment
+	amount     float64
+}
+
+// liquidityDraws splits remaining cents across commitments in order, each
+// giving up to its available amount and per-invoice cap. Commitments asking
+// for a higher APR than aprPercent give nothing. Amounts are worked out in
+// cents, so the draws add up to exactly what the invoice still needs.
+func liquidityDraws(commitments []domain.LiquidityCommitment, remaining int64, aprPercent float64) []liquidityDraw {
+	draws := []liquidityDraw{}
+	for i := range commitments {
+		commitment := &commitments[i]
+		if remaining <= 0 {
+			break
+		}
+		if commitment.MinAPRPercent != nil && aprPercent < *commitment.MinAPRPercent {
+			continue
+		}
+
+		amount := min(remaining, toCents(commitment.AvailableAmount))
+		if commitment.MaxPerInvoice != nil {
+			amount = min(amount, toCents(*commitment.MaxPerInvoice))
+		}
+		if amount <= 0 {
+			continue
+		}
+
+		draws = append(draws, liquidityDraw{commitment: commitment, amount: float64(amount) / 100})
+		remaining -= amount
+	}
+
+	return draws
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"testing"
+
+	"invoiceflow/internal/domain"
+)
+
+func TestLiquidityDraws(t *testing.T) {
+	amount := func(v float64) *float64 { return &v }
+	commitments := []domain.LiquidityCommitment{
+		{ID: "wants-more-apr", AvailableAmount: 5000, MinAPRPercent: amount(12)},
+		{ID: "capped", AvailableAmount: 5000, MaxPerInvoice: amount(1500)},
+		{ID: "small", AvailableAmount: 1000},
+		{ID: "large", AvailableAmount: 10000, MinAPRPercent: amount(10)},
+		{ID: "not-needed", AvailableAmount: 10000},
+	}
+
+	draws := liquidityDraws(commitments, 400000, 10)
+
+	want := []struct {
+		id     string
+		amount float64
+	}{{"capped", 1500}, {"small", 1000}, {"large", 1500}}
+	if len(draws) != len(want) {
+		t.Fatalf("got %d draws, want %d", len(draws), len(want))
+	}
+	for i, w := range want {
+		if draws[i].commitment.ID != w.id || draws[i].amount != w.amount {
+			t.Fatalf("draw %d = %s %.2f, want %s %.2f", i, draws[i].commitment.ID, draws[i].amount, w.id, w.amount)
+		}
+	}
+}
+
+func TestLiquidityDrawsNothingForAFundedInvoice(t *testing.T) {
+	commitments := []domain.LiquidityCommitment{{ID: "c", AvailableAmount: 1000}}
+
+	if draws := liquidityDraws(commitments, 0, 10); len(draws) != 0 {
+		t.Fatalf("got %d draws for a funded invoice", len(draws))
+	}
+}
+
+func TestLiquidityDrawsFillAnInvoiceExactly(t *testing.T) {
+	commitments := []domain.LiquidityCommitment{
+		{ID: "first", AvailableAmount: 300.1},
+		{ID: "second", AvailableAmount: 700.7},
+	}
+
+	// A target of 1000 with 70.68 funded leaves 929.32.
+	draws := liquidityDraws(commitments, toCents(1000)-toCents(70.68), 10)
+
+	if len(draws) != 2 || draws[0].amount != 300.1 || draws[1].amount != 629.22 {
+		t.Fatalf("draws = %+v, want 300.10 and 629.22", draws)
+	}
+	var funded int64
+	for _, draw := range draws {
+		funded += toCents(draw.amount)
+	}
+	if funded != 92932 {
+		t.Fatalf("draws add up to %d cents, want 92932", funded)
+	}
+}
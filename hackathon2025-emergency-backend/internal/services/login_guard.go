@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"invoiceflow/internal/config"
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/repositories"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const maxLoginDelay = 30 * time.Second
+
+// ThrottleError is returned instead of checking credentials while an email or
+// IP is locked out or still inside its back-off delay.
+type ThrottleError struct {
+	Locked     bool
+	RetryAfter time.Duration
+}
+
+func (e *ThrottleError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("login locked, retry in %s", e.RetryAfter)
+	}
+	return fmt.Sprintf("too many login attempts, retry in %s", e.RetryAfter)
+}
+
+// LoginGuard tracks sign-in attempts per email and per IP. Each attempt is
+// counted before its credentials are checked and taken back when it
+// succeeds. After the second failure each further attempt must wait an
+// exponentially growing delay, and reaching the configured limit locks the
+// key for the lockout period.
+type LoginGuard struct {
+	cfg  *config.Config
+	repo *repositories.AuthEventRepository
+}
+
+func NewLoginGuard(cfg *config.Config, repo *repositories.AuthEventRepository) *LoginGuard {
+	return &LoginGuard{cfg: cfg, repo: repo}
+}
+
+// LoginAttempt is an attempt reserved by Reserve. It must end in Failure,
+// Success or Release.
+type LoginAttempt struct {
+	email    string
+	meta     SessionMeta
+	failures int
+	ip       bool
+}
+
+// Reserve counts an attempt against the email and IP, or returns a
+// *ThrottleError without counting it while either is locked or backing off.
+func (g *LoginGuard) Reserve(ctx context.Context, email string, meta SessionMeta) (*LoginAttempt, error) {
+	attempt := &LoginAttempt{email: email, meta: meta}
+
+	throttle, err := g.reserve(ctx, domain.ThrottleScopeEmail, normalizeEmail(email), g.cfg.LoginMaxFailures)
+	if err != nil {
+		return nil, err
+	}
+	attempt.failures = throttle.Failures
+
+	if meta.IP == "" {
+		return attempt, nil
+	}
+
+	if _, err := g.reserve(ctx, domain.ThrottleScopeIP, meta.IP, g.cfg.LoginIPMaxFailures); err != nil {
+		// The email attempt was never tried, so it must not count.
+		if releaseErr := g.repo.ReleaseAttempt(ctx, domain.ThrottleScopeEmail, normalizeEmail(email), g.cfg.LoginMaxFailures); releaseErr != nil {
+			log.Printf("login attempt not released: %v", releaseErr)
+		}
+		return nil, err
+	}
+	attempt.ip = true
+
+	return attempt, nil
+}
+
+func (g *LoginGuard) reserve(ctx context.Context, scope string, key string, maxFailures int) (*domain.LoginThrottle, error) {
+	throttle, reserved, err := g.repo.ReserveAttempt(ctx, scope, key, g.cfg.LoginFailureWindow, maxFailures, g.cfg.LoginLockout, maxLoginDelay)
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return throttle, nil
+	}
+
+	if throttleErr := throttleFor(throttle, time.Now(), g.cfg.LoginFailureWindow); throttleErr != nil {
+		return nil, throttleErr
+	}
+	// The database refused the attempt right at the end of its delay.
+	return nil, &ThrottleError{RetryAfter: time.Second}
+}
+
+// throttleFor is the error for an attempt against throttle at now, or nil
+// when the attempt may go ahead.
+func throttleFor(throttle *domain.LoginThrottle, now time.Time, window time.Duration) *ThrottleError {
+	if throttle.LockedUntil != nil && throttle.LockedUntil.After(now) {
+		return &ThrottleError{Locked: true, RetryAfter: throttle.LockedUntil.Sub(now)}
+	}
+
+	if throttle.FirstFailedAt.Before(now.Add(-window)) {
+		return nil
+	}
+
+	if next := throttle.LastFailedAt.Add(loginDelay(throttle.Failures)); next.After(now) {
+		return &ThrottleError{RetryAfter: next.Sub(now)}
+	}
+
+	return nil
+}
+
+// Failure records a failed attempt; Reserve already counted it.
+func (g *LoginGuard) Failure(ctx context.Context, attempt *LoginAttempt, userID string, event string, reason string) {
+	g.Record(ctx, userID, attempt.email, attempt.meta, event, reason)
+	if attempt.failures == g.cfg.LoginMaxFailures {
+		g.Record(ctx, userID, attempt.email, attempt.meta, domain.AuthEventLocked, fmt.Sprintf("%d failures", attempt.failures))
+	}
+}
+
+// Success clears the email's count and takes back the attempt from the IP
+// count. The rest of the IP count is left alone so one known-good account
+// cannot be used to reset a stuffing run from that address.
+func (g *LoginGuard) Success(ctx context.Context, attempt *LoginAttempt, userID string, event string) error {
+	if err := g.repo.ClearThrottle(ctx, domain.ThrottleScopeEmail, normalizeEmail(attempt.email)); err != nil {
+		return err
+	}
+	if attempt.ip {
+		if err := g.repo.ReleaseAttempt(ctx, domain.ThrottleScopeIP, attempt.meta.IP, g.cfg.LoginIPMaxFailures); err != nil {
+			return err
+		}
+	}
+
+	g.Record(ctx, userID, attempt.email, attempt.meta, event, "")
+	return nil
+}
+
+// Release takes back an attempt that neither failed nor completed a sign-in,
+// such as a correct password that still needs its second factor. Like
+// Record, it logs failures rather than returning them.
+func (g *LoginGuard) Release(ctx context.Context, attempt *LoginAttempt) {
+	if err := g.repo.ReleaseAttempt(ctx, domain.ThrottleScopeEmail, normalizeEmail(attempt.email), g.cfg.LoginMaxFailures); err != nil {
+		log.Printf("login attempt not released: %v", err)
+	}
+	if !attempt.ip {
+		return
+	}
+	if err := g.repo.ReleaseAttempt(ctx, domain.ThrottleScopeIP, attempt.meta.IP, g.cfg.LoginIPMaxFailures); err != nil {
+		log.Printf("login attempt not released: %v", err)
+	}
+}
+
+// Record writes an auth event. Failures are logged rather than returned so
+// that bookkeeping never blocks a sign-in.
+func (g *LoginGuard) Record(ctx context.Context, userID string, email string, meta SessionMeta, event string, reason string) {
+	if err := g.repo.Record(ctx, &domain.AuthEvent{
+		UserID:    nullableString(userID),
+		Email:     nullableString(normalizeEmail(email)),
+		IP:        nullableString(meta.IP),
+		UserAgent: nullableString(meta.UserAgent),
+		Event:     event,
+		Reason:    nullableString(reason),
+	}); err != nil {
+		log.Printf("auth event %s not recorded: %v", event, err)
+	}
+}
+
+func loginDelay(failures int) time.Duration {
+	if failures < 2 {
+		return 0
+	}
+
+	delay := time.Duration(math.Pow(2, float64(failures-2))) * time.Second
+	if delay > maxLoginDelay {
+		return maxLoginDelay
+	}
+	return delay
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// equalizeTiming burns a bcrypt compare for unknown emails so response times
+// do not reveal which addresses are registered.
+func equalizeTiming(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("invoiceflow-timing-equalizer"), bcrypt.DefaultCost)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"invoiceflow/internal/domain"
+)
+
+func TestLoginDelay(t *testing.T) {
+	cases := map[int]time.Duration{
+		0:  0,
+		1:  0,
+		2:  time.Second,
+		3:  2 * time.Second,
+		4:  4 * time.Second,
+		7:  30 * time.Second,
+		20: maxLoginDelay,
+	}
+
+	for failures, want := range cases {
+		if got := loginDelay(failures); got != want {
+			t.Errorf("loginDelay(%d) = %s, want %s", failures, got, want)
+		}
+	}
+}
+
+func TestThrottleFor(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	window := 15 * time.Minute
+	lockedUntil := now.Add(10 * time.Minute)
+	expiredLock := now.Add(-time.Minute)
+
+	cases := []struct {
+		name     string
+		throttle domain.LoginThrottle
+		want     *ThrottleError
+	}{
+		{
+			name:     "first failure",
+			throttle: domain.LoginThrottle{Failures: 1, FirstFailedAt: now, LastFailedAt: now},
+		},
+		{
+			name:     "inside back-off",
+			throttle: domain.LoginThrottle{Failures: 3, FirstFailedAt: now.Add(-time.Minute), LastFailedAt: now.Add(-time.Second)},
+			want:     &ThrottleError{RetryAfter: time.Second},
+		},
+		{
+			name:     "back-off over",
+			throttle: domain.LoginThrottle{Failures: 3, FirstFailedAt: now.Add(-time.Minute), LastFailedAt: now.Add(-2 * time.Second)},
+		},
+		{
+			name:     "window expired",
+			throttle: domain.LoginThrottle{Failures: 6, FirstFailedAt: now.Add(-window - time.Second), LastFailedAt: now},
+		},
+		{
+			name:     "locked",
+			throttle: domain.LoginThrottle{Failures: 5, FirstFailedAt: now.Add(-time.Minute), LastFailedAt: now, LockedUntil: &lockedUntil},
+			want:     &ThrottleError{Locked: true, RetryAfter: 10 * time.Minute},
+		},
+		{
+			name:     "lock expired",
+			throttle: domain.LoginThrottle{Failures: 1, FirstFailedAt: now.Add(-window - time.Minute), LastFailedAt: now.Add(-window), LockedUntil: &expiredLock},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := throttleFor(&tc.throttle, now, window)
+			if (got == nil) != (tc.want == nil) {
+				t.Fatalf("throttleFor = %v, want %v", got, tc.want)
+			}
+			if got != nil && *got != *tc.want {
+				t.Fatalf("throttleFor = %+v, want %+v", *got, *tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/mailer"
+	"invoiceflow/internal/repositories"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	mailPollInterval = 5 * time.Second
+	mailMaxAttempts  = 8
+	mailBaseBackoff  = 30 * time.Second
+	mailMaxBackoff   = 2 * time.Hour
+)
+
+// MailOutbox sends mail only once the transaction that asked for it
+// commits. Enqueue writes the message in that transaction and the relay
+// started by Start sends it, retrying failed sends with back-off. Delivery
+// is at least once: a crash between sending and marking sent resends.
+type MailOutbox struct {
+	db   *sqlx.DB
+	repo *repositories.MailOutboxRepository
+	mail mailer.Mailer
+	wake chan struct{}
+}
+
+func NewMailOutbox(db *sqlx.DB, repo *repositories.MailOutboxRepository, mail mailer.Mailer) *MailOutbox {
+	return &MailOutbox{db: db, repo: repo, mail: mail, wake: make(chan struct{}, 1)}
+}
+
+func (o *MailOutbox) Enqueue(ctx context.Context, tx *sqlx.Tx, msg mailer.Message) error {
+	return o.repo.Insert(ctx, tx, &domain.OutboxMail{
+		Recipient: msg.To,
+		Subject:   msg.Subject,
+		Body:      msg.Body,
+	})
+}
+
+// EnqueueForEvent queues mail raised by a domain event. Each recipient gets
+// it once however often the event is delivered.
+func (o *MailOutbox) EnqueueForEvent(ctx context.Context, tx *sqlx.Tx, eventID string, msg mailer.Message) error {
+	return o.repo.Insert(ctx, tx, &domain.OutboxMail{
+		EventID:   &eventID,
+		Recipient: msg.To,
+		Subject:   msg.Subject,
+		Body:      msg.Body,
+	})
+}
+
+// Flush asks the relay to send now rather than on its next tick. Call it
+// after committing the transaction that enqueued mail.
+func (o *MailOutbox) Flush() {
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start runs the relay until ctx is cancelled. Several API instances may run
+// it at once; each mail is claimed with SKIP LOCKED.
+func (o *MailOutbox) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(mailPollInterval)
+		defer ticker.Stop()
+		for {
+			o.sendPending(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-o.wake:
+			}
+		}
+	}()
+}
+
+func (o *MailOutbox) sendPending(ctx context.Context) {
+	for {
+		sent, err := o.sendNext(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("mail relay failed: %v", err)
+			}
+			return
+		}
+		if !sent {
+			return
+		}
+	}
+}
+
+// sendNext sends one due mail and reports whether there was one.
+func (o *MailOutbox) sendNext(ctx context.Context) (bool, error) {
+	tx, err := o.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	mail, err := o.repo.ClaimNext(ctx, tx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := o.mail.Send(ctx, mailer.Message{To: mail.Recipient, Subject: mail.Subject, Body: mail.Body}); err != nil {
+		var retryAt *time.Time
+		if attempts := mail.Attempts + 1; attempts < mailMaxAttempts {
+			next := time.Now().Add(mailBackoff(attempts))
+			retryAt = &next
+		}
+		log.Printf("outbox mail %s failed: %v", mail.ID, err)
+		if err := o.repo.MarkAttemptFailed(ctx, tx, mail.ID, err.Error(), retryAt); err != nil {
+			return false, err
+		}
+		return true, tx.Commit()
+	}
+
+	if err := o.repo.MarkSent(ctx, tx, mail.ID); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// mailBackoff doubles from 30s after each failed send, capped at two hours.
+func mailBackoff(attempts int) time.Duration {
+	delay := mailBaseBackoff
+	for i := 1; i < attempts && delay < mailMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > mailMaxBackoff {
+		delay = mailMaxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMailBackoff(t *testing.T) {
+	cases := map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		3:  2 * time.Minute,
+		8:  64 * time.Minute,
+		9:  mailMaxBackoff,
+		40: mailMaxBackoff,
+	}
+
+	for attempts, want := range cases {
+		if got := mailBackoff(attempts); got != want {
+			t.Errorf("mailBackoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
+
+func TestMailOutboxFlushDoesNotBlock(t *testing.T) {
+	outbox := NewMailOutbox(nil, nil, nil)
+	outbox.Flush()
+	outbox.Flush()
+
+	select {
+	case <-outbox.wake:
+	default:
+		t.Fatal("flush did not wake the relay")
+	}
+}
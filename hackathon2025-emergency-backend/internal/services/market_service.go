@@ -0,0 +1,353 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"invoiceflow/internal/config"
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/events"
+	"invoiceflow/internal/pagination"
+	"invoiceflow/internal/repositories"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrPositionNotFound        = errors.New("funding position not found")
+	ErrPositionNotTradable     = errors.New("funding position is not tradable")
+	ErrPositionAlreadyListed   = errors.New("funding position already listed")
+	ErrListingNotFound         = errors.New("market listing not found")
+	ErrListingInvalid          = errors.New("invalid market listing")
+	ErrListingClosed           = errors.New("market listing is no longer active")
+	ErrListingOwnPosition      = errors.New("cannot buy a position of your own organization")
+	ErrListingExceedsPrincipal = errors.New("purchase exceeds listed principal")
+	ErrPositionOnchain         = errors.New("positions of tokenized invoices cannot be traded while chain mode is on")
+)
+
+// MarketService runs the secondary market, where investors sell all or part
+// of a confirmed funding position to other investors. Trades move positions
+// in the ledger only; the invoice contract cannot move them on-chain, so with
+// chain mode on, positions of tokenized invoices are not traded.
+type MarketService struct {
+	cfg         *config.Config
+	db          *sqlx.DB
+	repo        *repositories.MarketRepository
+	fundingRepo *repositories.FundingRepository
+	invoiceRepo *repositories.InvoiceRepository
+	chainRepo   *repositories.ChainRepository
+	audit       *AuditService
+	bus         *events.Bus
+}
+
+func NewMarketService(cfg *config.Config, db *sqlx.DB, repo *repositories.MarketRepository, fundingRepo *repositories.FundingRepository, invoiceRepo *repositories.InvoiceRepository, chainRepo *repositories.ChainRepository, audit *AuditService, bus *events.Bus) *MarketService {
+	return &MarketService{cfg: cfg, db: db, repo: repo, fundingRepo: fundingRepo, invoiceRepo: invoiceRepo, chainRepo: chainRepo, audit: audit, bus: bus}
+}
+
+// ListingInput describes a new listing. Principal defaults to the whole
+// position; UnitPrice is the price per unit of principal.
+type ListingInput struct {
+	FundingID string
+	Principal *float64
+	UnitPrice float64
+}
+
+func (s *MarketService) ListListings(ctx context.Context, filters repositories.ListingFilters) ([]domain.MarketListing, pagination.Meta, error) {
+	return s.repo.ListListings(ctx, filters)
+}
+
+func (s *MarketService) GetListing(ctx context.Context, id string) (*domain.MarketListing, error) {
+	listing, err := s.repo.GetListing(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrListingNotFound
+	}
+	return listing, err
+}
+
+func (s *MarketService) ListTransfers(ctx context.Context, organizationIDs []string, invoiceID string, page pagination.Page) ([]domain.PositionTransfer, pagination.Meta, error) {
+	return s.repo.ListTransfers(ctx, organizationIDs, invoiceID, page)
+}
+
+// CreateListing offers a position held by the organization for sale on
+// behalf of sellerID; callers check the seller may act for it.
+func (s *MarketService) CreateListing(ctx context.Context, organizationID string, sellerID string, input ListingInput) (*domain.MarketListing, error) {
+	if input.UnitPrice <= 0 {
+		return nil, ErrListingInvalid
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	funding, err := s.fundingRepo.GetForUpdate(ctx, tx, input.FundingID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrPositionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if funding.OrganizationID != organizationID {
+		return nil, ErrPositionNotFound
+	}
+	if funding.Status != domain.FundingStatusConfirmed {
+		return nil, ErrPositionNotTradable
+	}
+
+	principal, err := listingPrincipal(funding, input.Principal)
+	if err != nil {
+		return nil, err
+	}
+
+	invoice, err := s.invoiceRepo.GetByID(ctx, funding.InvoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkTradable(ctx, invoice); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.ActiveListingForFunding(ctx, tx, funding.ID); err == nil {
+		return nil, ErrPositionAlreadyListed
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	created, err := s.repo.CreateListing(ctx, tx, &domain.MarketListing{
+		FundingID:      funding.ID,
+		InvoiceID:      funding.InvoiceID,
+		OrganizationID: organizationID,
+		SellerID:       sellerID,
+		Currency:       invoice.Currency,
+		Principal:      principal,
+		UnitPrice:      input.UnitPrice,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(ctx, tx, domain.AuditEntityMarketListing, created.ID, domain.AuditActionMarketList, nil, created); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// CancelListing withdraws what is left of an active listing.
+func (s *MarketService) CancelListing(ctx context.Context, organizationID string, id string) (*domain.MarketListing, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	listing, err := s.repo.GetListingForUpdate(ctx, tx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrListingNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if listing.OrganizationID != organizationID {
+		return nil, ErrListingNotFound
+	}
+	if listing.Status != domain.MarketListingActive {
+		return nil, ErrListingClosed
+	}
+
+	canceled, err := s.repo.UpdateListingStatus(ctx, tx, id, domain.MarketListingCanceled)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(ctx, tx, domain.AuditEntityMarketListing, id, domain.AuditActionMarketCancel, listing, canceled); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return canceled, nil
+}
+
+// Buy purchases principal from a listing for the buyer's organization, or
+// all that is left of it when principal is nil. The seller's position shrinks
+// by the principal and the buyer gets a new confirmed position at the same APR
+// and term, in one transaction.
+func (s *MarketService) Buy(ctx context.Context, organizationID string, buyerID string, listingID string, principal *float64) (*domain.PositionTransfer, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	listing, err := s.repo.GetListingForUpdate(ctx, tx, listingID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrListingNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if listing.Status != domain.MarketListingActive {
+		return nil, ErrListingClosed
+	}
+	if listing.OrganizationID == organizationID {
+		return nil, ErrListingOwnPosition
+	}
+
+	amount, err := purchasePrincipal(listing, principal)
+	if err != nil {
+		return nil, err
+	}
+
+	funding, err := s.fundingRepo.GetForUpdate(ctx, tx, listing.FundingID)
+	if err != nil {
+		return nil, err
+	}
+	if funding.Status != domain.FundingStatusConfirmed || toCents(amount) > toCents(funding.Amount) {
+		return nil, ErrPositionNotTradable
+	}
+	invoice, err := s.invoiceRepo.GetByID(ctx, funding.InvoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkTradable(ctx, invoice); err != nil {
+		return nil, err
+	}
+
+	reduced, err := s.fundingRepo.ReduceAmount(ctx, tx, funding.ID, amount, domain.FundingStatusTransferred)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	bought, err := s.fundingRepo.Create(ctx, tx, &domain.Funding{
+		InvoiceID:       funding.InvoiceID,
+		InvestorID:      buyerID,
+		OrganizationID:  organizationID,
+		Amount:          amount,
+		APRPercent:      funding.APRPercent,
+		TermMonths:      funding.TermMonths,
+		Status:          domain.FundingStatusConfirmed,
+		ConfirmedAt:     &now,
+		SourceFundingID: &funding.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	updatedListing, err := s.repo.TakeFromListing(ctx, tx, listing.ID, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer, err := s.repo.CreateTransfer(ctx, tx, &domain.PositionTransfer{
+		ListingID:            listing.ID,
+		InvoiceID:            funding.InvoiceID,
+		FromFundingID:        funding.ID,
+		ToFundingID:          bought.ID,
+		SellerOrganizationID: listing.OrganizationID,
+		BuyerOrganizationID:  organizationID,
+		BuyerID:              buyerID,
+		Principal:            amount,
+		Price:                purchasePrice(listing, amount),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.bus.Publish(ctx, tx, domain.EventFundingTransferred, domain.AggregateFunding, funding.ID, []string{listing.OrganizationID, organizationID}, map[string]any{
+		"transfer": transfer,
+		"listing":  updatedListing,
+		"funding":  bought,
+		"invoice":  invoice,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(ctx, tx, domain.AuditEntityFunding, funding.ID, domain.AuditActionFundingTransfer, funding, map[string]any{
+		"transfer": transfer,
+		"from":     reduced,
+		"to":       bought,
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+// listingPrincipal is the principal a new listing offers: requested, or the
+// whole position when nil, never more than the position holds.
+func listingPrincipal(funding *domain.Funding, requested *float64) (float64, error) {
+	principal := funding.Amount
+	if requested != nil {
+		principal = *requested
+	}
+	if principal <= 0 || toCents(principal) > toCents(funding.Amount) {
+		return 0, ErrListingInvalid
+	}
+	return principal, nil
+}
+
+// purchasePrincipal is the principal a purchase takes: requested, or all
+// that is left of the listing when nil.
+func purchasePrincipal(listing *domain.MarketListing, requested *float64) (float64, error) {
+	amount := listing.RemainingPrincipal
+	if requested != nil {
+		amount = *requested
+	}
+	if amount <= 0 {
+		return 0, ErrListingInvalid
+	}
+	if toCents(amount) > toCents(listing.RemainingPrincipal) {
+		return 0, ErrListingExceedsPrincipal
+	}
+	return amount, nil
+}
+
+// purchasePrice is what the buyer pays for principal, to the cent.
+func purchasePrice(listing *domain.MarketListing, principal float64) float64 {
+	return roundCents(principal * listing.UnitPrice)
+}
+
+// checkTradable refuses trades in positions of an invoice that was paid or
+// defaulted, as they are settled, and, while chain mode is on, of an invoice
+// minted on-chain, as the token would no longer match the ledger.
+func (s *MarketService) checkTradable(ctx context.Context, invoice *domain.Invoice) error {
+	if invoice.Status == domain.InvoiceStatusPaid || invoice.Status == domain.InvoiceStatusDefaulted {
+		return ErrPositionNotTradable
+	}
+	if !s.cfg.EnableChain {
+		return nil
+	}
+
+	record, err := s.chainRepo.GetOnchainByInvoiceID(ctx, invoice.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if tokenized(record) {
+		return ErrPositionOnchain
+	}
+	return nil
+}
+
+// tokenized reports whether an invoice has, or may yet have, a token: its
+// mint is confirmed or still pending.
+func tokenized(record *domain.InvoiceOnChain) bool {
+	return record.ChainStatus != domain.ChainStatusFailed
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"invoiceflow/internal/config"
+	"invoiceflow/internal/domain"
+)
+
+func TestListingPrincipal(t *testing.T) {
+	amount := func(v float64) *float64 { return &v }
+	funding := &domain.Funding{Amount: 1000}
+
+	cases := []struct {
+		name      string
+		requested *float64
+		want      float64
+		err       error
+	}{
+		{"whole position by default", nil, 1000, nil},
+		{"part of the position", amount(250.5), 250.5, nil},
+		{"all of it", amount(1000.001), 1000.001, nil},
+		{"more than held", amount(1000.01), 0, ErrListingInvalid},
+		{"nothing", amount(0), 0, ErrListingInvalid},
+		{"negative", amount(-5), 0, ErrListingInvalid},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := listingPrincipal(funding, tc.requested)
+			if !errors.Is(err, tc.err) || got != tc.want {
+				t.Fatalf("listingPrincipal = %v, %v; want %v, %v", got, err, tc.want, tc.err)
+			}
+		})
+	}
+}
+
+func TestPurchasePrincipal(t *testing.T) {
+	amount := func(v float64) *float64 { return &v }
+	listing := &domain.MarketListing{Principal: 1000, RemainingPrincipal: 400, UnitPrice: 0.98}
+
+	cases := []struct {
+		name      string
+		requested *float64
+		want      float64
+		err       error
+	}{
+		{"what is left by default", nil, 400, nil},
+		{"part of what is left", amount(150), 150, nil},
+		{"more than is left", amount(400.01), 0, ErrListingExceedsPrincipal},
+		{"nothing", amount(0), 0, ErrListingInvalid},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := purchasePrincipal(listing, tc.requested)
+			if !errors.Is(err, tc.err) || got != tc.want {
+				t.Fatalf("purchasePrincipal = %v, %v; want %v, %v", got, err, tc.want, tc.err)
+			}
+		})
+	}
+}
+
+func TestPurchasePriceRoundsToCents(t *testing.T) {
+	listing := &domain.MarketListing{UnitPrice: 0.987}
+
+	if got := purchasePrice(listing, 333.33); got != 329.0 {
+		t.Fatalf("purchasePrice = %v, want 329", got)
+	}
+	if got := purchasePrice(&domain.MarketListing{UnitPrice: 1}, 150.25); got != 150.25 {
+		t.Fatalf("purchasePrice at par = %v, want 150.25", got)
+	}
+}
+
+func TestTokenizedInvoicesAreNotTradedOnChain(t *testing.T) {
+	cases := []struct {
+		status string
+		want   bool
+	}{
+		{domain.ChainStatusPending, true},
+		{domain.ChainStatusConfirmed, true},
+		{domain.ChainStatusFailed, false},
+	}
+
+	for _, tc := range cases {
+		if got := tokenized(&domain.InvoiceOnChain{ChainStatus: tc.status}); got != tc.want {
+			t.Fatalf("tokenized(%s) = %v, want %v", tc.status, got, tc.want)
+		}
+	}
+}
+
+func TestTradableInvoices(t *testing.T) {
+	service := &MarketService{cfg: &config.Config{EnableChain: false}}
+
+	cases := []struct {
+		status string
+		want   error
+	}{
+		{domain.InvoiceStatusFunded, nil},
+		{domain.InvoiceStatusPartiallyPaid, nil},
+		{domain.InvoiceStatusPaid, ErrPositionNotTradable},
+		{domain.InvoiceStatusDefaulted, ErrPositionNotTradable},
+	}
+
+	for _, tc := range cases {
+		if err := service.checkTradable(context.Background(), &domain.Invoice{ID: "invoice-1", Status: tc.status}); !errors.Is(err, tc.want) {
+			t.Fatalf("checkTradable(%s) = %v, want %v", tc.status, err, tc.want)
+		}
+	}
+}
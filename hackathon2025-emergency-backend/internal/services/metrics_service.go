@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"invoiceflow/internal/config"
+	"invoiceflow/internal/db"
+	"invoiceflow/internal/repositories"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// MetricsService keeps the dashboard rollups fresh: it refreshes them on
+// start and then every METRICS_REFRESH_MINUTES. Every instance runs the
+// timer, but a refresh happens under lock, so only one instance refreshes per
+// tick and the others skip it.
+type MetricsService struct {
+	cfg     *config.Config
+	lock    db.Locker
+	refresh func(ctx context.Context) error
+}
+
+func NewMetricsService(cfg *config.Config, database *sqlx.DB, repo *repositories.MetricsRepository) *MetricsService {
+	return &MetricsService{cfg: cfg, lock: db.AdvisoryLocker(database, db.LockMetricsRefresh), refresh: repo.Refresh}
+}
+
+func (s *MetricsService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.cfg.MetricsRefreshInterval)
+		defer ticker.Stop()
+		for {
+			if err := s.refreshLocked(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("metrics rollup refresh failed: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// refreshLocked refreshes the rollups unless another instance holds the lock,
+// in which case that instance is refreshing them already.
+func (s *MetricsService) refreshLocked(ctx context.Context) error {
+	release, acquired, err := s.lock(ctx)
+	if err != nil || !acquired {
+		return err
+	}
+	defer release()
+
+	return s.refresh(ctx)
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMetricsRefreshRunsOnlyUnderLock(t *testing.T) {
+	refreshes, releases := 0, 0
+	held := false
+	service := &MetricsService{
+		lock: func(context.Context) (func(), bool, error) {
+			if held {
+				return nil, false, nil
+			}
+			return func() { releases++ }, true, nil
+		},
+		refresh: func(context.Context) error {
+			refreshes++
+			return nil
+		},
+	}
+
+	if err := service.refreshLocked(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if refreshes != 1 || releases != 1 {
+		t.Fatalf("refreshes = %d, releases = %d; want 1, 1", refreshes, releases)
+	}
+
+	// Another instance holds the lock for this tick.
+	held = true
+	if err := service.refreshLocked(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if refreshes != 1 {
+		t.Fatalf("refreshed without the lock")
+	}
+}
+
+func TestMetricsRefreshReleasesLockOnFailure(t *testing.T) {
+	released := false
+	failure := errors.New("refresh failed")
+	service := &MetricsService{
+		lock: func(context.Context) (func(), bool, error) {
+			return func() { released = true }, true, nil
+		},
+		refresh: func(context.Context) error { return failure },
+	}
+
+	if err := service.refreshLocked(context.Background()); !errors.Is(err, failure) {
+		t.Fatalf("err = %v, want %v", err, failure)
+	}
+	if !released {
+		t.Fatal("lock kept after a failed refresh")
+	}
+}
+
+func TestMetricsRefreshSkipsWhenLockFails(t *testing.T) {
+	failure := errors.New("no connection")
+	service := &MetricsService{
+		lock: func(context.Context) (func(), bool, error) { return nil, false, failure },
+		refresh: func(context.Context) error {
+			t.Fatal("refreshed without the lock")
+			return nil
+		},
+	}
+
+	if err := service.refreshLocked(context.Background()); !errors.Is(err, failure) {
+		t.Fatalf("err = %v, want %v", err, failure)
+	}
+}
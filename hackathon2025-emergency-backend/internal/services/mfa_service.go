@@ -0,0 +1,374 @@
+package services
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"invoiceflow/internal/config"
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/repositories"
+	"invoiceflow/internal/totp"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrMFANotEnrolled     = errors.New("mfa not enrolled")
+	ErrMFAAlreadyEnabled  = errors.New("mfa already enabled")
+	ErrMFAInvalidCode     = errors.New("invalid mfa code")
+	ErrMFARequiredForRole = errors.New("mfa is required for this role")
+)
+
+const (
+	recoveryCodeCount = 10
+	totpSkewSteps     = 1
+)
+
+type MFAService struct {
+	cfg         *config.Config
+	db          *sqlx.DB
+	mfaRepo     *repositories.MFARepository
+	sessionRepo *repositories.SessionRepository
+	roleRepo    *repositories.RoleRepository
+}
+
+func NewMFAService(cfg *config.Config, db *sqlx.DB, mfaRepo *repositories.MFARepository, sessionRepo *repositories.SessionRepository, roleRepo *repositories.RoleRepository) *MFAService {
+	return &MFAService{cfg: cfg, db: db, mfaRepo: mfaRepo, sessionRepo: sessionRepo, roleRepo: roleRepo}
+}
+
+type MFAEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// RequiredForRole reports whether accounts with role must use a second
+// factor: any role holding a permission of the /admin routes does.
+func (s *MFAService) RequiredForRole(ctx context.Context, role string) (bool, error) {
+	permissions, err := s.roleRepo.PermissionsFor(ctx, role)
+	if err != nil {
+		return false, err
+	}
+	return domain.RequiresMFA(permissions), nil
+}
+
+func (s *MFAService) Status(ctx context.Context, user *domain.User) (*domain.MFAStatus, error) {
+	required, err := s.RequiredForRole(ctx, user.Role)
+	if err != nil {
+		return nil, err
+	}
+	status := &domain.MFAStatus{Required: required}
+
+	mfa, err := s.mfaRepo.GetByUser(ctx, user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return status, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	status.Enabled = mfa.EnabledAt != nil
+	status.EnabledAt = mfa.EnabledAt
+	if status.Enabled {
+		remaining, err := s.mfaRepo.CountUnusedRecoveryCodes(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		status.RecoveryCodesRemaining = remaining
+	}
+
+	return status, nil
+}
+
+func (s *MFAService) Enabled(ctx context.Context, userID string) (bool, error) {
+	mfa, err := s.mfaRepo.GetByUser(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return mfa.EnabledAt != nil, nil
+}
+
+// Enroll generates a new secret. It stays inactive until Activate confirms the
+// user's authenticator produces matching codes.
+func (s *MFAService) Enroll(ctx context.Context, user *domain.User) (*MFAEnrollment, error) {
+	enabled, err := s.Enabled(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := s.seal(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := s.mfaRepo.SavePending(ctx, tx, user.ID, sealed); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &MFAEnrollment{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(s.cfg.MFAIssuer, user.Email, secret),
+	}, nil
+}
+
+// Activate turns on MFA once the user proves their authenticator works and
+// returns the one-time recovery codes. The session used to activate counts as
+// MFA-verified from then on.
+func (s *MFAService) Activate(ctx context.Context, userID string, sessionID string, code string) ([]string, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	mfa, err := s.mfaRepo.GetByUserForUpdate(ctx, tx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrMFANotEnrolled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if mfa.EnabledAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	step, err := s.validateTOTP(mfa, code, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.mfaRepo.Enable(ctx, tx, userID, step); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.sessionRepo.MarkMFAVerified(ctx, tx, sessionID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (s *MFAService) Disable(ctx context.Context, user *domain.User, code string) error {
+	required, err := s.RequiredForRole(ctx, user.Role)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrMFARequiredForRole
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.verify(ctx, tx, user.ID, code); err != nil {
+		return err
+	}
+
+	if err := s.mfaRepo.Delete(ctx, tx, user.ID); err != nil {
+		return err
+	}
+
+	// Sessions verified with the removed factor must not keep passing
+	// RequireMFA, including after a later re-enrollment.
+	if err := s.sessionRepo.ClearMFAVerified(ctx, tx, user.ID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID string, code string) ([]string, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := s.verify(ctx, tx, userID, code); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.replaceRecoveryCodes(ctx, tx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Verify accepts a current TOTP code or an unused recovery code.
+func (s *MFAService) Verify(ctx context.Context, userID string, code string) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.verify(ctx, tx, userID, code); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *MFAService) verify(ctx context.Context, tx *sqlx.Tx, userID string, code string) error {
+	mfa, err := s.mfaRepo.GetByUserForUpdate(ctx, tx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrMFANotEnrolled
+	}
+	if err != nil {
+		return err
+	}
+	if mfa.EnabledAt == nil {
+		return ErrMFANotEnrolled
+	}
+
+	if step, err := s.validateTOTP(mfa, code, time.Now()); err == nil {
+		return s.mfaRepo.UpdateLastStep(ctx, tx, userID, step)
+	}
+
+	used, err := s.mfaRepo.UseRecoveryCode(ctx, tx, userID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrMFAInvalidCode
+	}
+
+	return nil
+}
+
+// validateTOTP rejects codes from a step at or before the last accepted one,
+// so an observed code cannot be replayed within its validity window.
+func (s *MFAService) validateTOTP(mfa *domain.UserMFA, code string, now time.Time) (int64, error) {
+	secret, err := s.open(mfa.SecretEncrypted)
+	if err != nil {
+		return 0, err
+	}
+
+	step, ok := totp.Validate(secret, code, now, totpSkewSteps)
+	if !ok || step <= mfa.LastUsedStep {
+		return 0, ErrMFAInvalidCode
+	}
+
+	return step, nil
+}
+
+func (s *MFAService) replaceRecoveryCodes(ctx context.Context, tx *sqlx.Tx, userID string) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := randomRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hashToken(normalizeRecoveryCode(code)))
+	}
+
+	if err := s.mfaRepo.ReplaceRecoveryCodes(ctx, tx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+func (s *MFAService) seal(plaintext string) ([]byte, error) {
+	gcm, err := s.cipher()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, []byte(plaintext), nil), nil
+}
+
+func (s *MFAService) open(sealed []byte) (string, error) {
+	gcm, err := s.cipher()
+	if err != nil {
+		return "", err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("mfa secret is corrupt")
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+func (s *MFAService) cipher() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(s.cfg.MFAEncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func randomRecoveryCode() (string, error) {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf))
+	return code[:5] + "-" + code[5:10], nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
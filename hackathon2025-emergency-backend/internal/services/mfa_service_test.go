@@ -0,0 +1,54 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"invoiceflow/internal/config"
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/totp"
+)
+
+func TestValidateTOTPRefusesReplay(t *testing.T) {
+	service := &MFAService{cfg: &config.Config{MFAEncryptionKey: make([]byte, 32)}}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := service.seal(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1_700_000_000, 0)
+	current := totp.Step(now)
+	codeAt := func(step int64) string {
+		code, err := totp.Code(secret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	mfa := &domain.UserMFA{SecretEncrypted: sealed}
+	step, err := service.validateTOTP(mfa, codeAt(current-1), now)
+	if err != nil || step != current-1 {
+		t.Fatalf("validateTOTP = %d, %v, want step %d", step, err, current-1)
+	}
+	mfa.LastUsedStep = step
+
+	if _, err := service.validateTOTP(mfa, codeAt(current-1), now); !errors.Is(err, ErrMFAInvalidCode) {
+		t.Fatalf("replayed code: err = %v, want ErrMFAInvalidCode", err)
+	}
+
+	step, err = service.validateTOTP(mfa, codeAt(current), now)
+	if err != nil || step != current {
+		t.Fatalf("validateTOTP = %d, %v, want step %d", step, err, current)
+	}
+	mfa.LastUsedStep = step
+
+	if _, err := service.validateTOTP(mfa, codeAt(current-1), now); !errors.Is(err, ErrMFAInvalidCode) {
+		t.Fatalf("code older than the last one used: err = %v, want ErrMFAInvalidCode", err)
+	}
+}
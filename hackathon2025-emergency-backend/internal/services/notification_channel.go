@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/mailer"
+	"invoiceflow/internal/repositories"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// NotificationChannel delivers a notification to one recipient. Channels run
+// inside the event delivery's transaction and should only write to it;
+// Deliver returning an error rolls the delivery back and retries it.
+type NotificationChannel interface {
+	Name() string
+	Deliver(ctx context.Context, tx *sqlx.Tx, recipient domain.NotificationRecipient, notification *domain.Notification) error
+}
+
+// InAppChannel stores the notification for the /me/notifications feed.
+type InAppChannel struct {
+	repo *repositories.NotificationRepository
+}
+
+func NewInAppChannel(repo *repositories.NotificationRepository) *InAppChannel {
+	return &InAppChannel{repo: repo}
+}
+
+func (ch *InAppChannel) Name() string {
+	return domain.NotificationChannelInApp
+}
+
+func (ch *InAppChannel) Deliver(ctx context.Context, tx *sqlx.Tx, recipient domain.NotificationRecipient, notification *domain.Notification) error {
+	_, err := ch.repo.Create(ctx, tx, notification)
+	return err
+}
+
+// EmailChannel queues the notification in the mail outbox, which sends it
+// once the delivery commits. A retried delivery does not mail twice: the
+// outbox keeps one mail per recipient and event.
+type EmailChannel struct {
+	outbox  *MailOutbox
+	baseURL string
+}
+
+func NewEmailChannel(outbox *MailOutbox, baseURL string) *EmailChannel {
+	return &EmailChannel{outbox: outbox, baseURL: baseURL}
+}
+
+func (ch *EmailChannel) Name() string {
+	return domain.NotificationChannelEmail
+}
+
+func (ch *EmailChannel) Deliver(ctx context.Context, tx *sqlx.Tx, recipient domain.NotificationRecipient, notification *domain.Notification) error {
+	return ch.outbox.EnqueueForEvent(ctx, tx, notification.EventID, notificationMail(recipient, notification, ch.baseURL))
+}
+
+func notificationMail(recipient domain.NotificationRecipient, notification *domain.Notification, baseURL string) mailer.Message {
+	return mailer.Message{
+		To:      recipient.Email,
+		Subject: notification.Title,
+		Body: fmt.Sprintf("Hi %s,\n\n%s\n\nSee your notifications at %s/notifications\n",
+			recipient.Name, notification.Body, baseURL),
+	}
+}
@@ -0,0 +1,24 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"invoiceflow/internal/domain"
+)
+
+func TestNotificationMail(t *testing.T) {
+	recipient := domain.NotificationRecipient{ID: "user-1", Name: "Ada", Email: "ada@example.com"}
+	notification := &domain.Notification{EventID: "evt-1", Title: "Invoice approved", Body: "INV-7 is open for funding."}
+
+	msg := notificationMail(recipient, notification, "https://app.example.com")
+
+	if msg.To != "ada@example.com" || msg.Subject != "Invoice approved" {
+		t.Fatalf("message = %+v", msg)
+	}
+	for _, want := range []string{"Hi Ada,", "INV-7 is open for funding.", "https://app.example.com/notifications"} {
+		if !strings.Contains(msg.Body, want) {
+			t.Errorf("body %q lacks %q", msg.Body, want)
+		}
+	}
+}
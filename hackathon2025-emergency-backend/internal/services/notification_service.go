@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/pagination"
+	"invoiceflow/internal/repositories"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrNotificationNotFound     = errors.New("notification not found")
+	ErrNotificationInvalidEvent = errors.New("unknown notification event type")
+)
+
+type NotificationService struct {
+	db       *sqlx.DB
+	repo     *repositories.NotificationRepository
+	channels []NotificationChannel
+}
+
+func NewNotificationService(db *sqlx.DB, repo *repositories.NotificationRepository, channels ...NotificationChannel) *NotificationService {
+	return &NotificationService{db: db, repo: repo, channels: channels}
+}
+
+// notificationPayload is the part of an event payload notifications render.
+type notificationPayload struct {
+	Invoice  *domain.Invoice          `json:"invoice"`
+	Funding  *domain.Funding          `json:"funding"`
+	Amount   float64                  `json:"amount"`
+	Review   *domain.EmergencyReview  `json:"review"`
+	Transfer *domain.PositionTransfer `json:"transfer"`
+}
+
+// HandleEvent is the event bus subscriber that notifies the members of the
+// organizations an event concerns, plus the reviewers for submissions and
+// emergency-lane escalations, on
+// each channel their preferences enable.
+func (s *NotificationService) HandleEvent(ctx context.Context, tx *sqlx.Tx, event *domain.Event) error {
+	var payload notificationPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return err
+	}
+	if payload.Invoice == nil {
+		return nil
+	}
+
+	title, body := renderNotification(event.Type, &payload)
+
+	recipients, err := s.repo.OrganizationRecipients(ctx, tx, event.OrganizationIDs)
+	if err != nil {
+		return err
+	}
+
+	if event.Type == domain.EventInvoiceSubmitted || event.Type == domain.EventInvoiceEmergencyEscalated {
+		reviewers, err := s.repo.PermissionRecipients(ctx, tx, domain.PermInvoiceApprove)
+		if err != nil {
+			return err
+		}
+		recipients = append(recipients, reviewers...)
+	}
+
+	seen := make(map[string]bool, len(recipients))
+	userIDs := make([]string, 0, len(recipients))
+	unique := make([]domain.NotificationRecipient, 0, len(recipients))
+	for _, recipient := range recipients {
+		if seen[recipient.ID] {
+			continue
+		}
+		seen[recipient.ID] = true
+		userIDs = append(userIDs, recipient.ID)
+		unique = append(unique, recipient)
+	}
+
+	prefs, err := s.repo.PreferencesForEvent(ctx, tx, userIDs, event.Type)
+	if err != nil {
+		return err
+	}
+
+	for _, recipient := range unique {
+		pref, ok := prefs[recipient.ID]
+		if !ok {
+			pref = domain.DefaultNotificationPreference(recipient.ID, event.Type)
+		}
+
+		notification := &domain.Notification{
+			UserID:     recipient.ID,
+			EventID:    event.ID,
+			EventType:  event.Type,
+			EntityType: event.AggregateType,
+			EntityID:   event.AggregateID,
+			Title:      title,
+			Body:       body,
+		}
+
+		for _, channel := range s.channels {
+			if !channelEnabled(pref, channel.Name()) {
+				continue
+			}
+			if err := channel.Deliver(ctx, tx, recipient, notification); err != nil {
+				return fmt.Errorf("%s channel: %w", channel.Name(), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func channelEnabled(pref domain.NotificationPreference, channel string) bool {
+	switch channel {
+	case domain.NotificationChannelInApp:
+		return pref.InApp
+	case domain.NotificationChannelEmail:
+		return pref.Email
+	default:
+		return false
+	}
+}
+
+func renderNotification(eventType string, payload *notificationPayload) (string, string) {
+	invoice := payload.Invoice
+	ref := invoice.InvoiceNumber
+	if ref == "" {
+		ref = invoice.Title
+	}
+
+	switch eventType {
+	case domain.EventInvoiceSubmitted:
+		if invoice.EmergencyLane {
+			return fmt.Sprintf("Emergency invoice %s submitted for review", ref),
+				fmt.Sprintf("%s (%.2f %s) is in the emergency lane and waiting for fast-track approval.", invoice.Title, invoice.Amount, invoice.Currency)
+		}
+		return fmt.Sprintf("Invoice %s submitted for review", ref),
+			fmt.Sprintf("%s (%.2f %s) is waiting for approval.", invoice.Title, invoice.Amount, invoice.Currency)
+	case domain.EventInvoiceEmergencyEscalated:
+		body := fmt.Sprintf("%s (%.2f %s) has not been reviewed within the emergency lane SLA.", invoice.Title, invoice.Amount, invoice.Currency)
+		if payload.Review != nil {
+			body = fmt.Sprintf("%s It was due at %s (escalation %d).", body, payload.Review.DueAt.UTC().Format("2006-01-02 15:04 MST"), payload.Review.Escalations)
+		}
+		return fmt.Sprintf("Emergency invoice %s is overdue for review", ref), body
+	case domain.EventInvoiceApproved:
+		body := "It is now open for funding."
+		if invoice.RiskTier != nil && invoice.APRPercent != nil {
+			body = fmt.Sprintf("Risk tier %s at %.2f%% APR. It is now open for funding.", *invoice.RiskTier, *invoice.APRPercent)
+		}
+		return fmt.Sprintf("Invoice %s approved", ref), body
+	case domain.EventFundingCreated:
+		body := fmt.Sprintf("%.2f of %.2f %s is now funded.", invoice.FundedAmount, invoice.FundingTarget, invoice.Currency)
+		if payload.Funding != nil {
+			body = fmt.Sprintf("%.2f %s funded at %.2f%% APR. %s", payload.Funding.Amount, invoice.Currency, payload.Funding.APRPercent, body)
+		}
+		return fmt.Sprintf("New funding on invoice %s", ref), body
+	case domain.EventFundingTransferred:
+		body := "A position in this invoice changed hands on the secondary market."
+		if payload.Transfer != nil {
+			body = fmt.Sprintf("%.2f %s of principal was sold on the secondary market for %.2f %s.", payload.Transfer.Principal, invoice.Currency, payload.Transfer.Price, invoice.Currency)
+		}
+		return fmt.Sprintf("Position in invoice %s transferred", ref), body
+	case domain.EventInvoiceFunded:
+		return fmt.Sprintf("Invoice %s fully funded", ref),
+			fmt.Sprintf("The funding target of %.2f %s has been reached.", invoice.FundingTarget, invoice.Currency)
+	case domain.EventInvoiceTokenized:
+		return fmt.Sprintf("Invoice %s tokenized", ref), "The invoice token was minted on-chain."
+	case domain.EventChainMintFailed:
+		return fmt.Sprintf("Tokenization of invoice %s failed", ref), "The mint transaction did not succeed. It can be retried."
+	case domain.EventInvoicePartiallyPaid:
+		return fmt.Sprintf("Repayment received on invoice %s", ref),
+			fmt.Sprintf("%.2f %s received; %.2f of %.2f repaid.", payload.Amount, invoice.Currency, invoice.PaidAmount, invoice.Amount)
+	case domain.EventInvoicePaid:
+		return fmt.Sprintf("Invoice %s repaid in full", ref),
+			fmt.Sprintf("%.2f %s has been repaid.", invoice.PaidAmount, invoice.Currency)
+	case domain.EventInvoiceDefaulted:
+		return fmt.Sprintf("Invoice %s defaulted", ref),
+			fmt.Sprintf("The invoice was marked defaulted with %.2f of %.2f %s repaid.", invoice.PaidAmount, invoice.Amount, invoice.Currency)
+	default:
+		return fmt.Sprintf("Invoice %s updated", ref), fmt.Sprintf("Status is now %s.", invoice.Status)
+	}
+}
+
+func (s *NotificationService) List(ctx context.Context, userID string, unreadOnly bool, page pagination.Page) ([]domain.Notification, pagination.Meta, error) {
+	return s.repo.List(ctx, userID, unreadOnly, page)
+}
+
+func (s *NotificationService) UnreadCount(ctx context.Context, userID string) (int, error) {
+	return s.repo.CountUnread(ctx, userID)
+}
+
+func (s *NotificationService) MarkRead(ctx context.Context, userID string, id string) error {
+	affected, err := s.repo.MarkRead(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+func (s *NotificationService) MarkAllRead(ctx context.Context, userID string) (int64, error) {
+	return s.repo.MarkAllRead(ctx, userID)
+}
+
+// Preferences returns the user's setting for every notification event,
+// filling in the defaults for those never changed.
+func (s *NotificationService) Preferences(ctx context.Context, userID string) ([]domain.NotificationPreference, error) {
+	stored, err := s.repo.ListPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byEvent := make(map[string]domain.NotificationPreference, len(stored))
+	for _, pref := range stored {
+		byEvent[pref.EventType] = pref
+	}
+
+	prefs := make([]domain.NotificationPreference, 0, len(domain.NotificationEventTypes))
+	for _, eventType := range domain.NotificationEventTypes {
+		pref, ok := byEvent[eventType]
+		if !ok {
+			pref = domain.DefaultNotificationPreference(userID, eventType)
+		}
+		prefs = append(prefs, pref)
+	}
+
+	return prefs, nil
+}
+
+func (s *NotificationService) UpdatePreferences(ctx context.Context, userID string, prefs []domain.NotificationPreference) ([]domain.NotificationPreference, error) {
+	for _, pref := range prefs {
+		if !domain.ValidNotificationEventType(pref.EventType) {
+			return nil, ErrNotificationInvalidEvent
+		}
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, pref := range prefs {
+		pref.UserID = userID
+		if err := s.repo.UpsertPreference(ctx, tx, pref); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.Preferences(ctx, userID)
+}
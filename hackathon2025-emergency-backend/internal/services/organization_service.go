@@ -0,0 +1,351 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"invoiceflow/internal/config"
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/mailer"
+	"invoiceflow/internal/repositories"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrOrgNotFound             = errors.New("organization not found")
+	ErrOrgAccessDenied         = errors.New("organization role insufficient")
+	ErrOrgInvalidRole          = errors.New("invalid organization role")
+	ErrOrgMemberNotFound       = errors.New("organization member not found")
+	ErrOrgLastOwner            = errors.New("organization needs at least one owner")
+	ErrInvitationInvalid       = errors.New("invitation invalid or expired")
+	ErrInvitationEmailMismatch = errors.New("invitation was sent to a different email")
+	ErrOrgAlreadyMember        = errors.New("already a member of the organization")
+	ErrOrgWrongKind            = errors.New("organization kind cannot take this action")
+)
+
+const invitationTTL = 7 * 24 * time.Hour
+
+type OrganizationService struct {
+	cfg      *config.Config
+	db       *sqlx.DB
+	orgRepo  *repositories.OrganizationRepository
+	userRepo *repositories.UserRepository
+	mail     *MailOutbox
+}
+
+func NewOrganizationService(cfg *config.Config, db *sqlx.DB, orgRepo *repositories.OrganizationRepository, userRepo *repositories.UserRepository, mail *MailOutbox) *OrganizationService {
+	return &OrganizationService{cfg: cfg, db: db, orgRepo: orgRepo, userRepo: userRepo, mail: mail}
+}
+
+// CreatePersonal gives a newly registered user an organization of their own,
+// so a sole trader never has to think about organizations.
+func (s *OrganizationService) CreatePersonal(ctx context.Context, tx *sqlx.Tx, user *domain.User) (*domain.Organization, error) {
+	org, err := s.orgRepo.Create(ctx, tx, &domain.Organization{
+		Name:      user.Name,
+		Kind:      user.Role,
+		Personal:  true,
+		CreatedBy: &user.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.orgRepo.AddMember(ctx, tx, org.ID, user.ID, domain.OrgRoleOwner); err != nil {
+		return nil, err
+	}
+
+	return org, nil
+}
+
+func (s *OrganizationService) Create(ctx context.Context, user *domain.User, name string) (*domain.Membership, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	org, err := s.orgRepo.Create(ctx, tx, &domain.Organization{
+		Name:      name,
+		Kind:      user.Role,
+		CreatedBy: &user.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.orgRepo.AddMember(ctx, tx, org.ID, user.ID, domain.OrgRoleOwner); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &domain.Membership{Organization: *org, Role: domain.OrgRoleOwner}, nil
+}
+
+func (s *OrganizationService) ListForUser(ctx context.Context, userID string) ([]domain.Membership, error) {
+	return s.orgRepo.ListForUser(ctx, userID)
+}
+
+func (s *OrganizationService) MemberOrganizationIDs(ctx context.Context, userID string) ([]string, error) {
+	return s.orgRepo.ListMemberOrganizationIDs(ctx, userID)
+}
+
+// Authorize returns the user's membership in the organization if their org
+// role is at least minRole. An empty organizationID means the user's personal
+// organization. Non-members get ErrOrgNotFound so ids cannot be probed.
+func (s *OrganizationService) Authorize(ctx context.Context, userID string, organizationID string, minRole string) (*domain.Membership, error) {
+	var membership *domain.Membership
+	var err error
+	if organizationID == "" {
+		membership, err = s.orgRepo.GetPersonalMembership(ctx, userID)
+	} else {
+		membership, err = s.orgRepo.GetMembership(ctx, organizationID, userID)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrOrgNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := membershipAllows(membership, minRole, ""); err != nil {
+		return nil, err
+	}
+
+	return membership, nil
+}
+
+// AuthorizeKind is Authorize for actions only one kind of organization may
+// take: SME organizations issue invoices and investor organizations fund
+// them.
+func (s *OrganizationService) AuthorizeKind(ctx context.Context, userID string, organizationID string, minRole string, kind string) (*domain.Membership, error) {
+	membership, err := s.Authorize(ctx, userID, organizationID, minRole)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := membershipAllows(membership, minRole, kind); err != nil {
+		return nil, err
+	}
+
+	return membership, nil
+}
+
+// membershipAllows checks the member's role is at least minRole and, unless
+// kind is empty, that the organization is of that kind.
+func membershipAllows(membership *domain.Membership, minRole string, kind string) error {
+	if !domain.OrgRoleAtLeast(membership.Role, minRole) {
+		return ErrOrgAccessDenied
+	}
+	if kind != "" && membership.Kind != kind {
+		return ErrOrgWrongKind
+	}
+	return nil
+}
+
+func (s *OrganizationService) ListMembers(ctx context.Context, userID string, organizationID string) ([]domain.OrganizationMember, error) {
+	if _, err := s.Authorize(ctx, userID, organizationID, domain.OrgRoleViewer); err != nil {
+		return nil, err
+	}
+
+	return s.orgRepo.ListMembers(ctx, organizationID)
+}
+
+func (s *OrganizationService) UpdateMemberRole(ctx context.Context, actorID string, organizationID string, userID string, role string) error {
+	if !domain.ValidOrgRole(role) {
+		return ErrOrgInvalidRole
+	}
+	if _, err := s.Authorize(ctx, actorID, organizationID, domain.OrgRoleOwner); err != nil {
+		return err
+	}
+
+	return s.changeMembers(ctx, organizationID, userID, role)
+}
+
+// RemoveMember lets owners remove anyone and members remove themselves.
+func (s *OrganizationService) RemoveMember(ctx context.Context, actorID string, organizationID string, userID string) error {
+	minRole := domain.OrgRoleOwner
+	if actorID == userID {
+		minRole = domain.OrgRoleViewer
+	}
+	if _, err := s.Authorize(ctx, actorID, organizationID, minRole); err != nil {
+		return err
+	}
+
+	return s.changeMembers(ctx, organizationID, userID, "")
+}
+
+// changeMembers sets userID's role, or removes them when role is empty,
+// refusing any change that would leave the organization without an owner.
+func (s *OrganizationService) changeMembers(ctx context.Context, organizationID string, userID string, role string) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	members, err := s.orgRepo.LockMembers(ctx, tx, organizationID)
+	if err != nil {
+		return err
+	}
+
+	owners := 0
+	var target *domain.OrganizationMember
+	for i := range members {
+		if members[i].Role == domain.OrgRoleOwner {
+			owners++
+		}
+		if members[i].UserID == userID {
+			target = &members[i]
+		}
+	}
+	if target == nil {
+		return ErrOrgMemberNotFound
+	}
+	if target.Role == domain.OrgRoleOwner && role != domain.OrgRoleOwner && owners == 1 {
+		return ErrOrgLastOwner
+	}
+
+	if role == "" {
+		err = s.orgRepo.RemoveMember(ctx, tx, organizationID, userID)
+	} else {
+		err = s.orgRepo.UpdateMemberRole(ctx, tx, organizationID, userID, role)
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (s *OrganizationService) Invite(ctx context.Context, actorID string, organizationID string, email string, role string) (*domain.OrganizationInvitation, error) {
+	if !domain.ValidOrgRole(role) {
+		return nil, ErrOrgInvalidRole
+	}
+
+	membership, err := s.Authorize(ctx, actorID, organizationID, domain.OrgRoleOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	inviter, err := s.userRepo.GetByID(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	invitation, err := s.orgRepo.CreateInvitation(ctx, tx, &domain.OrganizationInvitation{
+		OrganizationID: organizationID,
+		Email:          normalizeEmail(email),
+		Role:           role,
+		TokenHash:      hashToken(token),
+		InvitedBy:      &actorID,
+		ExpiresAt:      time.Now().Add(invitationTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The mail is queued with the invitation and only sent once it commits.
+	link := s.cfg.AppBaseURL + "/invitations/accept?token=" + url.QueryEscape(token)
+	if err := s.mail.Enqueue(ctx, tx, mailer.Message{
+		To:      invitation.Email,
+		Subject: fmt.Sprintf("%s invited you to %s on InvoiceFlow", inviter.Name, membership.Name),
+		Body: fmt.Sprintf("%s invited you to join %s as %s.\n\nSign in or register with this email address, then open:\n\n%s\n\nThe invitation expires in 7 days.\n",
+			inviter.Name, membership.Name, role, link),
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	s.mail.Flush()
+
+	return invitation, nil
+}
+
+func (s *OrganizationService) ListInvitations(ctx context.Context, actorID string, organizationID string) ([]domain.OrganizationInvitation, error) {
+	if _, err := s.Authorize(ctx, actorID, organizationID, domain.OrgRoleOwner); err != nil {
+		return nil, err
+	}
+
+	return s.orgRepo.ListInvitations(ctx, organizationID)
+}
+
+func (s *OrganizationService) RevokeInvitation(ctx context.Context, actorID string, organizationID string, invitationID string) error {
+	if _, err := s.Authorize(ctx, actorID, organizationID, domain.OrgRoleOwner); err != nil {
+		return err
+	}
+
+	affected, err := s.orgRepo.RevokeInvitation(ctx, organizationID, invitationID)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrInvitationInvalid
+	}
+
+	return nil
+}
+
+// AcceptInvitation adds the signed-in user to the inviting organization. The
+// account email must match the invited address.
+func (s *OrganizationService) AcceptInvitation(ctx context.Context, user *domain.User, token string) (*domain.Membership, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	invitation, err := s.orgRepo.GetInvitationByHashForUpdate(ctx, tx, hashToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrInvitationInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if invitation.AcceptedAt != nil || invitation.RevokedAt != nil || time.Now().After(invitation.ExpiresAt) {
+		return nil, ErrInvitationInvalid
+	}
+	if invitation.Email != normalizeEmail(user.Email) {
+		return nil, ErrInvitationEmailMismatch
+	}
+
+	if err := s.orgRepo.AddMember(ctx, tx, invitation.OrganizationID, user.ID, invitation.Role); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrOrgAlreadyMember
+		}
+		return nil, err
+	}
+
+	if err := s.orgRepo.MarkInvitationAccepted(ctx, tx, invitation.ID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.Authorize(ctx, user.ID, invitation.OrganizationID, domain.OrgRoleViewer)
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"testing"
+
+	"invoiceflow/internal/domain"
+)
+
+func TestMembershipAllows(t *testing.T) {
+	member := func(role string, kind string) *domain.Membership {
+		return &domain.Membership{Organization: domain.Organization{ID: "org-1", Kind: kind}, Role: role}
+	}
+
+	cases := []struct {
+		name       string
+		membership *domain.Membership
+		minRole    string
+		kind       string
+		want       error
+	}{
+		{"editor acting as editor", member(domain.OrgRoleEditor, domain.OrgKindSME), domain.OrgRoleEditor, "", nil},
+		{"viewer acting as editor", member(domain.OrgRoleViewer, domain.OrgKindSME), domain.OrgRoleEditor, "", ErrOrgAccessDenied},
+		{"any kind", member(domain.OrgRoleOwner, domain.RoleAdmin), domain.OrgRoleViewer, "", nil},
+		{"sme issuing", member(domain.OrgRoleEditor, domain.OrgKindSME), domain.OrgRoleEditor, domain.OrgKindSME, nil},
+		{"investor issuing", member(domain.OrgRoleOwner, domain.OrgKindInvestor), domain.OrgRoleEditor, domain.OrgKindSME, ErrOrgWrongKind},
+		{"sme funding", member(domain.OrgRoleOwner, domain.OrgKindSME), domain.OrgRoleEditor, domain.OrgKindInvestor, ErrOrgWrongKind},
+		{"role checked before kind", member(domain.OrgRoleViewer, domain.OrgKindSME), domain.OrgRoleEditor, domain.OrgKindInvestor, ErrOrgAccessDenied},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := membershipAllows(tc.membership, tc.minRole, tc.kind); got != tc.want {
+				t.Fatalf("membershipAllows = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,393 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/repositories"
+)
+
+const (
+	portfolioUnrated    = "UNRATED"
+	portfolioNoDebtor   = "UNSPECIFIED"
+	xirrMaxIterations   = 100
+	xirrTolerance       = 1e-7
+	daysPerYear         = 365.0
+	portfolioMonthStamp = "2006-01"
+)
+
+// PortfolioService computes investor portfolio analytics from fundings,
+// secondary-market transfers and what closed invoices repaid. It only reads.
+type PortfolioService struct {
+	repo portfolioSource
+	now  func() time.Time
+}
+
+// portfolioSource is the part of PortfolioRepository the service reads.
+type portfolioSource interface {
+	Positions(ctx context.Context, organizationIDs []string) ([]domain.PortfolioPosition, error)
+	Sales(ctx context.Context, organizationIDs []string) ([]domain.PositionSale, error)
+}
+
+func NewPortfolioService(repo *repositories.PortfolioRepository) *PortfolioService {
+	return &PortfolioService{repo: repo, now: time.Now}
+}
+
+// Portfolio holds one summary per currency; amounts are never added across
+// currencies.
+type Portfolio struct {
+	AsOf      time.Time          `json:"as_of"`
+	Summaries []PortfolioSummary `json:"summaries"`
+	Exposure  PortfolioExposure  `json:"exposure"`
+	Cashflows []CashflowMonth    `json:"cashflow_calendar"`
+}
+
+type PortfolioSummary struct {
+	Currency             string   `json:"currency"`
+	OpenPositions        int      `json:"open_positions"`
+	TotalInvested        float64  `json:"total_invested"`
+	OutstandingPrincipal float64  `json:"outstanding_principal"`
+	AccruedInterest      float64  `json:"accrued_interest"`
+	RealizedReturns      float64  `json:"realized_returns"`
+	DefaultLosses        float64  `json:"default_losses"`
+	WeightedAvgAPR       *float64 `json:"weighted_avg_apr"`
+	XIRR                 *float64 `json:"xirr"`
+}
+
+// PortfolioExposure splits outstanding principal by risk tier, SME (the
+// issuing organization) and debtor.
+type PortfolioExposure struct {
+	RiskTier []ExposureItem `json:"risk_tier"`
+	SME      []ExposureItem `json:"sme"`
+	Debtor   []ExposureItem `json:"debtor"`
+}
+
+type ExposureItem struct {
+	Key         string  `json:"key"`
+	Currency    string  `json:"currency"`
+	Outstanding float64 `json:"outstanding"`
+	Share       float64 `json:"share"`
+}
+
+// CashflowMonth is what open positions are expected to return in a month,
+// at their invoices' due dates.
+type CashflowMonth struct {
+	Month     string  `json:"month"`
+	Currency  string  `json:"currency"`
+	Principal float64 `json:"principal"`
+	Interest  float64 `json:"interest"`
+	Total     float64 `json:"total"`
+}
+
+type cashflow struct {
+	at     time.Time
+	amount float64
+}
+
+// Get computes the portfolio of the given organizations as of now.
+//
+//   - Total invested is what was paid for each position: its original
+//     principal, or the price paid on the secondary market.
+//   - Accrued interest is simple interest on outstanding principal since the
+//     position was confirmed, up to its term.
+//   - A position is settled once its invoice is paid or defaulted: see
+//     settledReturn. Realized returns are settled interest plus gains on
+//     secondary-market sales against principal; default losses are the
+//     unrecovered principal.
+//   - XIRR discounts purchases, sales and settlements, with open positions
+//     valued at principal plus accrued interest today.
+func (s *PortfolioService) Get(ctx context.Context, organizationIDs []string) (*Portfolio, error) {
+	positions, err := s.repo.Positions(ctx, organizationIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	sales, err := s.repo.Sales(ctx, organizationIDs)
+	if err != nil {
+		return nil, err
+	}
+	salesByFunding := map[string][]domain.PositionSale{}
+	for _, sale := range sales {
+		salesByFunding[sale.FundingID] = append(salesByFunding[sale.FundingID], sale)
+	}
+
+	now := s.now().UTC()
+	summaries := map[string]*PortfolioSummary{}
+	flows := map[string][]cashflow{}
+	weighted := map[string]float64{}
+	riskTiers := map[[2]string]float64{}
+	smes := map[[2]string]float64{}
+	debtors := map[[2]string]float64{}
+	calendar := map[[2]string]*CashflowMonth{}
+
+	for _, position := range positions {
+		summary, ok := summaries[position.Currency]
+		if !ok {
+			summary = &PortfolioSummary{Currency: position.Currency}
+			summaries[position.Currency] = summary
+		}
+
+		soldPrincipal := 0.0
+		for _, sale := range salesByFunding[position.ID] {
+			soldPrincipal += sale.Principal
+			summary.RealizedReturns += sale.Price - sale.Principal
+			flows[position.Currency] = append(flows[position.Currency], cashflow{at: sale.SoldAt, amount: sale.Price})
+		}
+
+		cost := position.Amount + soldPrincipal
+		if position.PurchasePrice != nil {
+			cost = *position.PurchasePrice
+		}
+		summary.TotalInvested += cost
+		flows[position.Currency] = append(flows[position.Currency], cashflow{at: position.CreatedAt, amount: -cost})
+
+		if position.Status != domain.FundingStatusPending && position.Status != domain.FundingStatusConfirmed || position.Amount <= 0 {
+			continue
+		}
+
+		if position.InvoiceStatus == domain.InvoiceStatusPaid || position.InvoiceStatus == domain.InvoiceStatusDefaulted {
+			settled := settledReturn(&position)
+			summary.RealizedReturns += settled.interest
+			summary.DefaultLosses += settled.loss
+			settledAt := now
+			if position.ClosedAt != nil {
+				settledAt = *position.ClosedAt
+			}
+			flows[position.Currency] = append(flows[position.Currency], cashflow{at: settledAt, amount: settled.payout})
+			continue
+		}
+
+		accrued := accruedInterest(&position.Funding, now)
+		summary.OpenPositions++
+		summary.OutstandingPrincipal += position.Amount
+		summary.AccruedInterest += accrued
+		weighted[position.Currency] += position.Amount * position.APRPercent
+		flows[position.Currency] = append(flows[position.Currency], cashflow{at: now, amount: position.Amount + accrued})
+
+		riskTier := portfolioUnrated
+		if position.RiskTier != nil {
+			riskTier = *position.RiskTier
+		}
+		debtor := portfolioNoDebtor
+		if position.DebtorName != nil && *position.DebtorName != "" {
+			debtor = *position.DebtorName
+		}
+		riskTiers[[2]string{riskTier, position.Currency}] += position.Amount
+		smes[[2]string{position.SMEName, position.Currency}] += position.Amount
+		debtors[[2]string{debtor, position.Currency}] += position.Amount
+
+		monthKey := [2]string{position.DueDate.Format(portfolioMonthStamp), position.Currency}
+		month, ok := calendar[monthKey]
+		if !ok {
+			month = &CashflowMonth{Month: monthKey[0], Currency: position.Currency}
+			calendar[monthKey] = month
+		}
+		month.Principal += position.Amount
+		month.Interest += position.Amount * position.APRPercent / 100 * float64(position.TermMonths) / 12
+	}
+
+	portfolio := &Portfolio{AsOf: now, Summaries: []PortfolioSummary{}, Cashflows: []CashflowMonth{}}
+	for currency, summary := range summaries {
+		if summary.OutstandingPrincipal > 0 {
+			apr := roundCents(weighted[currency] / summary.OutstandingPrincipal)
+			summary.WeightedAvgAPR = &apr
+		}
+		if rate, ok := xirr(flows[currency]); ok {
+			rate = math.Round(rate*1e6) / 1e6
+			summary.XIRR = &rate
+		}
+		summary.TotalInvested = roundCents(summary.TotalInvested)
+		summary.OutstandingPrincipal = roundCents(summary.OutstandingPrincipal)
+		summary.AccruedInterest = roundCents(summary.AccruedInterest)
+		summary.RealizedReturns = roundCents(summary.RealizedReturns)
+		summary.DefaultLosses = roundCents(summary.DefaultLosses)
+		portfolio.Summaries = append(portfolio.Summaries, *summary)
+	}
+	sort.Slice(portfolio.Summaries, func(i, j int) bool {
+		return portfolio.Summaries[i].Currency < portfolio.Summaries[j].Currency
+	})
+
+	portfolio.Exposure.RiskTier = exposureItems(riskTiers, summaries)
+	portfolio.Exposure.SME = exposureItems(smes, summaries)
+	portfolio.Exposure.Debtor = exposureItems(debtors, summaries)
+
+	for _, month := range calendar {
+		month.Principal = roundCents(month.Principal)
+		month.Interest = roundCents(month.Interest)
+		month.Total = roundCents(month.Principal + month.Interest)
+		portfolio.Cashflows = append(portfolio.Cashflows, *month)
+	}
+	sort.Slice(portfolio.Cashflows, func(i, j int) bool {
+		if portfolio.Cashflows[i].Month != portfolio.Cashflows[j].Month {
+			return portfolio.Cashflows[i].Month < portfolio.Cashflows[j].Month
+		}
+		return portfolio.Cashflows[i].Currency < portfolio.Cashflows[j].Currency
+	})
+
+	return portfolio, nil
+}
+
+type settlement struct {
+	interest float64
+	loss     float64
+	payout   float64
+}
+
+// settledReturn is what a position of a paid or defaulted invoice returned:
+// its principal plus simple interest for its term, out of what the invoice
+// repaid and never more. When repayments fall short of what the invoice's
+// open positions are owed, each gets its pro rata share of them. Principal
+// not paid back is the loss.
+func settledReturn(position *domain.PortfolioPosition) settlement {
+	principal := toCents(position.Amount)
+	owed := principal + toCents(position.Amount*position.APRPercent/100*float64(position.TermMonths)/12)
+
+	payout := owed
+	if invoiceOwed := toCents(position.InvoiceOwed); invoiceOwed > 0 && toCents(position.PaidAmount) < invoiceOwed {
+		payout = int64(math.Floor(float64(toCents(position.PaidAmount)) * float64(owed) / float64(invoiceOwed)))
+	}
+
+	var result settlement
+	result.payout = float64(payout) / 100
+	if payout > principal {
+		result.interest = float64(payout-principal) / 100
+	} else {
+		result.loss = float64(principal-payout) / 100
+	}
+	return result
+}
+
+// accruedInterest is simple interest on a position from its confirmation to
+// at, capped at its term. Pending positions have not started accruing.
+func accruedInterest(funding *domain.Funding, at time.Time) float64 {
+	if funding.ConfirmedAt == nil {
+		return 0
+	}
+
+	days := at.Sub(*funding.ConfirmedAt).Hours() / 24
+	termDays := float64(funding.TermMonths) * daysPerYear / 12
+	days = math.Max(0, math.Min(days, termDays))
+
+	return funding.Amount * funding.APRPercent / 100 * days / daysPerYear
+}
+
+// exposureItems lists outstanding principal per key, largest first, with its
+// share of the currency's outstanding principal.
+func exposureItems(totals map[[2]string]float64, summaries map[string]*PortfolioSummary) []ExposureItem {
+	items := make([]ExposureItem, 0, len(totals))
+	for key, outstanding := range totals {
+		item := ExposureItem{Key: key[0], Currency: key[1], Outstanding: roundCents(outstanding)}
+		if total := summaries[key[1]].OutstandingPrincipal; total > 0 {
+			item.Share = math.Round(outstanding/total*1e4) / 1e4
+		}
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Currency != items[j].Currency {
+			return items[i].Currency < items[j].Currency
+		}
+		if items[i].Outstanding != items[j].Outstanding {
+			return items[i].Outstanding > items[j].Outstanding
+		}
+		return items[i].Key < items[j].Key
+	})
+	return items
+}
+
+// xirr is the annual rate r at which the cashflows' net present value,
+// discounting each by (1+r)^(days/365) from the first, is zero. It needs at
+// least one outflow and one inflow. Newton's method is tried first and
+// bisection is the fallback when it does not converge.
+func xirr(flows []cashflow) (float64, bool) {
+	if len(flows) < 2 {
+		return 0, false
+	}
+
+	start := flows[0].at
+	hasOut, hasIn := false, false
+	for _, flow := range flows {
+		if flow.at.Before(start) {
+			start = flow.at
+		}
+		if flow.amount < 0 {
+			hasOut = true
+		}
+		if flow.amount > 0 {
+			hasIn = true
+		}
+	}
+	if !hasOut || !hasIn {
+		return 0, false
+	}
+
+	if rate, ok := newtonXIRR(flows, start); ok {
+		return rate, true
+	}
+	return bisectXIRR(flows, start)
+}
+
+// xirrNPV is the net present value of flows at rate, discounted from start,
+// and its derivative by rate.
+func xirrNPV(flows []cashflow, start time.Time, rate float64) (float64, float64) {
+	var value, derivative float64
+	for _, flow := range flows {
+		years := flow.at.Sub(start).Hours() / 24 / daysPerYear
+		factor := math.Pow(1+rate, years)
+		value += flow.amount / factor
+		derivative -= years * flow.amount / (factor * (1 + rate))
+	}
+	return value, derivative
+}
+
+// newtonXIRR runs Newton's method from 10%. It gives up when a step leaves
+// the domain (rates at or below -100%) or it does not converge.
+func newtonXIRR(flows []cashflow, start time.Time) (float64, bool) {
+	rate := 0.1
+	for i := 0; i < xirrMaxIterations; i++ {
+		value, derivative := xirrNPV(flows, start, rate)
+		if math.Abs(value) < xirrTolerance {
+			return rate, true
+		}
+		if derivative == 0 {
+			return 0, false
+		}
+		next := rate - value/derivative
+		if next <= -1 || math.IsNaN(next) || math.IsInf(next, 0) {
+			return 0, false
+		}
+		if math.Abs(next-rate) < xirrTolerance {
+			return next, true
+		}
+		rate = next
+	}
+	return 0, false
+}
+
+// bisectXIRR searches rates from just above -100% to 1000%, and finds none
+// when the net present value has the same sign at both ends.
+func bisectXIRR(flows []cashflow, start time.Time) (float64, bool) {
+	low, high := -0.9999, 10.0
+	lowValue, _ := xirrNPV(flows, start, low)
+	highValue, _ := xirrNPV(flows, start, high)
+	if lowValue*highValue > 0 {
+		return 0, false
+	}
+	for i := 0; i < xirrMaxIterations*2; i++ {
+		mid := (low + high) / 2
+		midValue, _ := xirrNPV(flows, start, mid)
+		if math.Abs(midValue) < xirrTolerance || (high-low)/2 < xirrTolerance {
+			return mid, true
+		}
+		if midValue*lowValue < 0 {
+			high = mid
+		} else {
+			low, lowValue = mid, midValue
+		}
+	}
+
+	return (low + high) / 2, true
+}
@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"invoiceflow/internal/domain"
+)
+
+func day(value string) time.Time {
+	parsed, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+func TestXIRR(t *testing.T) {
+	cases := []struct {
+		name  string
+		flows []cashflow
+		want  float64
+	}{
+		{"ten percent over a year", []cashflow{
+			{at: day("2024-01-01"), amount: -1000},
+			{at: day("2024-12-31"), amount: 1100},
+		}, 0.1},
+		// The XIRR worked example in the spreadsheet documentation.
+		{"irregular flows", []cashflow{
+			{at: day("2008-01-01"), amount: -10000},
+			{at: day("2008-03-01"), amount: 2750},
+			{at: day("2008-10-30"), amount: 4250},
+			{at: day("2009-02-15"), amount: 3250},
+			{at: day("2009-04-01"), amount: 2750},
+		}, 0.373362535},
+		{"flows out of order", []cashflow{
+			{at: day("2024-12-31"), amount: 1100},
+			{at: day("2024-01-01"), amount: -1000},
+		}, 0.1},
+		{"a loss", []cashflow{
+			{at: day("2024-01-01"), amount: -1000},
+			{at: day("2024-12-31"), amount: 500},
+		}, -0.5},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := xirr(tc.flows)
+			if !ok || math.Abs(got-tc.want) > 1e-6 {
+				t.Fatalf("xirr = %v, %v; want %v", got, ok, tc.want)
+			}
+		})
+	}
+}
+
+func TestXIRRFallsBackToBisection(t *testing.T) {
+	// Almost everything lost: Newton's first step from 10% leaves the domain.
+	flows := []cashflow{
+		{at: day("2024-01-01"), amount: -1000},
+		{at: day("2024-12-31"), amount: 1},
+	}
+
+	if _, ok := newtonXIRR(flows, flows[0].at); ok {
+		t.Fatal("Newton's method converged; the case no longer covers the fallback")
+	}
+	got, ok := xirr(flows)
+	if !ok || math.Abs(got-(-0.999)) > 1e-6 {
+		t.Fatalf("xirr = %v, %v; want -0.999", got, ok)
+	}
+}
+
+func TestXIRRNeedsMoneyBothWays(t *testing.T) {
+	cases := map[string][]cashflow{
+		"single flow":    {{at: day("2024-01-01"), amount: -1000}},
+		"only outflows":  {{at: day("2024-01-01"), amount: -1000}, {at: day("2024-06-01"), amount: -500}},
+		"only inflows":   {{at: day("2024-01-01"), amount: 1000}, {at: day("2024-06-01"), amount: 500}},
+		"no root in 10x": {{at: day("2024-01-01"), amount: -1}, {at: day("2024-01-02"), amount: 1000}},
+	}
+
+	for name, flows := range cases {
+		if rate, ok := xirr(flows); ok {
+			t.Fatalf("%s: xirr = %v, want none", name, rate)
+		}
+	}
+}
+
+func TestAccruedInterest(t *testing.T) {
+	confirmed := day("2025-01-01")
+	funding := func(confirmedAt *time.Time) *domain.Funding {
+		return &domain.Funding{Amount: 1000, APRPercent: 12, TermMonths: 3, ConfirmedAt: confirmedAt}
+	}
+
+	cases := []struct {
+		name    string
+		funding *domain.Funding
+		at      time.Time
+		want    float64
+	}{
+		{"pending", funding(nil), day("2025-02-01"), 0},
+		{"on confirmation", funding(&confirmed), confirmed, 0},
+		{"after 73 days", funding(&confirmed), confirmed.AddDate(0, 0, 73), 24},
+		{"capped at the term", funding(&confirmed), day("2026-01-01"), 30},
+		{"before confirmation", funding(&confirmed), day("2024-12-01"), 0},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := accruedInterest(tc.funding, tc.at); math.Abs(got-tc.want) > 1e-9 {
+				t.Fatalf("accruedInterest = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSettledReturn(t *testing.T) {
+	position := func(status string, paid float64, invoiceOwed float64) *domain.PortfolioPosition {
+		return &domain.PortfolioPosition{
+			Funding:       domain.Funding{Amount: 1000, APRPercent: 12, TermMonths: 3},
+			InvoiceStatus: status,
+			PaidAmount:    paid,
+			InvoiceOwed:   invoiceOwed,
+		}
+	}
+
+	cases := []struct {
+		name     string
+		position *domain.PortfolioPosition
+		want     settlement
+	}{
+		{"paid in full", position(domain.InvoiceStatusPaid, 2500, 2060), settlement{interest: 30, payout: 1030}},
+		// 2040 of the 2060 owed: each position gets 1030 * 2040 / 2060.
+		{"paid short of the interest", position(domain.InvoiceStatusPaid, 2040, 2060), settlement{interest: 20, payout: 1020}},
+		{"defaulted half way", position(domain.InvoiceStatusDefaulted, 1030, 2060), settlement{loss: 485, payout: 515}},
+		{"defaulted with nothing repaid", position(domain.InvoiceStatusDefaulted, 0, 2060), settlement{loss: 1000}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := settledReturn(tc.position); got != tc.want {
+				t.Fatalf("settledReturn = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+type memoryPortfolio struct {
+	positions []domain.PortfolioPosition
+	sales     []domain.PositionSale
+}
+
+func (m *memoryPortfolio) Positions(context.Context, []string) ([]domain.PortfolioPosition, error) {
+	return m.positions, nil
+}
+
+func (m *memoryPortfolio) Sales(context.Context, []string) ([]domain.PositionSale, error) {
+	return m.sales, nil
+}
+
+func TestPortfolioGet(t *testing.T) {
+	now := day("2025-07-01")
+	tierA, debtor := "A", "Big Co"
+	confirmedA, confirmedB := day("2025-01-01"), day("2025-03-01")
+	closedC, closedD := day("2025-04-01"), day("2025-05-01")
+	purchase := 196.0
+
+	source := &memoryPortfolio{
+		positions: []domain.PortfolioPosition{
+			// Half of it sold on the secondary market at a gain.
+			{
+				Funding:  domain.Funding{ID: "a", Amount: 500, APRPercent: 12, TermMonths: 12, Status: domain.FundingStatusConfirmed, CreatedAt: confirmedA, ConfirmedAt: &confirmedA},
+				Currency: "EUR", DueDate: day("2025-12-31"), RiskTier: &tierA, DebtorName: &debtor, SMEName: "Acme",
+				InvoiceStatus: domain.InvoiceStatusFunded,
+			},
+			// Bought on the secondary market below par.
+			{
+				Funding:  domain.Funding{ID: "b", Amount: 200, APRPercent: 10, TermMonths: 6, Status: domain.FundingStatusConfirmed, CreatedAt: confirmedB, ConfirmedAt: &confirmedB},
+				Currency: "EUR", DueDate: day("2025-08-31"), SMEName: "Bolt",
+				InvoiceStatus: domain.InvoiceStatusFunded, PurchasePrice: &purchase,
+			},
+			{
+				Funding:  domain.Funding{ID: "c", Amount: 1000, APRPercent: 12, TermMonths: 3, Status: domain.FundingStatusConfirmed, CreatedAt: confirmedA, ConfirmedAt: &confirmedA},
+				Currency: "EUR", DueDate: day("2025-04-01"), SMEName: "Acme",
+				InvoiceStatus: domain.InvoiceStatusPaid, PaidAmount: 2040, InvoiceOwed: 2060, ClosedAt: &closedC,
+			},
+			{
+				Funding:  domain.Funding{ID: "d", Amount: 1000, APRPercent: 12, TermMonths: 3, Status: domain.FundingStatusConfirmed, CreatedAt: confirmedA, ConfirmedAt: &confirmedA},
+				Currency: "EUR", DueDate: day("2025-04-01"), SMEName: "Bolt",
+				InvoiceStatus: domain.InvoiceStatusDefaulted, PaidAmount: 1030, InvoiceOwed: 2060, ClosedAt: &closedD,
+			},
+			// Sold in full at a loss.
+			{
+				Funding:  domain.Funding{ID: "e", Amount: 0, APRPercent: 8, TermMonths: 6, Status: domain.FundingStatusTransferred, CreatedAt: confirmedA, ConfirmedAt: &confirmedA},
+				Currency: "EUR", DueDate: day("2025-07-01"), SMEName: "Acme",
+				InvoiceStatus: domain.InvoiceStatusFunded,
+			},
+		},
+		sales: []domain.PositionSale{
+			{FundingID: "a", Principal: 500, Price: 510, SoldAt: day("2025-04-01")},
+			{FundingID: "e", Principal: 300, Price: 290, SoldAt: day("2025-02-01")},
+		},
+	}
+	service := &PortfolioService{repo: source, now: func() time.Time { return now }}
+
+	portfolio, err := service.Get(context.Background(), []string{"org-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(portfolio.Summaries) != 1 {
+		t.Fatalf("got %d summaries, want 1", len(portfolio.Summaries))
+	}
+	summary := portfolio.Summaries[0]
+	// a: 500 * 12% * 181/365; b: 200 * 10% * 122/365.
+	accrued := roundCents(500*0.12*181/365 + 200*0.10*122/365)
+	want := PortfolioSummary{
+		Currency:             "EUR",
+		OpenPositions:        2,
+		TotalInvested:        1000 + 196 + 1000 + 1000 + 300,
+		OutstandingPrincipal: 700,
+		AccruedInterest:      accrued,
+		RealizedReturns:      10 + 20 - 10,
+		DefaultLosses:        485,
+	}
+	if summary.Currency != want.Currency || summary.OpenPositions != want.OpenPositions ||
+		summary.TotalInvested != want.TotalInvested || summary.OutstandingPrincipal != want.OutstandingPrincipal ||
+		summary.AccruedInterest != want.AccruedInterest || summary.RealizedReturns != want.RealizedReturns ||
+		summary.DefaultLosses != want.DefaultLosses {
+		t.Fatalf("summary = %+v, want %+v", summary, want)
+	}
+	if summary.WeightedAvgAPR == nil || *summary.WeightedAvgAPR != 11.43 {
+		t.Fatalf("weighted_avg_apr = %v, want 11.43", summary.WeightedAvgAPR)
+	}
+
+	flows := []cashflow{
+		{at: confirmedA, amount: -1000}, {at: day("2025-04-01"), amount: 510}, {at: now, amount: 500 + 500*0.12*181/365},
+		{at: confirmedB, amount: -196}, {at: now, amount: 200 + 200*0.10*122/365},
+		{at: confirmedA, amount: -1000}, {at: closedC, amount: 1020},
+		{at: confirmedA, amount: -1000}, {at: closedD, amount: 515},
+		{at: day("2025-02-01"), amount: 290}, {at: confirmedA, amount: -300},
+	}
+	rate, _ := xirr(flows)
+	if summary.XIRR == nil || *summary.XIRR != math.Round(rate*1e6)/1e6 {
+		t.Fatalf("xirr = %v, want %v", summary.XIRR, rate)
+	}
+
+	tiers := portfolio.Exposure.RiskTier
+	if len(tiers) != 2 || tiers[0].Key != "A" || tiers[0].Outstanding != 500 || tiers[0].Share != 0.7143 ||
+		tiers[1].Key != portfolioUnrated || tiers[1].Share != 0.2857 {
+		t.Fatalf("risk tier exposure = %+v", tiers)
+	}
+	if debtors := portfolio.Exposure.Debtor; len(debtors) != 2 || debtors[1].Key != portfolioNoDebtor {
+		t.Fatalf("debtor exposure = %+v", debtors)
+	}
+
+	calendar := portfolio.Cashflows
+	if len(calendar) != 2 || calendar[0].Month != "2025-08" || calendar[0].Total != 210 ||
+		calendar[1].Month != "2025-12" || calendar[1].Principal != 500 || calendar[1].Interest != 60 {
+		t.Fatalf("cashflow calendar = %+v", calendar)
+	}
+}
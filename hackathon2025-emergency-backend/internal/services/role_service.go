@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/repositories"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrRoleInvalidName   = errors.New("invalid role name")
+	ErrRoleProtected     = errors.New("role is protected")
+	ErrRoleInUse         = errors.New("role is assigned to users")
+	ErrUnknownPermission = errors.New("unknown permission")
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,31}$`)
+
+type RoleService struct {
+	db       *sqlx.DB
+	roleRepo *repositories.RoleRepository
+	audit    *AuditService
+}
+
+func NewRoleService(db *sqlx.DB, roleRepo *repositories.RoleRepository, audit *AuditService) *RoleService {
+	return &RoleService{db: db, roleRepo: roleRepo, audit: audit}
+}
+
+func (s *RoleService) List(ctx context.Context) ([]domain.Role, error) {
+	return s.roleRepo.List(ctx)
+}
+
+func (s *RoleService) ListPermissions(ctx context.Context) ([]domain.Permission, error) {
+	return s.roleRepo.ListPermissions(ctx)
+}
+
+// Put creates the role or replaces its description and permission set. The
+// admin role is fixed so nobody can lock themselves out of role management.
+func (s *RoleService) Put(ctx context.Context, name string, description string, permissions []string) (*domain.Role, error) {
+	if !roleNamePattern.MatchString(name) {
+		return nil, ErrRoleInvalidName
+	}
+	if name == domain.RoleAdmin {
+		return nil, ErrRoleProtected
+	}
+
+	before, err := s.roleRepo.Get(ctx, name)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := s.roleRepo.Upsert(ctx, tx, name, description); err != nil {
+		return nil, err
+	}
+
+	if err := s.roleRepo.ReplacePermissions(ctx, tx, name, uniqueStrings(permissions)); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return nil, ErrUnknownPermission
+		}
+		return nil, err
+	}
+
+	after := map[string]any{"name": name, "description": description, "permissions": uniqueStrings(permissions)}
+	if err := s.audit.Record(ctx, tx, domain.AuditEntityRole, name, domain.AuditActionRolePut, before, after); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.roleRepo.Get(ctx, name)
+}
+
+func (s *RoleService) Delete(ctx context.Context, name string) error {
+	role, err := s.roleRepo.Get(ctx, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrRoleNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if role.IsSystem {
+		return ErrRoleProtected
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := s.roleRepo.Delete(ctx, tx, name); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return ErrRoleInUse
+		}
+		return err
+	}
+
+	if err := s.audit.Record(ctx, tx, domain.AuditEntityRole, name, domain.AuditActionRoleDelete, role, nil); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func uniqueStrings(values []string) []string {
+	seen := map[string]bool{}
+	unique := []string{}
+	for _, value := range values {
+		if seen[value] {
+			continue
+		}
+		seen[value] = true
+		unique = append(unique, value)
+	}
+	return unique
+}
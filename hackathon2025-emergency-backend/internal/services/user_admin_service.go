@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/pagination"
+	"invoiceflow/internal/repositories"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrUserNotFound      = errors.New("user not found")
+	ErrUserInvalidStatus = errors.New("invalid user status")
+	ErrUserSelfAction    = errors.New("cannot apply action to own account")
+	ErrInvalidRole       = errors.New("invalid role")
+	ErrAdminsExist       = errors.New("an admin account already exists")
+)
+
+type UserAdminService struct {
+	db          *sqlx.DB
+	userRepo    *repositories.UserRepository
+	sessionRepo *repositories.SessionRepository
+	roleRepo    *repositories.RoleRepository
+	eventRepo   *repositories.AuthEventRepository
+	orgs        *OrganizationService
+	audit       *AuditService
+}
+
+func NewUserAdminService(db *sqlx.DB, userRepo *repositories.UserRepository, sessionRepo *repositories.SessionRepository, roleRepo *repositories.RoleRepository, eventRepo *repositories.AuthEventRepository, orgs *OrganizationService, audit *AuditService) *UserAdminService {
+	return &UserAdminService{db: db, userRepo: userRepo, sessionRepo: sessionRepo, roleRepo: roleRepo, eventRepo: eventRepo, orgs: orgs, audit: audit}
+}
+
+type UserDetail struct {
+	User    *domain.User             `json:"user"`
+	Actions []domain.UserAdminAction `json:"actions"`
+}
+
+func (s *UserAdminService) List(ctx context.Context, filters repositories.UserFilters) ([]domain.User, pagination.Meta, error) {
+	return s.userRepo.List(ctx, filters)
+}
+
+func (s *UserAdminService) Get(ctx context.Context, id string) (*UserDetail, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	actions, err := s.userRepo.ListAdminActions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserDetail{User: user, Actions: actions}, nil
+}
+
+func (s *UserAdminService) Suspend(ctx context.Context, actorID string, userID string, reason string) (*domain.User, error) {
+	if actorID == userID {
+		return nil, ErrUserSelfAction
+	}
+
+	return s.withUser(ctx, userID, func(tx *sqlx.Tx, user *domain.User) (*domain.User, error) {
+		if user.Status == domain.UserStatusSuspended {
+			return nil, ErrUserInvalidStatus
+		}
+
+		updated, err := s.userRepo.UpdateStatus(ctx, tx, userID, domain.UserStatusSuspended)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := revokeUserSessions(ctx, tx, s.userRepo, s.sessionRepo, userID); err != nil {
+			return nil, err
+		}
+
+		return updated, s.recordAction(ctx, tx, user, updated, actorID, domain.UserActionSuspend, user.Status, domain.UserStatusSuspended, reason)
+	})
+}
+
+// Reactivate restores the status the user had before the last suspension, so
+// a user suspended mid-onboarding goes back to PENDING rather than skipping KYC.
+func (s *UserAdminService) Reactivate(ctx context.Context, actorID string, userID string, reason string) (*domain.User, error) {
+	return s.withUser(ctx, userID, func(tx *sqlx.Tx, user *domain.User) (*domain.User, error) {
+		if user.Status != domain.UserStatusSuspended {
+			return nil, ErrUserInvalidStatus
+		}
+
+		restored := domain.UserStatusActive
+		last, err := s.userRepo.LastAdminAction(ctx, tx, userID, domain.UserActionSuspend)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		if last != nil && last.FromValue != nil && *last.FromValue != domain.UserStatusSuspended {
+			restored = *last.FromValue
+		}
+
+		updated, err := s.userRepo.UpdateStatus(ctx, tx, userID, restored)
+		if err != nil {
+			return nil, err
+		}
+
+		return updated, s.recordAction(ctx, tx, user, updated, actorID, domain.UserActionReactivate, user.Status, restored, reason)
+	})
+}
+
+// Unlock clears the failed-login lockout on the user's email ahead of its
+// expiry.
+func (s *UserAdminService) Unlock(ctx context.Context, actorID string, userID string, reason string) (*domain.User, error) {
+	return s.withUser(ctx, userID, func(tx *sqlx.Tx, user *domain.User) (*domain.User, error) {
+		if err := s.eventRepo.ClearThrottleTx(ctx, tx, domain.ThrottleScopeEmail, normalizeEmail(user.Email)); err != nil {
+			return nil, err
+		}
+
+		if err := s.eventRepo.RecordTx(ctx, tx, &domain.AuthEvent{
+			UserID: &user.ID,
+			Email:  nullableString(normalizeEmail(user.Email)),
+			Event:  domain.AuthEventUnlock,
+			Reason: nullableString(reason),
+		}); err != nil {
+			return nil, err
+		}
+
+		return user, s.recordAction(ctx, tx, user, user, actorID, domain.UserActionUnlock, "", "", reason)
+	})
+}
+
+func (s *UserAdminService) ListAuthEvents(ctx context.Context, filters repositories.AuthEventFilters) ([]domain.AuthEvent, pagination.Meta, error) {
+	return s.eventRepo.List(ctx, filters)
+}
+
+func (s *UserAdminService) ChangeRole(ctx context.Context, actorID string, userID string, role string, reason string) (*domain.User, error) {
+	exists, err := s.roleRepo.Exists(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrInvalidRole
+	}
+	if actorID == userID {
+		return nil, ErrUserSelfAction
+	}
+
+	return s.withUser(ctx, userID, func(tx *sqlx.Tx, user *domain.User) (*domain.User, error) {
+		if user.Role == role {
+			return user, nil
+		}
+
+		updated, err := s.userRepo.UpdateRole(ctx, tx, userID, role)
+		if err != nil {
+			return nil, err
+		}
+
+		// The role and its permissions are embedded in issued access tokens.
+		if err := revokeUserSessions(ctx, tx, s.userRepo, s.sessionRepo, userID); err != nil {
+			return nil, err
+		}
+
+		return updated, s.recordAction(ctx, tx, user, updated, actorID, domain.UserActionRoleChange, user.Role, role, reason)
+	})
+}
+
+func (s *UserAdminService) CreateAdmin(ctx context.Context, actorID string, name string, email string, password string) (*domain.User, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	created, err := s.createAdmin(ctx, tx, actorID, name, email, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// BootstrapAdmin creates the first admin account. It refuses once any admin
+// exists so the CLI cannot be used as a back door later on. The check and the
+// insert share a transaction under a lock, so concurrent runs create one.
+func (s *UserAdminService) BootstrapAdmin(ctx context.Context, name string, email string, password string) (*domain.User, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	count, err := s.userRepo.CountByRoleLocked(ctx, tx, domain.RoleAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, ErrAdminsExist
+	}
+
+	created, err := s.createAdmin(ctx, tx, "", name, email, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (s *UserAdminService) createAdmin(ctx context.Context, tx *sqlx.Tx, actorID string, name string, email string, password string) (*domain.User, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.userRepo.CreateTx(ctx, tx, &domain.User{
+		Role:         domain.RoleAdmin,
+		Name:         name,
+		Email:        email,
+		PasswordHash: string(hashed),
+		Status:       domain.UserStatusActive,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrEmailExists
+		}
+		return nil, err
+	}
+
+	// Admins get a personal organization like registered users, so
+	// endpoints that default to it work for them too.
+	if _, err := s.orgs.CreatePersonal(ctx, tx, created); err != nil {
+		return nil, err
+	}
+
+	if err := s.recordAction(ctx, tx, nil, created, actorID, domain.UserActionCreateAdmin, "", domain.RoleAdmin, ""); err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (s *UserAdminService) withUser(ctx context.Context, userID string, fn func(tx *sqlx.Tx, user *domain.User) (*domain.User, error)) (*domain.User, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	user, err := s.userRepo.GetByIDForUpdate(ctx, tx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := fn(tx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// recordAction writes the user's admin history row and the audit event for
+// the change from before to after. It must be the last write in tx.
+func (s *UserAdminService) recordAction(ctx context.Context, tx *sqlx.Tx, before *domain.User, after *domain.User, actorID string, action string, from string, to string, reason string) error {
+	if err := s.userRepo.RecordAdminAction(ctx, tx, &domain.UserAdminAction{
+		UserID:    after.ID,
+		ActorID:   nullableString(actorID),
+		Action:    action,
+		FromValue: nullableString(from),
+		ToValue:   nullableString(to),
+		Reason:    nullableString(reason),
+	}); err != nil {
+		return err
+	}
+
+	return s.audit.Record(ctx, tx, domain.AuditEntityUser, after.ID, "user."+strings.ToLower(action), before, after)
+}
@@ -0,0 +1,417 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"invoiceflow/internal/config"
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/pagination"
+	"invoiceflow/internal/repositories"
+	"invoiceflow/internal/webhook"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook subscription not found")
+	ErrWebhookInvalidURL       = errors.New("invalid webhook url")
+	ErrWebhookInvalidEvent     = errors.New("unknown webhook event type")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+const (
+	webhookPollInterval = 5 * time.Second
+	webhookBatchSize    = 20
+	webhookBaseBackoff  = 30 * time.Second
+	webhookMaxBackoff   = 6 * time.Hour
+	webhookSecretPrefix = "whsec_"
+)
+
+type WebhookService struct {
+	cfg    *config.Config
+	db     *sqlx.DB
+	repo   *repositories.WebhookRepository
+	client *http.Client
+}
+
+func NewWebhookService(cfg *config.Config, db *sqlx.DB, repo *repositories.WebhookRepository) *WebhookService {
+	return &WebhookService{
+		cfg:    cfg,
+		db:     db,
+		repo:   repo,
+		client: webhook.NewClient(cfg.WebhookTimeout, cfg.AppEnv == "dev"),
+	}
+}
+
+// WebhookSubscriptionInput carries the fields of a create or update; nil
+// fields are left unchanged on update.
+type WebhookSubscriptionInput struct {
+	URL         *string
+	Description *string
+	EventTypes  []string
+	Active      *bool
+}
+
+// CreatedWebhookSubscription is returned once, on creation or secret
+// rotation; the secret is not shown again.
+type CreatedWebhookSubscription struct {
+	*domain.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+// CreateSubscription and the other subscription methods are scoped to an
+// organization, or to the platform when organizationID is nil. Callers check
+// the user may manage that scope.
+func (s *WebhookService) CreateSubscription(ctx context.Context, organizationID *string, createdBy string, input WebhookSubscriptionInput) (*CreatedWebhookSubscription, error) {
+	if input.URL == nil {
+		return nil, ErrWebhookInvalidURL
+	}
+	if err := s.validateURL(*input.URL); err != nil {
+		return nil, err
+	}
+	if err := validateWebhookEvents(input.EventTypes); err != nil {
+		return nil, err
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &domain.WebhookSubscription{
+		OrganizationID: organizationID,
+		URL:            *input.URL,
+		EventTypes:     uniqueStrings(input.EventTypes),
+		Secret:         secret,
+		Active:         true,
+		CreatedBy:      nullableString(createdBy),
+	}
+	if input.Description != nil {
+		sub.Description = *input.Description
+	}
+	if input.Active != nil {
+		sub.Active = *input.Active
+	}
+
+	created, err := s.repo.CreateSubscription(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CreatedWebhookSubscription{WebhookSubscription: created, Secret: secret}, nil
+}
+
+func (s *WebhookService) ListSubscriptions(ctx context.Context, organizationID *string) ([]domain.WebhookSubscription, error) {
+	return s.repo.ListSubscriptions(ctx, organizationID)
+}
+
+func (s *WebhookService) GetSubscription(ctx context.Context, organizationID *string, id string) (*domain.WebhookSubscription, error) {
+	sub, err := s.repo.GetSubscription(ctx, organizationID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWebhookNotFound
+	}
+	return sub, err
+}
+
+func (s *WebhookService) UpdateSubscription(ctx context.Context, organizationID *string, id string, input WebhookSubscriptionInput) (*domain.WebhookSubscription, error) {
+	sub, err := s.GetSubscription(ctx, organizationID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.URL != nil {
+		if err := s.validateURL(*input.URL); err != nil {
+			return nil, err
+		}
+		sub.URL = *input.URL
+	}
+	if input.EventTypes != nil {
+		if err := validateWebhookEvents(input.EventTypes); err != nil {
+			return nil, err
+		}
+		sub.EventTypes = uniqueStrings(input.EventTypes)
+	}
+	if input.Description != nil {
+		sub.Description = *input.Description
+	}
+	if input.Active != nil {
+		sub.Active = *input.Active
+	}
+
+	return s.repo.UpdateSubscription(ctx, sub)
+}
+
+// RotateSecret replaces the signing secret. Deliveries still queued are
+// signed with the new one.
+func (s *WebhookService) RotateSecret(ctx context.Context, organizationID *string, id string) (*CreatedWebhookSubscription, error) {
+	sub, err := s.GetSubscription(ctx, organizationID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	sub.Secret = secret
+
+	updated, err := s.repo.UpdateSubscription(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CreatedWebhookSubscription{WebhookSubscription: updated, Secret: secret}, nil
+}
+
+func (s *WebhookService) DeleteSubscription(ctx context.Context, organizationID *string, id string) error {
+	deleted, err := s.repo.DeleteSubscription(ctx, organizationID, id)
+	if err != nil {
+		return err
+	}
+	if deleted == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, organizationID *string, subscriptionID string, page pagination.Page) ([]domain.WebhookDelivery, pagination.Meta, error) {
+	if _, err := s.GetSubscription(ctx, organizationID, subscriptionID); err != nil {
+		return nil, pagination.Meta{}, err
+	}
+
+	return s.repo.ListDeliveries(ctx, subscriptionID, page)
+}
+
+// Replay queues a fresh delivery of a logged one with the same event id and
+// payload, so receivers can deduplicate on the event id.
+func (s *WebhookService) Replay(ctx context.Context, organizationID *string, subscriptionID string, deliveryID string) (*domain.WebhookDelivery, error) {
+	if _, err := s.GetSubscription(ctx, organizationID, subscriptionID); err != nil {
+		return nil, err
+	}
+
+	original, err := s.repo.GetDelivery(ctx, subscriptionID, deliveryID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	replay, err := s.repo.CreateDelivery(ctx, tx, &domain.WebhookDelivery{
+		SubscriptionID: subscriptionID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		ReplayOf:       &original.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return replay, nil
+}
+
+// HandleEvent is the event bus subscriber that queues one delivery per
+// subscription matching the event: those of the organizations it concerns
+// plus every platform subscription. The delivery carries the event id so
+// receivers can deduplicate.
+func (s *WebhookService) HandleEvent(ctx context.Context, tx *sqlx.Tx, event *domain.Event) error {
+	subs, err := s.repo.MatchSubscriptions(ctx, tx, event.Type, event.OrganizationIDs)
+	if err != nil || len(subs) == 0 {
+		return err
+	}
+
+	payload, err := json.Marshal(map[string]any{
+		"id":         event.ID,
+		"type":       event.Type,
+		"created_at": event.OccurredAt.UTC().Format(time.RFC3339),
+		"data":       event.Payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subs {
+		if _, err := s.repo.CreateDelivery(ctx, tx, &domain.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        event.ID,
+			EventType:      event.Type,
+			Payload:        payload,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Start runs the delivery worker until ctx is cancelled. Several API
+// instances may run it at once; claims use SKIP LOCKED.
+func (s *WebhookService) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(webhookPollInterval)
+		defer ticker.Stop()
+		for {
+			s.dispatchDue(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (s *WebhookService) dispatchDue(ctx context.Context) {
+	for {
+		// The lease outlasts the request timeout so a slow receiver is not
+		// sent the same delivery twice concurrently.
+		dispatches, err := s.repo.ClaimDue(ctx, webhookBatchSize, s.cfg.WebhookTimeout+time.Minute)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("webhook claim failed: %v", err)
+			}
+			return
+		}
+
+		for i := range dispatches {
+			s.deliver(ctx, &dispatches[i])
+		}
+
+		if len(dispatches) < webhookBatchSize {
+			return
+		}
+	}
+}
+
+func (s *WebhookService) deliver(ctx context.Context, dispatch *domain.WebhookDispatch) {
+	statusCode, err := s.send(ctx, dispatch)
+	if err == nil {
+		if err := s.repo.MarkSucceeded(ctx, dispatch.ID, statusCode); err != nil {
+			log.Printf("webhook delivery %s: mark succeeded failed: %v", dispatch.ID, err)
+		}
+		return
+	}
+
+	var code *int
+	if statusCode != 0 {
+		code = &statusCode
+	}
+
+	var retryAt *time.Time
+	if dispatch.Attempts < s.cfg.WebhookMaxAttempts {
+		next := time.Now().Add(webhookBackoff(dispatch.Attempts))
+		retryAt = &next
+	}
+
+	if err := s.repo.MarkAttemptFailed(ctx, dispatch.ID, code, err.Error(), retryAt); err != nil {
+		log.Printf("webhook delivery %s: mark failed failed: %v", dispatch.ID, err)
+	}
+}
+
+func (s *WebhookService) send(ctx context.Context, dispatch *domain.WebhookDispatch) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, dispatch.URL, bytes.NewReader(dispatch.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "InvoiceFlow-Webhooks/1")
+	req.Header.Set(webhook.HeaderEvent, dispatch.EventType)
+	req.Header.Set(webhook.HeaderDelivery, dispatch.ID)
+	req.Header.Set(webhook.HeaderSignature, webhook.Sign(dispatch.Secret, time.Now(), dispatch.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// webhookBackoff doubles from 30s after each failed attempt, capped at 6h.
+func webhookBackoff(attempts int) time.Duration {
+	delay := webhookBaseBackoff
+	for i := 1; i < attempts && delay < webhookMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > webhookMaxBackoff {
+		delay = webhookMaxBackoff
+	}
+	return delay
+}
+
+// validateURL requires an absolute https URL; plain http and internal hosts
+// are allowed in dev so a local receiver can be used. Hostnames are resolved
+// and checked again on every delivery, see webhook.NewClient.
+func (s *WebhookService) validateURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Hostname() == "" {
+		return ErrWebhookInvalidURL
+	}
+	if s.cfg.AppEnv == "dev" {
+		if parsed.Scheme != "https" && parsed.Scheme != "http" {
+			return ErrWebhookInvalidURL
+		}
+		return nil
+	}
+	if parsed.Scheme != "https" {
+		return ErrWebhookInvalidURL
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrWebhookInvalidURL
+	}
+	if ip := net.ParseIP(host); ip != nil && !webhook.PublicAddress(ip) {
+		return ErrWebhookInvalidURL
+	}
+	return nil
+}
+
+func validateWebhookEvents(eventTypes []string) error {
+	if len(eventTypes) == 0 {
+		return ErrWebhookInvalidEvent
+	}
+	for _, eventType := range eventTypes {
+		if !domain.ValidWebhookEventType(eventType) {
+			return ErrWebhookInvalidEvent
+		}
+	}
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	return webhookSecretPrefix + token, nil
+}
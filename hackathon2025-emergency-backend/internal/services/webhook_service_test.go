@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"invoiceflow/internal/config"
+	"invoiceflow/internal/domain"
+	"invoiceflow/internal/webhook"
+)
+
+func TestWebhookBackoff(t *testing.T) {
+	cases := map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		5:  8 * time.Minute,
+		10: 256 * time.Minute,
+		11: webhookMaxBackoff,
+		50: webhookMaxBackoff,
+	}
+
+	for attempts, want := range cases {
+		if got := webhookBackoff(attempts); got != want {
+			t.Errorf("webhookBackoff(%d) = %s, want %s", attempts, got, want)
+		}
+	}
+}
+
+// A local receiver checks what a delivery carries.
+func TestWebhookSendSignsForReceiver(t *testing.T) {
+	payload := []byte(`{"id":"evt_1","type":"invoice.approved"}`)
+	var verifyErr error
+	var event, delivery string
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verifyErr = webhook.Verify("whsec_test", r.Header.Get(webhook.HeaderSignature), body, time.Minute, time.Now())
+		event, delivery = r.Header.Get(webhook.HeaderEvent), r.Header.Get(webhook.HeaderDelivery)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer receiver.Close()
+
+	service := &WebhookService{client: webhook.NewClient(time.Second, true)}
+	dispatch := &domain.WebhookDispatch{
+		WebhookDelivery: domain.WebhookDelivery{ID: "dlv_1", EventType: "invoice.approved", Payload: payload},
+		URL:             receiver.URL,
+		Secret:          "whsec_test",
+	}
+
+	status, err := service.send(context.Background(), dispatch)
+	if err != nil || status != http.StatusAccepted {
+		t.Fatalf("send = %d, %v", status, err)
+	}
+	if verifyErr != nil {
+		t.Fatalf("receiver rejected signature: %v", verifyErr)
+	}
+	if event != "invoice.approved" || delivery != "dlv_1" {
+		t.Fatalf("headers = %q, %q", event, delivery)
+	}
+}
+
+func TestWebhookSendFailsOnNon2xx(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data", http.StatusFound)
+	}))
+	defer receiver.Close()
+
+	service := &WebhookService{client: webhook.NewClient(time.Second, true)}
+	dispatch := &domain.WebhookDispatch{URL: receiver.URL, Secret: "whsec_test"}
+
+	status, err := service.send(context.Background(), dispatch)
+	if err == nil || status != http.StatusFound {
+		t.Fatalf("send = %d, %v; want a failed 302", status, err)
+	}
+}
+
+func TestWebhookSendRefusesInternalTargetsOutsideDev(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer receiver.Close()
+
+	service := &WebhookService{client: webhook.NewClient(time.Second, false)}
+	_, err := service.send(context.Background(), &domain.WebhookDispatch{URL: receiver.URL})
+	if !errors.Is(err, webhook.ErrForbiddenAddress) {
+		t.Fatalf("err = %v, want ErrForbiddenAddress", err)
+	}
+}
+
+func TestWebhookValidateURL(t *testing.T) {
+	production := &WebhookService{cfg: &config.Config{AppEnv: "production"}}
+	dev := &WebhookService{cfg: &config.Config{AppEnv: "dev"}}
+
+	cases := []struct {
+		url  string
+		prod bool
+		dev  bool
+	}{
+		{"https://erp.example.com/hooks", true, true},
+		{"http://erp.example.com/hooks", false, true},
+		{"https://localhost/hooks", false, true},
+		{"https://127.0.0.1:8443/hooks", false, true},
+		{"https://169.254.169.254/latest/meta-data", false, true},
+		{"https://10.0.0.5/hooks", false, true},
+		{"https://[::1]/hooks", false, true},
+		{"ftp://erp.example.com/hooks", false, false},
+		{"/relative", false, false},
+	}
+
+	for _, tc := range cases {
+		if got := production.validateURL(tc.url) == nil; got != tc.prod {
+			t.Errorf("production %s: valid = %v, want %v", tc.url, got, tc.prod)
+		}
+		if got := dev.validateURL(tc.url) == nil; got != tc.dev {
+			t.Errorf("dev %s: valid = %v, want %v", tc.url, got, tc.dev)
+		}
+	}
+}
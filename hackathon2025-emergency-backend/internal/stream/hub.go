@@ -0,0 +1,193 @@
+// Package stream fans invoice updates out to Server-Sent Events clients. The
+// event relay publishes each update with pg_notify, and every API instance
+// LISTENs and hands it to the clients connected to it.
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"invoiceflow/internal/domain"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	notifyChannel = "invoiceflow_stream"
+	// Postgres rejects NOTIFY payloads of 8000 bytes or more.
+	maxNotifyPayload    = 7900
+	subscriptionBuffer  = 64
+	listenRetryInterval = 5 * time.Second
+)
+
+// Update is what clients receive for an invoice event.
+type Update struct {
+	EventID       string    `json:"event_id"`
+	Type          string    `json:"type"`
+	InvoiceID     string    `json:"invoice_id"`
+	Status        string    `json:"status"`
+	FundedAmount  float64   `json:"funded_amount"`
+	FundingTarget float64   `json:"funding_target"`
+	PaidAmount    float64   `json:"paid_amount"`
+	Currency      string    `json:"currency"`
+	OnchainStatus *string   `json:"onchain_status,omitempty"`
+	OccurredAt    time.Time `json:"occurred_at"`
+}
+
+// Envelope is an update plus who may see it; it travels through NOTIFY and
+// is never sent to clients as is.
+type Envelope struct {
+	Update         Update   `json:"update"`
+	OrganizationID string   `json:"organization_id"`
+	Audience       []string `json:"audience"`
+}
+
+type Subscription struct {
+	C chan *Envelope
+}
+
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subscribers: make(map[*Subscription]struct{})}
+}
+
+func (h *Hub) Subscribe() *Subscription {
+	sub := &Subscription{C: make(chan *Envelope, subscriptionBuffer)}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subscribers[sub] = struct{}{}
+	return sub
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subscribers[sub]; ok {
+		delete(h.subscribers, sub)
+		close(sub.C)
+	}
+}
+
+// broadcast never blocks: a client too slow to keep up is disconnected, and
+// reconnects to a fresh view instead of silently missing updates.
+func (h *Hub) broadcast(envelope *Envelope) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subscribers {
+		select {
+		case sub.C <- envelope:
+		default:
+			delete(h.subscribers, sub)
+			close(sub.C)
+		}
+	}
+}
+
+// eventPayload is the part of an event payload the stream reports.
+type eventPayload struct {
+	Invoice *domain.Invoice `json:"invoice"`
+	Onchain *struct {
+		Status string `json:"status"`
+	} `json:"onchain"`
+}
+
+// HandleEvent is the event bus subscriber that publishes invoice events with
+// pg_notify. NOTIFY is transactional, so listeners only hear about events
+// whose relay transaction committed.
+func (h *Hub) HandleEvent(ctx context.Context, tx *sqlx.Tx, event *domain.Event) error {
+	var payload eventPayload
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return err
+	}
+	if payload.Invoice == nil {
+		return nil
+	}
+
+	invoice := payload.Invoice
+	envelope := &Envelope{
+		Update: Update{
+			EventID:       event.ID,
+			Type:          event.Type,
+			InvoiceID:     invoice.ID,
+			Status:        invoice.Status,
+			FundedAmount:  invoice.FundedAmount,
+			FundingTarget: invoice.FundingTarget,
+			PaidAmount:    invoice.PaidAmount,
+			Currency:      invoice.Currency,
+			OccurredAt:    event.OccurredAt,
+		},
+		OrganizationID: invoice.OrganizationID,
+		Audience:       event.OrganizationIDs,
+	}
+	if payload.Onchain != nil {
+		envelope.Update.OnchainStatus = &payload.Onchain.Status
+	}
+
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	if len(data) > maxNotifyPayload {
+		// Too many funders to list: fall back to the owner and marketplace
+		// visibility rules.
+		envelope.Audience = nil
+		if data, err = json.Marshal(envelope); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "SELECT pg_notify($1, $2)", notifyChannel, string(data))
+	return err
+}
+
+// Start LISTENs on a dedicated connection until ctx is cancelled,
+// reconnecting after failures.
+func (h *Hub) Start(ctx context.Context, dsn string) {
+	go func() {
+		for {
+			if err := h.listen(ctx, dsn); err != nil && ctx.Err() == nil {
+				log.Printf("stream listener failed: %v", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(listenRetryInterval):
+			}
+		}
+	}()
+}
+
+func (h *Hub) listen(ctx context.Context, dsn string) error {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var envelope Envelope
+		if err := json.Unmarshal([]byte(notification.Payload), &envelope); err != nil {
+			log.Printf("stream: bad notification payload: %v", err)
+			continue
+		}
+		h.broadcast(&envelope)
+	}
+}
@@ -0,0 +1,84 @@
+// Package webhook holds the signing scheme shared by the delivery worker and
+// receivers.
+//
+// Each delivery carries
+//
+//	X-InvoiceFlow-Signature: t=<unix seconds>,v1=<hex HMAC-SHA256>
+//
+// where the HMAC is keyed with the subscription secret over "<t>.<raw body>".
+// Receivers should recompute it and reject stale timestamps to stop replays.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	HeaderSignature = "X-InvoiceFlow-Signature"
+	HeaderEvent     = "X-InvoiceFlow-Event"
+	HeaderDelivery  = "X-InvoiceFlow-Delivery"
+)
+
+var (
+	ErrMalformedSignature = errors.New("malformed signature header")
+	ErrSignatureMismatch  = errors.New("signature mismatch")
+	ErrTimestampExpired   = errors.New("signature timestamp outside tolerance")
+)
+
+// Sign returns the signature header value for body sent at ts.
+func Sign(secret string, ts time.Time, body []byte) string {
+	t := strconv.FormatInt(ts.Unix(), 10)
+	return "t=" + t + ",v1=" + mac(secret, t, body)
+}
+
+// Verify checks header against body and rejects timestamps further than
+// tolerance from now.
+func Verify(secret string, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var t string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return ErrMalformedSignature
+		}
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrMalformedSignature
+	}
+
+	age := now.Sub(time.Unix(unix, 0))
+	if age > tolerance || age < -tolerance {
+		return ErrTimestampExpired
+	}
+
+	expected := mac(secret, t, body)
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return ErrSignatureMismatch
+}
+
+func mac(secret string, t string, body []byte) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(t))
+	h.Write([]byte("."))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
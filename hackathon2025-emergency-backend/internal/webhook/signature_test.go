@@ -0,0 +1,62 @@
+package webhook
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+const testSecret = "whsec_test"
+
+func TestVerifyAcceptsOwnSignature(t *testing.T) {
+	now := time.Unix(1_760_000_000, 0)
+	body := []byte(`{"id":"evt_1"}`)
+
+	header := Sign(testSecret, now, body)
+	if err := Verify(testSecret, header, body, 5*time.Minute, now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	now := time.Unix(1_760_000_000, 0)
+	body := []byte(`{"id":"evt_1"}`)
+	header := Sign(testSecret, now, body)
+
+	cases := []struct {
+		name   string
+		secret string
+		header string
+		body   string
+		now    time.Time
+		want   error
+	}{
+		{"altered body", testSecret, header, `{"id":"evt_2"}`, now, ErrSignatureMismatch},
+		{"other secret", "whsec_other", header, string(body), now, ErrSignatureMismatch},
+		{"stale timestamp", testSecret, header, string(body), now.Add(10 * time.Minute), ErrTimestampExpired},
+		{"future timestamp", testSecret, header, string(body), now.Add(-10 * time.Minute), ErrTimestampExpired},
+		{"no signature", testSecret, "t=1760000000", string(body), now, ErrMalformedSignature},
+		{"no timestamp", testSecret, "v1=abc", string(body), now, ErrMalformedSignature},
+		{"garbage", testSecret, "nonsense", string(body), now, ErrMalformedSignature},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Verify(tc.secret, tc.header, []byte(tc.body), 5*time.Minute, tc.now)
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("err = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestVerifyAcceptsAnyListedSignature(t *testing.T) {
+	now := time.Unix(1_760_000_000, 0)
+	body := []byte(`{}`)
+
+	// During secret rotation a sender may list signatures under both secrets.
+	header := Sign(testSecret, now, body) + ",v1=" + mac("whsec_old", "1760000000", body)
+	if err := Verify("whsec_old", header, body, time.Minute, now); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE invoice_onchain ADD COLUMN chain_id bigint NOT NULL DEFAULT 0;
+ALTER TABLE invoice_onchain ALTER COLUMN chain_id DROP DEFAULT;
+
+ALTER TABLE chain_txs ADD COLUMN chain_id bigint NOT NULL DEFAULT 0;
+ALTER TABLE chain_txs ALTER COLUMN chain_id DROP DEFAULT;
+
+CREATE INDEX idx_invoice_onchain_chain ON invoice_onchain(chain_id, contract_address);
+CREATE INDEX idx_chain_txs_chain ON chain_txs(chain_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_chain_txs_chain;
+DROP INDEX IF EXISTS idx_invoice_onchain_chain;
+ALTER TABLE chain_txs DROP COLUMN IF EXISTS chain_id;
+ALTER TABLE invoice_onchain DROP COLUMN IF EXISTS chain_id;
@@ -0,0 +1,25 @@
+-- +goose Up
+ALTER TABLE chain_txs
+  ADD COLUMN invoice_id uuid REFERENCES invoices(id) ON DELETE SET NULL,
+  ADD COLUMN gas_limit bigint,
+  ADD COLUMN max_fee_wei numeric(78,0),
+  ADD COLUMN gas_used bigint,
+  ADD COLUMN effective_gas_price numeric(78,0),
+  ADD COLUMN fee_wei numeric(78,0);
+
+UPDATE chain_txs t
+SET invoice_id = o.invoice_id
+FROM invoice_onchain o
+WHERE o.mint_tx_hash = t.tx_hash;
+
+CREATE INDEX idx_chain_txs_invoice ON chain_txs(invoice_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_chain_txs_invoice;
+ALTER TABLE chain_txs
+  DROP COLUMN IF EXISTS fee_wei,
+  DROP COLUMN IF EXISTS effective_gas_price,
+  DROP COLUMN IF EXISTS gas_used,
+  DROP COLUMN IF EXISTS max_fee_wei,
+  DROP COLUMN IF EXISTS gas_limit,
+  DROP COLUMN IF EXISTS invoice_id;
@@ -0,0 +1,22 @@
+-- +goose Up
+ALTER TABLE users ADD COLUMN token_version integer NOT NULL DEFAULT 0;
+
+CREATE TABLE sessions (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  refresh_token_hash text NOT NULL UNIQUE,
+  previous_token_hash text,
+  user_agent text,
+  ip text,
+  expires_at timestamptz NOT NULL,
+  last_used_at timestamptz,
+  revoked_at timestamptz,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_sessions_user ON sessions(user_id);
+CREATE INDEX idx_sessions_previous_token ON sessions(previous_token_hash);
+
+-- +goose Down
+DROP TABLE IF EXISTS sessions;
+ALTER TABLE users DROP COLUMN IF EXISTS token_version;
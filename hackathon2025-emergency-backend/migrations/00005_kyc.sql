@@ -0,0 +1,20 @@
+-- +goose Up
+CREATE TABLE kyc_submissions (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  kind text NOT NULL,
+  status text NOT NULL DEFAULT 'SUBMITTED',
+  details jsonb NOT NULL DEFAULT '{}'::jsonb,
+  documents jsonb NOT NULL DEFAULT '[]'::jsonb,
+  reviewer_id uuid REFERENCES users(id),
+  review_note text,
+  submitted_at timestamptz NOT NULL DEFAULT now(),
+  reviewed_at timestamptz
+);
+
+CREATE INDEX idx_kyc_submissions_user ON kyc_submissions(user_id, submitted_at DESC);
+CREATE INDEX idx_kyc_submissions_queue ON kyc_submissions(status, kind, submitted_at);
+CREATE UNIQUE INDEX idx_kyc_submissions_open ON kyc_submissions(user_id) WHERE status = 'SUBMITTED';
+
+-- +goose Down
+DROP TABLE IF EXISTS kyc_submissions;
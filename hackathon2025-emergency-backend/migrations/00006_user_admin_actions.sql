@@ -0,0 +1,18 @@
+-- +goose Up
+CREATE TABLE user_admin_actions (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  actor_id uuid REFERENCES users(id),
+  action text NOT NULL,
+  from_value text,
+  to_value text,
+  reason text,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_user_admin_actions_user ON user_admin_actions(user_id, created_at DESC);
+CREATE INDEX idx_users_role_status ON users(role, status);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_users_role_status;
+DROP TABLE IF EXISTS user_admin_actions;
@@ -0,0 +1,80 @@
+-- +goose Up
+CREATE TABLE roles (
+  name text PRIMARY KEY,
+  description text NOT NULL DEFAULT '',
+  is_system boolean NOT NULL DEFAULT false,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE permissions (
+  name text PRIMARY KEY,
+  description text NOT NULL DEFAULT ''
+);
+
+CREATE TABLE role_permissions (
+  role text NOT NULL REFERENCES roles(name) ON DELETE CASCADE ON UPDATE CASCADE,
+  permission text NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+  PRIMARY KEY (role, permission)
+);
+
+INSERT INTO roles (name, description, is_system) VALUES
+  ('admin', 'Platform administrator', true),
+  ('investor', 'Funds approved invoices', true),
+  ('sme', 'Issues invoices for financing', true),
+  ('auditor', 'Read-only access to metrics, users and verification records', true),
+  ('risk_officer', 'Reviews invoices and verifications', true),
+  ('ops', 'Operates on-chain tokenization', true);
+
+INSERT INTO permissions (name, description) VALUES
+  ('invoice.create', 'Create draft invoices'),
+  ('invoice.submit', 'Submit own invoices for review'),
+  ('invoice.approve', 'Approve submitted invoices'),
+  ('invoice.mark_paid', 'Record invoice repayments'),
+  ('funding.create', 'Fund approved invoices'),
+  ('funding.read_own', 'List own fundings'),
+  ('chain.read', 'Read on-chain records and chain profiles'),
+  ('chain.tokenize', 'Mint invoice tokens'),
+  ('chain.refresh', 'Refresh on-chain transaction status'),
+  ('metrics.read', 'Read dashboard metrics and chain costs'),
+  ('kyc.submit', 'Submit own KYC/KYB documents'),
+  ('kyc.read', 'Read the verification queue'),
+  ('kyc.review', 'Approve or reject verifications'),
+  ('users.read', 'List and inspect users'),
+  ('users.manage', 'Create admins, suspend, reactivate and change roles'),
+  ('roles.manage', 'Define roles and their permissions');
+
+INSERT INTO role_permissions (role, permission)
+SELECT 'admin', name FROM permissions;
+
+INSERT INTO role_permissions (role, permission) VALUES
+  ('investor', 'funding.create'),
+  ('investor', 'funding.read_own'),
+  ('investor', 'kyc.submit'),
+  ('sme', 'invoice.create'),
+  ('sme', 'invoice.submit'),
+  ('sme', 'chain.read'),
+  ('sme', 'chain.tokenize'),
+  ('sme', 'chain.refresh'),
+  ('sme', 'kyc.submit'),
+  ('auditor', 'metrics.read'),
+  ('auditor', 'chain.read'),
+  ('auditor', 'kyc.read'),
+  ('auditor', 'users.read'),
+  ('risk_officer', 'invoice.approve'),
+  ('risk_officer', 'metrics.read'),
+  ('risk_officer', 'chain.read'),
+  ('risk_officer', 'kyc.read'),
+  ('risk_officer', 'kyc.review'),
+  ('ops', 'chain.read'),
+  ('ops', 'chain.tokenize'),
+  ('ops', 'chain.refresh'),
+  ('ops', 'metrics.read');
+
+ALTER TABLE users ADD CONSTRAINT fk_users_role FOREIGN KEY (role) REFERENCES roles(name) ON UPDATE CASCADE;
+
+-- +goose Down
+ALTER TABLE users DROP CONSTRAINT IF EXISTS fk_users_role;
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE user_mfa (
+  user_id uuid PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+  secret_encrypted bytea NOT NULL,
+  enabled_at timestamptz,
+  last_used_step bigint NOT NULL DEFAULT 0,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE mfa_recovery_codes (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  code_hash text NOT NULL,
+  used_at timestamptz,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  UNIQUE (user_id, code_hash)
+);
+
+ALTER TABLE sessions ADD COLUMN mfa_verified boolean NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE sessions DROP COLUMN IF EXISTS mfa_verified;
+DROP TABLE IF EXISTS mfa_recovery_codes;
+DROP TABLE IF EXISTS user_mfa;
@@ -0,0 +1,29 @@
+-- +goose Up
+CREATE TABLE auth_events (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id uuid REFERENCES users(id) ON DELETE SET NULL,
+  email text,
+  ip text,
+  user_agent text,
+  event text NOT NULL,
+  reason text,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_auth_events_created ON auth_events(created_at DESC);
+CREATE INDEX idx_auth_events_email ON auth_events(email, created_at DESC);
+CREATE INDEX idx_auth_events_ip ON auth_events(ip, created_at DESC);
+
+CREATE TABLE login_throttles (
+  scope text NOT NULL,
+  key text NOT NULL,
+  failures integer NOT NULL DEFAULT 0,
+  first_failed_at timestamptz NOT NULL DEFAULT now(),
+  last_failed_at timestamptz NOT NULL DEFAULT now(),
+  locked_until timestamptz,
+  PRIMARY KEY (scope, key)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS login_throttles;
+DROP TABLE IF EXISTS auth_events;
@@ -0,0 +1,71 @@
+-- +goose Up
+CREATE TABLE organizations (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  name text NOT NULL,
+  kind text NOT NULL,
+  personal boolean NOT NULL DEFAULT false,
+  created_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX uq_organizations_personal ON organizations(created_by) WHERE personal;
+
+CREATE TABLE organization_members (
+  organization_id uuid NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  role text NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX idx_organization_members_user ON organization_members(user_id);
+
+CREATE TABLE organization_invitations (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  organization_id uuid NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  email text NOT NULL,
+  role text NOT NULL,
+  token_hash text NOT NULL UNIQUE,
+  invited_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  expires_at timestamptz NOT NULL,
+  accepted_at timestamptz,
+  revoked_at timestamptz,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_organization_invitations_org ON organization_invitations(organization_id);
+
+-- Every existing user becomes the owner of a personal organization that takes
+-- over their invoices and fundings.
+INSERT INTO organizations (name, kind, personal, created_by)
+SELECT name, role, true, id FROM users;
+
+INSERT INTO organization_members (organization_id, user_id, role)
+SELECT id, created_by, 'owner' FROM organizations WHERE personal;
+
+ALTER TABLE invoices ADD COLUMN organization_id uuid REFERENCES organizations(id);
+ALTER TABLE fundings ADD COLUMN organization_id uuid REFERENCES organizations(id);
+
+UPDATE invoices i SET organization_id = o.id
+FROM organizations o
+WHERE o.personal AND o.created_by = i.issuer_id;
+
+UPDATE fundings f SET organization_id = o.id
+FROM organizations o
+WHERE o.personal AND o.created_by = f.investor_id;
+
+ALTER TABLE invoices ALTER COLUMN organization_id SET NOT NULL;
+ALTER TABLE fundings ALTER COLUMN organization_id SET NOT NULL;
+
+CREATE INDEX idx_invoices_organization ON invoices(organization_id);
+CREATE INDEX idx_fundings_organization ON fundings(organization_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_fundings_organization;
+DROP INDEX IF EXISTS idx_invoices_organization;
+ALTER TABLE fundings DROP COLUMN IF EXISTS organization_id;
+ALTER TABLE invoices DROP COLUMN IF EXISTS organization_id;
+DROP TABLE IF EXISTS organization_invitations;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,55 @@
+-- +goose Up
+-- before/after are json rather than jsonb so the stored text is exactly what
+-- was hashed; jsonb would reorder keys and break verification.
+CREATE TABLE audit_events (
+  id bigserial PRIMARY KEY,
+  occurred_at timestamptz NOT NULL,
+  actor_id uuid,
+  actor_role text,
+  ip text,
+  request_id text,
+  entity_type text NOT NULL,
+  entity_id text NOT NULL,
+  action text NOT NULL,
+  before json,
+  after json,
+  prev_hash text NOT NULL,
+  hash text NOT NULL UNIQUE
+);
+
+CREATE INDEX idx_audit_events_entity ON audit_events(entity_type, entity_id, id DESC);
+CREATE INDEX idx_audit_events_actor ON audit_events(actor_id, id DESC);
+CREATE INDEX idx_audit_events_action ON audit_events(action, id DESC);
+CREATE INDEX idx_audit_events_request ON audit_events(request_id);
+
+-- +goose StatementBegin
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER trg_audit_events_append_only
+BEFORE UPDATE OR DELETE ON audit_events
+FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+
+CREATE TRIGGER trg_audit_events_no_truncate
+BEFORE TRUNCATE ON audit_events
+FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only();
+
+ALTER TABLE invoices ADD COLUMN paid_amount numeric(18,2) NOT NULL DEFAULT 0;
+
+INSERT INTO permissions (name, description) VALUES
+  ('audit.read', 'Read and verify the audit log');
+
+INSERT INTO role_permissions (role, permission) VALUES
+  ('admin', 'audit.read'),
+  ('auditor', 'audit.read');
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission = 'audit.read';
+DELETE FROM permissions WHERE name = 'audit.read';
+ALTER TABLE invoices DROP COLUMN IF EXISTS paid_amount;
+DROP TABLE IF EXISTS audit_events;
+DROP FUNCTION IF EXISTS audit_events_append_only();
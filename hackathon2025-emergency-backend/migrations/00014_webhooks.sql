@@ -0,0 +1,49 @@
+-- +goose Up
+-- organization_id NULL marks a platform subscription that receives events for
+-- every organization; those are managed by admins.
+CREATE TABLE webhook_subscriptions (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  organization_id uuid REFERENCES organizations(id) ON DELETE CASCADE,
+  url text NOT NULL,
+  description text NOT NULL DEFAULT '',
+  event_types jsonb NOT NULL DEFAULT '[]'::jsonb,
+  secret text NOT NULL,
+  active boolean NOT NULL DEFAULT true,
+  created_by uuid REFERENCES users(id) ON DELETE SET NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_webhook_subscriptions_org ON webhook_subscriptions(organization_id);
+
+-- payload is json, not jsonb, so the signed bytes are stored verbatim.
+CREATE TABLE webhook_deliveries (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  subscription_id uuid NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+  event_id uuid NOT NULL,
+  event_type text NOT NULL,
+  payload json NOT NULL,
+  status text NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'SUCCEEDED', 'FAILED')),
+  attempts int NOT NULL DEFAULT 0,
+  next_attempt_at timestamptz NOT NULL DEFAULT now(),
+  last_attempt_at timestamptz,
+  last_status_code int,
+  last_error text,
+  delivered_at timestamptz,
+  replay_of uuid REFERENCES webhook_deliveries(id) ON DELETE SET NULL,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'PENDING';
+CREATE INDEX idx_webhook_deliveries_subscription ON webhook_deliveries(subscription_id, created_at DESC);
+
+INSERT INTO permissions (name, description) VALUES
+  ('webhooks.manage', 'Manage platform-wide webhook subscriptions');
+
+INSERT INTO role_permissions (role, permission) VALUES
+  ('admin', 'webhooks.manage');
+
+-- +goose Down
+DELETE FROM permissions WHERE name = 'webhooks.manage';
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
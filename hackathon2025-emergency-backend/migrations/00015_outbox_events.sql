@@ -0,0 +1,24 @@
+-- +goose Up
+-- Domain events are written here in the same transaction as the change that
+-- raised them and handed to in-process subscribers by the relay.
+CREATE TABLE outbox_events (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  sequence bigserial NOT NULL UNIQUE,
+  event_type text NOT NULL,
+  aggregate_type text NOT NULL,
+  aggregate_id text NOT NULL,
+  organization_ids jsonb NOT NULL DEFAULT '[]'::jsonb,
+  payload json NOT NULL,
+  status text NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'PUBLISHED', 'FAILED')),
+  attempts int NOT NULL DEFAULT 0,
+  next_attempt_at timestamptz NOT NULL DEFAULT now(),
+  last_error text,
+  occurred_at timestamptz NOT NULL DEFAULT now(),
+  published_at timestamptz
+);
+
+CREATE INDEX idx_outbox_events_pending ON outbox_events(sequence) WHERE status = 'PENDING';
+CREATE INDEX idx_outbox_events_aggregate ON outbox_events(aggregate_type, aggregate_id, sequence);
+
+-- +goose Down
+DROP TABLE IF EXISTS outbox_events;
@@ -0,0 +1,40 @@
+-- +goose Up
+-- One row per in-app notification. event_id is the outbox event that raised
+-- it, so a redelivered event does not notify twice.
+CREATE TABLE notifications (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  event_id uuid NOT NULL,
+  event_type text NOT NULL,
+  entity_type text NOT NULL,
+  entity_id text NOT NULL,
+  title text NOT NULL,
+  body text NOT NULL,
+  read_at timestamptz,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  UNIQUE (user_id, event_id)
+);
+
+CREATE INDEX idx_notifications_user ON notifications(user_id, created_at DESC);
+CREATE INDEX idx_notifications_unread ON notifications(user_id) WHERE read_at IS NULL;
+
+-- Missing rows mean the defaults: in-app on, email off.
+CREATE TABLE notification_preferences (
+  user_id uuid NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  event_type text NOT NULL,
+  in_app boolean NOT NULL DEFAULT true,
+  email boolean NOT NULL DEFAULT false,
+  updated_at timestamptz NOT NULL DEFAULT now(),
+  PRIMARY KEY (user_id, event_type)
+);
+
+INSERT INTO permissions (name, description) VALUES
+  ('invoice.mark_defaulted', 'Mark a funded invoice as defaulted');
+
+INSERT INTO role_permissions (role, permission) VALUES
+  ('admin', 'invoice.mark_defaulted');
+
+-- +goose Down
+DELETE FROM permissions WHERE name = 'invoice.mark_defaulted';
+DROP TABLE IF EXISTS notification_preferences;
+DROP TABLE IF EXISTS notifications;
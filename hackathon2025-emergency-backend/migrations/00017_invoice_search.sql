@@ -0,0 +1,15 @@
+-- +goose Up
+ALTER TABLE invoices
+  ADD COLUMN title_search tsvector GENERATED ALWAYS AS (to_tsvector('english', title)) STORED;
+
+CREATE INDEX idx_invoices_title_search ON invoices USING gin (title_search);
+CREATE INDEX idx_invoices_tags ON invoices USING gin (tags jsonb_path_ops);
+CREATE INDEX idx_invoices_status_apr ON invoices(status, apr_percent);
+CREATE INDEX idx_invoices_status_due ON invoices(status, due_date);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_invoices_status_due;
+DROP INDEX IF EXISTS idx_invoices_status_apr;
+DROP INDEX IF EXISTS idx_invoices_tags;
+DROP INDEX IF EXISTS idx_invoices_title_search;
+ALTER TABLE invoices DROP COLUMN IF EXISTS title_search;
@@ -0,0 +1,41 @@
+-- +goose Up
+-- One row per emergency-lane invoice awaiting review. due_at is the review
+-- SLA deadline; the escalation worker notifies reviewers once it passes and
+-- again every SLA period until the invoice is reviewed.
+CREATE TABLE emergency_reviews (
+  invoice_id uuid PRIMARY KEY REFERENCES invoices(id) ON DELETE CASCADE,
+  submitted_at timestamptz NOT NULL DEFAULT now(),
+  due_at timestamptz NOT NULL,
+  escalations int NOT NULL DEFAULT 0,
+  last_escalated_at timestamptz,
+  resolved_at timestamptz
+);
+
+CREATE INDEX idx_emergency_reviews_open ON emergency_reviews(due_at) WHERE resolved_at IS NULL;
+
+-- Invoices already awaiting review get their rows from the escalation
+-- worker, which knows the configured SLA.
+
+-- Liquidity investors pre-commit to the emergency pool. Approved
+-- emergency-lane invoices are funded from the oldest active commitments in
+-- their currency first.
+CREATE TABLE liquidity_commitments (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  organization_id uuid NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  investor_id uuid NOT NULL REFERENCES users(id),
+  currency text NOT NULL,
+  committed_amount numeric(18,2) NOT NULL CHECK (committed_amount > 0),
+  available_amount numeric(18,2) NOT NULL CHECK (available_amount >= 0),
+  max_per_invoice numeric(18,2) CHECK (max_per_invoice > 0),
+  min_apr_percent numeric(6,2),
+  status text NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'EXHAUSTED', 'WITHDRAWN')),
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_liquidity_commitments_org ON liquidity_commitments(organization_id, created_at DESC);
+CREATE INDEX idx_liquidity_commitments_active ON liquidity_commitments(currency, created_at) WHERE status = 'ACTIVE';
+
+-- +goose Down
+DROP TABLE IF EXISTS liquidity_commitments;
+DROP TABLE IF EXISTS emergency_reviews;
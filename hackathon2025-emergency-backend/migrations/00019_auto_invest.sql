@@ -0,0 +1,41 @@
+-- +goose Up
+-- Saved auto-invest strategies. invested_amount counts what the matcher has
+-- placed so far and never exceeds budget. Empty risk_tiers or tags match any
+-- invoice; otherwise the invoice must have one of the listed values.
+CREATE TABLE auto_invest_strategies (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  organization_id uuid NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  investor_id uuid NOT NULL REFERENCES users(id),
+  name text NOT NULL,
+  currency text NOT NULL,
+  max_per_invoice numeric(18,2) NOT NULL CHECK (max_per_invoice > 0),
+  budget numeric(18,2) NOT NULL CHECK (budget > 0),
+  invested_amount numeric(18,2) NOT NULL DEFAULT 0 CHECK (invested_amount >= 0 AND invested_amount <= budget),
+  risk_tiers jsonb NOT NULL DEFAULT '[]'::jsonb,
+  min_apr_percent numeric(6,2),
+  max_term_months int,
+  tags jsonb NOT NULL DEFAULT '[]'::jsonb,
+  emergency_lane text NOT NULL DEFAULT 'include' CHECK (emergency_lane IN ('include', 'only', 'exclude')),
+  active boolean NOT NULL DEFAULT true,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_auto_invest_strategies_org ON auto_invest_strategies(organization_id, created_at DESC);
+CREATE INDEX idx_auto_invest_strategies_active ON auto_invest_strategies(currency, created_at) WHERE active;
+
+-- One row per funding the matcher placed for a strategy.
+CREATE TABLE auto_invest_allocations (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  strategy_id uuid NOT NULL REFERENCES auto_invest_strategies(id) ON DELETE CASCADE,
+  invoice_id uuid NOT NULL REFERENCES invoices(id),
+  funding_id uuid NOT NULL REFERENCES fundings(id),
+  amount numeric(18,2) NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_auto_invest_allocations_strategy ON auto_invest_allocations(strategy_id, created_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS auto_invest_allocations;
+DROP TABLE IF EXISTS auto_invest_strategies;
@@ -0,0 +1,57 @@
+-- +goose Up
+-- Fundings are confirmed once their invoice is fully funded; backfill those
+-- placed before that rule existed. A funding created by a secondary-market
+-- purchase points at the position it was bought from.
+UPDATE fundings f
+SET status = 'CONFIRMED', confirmed_at = i.updated_at
+FROM invoices i
+WHERE i.id = f.invoice_id
+  AND f.status = 'PENDING'
+  AND i.status IN ('FUNDED', 'PARTIALLY_PAID', 'PAID', 'DEFAULTED');
+
+ALTER TABLE fundings ADD COLUMN source_funding_id uuid REFERENCES fundings(id);
+
+-- A confirmed position offered for sale. Buyers take all or part of
+-- remaining_principal at unit_price per unit of principal.
+CREATE TABLE market_listings (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  funding_id uuid NOT NULL REFERENCES fundings(id),
+  invoice_id uuid NOT NULL REFERENCES invoices(id),
+  organization_id uuid NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  seller_id uuid NOT NULL REFERENCES users(id),
+  currency text NOT NULL,
+  principal numeric(18,2) NOT NULL CHECK (principal > 0),
+  remaining_principal numeric(18,2) NOT NULL CHECK (remaining_principal >= 0 AND remaining_principal <= principal),
+  unit_price numeric(12,6) NOT NULL CHECK (unit_price > 0),
+  status text NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'FILLED', 'CANCELED')),
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_market_listings_funding_active ON market_listings(funding_id) WHERE status = 'ACTIVE';
+CREATE INDEX idx_market_listings_active ON market_listings(created_at DESC) WHERE status = 'ACTIVE';
+CREATE INDEX idx_market_listings_org ON market_listings(organization_id, created_at DESC);
+
+-- Every purchase, kept as the position's transfer history.
+CREATE TABLE position_transfers (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  listing_id uuid NOT NULL REFERENCES market_listings(id),
+  invoice_id uuid NOT NULL REFERENCES invoices(id),
+  from_funding_id uuid NOT NULL REFERENCES fundings(id),
+  to_funding_id uuid NOT NULL REFERENCES fundings(id),
+  seller_organization_id uuid NOT NULL REFERENCES organizations(id),
+  buyer_organization_id uuid NOT NULL REFERENCES organizations(id),
+  buyer_id uuid NOT NULL REFERENCES users(id),
+  principal numeric(18,2) NOT NULL,
+  price numeric(18,2) NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_position_transfers_invoice ON position_transfers(invoice_id, created_at DESC);
+CREATE INDEX idx_position_transfers_seller ON position_transfers(seller_organization_id, created_at DESC);
+CREATE INDEX idx_position_transfers_buyer ON position_transfers(buyer_organization_id, created_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS position_transfers;
+DROP TABLE IF EXISTS market_listings;
+ALTER TABLE fundings DROP COLUMN IF EXISTS source_funding_id;
@@ -0,0 +1,6 @@
+-- +goose Up
+-- The party that owes the invoice, for exposure reporting.
+ALTER TABLE invoices ADD COLUMN debtor_name text;
+
+-- +goose Down
+ALTER TABLE invoices DROP COLUMN IF EXISTS debtor_name;
@@ -0,0 +1,127 @@
+-- +goose Up
+-- Lifecycle timestamps let the dashboard measure time to approval and to
+-- funding. Existing invoices take them from the outbox and audit log, which
+-- recorded each transition when it happened.
+ALTER TABLE invoices
+  ADD COLUMN submitted_at timestamptz,
+  ADD COLUMN approved_at timestamptz,
+  ADD COLUMN funded_at timestamptz,
+  ADD COLUMN closed_at timestamptz;
+
+UPDATE invoices i
+SET submitted_at = e.occurred_at
+FROM (
+  SELECT aggregate_id, min(occurred_at) AS occurred_at
+  FROM outbox_events
+  WHERE event_type = 'invoice.submitted'
+  GROUP BY aggregate_id
+) e
+WHERE e.aggregate_id = i.id::text;
+
+UPDATE invoices i
+SET approved_at = e.occurred_at
+FROM (
+  SELECT entity_id, min(occurred_at) AS occurred_at
+  FROM audit_events
+  WHERE action = 'invoice.approve'
+  GROUP BY entity_id
+) e
+WHERE e.entity_id = i.id::text;
+
+UPDATE invoices i
+SET funded_at = e.occurred_at
+FROM (
+  SELECT aggregate_id, min(occurred_at) AS occurred_at
+  FROM outbox_events
+  WHERE event_type = 'invoice.funded'
+  GROUP BY aggregate_id
+) e
+WHERE e.aggregate_id = i.id::text;
+
+UPDATE invoices i
+SET closed_at = e.occurred_at
+FROM (
+  SELECT aggregate_id, min(occurred_at) AS occurred_at
+  FROM outbox_events
+  WHERE event_type IN ('invoice.paid', 'invoice.defaulted')
+  GROUP BY aggregate_id
+) e
+WHERE e.aggregate_id = i.id::text;
+
+UPDATE invoices
+SET closed_at = updated_at
+WHERE closed_at IS NULL AND status IN ('PAID', 'DEFAULTED');
+
+CREATE INDEX idx_invoices_due_open ON invoices(due_date) WHERE status IN ('FUNDED', 'PARTIALLY_PAID');
+
+-- Daily rollups behind the admin dashboard, refreshed by the metrics worker.
+-- Days are UTC. Funding volume counts primary fundings only, including what
+-- was later sold on the secondary market, so transfers do not count twice.
+CREATE MATERIALIZED VIEW metrics_daily_funding AS
+SELECT (f.created_at AT TIME ZONE 'UTC')::date AS day,
+       i.currency,
+       count(*) AS fundings,
+       sum(f.amount + COALESCE(t.sold, 0)) AS volume
+FROM fundings f
+JOIN invoices i ON i.id = f.invoice_id
+LEFT JOIN (
+  SELECT from_funding_id, sum(principal) AS sold
+  FROM position_transfers
+  GROUP BY from_funding_id
+) t ON t.from_funding_id = f.id
+WHERE f.source_funding_id IS NULL AND f.status NOT IN ('CANCELED', 'REFUNDED')
+GROUP BY 1, 2;
+
+CREATE UNIQUE INDEX idx_metrics_daily_funding ON metrics_daily_funding(day, currency);
+
+CREATE MATERIALIZED VIEW metrics_daily_invoices AS
+SELECT day,
+       count(*) FILTER (WHERE kind = 'submitted') AS submitted,
+       count(*) FILTER (WHERE kind = 'approved') AS approved,
+       count(*) FILTER (WHERE kind = 'funded') AS funded,
+       count(*) FILTER (WHERE kind = 'paid') AS paid,
+       count(*) FILTER (WHERE kind = 'defaulted') AS defaulted,
+       count(seconds) FILTER (WHERE kind = 'approved') AS approval_samples,
+       COALESCE(sum(seconds) FILTER (WHERE kind = 'approved'), 0) AS approval_seconds,
+       count(seconds) FILTER (WHERE kind = 'funded') AS funding_samples,
+       COALESCE(sum(seconds) FILTER (WHERE kind = 'funded'), 0) AS funding_seconds,
+       COALESCE(sum(apr_percent) FILTER (WHERE kind = 'approved'), 0) AS apr_sum
+FROM (
+  SELECT (submitted_at AT TIME ZONE 'UTC')::date AS day, 'submitted' AS kind, NULL::double precision AS seconds,
+    NULL::numeric AS apr_percent
+  FROM invoices WHERE submitted_at IS NOT NULL
+  UNION ALL
+  SELECT (approved_at AT TIME ZONE 'UTC')::date, 'approved', extract(epoch FROM approved_at - submitted_at), apr_percent
+  FROM invoices WHERE approved_at IS NOT NULL
+  UNION ALL
+  SELECT (funded_at AT TIME ZONE 'UTC')::date, 'funded', extract(epoch FROM funded_at - approved_at), NULL
+  FROM invoices WHERE funded_at IS NOT NULL
+  UNION ALL
+  SELECT (closed_at AT TIME ZONE 'UTC')::date, lower(status), NULL, NULL
+  FROM invoices WHERE closed_at IS NOT NULL AND status IN ('PAID', 'DEFAULTED')
+) transitions
+GROUP BY day;
+
+CREATE UNIQUE INDEX idx_metrics_daily_invoices ON metrics_daily_invoices(day);
+
+CREATE MATERIALIZED VIEW metrics_daily_mints AS
+SELECT (created_at AT TIME ZONE 'UTC')::date AS day,
+       count(*) AS mints,
+       count(*) FILTER (WHERE status = 'CONFIRMED') AS confirmed,
+       count(*) FILTER (WHERE status = 'FAILED') AS failed
+FROM chain_txs
+WHERE type = 'MINT'
+GROUP BY 1;
+
+CREATE UNIQUE INDEX idx_metrics_daily_mints ON metrics_daily_mints(day);
+
+-- +goose Down
+DROP MATERIALIZED VIEW IF EXISTS metrics_daily_mints;
+DROP MATERIALIZED VIEW IF EXISTS metrics_daily_invoices;
+DROP MATERIALIZED VIEW IF EXISTS metrics_daily_funding;
+DROP INDEX IF EXISTS idx_invoices_due_open;
+ALTER TABLE invoices
+  DROP COLUMN IF EXISTS closed_at,
+  DROP COLUMN IF EXISTS funded_at,
+  DROP COLUMN IF EXISTS approved_at,
+  DROP COLUMN IF EXISTS submitted_at;
@@ -0,0 +1,20 @@
+-- +goose Up
+INSERT INTO permissions (name, description) VALUES
+  ('invoice.read', 'Read the invoices of own organizations'),
+  ('invoice.read_listed', 'Browse invoices listed on the marketplace'),
+  ('invoice.read_all', 'Read every invoice');
+
+INSERT INTO role_permissions (role, permission) VALUES
+  ('admin', 'invoice.read'),
+  ('admin', 'invoice.read_listed'),
+  ('admin', 'invoice.read_all'),
+  ('investor', 'invoice.read'),
+  ('investor', 'invoice.read_listed'),
+  ('sme', 'invoice.read'),
+  ('auditor', 'invoice.read_all'),
+  ('risk_officer', 'invoice.read_all'),
+  ('ops', 'invoice.read_all');
+
+-- +goose Down
+DELETE FROM role_permissions WHERE permission IN ('invoice.read', 'invoice.read_listed', 'invoice.read_all');
+DELETE FROM permissions WHERE name IN ('invoice.read', 'invoice.read_listed', 'invoice.read_all');
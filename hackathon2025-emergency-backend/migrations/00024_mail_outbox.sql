@@ -0,0 +1,21 @@
+-- +goose Up
+-- Outgoing mail is written here in the same transaction as the change that
+-- asks for it, and sent by the mail relay once that transaction commits.
+CREATE TABLE mail_outbox (
+  id uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+  sequence bigserial NOT NULL UNIQUE,
+  recipient text NOT NULL,
+  subject text NOT NULL,
+  body text NOT NULL,
+  status text NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'SENT', 'FAILED')),
+  attempts int NOT NULL DEFAULT 0,
+  next_attempt_at timestamptz NOT NULL DEFAULT now(),
+  last_error text,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  sent_at timestamptz
+);
+
+CREATE INDEX idx_mail_outbox_pending ON mail_outbox(sequence) WHERE status = 'PENDING';
+
+-- +goose Down
+DROP TABLE IF EXISTS mail_outbox;
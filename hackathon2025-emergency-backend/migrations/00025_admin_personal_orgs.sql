@@ -0,0 +1,17 @@
+-- +goose Up
+-- Admins created after 00011 never got the personal organization registered
+-- users have.
+INSERT INTO organizations (name, kind, personal, created_by)
+SELECT u.name, u.role, true, u.id
+FROM users u
+WHERE NOT EXISTS (SELECT 1 FROM organizations o WHERE o.personal AND o.created_by = u.id);
+
+INSERT INTO organization_members (organization_id, user_id, role)
+SELECT o.id, o.created_by, 'owner'
+FROM organizations o
+WHERE o.personal AND o.created_by IS NOT NULL
+  AND NOT EXISTS (SELECT 1 FROM organization_members m WHERE m.organization_id = o.id AND m.user_id = o.created_by);
+
+-- +goose Down
+-- The backfilled organizations are left in place: invoices or fundings may
+-- already belong to them.
@@ -0,0 +1,13 @@
+-- +goose Up
+-- The audit log becomes one hash chain per entity type, each appended under
+-- its own lock, and new rows are sealed with HMAC-SHA256 under
+-- AUDIT_HMAC_KEY. Rows written before this form the 'legacy' chain and keep
+-- their unkeyed SHA-256 hashes.
+ALTER TABLE audit_events ADD COLUMN chain text NOT NULL DEFAULT 'legacy';
+ALTER TABLE audit_events ALTER COLUMN chain DROP DEFAULT;
+
+CREATE INDEX idx_audit_events_chain ON audit_events(chain, id DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_audit_events_chain;
+ALTER TABLE audit_events DROP COLUMN IF EXISTS chain;
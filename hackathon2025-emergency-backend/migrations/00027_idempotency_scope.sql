@@ -0,0 +1,14 @@
+-- +goose Up
+-- Idempotency keys are scoped to the organization a request acts for as well
+-- as to the user, so reusing a key under another X-Organization-ID cannot
+-- replay the first organization's response. '' is the caller's personal
+-- organization (no header).
+ALTER TABLE idempotency_keys ADD COLUMN organization_id text NOT NULL DEFAULT '';
+ALTER TABLE idempotency_keys DROP CONSTRAINT idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (user_id, organization_id, key);
+
+-- +goose Down
+DELETE FROM idempotency_keys WHERE organization_id <> '';
+ALTER TABLE idempotency_keys DROP CONSTRAINT idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (user_id, key);
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS organization_id;
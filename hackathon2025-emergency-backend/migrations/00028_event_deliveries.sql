@@ -0,0 +1,25 @@
+-- +goose Up
+-- Each event is handed to each subscriber separately, so one failing
+-- subscriber retries on its own without rolling back or repeating the
+-- others. The relay fans an event out into these rows and then marks it
+-- PUBLISHED.
+CREATE TABLE event_deliveries (
+  event_id uuid NOT NULL REFERENCES outbox_events(id) ON DELETE CASCADE,
+  subscriber text NOT NULL,
+  status text NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'DELIVERED', 'FAILED')),
+  attempts int NOT NULL DEFAULT 0,
+  next_attempt_at timestamptz NOT NULL DEFAULT now(),
+  last_error text,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  delivered_at timestamptz,
+  PRIMARY KEY (event_id, subscriber)
+);
+
+CREATE INDEX idx_event_deliveries_pending ON event_deliveries(next_attempt_at) WHERE status = 'PENDING';
+
+-- +goose Down
+UPDATE outbox_events
+SET status = 'PENDING'
+WHERE id IN (SELECT event_id FROM event_deliveries WHERE status = 'PENDING');
+
+DROP TABLE IF EXISTS event_deliveries;
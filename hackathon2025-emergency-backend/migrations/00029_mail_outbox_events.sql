@@ -0,0 +1,10 @@
+-- +goose Up
+-- Notification mail raised by a domain event records the event, so a
+-- redelivered event queues each recipient's mail only once.
+ALTER TABLE mail_outbox ADD COLUMN event_id uuid;
+
+CREATE UNIQUE INDEX idx_mail_outbox_event_recipient ON mail_outbox(recipient, event_id) WHERE event_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_mail_outbox_event_recipient;
+ALTER TABLE mail_outbox DROP COLUMN IF EXISTS event_id;